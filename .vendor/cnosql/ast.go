@@ -245,6 +245,8 @@ func (*ShowSeriesStatement) node()               {}
 func (*ShowSeriesCardinalityStatement) node()    {}
 func (*ShowRegionsStatement) node()              {}
 func (*ShowShardsStatement) node()               {}
+func (*ShowDataNodesStatement) node()            {}
+func (*ShowHintedHandoffStatement) node()        {}
 func (*ShowStatsStatement) node()                {}
 func (*ShowSubscriptionsStatement) node()        {}
 func (*ShowDiagnosticsStatement) node()          {}
@@ -277,6 +279,7 @@ func (SortFields) node()       {}
 func (Sources) node()          {}
 func (*StringLiteral) node()   {}
 func (*SubQuery) node()        {}
+func (*Join) node()            {}
 func (*Target) node()          {}
 func (*TimeLiteral) node()     {}
 func (*VarRef) node()          {}
@@ -368,6 +371,8 @@ func (*ShowSeriesStatement) stmt()               {}
 func (*ShowSeriesCardinalityStatement) stmt()    {}
 func (*ShowRegionsStatement) stmt()              {}
 func (*ShowShardsStatement) stmt()               {}
+func (*ShowDataNodesStatement) stmt()            {}
+func (*ShowHintedHandoffStatement) stmt()        {}
 func (*ShowStatsStatement) stmt()                {}
 func (*DropShardStatement) stmt()                {}
 func (*ShowSubscriptionsStatement) stmt()        {}
@@ -438,6 +443,7 @@ type Source interface {
 
 func (*Metric) source()   {}
 func (*SubQuery) source() {}
+func (*Join) source()     {}
 
 // Sources represents a list of sources.
 type Sources []Source
@@ -466,6 +472,8 @@ func (a Sources) Metrics() []*Metric {
 			mms = append(mms, src)
 		case *SubQuery:
 			mms = append(mms, src.Statement.Sources.Metrics()...)
+		case *Join:
+			mms = append(mms, Sources{src.Left, src.Right}.Metrics()...)
 		}
 	}
 	return mms
@@ -514,6 +522,12 @@ func (a Sources) RequiredPrivileges() (ExecutionPrivileges, error) {
 				return nil, err
 			}
 			ep = append(ep, privs...)
+		case *Join:
+			privs, err := Sources{source.Left, source.Right}.RequiredPrivileges()
+			if err != nil {
+				return nil, err
+			}
+			ep = append(ep, privs...)
 		default:
 			return nil, fmt.Errorf("invalid source: %s", source)
 		}
@@ -1166,6 +1180,13 @@ func cloneSource(s Source) Source {
 		return s.Clone()
 	case *SubQuery:
 		return &SubQuery{Statement: s.Statement.Clone()}
+	case *Join:
+		return &Join{
+			Type:  s.Type,
+			Left:  cloneSource(s.Left),
+			Right: cloneSource(s.Right),
+			On:    append([]string(nil), s.On...),
+		}
 	default:
 		panic("unreachable")
 	}
@@ -1195,6 +1216,16 @@ func (s *SelectStatement) RewriteFields(m FieldMapper) (*SelectStatement, error)
 				return nil, err
 			}
 			src.Statement = stmt
+		case *Join:
+			for _, side := range []Source{src.Left, src.Right} {
+				if side, ok := side.(*SubQuery); ok {
+					stmt, err := side.Statement.RewriteFields(m)
+					if err != nil {
+						return nil, err
+					}
+					side.Statement = stmt
+				}
+			}
 		}
 	}
 
@@ -1352,7 +1383,7 @@ func (s *SelectStatement) RewriteFields(m FieldMapper) (*SelectStatement, error)
 				// expression. If we find any, throw an error indicating that
 				// it's illegal.
 				var regex, wildcard bool
-				WalkFunc(expr, func(n Node) {
+				walkFieldSelectors(expr, func(n Node) {
 					switch n.(type) {
 					case *RegexLiteral:
 						regex = true
@@ -1663,6 +1694,9 @@ func (s *SelectStatement) ColumnNames() []string {
 						columnFields = append(columnFields, &Field{Expr: ref})
 					}
 				}
+			} else if s.Target == nil && f.Name == "histogram" {
+				// histogram() labels each bucket count with the upper bound of the bucket.
+				columnFields = append(columnFields, &Field{Expr: &VarRef{Val: "le"}})
 			}
 		}
 	}
@@ -1750,6 +1784,12 @@ func (s *SelectStatement) Reduce(valuer Valuer) *SelectStatement {
 		switch source := source.(type) {
 		case *SubQuery:
 			source.Statement = source.Statement.Reduce(valuer)
+		case *Join:
+			for _, side := range []Source{source.Left, source.Right} {
+				if side, ok := side.(*SubQuery); ok {
+					side.Statement = side.Statement.Reduce(valuer)
+				}
+			}
 		}
 	}
 	return stmt
@@ -1834,7 +1874,7 @@ func (s *SelectStatement) HasWildcard() bool {
 
 // HasFieldWildcard returns whether or not the select statement has at least 1 wildcard in the fields.
 func (s *SelectStatement) HasFieldWildcard() (hasWildcard bool) {
-	WalkFunc(s.Fields, func(n Node) {
+	walkFieldSelectors(s.Fields, func(n Node) {
 		if hasWildcard {
 			return
 		}
@@ -2741,6 +2781,30 @@ func (s *ShowShardsStatement) RequiredPrivileges() (ExecutionPrivileges, error)
 	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
 }
 
+// ShowDataNodesStatement represents a command for displaying the data nodes
+// in the cluster and their status.
+type ShowDataNodesStatement struct{}
+
+// String returns a string representation.
+func (s *ShowDataNodesStatement) String() string { return "SHOW DATA NODES" }
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowDataNodesStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
+// ShowHintedHandoffStatement represents a command for displaying the hinted
+// handoff queues of the node.
+type ShowHintedHandoffStatement struct{}
+
+// String returns a string representation.
+func (s *ShowHintedHandoffStatement) String() string { return "SHOW HINTED HANDOFF" }
+
+// RequiredPrivileges returns the privileges required to execute the statement.
+func (s *ShowHintedHandoffStatement) RequiredPrivileges() (ExecutionPrivileges, error) {
+	return ExecutionPrivileges{{Admin: true, Name: "", Privilege: AllPrivileges}}, nil
+}
+
 // ShowDiagnosticsStatement represents a command for show node diagnostics.
 type ShowDiagnosticsStatement struct {
 	// Module
@@ -3475,6 +3539,68 @@ func (s *SubQuery) String() string {
 	return fmt.Sprintf("(%s)", s.Statement.String())
 }
 
+// JoinType is the type of a join.
+type JoinType int
+
+const (
+	// InnerJoin only returns the rows found on both sides of the join.
+	InnerJoin JoinType = iota
+	// LeftJoin returns every row of the left side of the join.
+	LeftJoin
+)
+
+// String returns a string representation of the join type.
+func (t JoinType) String() string {
+	switch t {
+	case InnerJoin:
+		return "INNER JOIN"
+	case LeftJoin:
+		return "LEFT JOIN"
+	default:
+		return ""
+	}
+}
+
+// Join is a source combining the rows of two sources with the same time and
+// the same values for a set of tags.
+type Join struct {
+	Type  JoinType
+	Left  Source
+	Right Source
+
+	// On holds "time" and the tags the rows are joined on.
+	On []string
+}
+
+// String returns a string representation of the join.
+func (j *Join) String() string {
+	var buf strings.Builder
+	_, _ = buf.WriteString(j.Left.String())
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(j.Type.String())
+	_, _ = buf.WriteString(" ")
+	_, _ = buf.WriteString(j.Right.String())
+	_, _ = buf.WriteString(" ON ")
+	for i, name := range j.On {
+		if i > 0 {
+			_, _ = buf.WriteString(", ")
+		}
+		_, _ = buf.WriteString(QuoteIdent(name))
+	}
+	return buf.String()
+}
+
+// Tags returns the tags the rows are joined on.
+func (j *Join) Tags() []string {
+	tags := make([]string, 0, len(j.On))
+	for _, name := range j.On {
+		if name != "time" {
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
+
 // VarRef represents a reference to a variable.
 type VarRef struct {
 	Val  string
@@ -3974,6 +4100,10 @@ func Walk(v Visitor, node Node) {
 	case *SubQuery:
 		Walk(v, n.Statement)
 
+	case *Join:
+		Walk(v, n.Left)
+		Walk(v, n.Right)
+
 	case Statements:
 		for _, s := range n {
 			Walk(v, s)
@@ -3995,6 +4125,29 @@ type walkFuncVisitor func(Node)
 
 func (fn walkFuncVisitor) Visit(n Node) Visitor { fn(n); return fn }
 
+// walkFieldSelectors traverses node like WalkFunc but skips the regexes passed
+// after the first argument of a function, such as the pattern of
+// regexp_match(), since they do not select fields.
+func walkFieldSelectors(node Node, fn func(Node)) {
+	Walk(fieldSelectorVisitor(fn), node)
+}
+
+type fieldSelectorVisitor func(Node)
+
+func (fn fieldSelectorVisitor) Visit(n Node) Visitor {
+	fn(n)
+	if call, ok := n.(*Call); ok {
+		for i, arg := range call.Args {
+			if _, ok := arg.(*RegexLiteral); ok && i > 0 {
+				continue
+			}
+			Walk(fn, arg)
+		}
+		return nil
+	}
+	return fn
+}
+
 // Rewriter can be called by Rewrite to replace nodes in the AST hierarchy.
 // The Rewrite() function is called once per node.
 type Rewriter interface {
@@ -4030,6 +4183,10 @@ func Rewrite(r Rewriter, node Node) Node {
 	case *SubQuery:
 		n.Statement = Rewrite(r, n.Statement).(*SelectStatement)
 
+	case *Join:
+		n.Left = Rewrite(r, n.Left).(Source)
+		n.Right = Rewrite(r, n.Right).(Source)
+
 	case Fields:
 		for i, f := range n {
 			n[i] = Rewrite(r, f).(*Field)
@@ -4675,6 +4832,16 @@ func (v *TypeValuerEval) evalVarRefExprType(expr *VarRef) (DataType, error) {
 						}
 					}
 				}
+			case *Join:
+				valuer := TypeValuerEval{
+					TypeMapper: v.TypeMapper,
+					Sources:    Sources{src.Left, src.Right},
+				}
+				if t, err := valuer.evalVarRefExprType(expr); err != nil {
+					return Unknown, err
+				} else if typ.LessThan(t) {
+					typ = t
+				}
 			}
 		}
 	}
@@ -4833,6 +5000,20 @@ func FieldDimensions(sources Sources, m FieldMapper) (fields map[string]DataType
 					dimensions[expr.Val] = struct{}{}
 				}
 			}
+		case *Join:
+			f, d, err := FieldDimensions(Sources{src.Left, src.Right}, m)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			for k, typ := range f {
+				if fields[k].LessThan(typ) {
+					fields[k] = typ
+				}
+			}
+			for k := range d {
+				dimensions[k] = struct{}{}
+			}
 		}
 	}
 	return
@@ -1639,6 +1639,14 @@ func TestShow_Privileges(t *testing.T) {
 			stmt: &cnosql.ShowShardsStatement{},
 			exp:  cnosql.ExecutionPrivileges{{Admin: true, Privilege: cnosql.AllPrivileges}},
 		},
+		{
+			stmt: &cnosql.ShowDataNodesStatement{},
+			exp:  cnosql.ExecutionPrivileges{{Admin: true, Privilege: cnosql.AllPrivileges}},
+		},
+		{
+			stmt: &cnosql.ShowHintedHandoffStatement{},
+			exp:  cnosql.ExecutionPrivileges{{Admin: true, Privilege: cnosql.AllPrivileges}},
+		},
 		{
 			stmt: &cnosql.ShowStatsStatement{},
 			exp:  cnosql.ExecutionPrivileges{{Admin: true, Privilege: cnosql.AllPrivileges}},
@@ -1737,9 +1745,11 @@ func Test_EnforceHasDefaultDatabase(t *testing.T) {
 		"SelectStatement",
 		"SetPasswordUserStatement",
 		"ShowContinuousQueriesStatement",
+		"ShowDataNodesStatement",
 		"ShowDatabasesStatement",
 		"ShowDiagnosticsStatement",
 		"ShowGrantsForUserStatement",
+		"ShowHintedHandoffStatement",
 		"ShowQueriesStatement",
 		"ShowRegionsStatement",
 		"ShowShardsStatement",
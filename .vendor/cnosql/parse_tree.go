@@ -2,6 +2,7 @@ package cnosql
 
 import (
 	"fmt"
+	"strings"
 )
 
 var Language = &ParseTree{}
@@ -10,6 +11,12 @@ type ParseTree struct {
 	Handlers map[Token]func(*Parser) (Statement, error)
 	Tokens   map[Token]*ParseTree
 	Keys     []string
+
+	// KeywordHandlers and KeywordTokens are the handlers and subtrees of
+	// contextual keywords by upper case keyword. Contextual keywords are
+	// scanned as identifiers so they remain valid identifiers elsewhere.
+	KeywordHandlers map[string]func(*Parser) (Statement, error)
+	KeywordTokens   map[string]*ParseTree
 }
 
 // With passes the current parse tree to a function to allow nested functions.
@@ -63,6 +70,48 @@ func (t *ParseTree) Handle(tok Token, fn func(*Parser) (Statement, error)) {
 	t.Keys = append(t.Keys, tok.String())
 }
 
+// GroupKeyword groups together a set of related handlers with a common prefix
+// of contextual keywords.
+func (t *ParseTree) GroupKeyword(kws ...string) *ParseTree {
+	for _, kw := range kws {
+		if subtree := t.KeywordTokens[kw]; subtree != nil {
+			t = subtree
+			continue
+		}
+
+		if _, conflict := t.KeywordHandlers[kw]; conflict {
+			panic(fmt.Sprintf("conflict for keyword %s", kw))
+		}
+
+		newT := &ParseTree{}
+		if t.KeywordTokens == nil {
+			t.KeywordTokens = make(map[string]*ParseTree)
+		}
+		t.KeywordTokens[kw] = newT
+		t.Keys = append(t.Keys, kw)
+		t = newT
+	}
+	return t
+}
+
+// HandleKeyword registers a handler to be invoked when seeing the given
+// contextual keyword.
+func (t *ParseTree) HandleKeyword(kw string, fn func(*Parser) (Statement, error)) {
+	if _, conflict := t.KeywordTokens[kw]; conflict {
+		panic(fmt.Sprintf("conflict for keyword %s", kw))
+	}
+
+	if _, conflict := t.KeywordHandlers[kw]; conflict {
+		panic(fmt.Sprintf("conflict for keyword %s", kw))
+	}
+
+	if t.KeywordHandlers == nil {
+		t.KeywordHandlers = make(map[string]func(*Parser) (Statement, error))
+	}
+	t.KeywordHandlers[kw] = fn
+	t.Keys = append(t.Keys, kw)
+}
+
 // Parse parses a statement using the language defined in the parse tree.
 func (t *ParseTree) Parse(p *Parser) (Statement, error) {
 	for {
@@ -76,6 +125,17 @@ func (t *ParseTree) Parse(p *Parser) (Statement, error) {
 			return stmt(p)
 		}
 
+		if tok == IDENT {
+			kw := strings.ToUpper(lit)
+			if subtree := t.KeywordTokens[kw]; subtree != nil {
+				t = subtree
+				continue
+			}
+			if stmt := t.KeywordHandlers[kw]; stmt != nil {
+				return stmt(p)
+			}
+		}
+
 		// There were no registered handlers. Return the valid tokens in the order they were added.
 		return nil, newParseError(tokstr(tok, lit), t.Keys, pos)
 	}
@@ -96,6 +156,20 @@ func (t *ParseTree) Clone() *ParseTree {
 			newT.Tokens[tok] = subtree.Clone()
 		}
 	}
+
+	if t.KeywordHandlers != nil {
+		newT.KeywordHandlers = make(map[string]func(*Parser) (Statement, error), len(t.KeywordHandlers))
+		for kw, handler := range t.KeywordHandlers {
+			newT.KeywordHandlers[kw] = handler
+		}
+	}
+
+	if t.KeywordTokens != nil {
+		newT.KeywordTokens = make(map[string]*ParseTree, len(t.KeywordTokens))
+		for kw, subtree := range t.KeywordTokens {
+			newT.KeywordTokens[kw] = subtree.Clone()
+		}
+	}
 	return newT
 }
 
@@ -110,6 +184,9 @@ func init() {
 		show.Group(CONTINUOUS).Handle(QUERIES, func(p *Parser) (Statement, error) {
 			return p.parseShowContinuousQueriesStatement()
 		})
+		show.GroupKeyword("DATA").HandleKeyword("NODES", func(p *Parser) (Statement, error) {
+			return p.parseShowDataNodesStatement()
+		})
 		show.Handle(DATABASES, func(p *Parser) (Statement, error) {
 			return p.parseShowDatabasesStatement()
 		})
@@ -127,6 +204,9 @@ func init() {
 		show.Group(GRANTS).Handle(FOR, func(p *Parser) (Statement, error) {
 			return p.parseGrantsForUserStatement()
 		})
+		show.GroupKeyword("HINTED").HandleKeyword("HANDOFF", func(p *Parser) (Statement, error) {
+			return p.parseShowHintedHandoffStatement()
+		})
 		show.Group(METRIC).Handle(EXACT, func(p *Parser) (Statement, error) {
 			return p.parseShowMetricCardinalityStatement(true)
 		})
@@ -1951,6 +1951,18 @@ func (p *Parser) parseShowRegionsStatement() (*ShowRegionsStatement, error) {
 	return &ShowRegionsStatement{}, nil
 }
 
+// parseShowHintedHandoffStatement parses a string for "SHOW HINTED HANDOFF" statement.
+// This function assumes the "SHOW HINTED HANDOFF" tokens have already been consumed.
+func (p *Parser) parseShowHintedHandoffStatement() (*ShowHintedHandoffStatement, error) {
+	return &ShowHintedHandoffStatement{}, nil
+}
+
+// parseShowDataNodesStatement parses a string for "SHOW DATA NODES" statement.
+// This function assumes the "SHOW DATA NODES" tokens have already been consumed.
+func (p *Parser) parseShowDataNodesStatement() (*ShowDataNodesStatement, error) {
+	return &ShowDataNodesStatement{}, nil
+}
+
 // parseShowShardsStatement parses a string for "SHOW SHARDS" statement.
 // This function assumes the "SHOW SHARDS" tokens have already been consumed.
 func (p *Parser) parseShowShardsStatement() (*ShowShardsStatement, error) {
@@ -2123,6 +2135,13 @@ func (p *Parser) parseSources(subqueries bool) (Sources, error) {
 		if err != nil {
 			return nil, err
 		}
+
+		// Joins are only allowed where subqueries are.
+		if subqueries {
+			if s, err = p.parseJoin(s); err != nil {
+				return nil, err
+			}
+		}
 		sources = append(sources, s)
 
 		if tok, _, _ := p.ScanIgnoreWhitespace(); tok != COMMA {
@@ -2217,6 +2236,40 @@ func (p *Parser) parseSource(subqueries bool) (Source, error) {
 	return m, nil
 }
 
+// parseJoin parses a "[INNER | LEFT] JOIN source ON ident [, ident]" clause
+// following the left source, if it exists. INNER, LEFT and JOIN are only
+// keywords here, so they remain valid names.
+func (p *Parser) parseJoin(left Source) (Source, error) {
+	join := &Join{Type: InnerJoin, Left: left}
+	switch p.scanKeyword("INNER", "LEFT", "JOIN") {
+	case "INNER":
+	case "LEFT":
+		join.Type = LeftJoin
+	case "JOIN":
+		p.Unscan()
+	default:
+		return left, nil
+	}
+
+	if err := p.parseKeyword("JOIN"); err != nil {
+		return nil, err
+	}
+
+	right, err := p.parseSource(true)
+	if err != nil {
+		return nil, err
+	}
+	join.Right = right
+
+	if err := p.parseTokens([]Token{ON}); err != nil {
+		return nil, err
+	}
+	if join.On, err = p.ParseIdentList(); err != nil {
+		return nil, err
+	}
+	return join, nil
+}
+
 // parseCondition parses the "WHERE" clause of the query, if it exists.
 func (p *Parser) parseCondition() (Expr, error) {
 	// Check if the WHERE token exists.
@@ -3073,6 +3126,32 @@ func (p *Parser) parseTokens(toks []Token) error {
 	return nil
 }
 
+// scanKeyword scans the next token and returns which of the contextual
+// keywords kws it is. Contextual keywords are identifiers that are only
+// keywords where the grammar expects them. If the token is none of kws, it
+// is unscanned and an empty string is returned.
+func (p *Parser) scanKeyword(kws ...string) string {
+	tok, _, lit := p.ScanIgnoreWhitespace()
+	if tok == IDENT {
+		for _, kw := range kws {
+			if strings.EqualFold(lit, kw) {
+				return kw
+			}
+		}
+	}
+	p.Unscan()
+	return ""
+}
+
+// parseKeyword parses the contextual keyword kw.
+func (p *Parser) parseKeyword(kw string) error {
+	tok, pos, lit := p.ScanIgnoreWhitespace()
+	if tok != IDENT || !strings.EqualFold(lit, kw) {
+		return newParseError(tokstr(tok, lit), []string{kw}, pos)
+	}
+	return nil
+}
+
 var (
 	// Quote String replacer.
 	qsReplacer = strings.NewReplacer("\n", `\n`, `\`, `\\`, `'`, `\'`)
@@ -1614,6 +1614,86 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		},
 
+		{
+			s: `SELECT cpu.usage / mem.total FROM cpu JOIN mem ON time, host`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{{
+					Expr: &cnosql.BinaryExpr{
+						Op:  cnosql.DIV,
+						LHS: &cnosql.VarRef{Val: "cpu.usage"},
+						RHS: &cnosql.VarRef{Val: "mem.total"},
+					},
+				}},
+				Sources: []cnosql.Source{
+					&cnosql.Join{
+						Type:  cnosql.InnerJoin,
+						Left:  &cnosql.Metric{Name: "cpu"},
+						Right: &cnosql.Metric{Name: "mem"},
+						On:    []string{"time", "host"},
+					},
+				},
+			},
+		},
+
+		{
+			s: `SELECT cpu.usage, mem.free FROM cpu LEFT JOIN (SELECT free FROM mem) ON time`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.VarRef{Val: "cpu.usage"}},
+					{Expr: &cnosql.VarRef{Val: "mem.free"}},
+				},
+				Sources: []cnosql.Source{
+					&cnosql.Join{
+						Type: cnosql.LeftJoin,
+						Left: &cnosql.Metric{Name: "cpu"},
+						Right: &cnosql.SubQuery{
+							Statement: &cnosql.SelectStatement{
+								IsRawQuery: true,
+								Fields: []*cnosql.Field{{
+									Expr: &cnosql.VarRef{Val: "free"},
+								}},
+								Sources: []cnosql.Source{
+									&cnosql.Metric{Name: "mem"},
+								},
+							},
+						},
+						On: []string{"time"},
+					},
+				},
+			},
+		},
+
+		// INNER, LEFT and JOIN are only keywords after a source.
+		{
+			s: `SELECT left, inner FROM join`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.VarRef{Val: "left"}},
+					{Expr: &cnosql.VarRef{Val: "inner"}},
+				},
+				Sources: []cnosql.Source{&cnosql.Metric{Name: "join"}},
+			},
+		},
+
+		{
+			s: `SELECT value FROM left inner join right ON time`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*cnosql.Field{{Expr: &cnosql.VarRef{Val: "value"}}},
+				Sources: []cnosql.Source{
+					&cnosql.Join{
+						Type:  cnosql.InnerJoin,
+						Left:  &cnosql.Metric{Name: "left"},
+						Right: &cnosql.Metric{Name: "right"},
+						On:    []string{"time"},
+					},
+				},
+			},
+		},
+
 		{
 			s: `SELECT sum(derivative) FROM (SELECT derivative(mean(value)) FROM cpu GROUP BY host) WHERE time >= now() - 1d GROUP BY time(1h)`,
 			stmt: &cnosql.SelectStatement{
@@ -3383,6 +3463,59 @@ func TestParser_ParseStatement(t *testing.T) {
 			stmt: &cnosql.ShowShardsStatement{},
 		},
 
+		// SHOW DATA NODES
+		{
+			s:    `SHOW DATA NODES`,
+			stmt: &cnosql.ShowDataNodesStatement{},
+		},
+
+		// DATA and NODES are contextual keywords and remain valid identifiers
+		{
+			s:    `show data nodes`,
+			stmt: &cnosql.ShowDataNodesStatement{},
+		},
+		{
+			s: `SELECT data FROM cpu`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*cnosql.Field{{Expr: &cnosql.VarRef{Val: "data"}}},
+				Sources:    []cnosql.Source{&cnosql.Metric{Name: "cpu"}},
+			},
+		},
+		{
+			s: `SELECT value FROM data`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*cnosql.Field{{Expr: &cnosql.VarRef{Val: "value"}}},
+				Sources:    []cnosql.Source{&cnosql.Metric{Name: "data"}},
+			},
+		},
+		{
+			s: `SELECT nodes FROM cpu`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields:     []*cnosql.Field{{Expr: &cnosql.VarRef{Val: "nodes"}}},
+				Sources:    []cnosql.Source{&cnosql.Metric{Name: "cpu"}},
+			},
+		},
+
+		// SHOW HINTED HANDOFF
+		{
+			s:    `SHOW HINTED HANDOFF`,
+			stmt: &cnosql.ShowHintedHandoffStatement{},
+		},
+		{
+			s: `SELECT hinted, handoff FROM cpu`,
+			stmt: &cnosql.SelectStatement{
+				IsRawQuery: true,
+				Fields: []*cnosql.Field{
+					{Expr: &cnosql.VarRef{Val: "hinted"}},
+					{Expr: &cnosql.VarRef{Val: "handoff"}},
+				},
+				Sources: []cnosql.Source{&cnosql.Metric{Name: "cpu"}},
+			},
+		},
+
 		// SHOW DIAGNOSTICS
 		{
 			s:    `SHOW DIAGNOSTICS`,
@@ -3429,6 +3562,8 @@ func TestParser_ParseStatement(t *testing.T) {
 		{s: `blah blah`, err: `found blah, expected SELECT, DELETE, SHOW, CREATE, DROP, EXPLAIN, GRANT, REVOKE, ALTER, SET, KILL at line 1, char 1`},
 		{s: `SELECT field1 X`, err: `found X, expected FROM at line 1, char 15`},
 		{s: `SELECT field1 FROM "series" WHERE X +;`, err: `found ;, expected identifier, string, number, bool at line 1, char 38`},
+		{s: `SELECT cpu.usage FROM cpu JOIN mem`, err: `found EOF, expected ON at line 1, char 36`},
+		{s: `SELECT cpu.usage FROM cpu LEFT mem`, err: `found mem, expected JOIN at line 1, char 32`},
 		{s: `SELECT field1 FROM myseries GROUP`, err: `found EOF, expected BY at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT`, err: `found EOF, expected integer at line 1, char 35`},
 		{s: `SELECT field1 FROM myseries LIMIT 10.5`, err: `found 10.5, expected integer at line 1, char 35`},
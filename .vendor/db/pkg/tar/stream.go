@@ -2,6 +2,7 @@ package tar
 
 import (
 	"archive/tar"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
@@ -12,6 +13,10 @@ import (
 	"github.com/cnosdatabase/db/pkg/file"
 )
 
+// FileList is the name of the last file of the archives written by
+// StreamSince, which lists the files of the archived directory.
+const FileList = "snapshot.files"
+
 // Stream is a convenience function for creating a tar of a shard dir. It walks over the directory and subdirs,
 // possibly writing each file to a tar writer stream.  By default StreamFile is used, which will result in all files
 // being written.  A custom writeFunc can be passed so that each file may be written, modified+written, or skipped
@@ -20,6 +25,55 @@ func Stream(w io.Writer, dir, relativePath string, writeFunc func(f os.FileInfo,
 	tw := tar.NewWriter(w)
 	defer tw.Close()
 
+	return walk(tw, dir, relativePath, writeFunc)
+}
+
+// StreamSince creates a tar of the files of a shard dir modified since the
+// given time, followed by a FileList file naming every file of the dir, one
+// per line, so that the unmodified files can be told apart from the removed
+// ones. The FileList file is dated with snapshotTime, the time the dir was
+// snapshotted.
+func StreamSince(w io.Writer, dir, relativePath string, since, snapshotTime time.Time) error {
+	tw := tar.NewWriter(w)
+	defer tw.Close()
+
+	var list bytes.Buffer
+	filter := SinceFilterTarFile(since)
+	if err := walk(tw, dir, relativePath, func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
+		if f.Mode().IsRegular() {
+			name, err := filepath.Rel(dir, fullPath)
+			if err != nil {
+				return err
+			}
+			list.WriteString(filepath.ToSlash(name))
+			list.WriteByte('\n')
+		}
+		return filter(f, shardRelativePath, fullPath, tw)
+	}); err != nil {
+		return err
+	}
+
+	h := &tar.Header{
+		Name:     filepath.ToSlash(filepath.Join(relativePath, FileList)),
+		Mode:     0644,
+		Size:     int64(list.Len()),
+		ModTime:  snapshotTime,
+		Typeflag: tar.TypeReg,
+		// Keep the fractional seconds of the time.
+		Format: tar.FormatPAX,
+	}
+	if err := tw.WriteHeader(h); err != nil {
+		return err
+	}
+	if _, err := tw.Write(list.Bytes()); err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// walk writes the files of dir to tw with writeFunc, or StreamFile if it is
+// nil.
+func walk(tw *tar.Writer, dir, relativePath string, writeFunc func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error) error {
 	if writeFunc == nil {
 		writeFunc = StreamFile
 	}
@@ -122,6 +176,9 @@ func extractFile(tr *tar.Reader, dir string) error {
 	}
 
 	relativePath := filepath.Join(sections[3:]...)
+	if relativePath == FileList {
+		return nil
+	}
 
 	subDir, _ := filepath.Split(relativePath)
 	// If this is a directory entry (usually just `index` for tsi), create it an move on.
@@ -8,8 +8,9 @@ import (
 // A SpanContext represents the minimal information to identify a span in a trace.
 // This is typically serialized to continue a trace on a remote node.
 type SpanContext struct {
-	TraceID uint64 // TraceID is assigned a random number to this trace.
-	SpanID  uint64 // SpanID is assigned a random number to identify this span.
+	TraceID     uint64 // TraceID is assigned a random number to this trace.
+	SpanID      uint64 // SpanID is assigned a random number to identify this span.
+	TraceIDHigh uint64 // TraceIDHigh holds the high 64 bits of a 128-bit trace id continued from another tracer.
 }
 
 func (s SpanContext) MarshalBinary() ([]byte, error) {
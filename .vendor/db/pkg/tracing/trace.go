@@ -33,7 +33,7 @@ func NewTraceFromSpan(name string, parent SpanContext, opt ...StartSpanOption) (
 	s := &Span{tracer: t}
 	s.raw.Name = name
 	s.raw.ParentSpanID = parent.SpanID
-	s.raw.Context.TraceID = parent.TraceID
+	s.raw.Context = parent
 	s.raw.Context.SpanID = randomID()
 	setOptions(s, opt)
 
@@ -43,8 +43,8 @@ func NewTraceFromSpan(name string, parent SpanContext, opt ...StartSpanOption) (
 func (t *Trace) startSpan(name string, sc SpanContext, opt []StartSpanOption) *Span {
 	s := &Span{tracer: t}
 	s.raw.Name = name
+	s.raw.Context = sc
 	s.raw.Context.SpanID = randomID()
-	s.raw.Context.TraceID = sc.TraceID
 	s.raw.ParentSpanID = sc.SpanID
 	setOptions(s, opt)
 
@@ -67,6 +67,18 @@ func (t *Trace) addRawSpan(raw RawSpan) {
 	t.mu.Unlock()
 }
 
+// Spans returns the finished spans of the trace.
+func (t *Trace) Spans() []RawSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	spans := make([]RawSpan, 0, len(t.spans))
+	for _, s := range t.spans {
+		spans = append(spans, s)
+	}
+	return spans
+}
+
 // Tree returns a graph of the current trace.
 func (t *Trace) Tree() *TreeNode {
 	t.mu.Lock()
@@ -83,6 +95,9 @@ func (t *Trace) Tree() *TreeNode {
 // Merge combines other with the current trace. This is
 // typically necessary when traces are transferred from a remote.
 func (t *Trace) Merge(other *Trace) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	for k, s := range other.spans {
 		t.spans[k] = s
 	}
@@ -64,13 +64,11 @@ func (t *Trace) MarshalBinary() ([]byte, error) {
 	wt := wire.Trace{}
 	for _, sp := range t.spans {
 		wt.Spans = append(wt.Spans, &wire.Span{
-			Context: wire.SpanContext{
-				TraceID: sp.Context.TraceID,
-				SpanID:  sp.Context.SpanID,
-			},
+			Context:      wire.SpanContext(sp.Context),
 			ParentSpanID: sp.ParentSpanID,
 			Name:         sp.Name,
 			Start:        sp.Start,
+			Duration:     sp.Duration,
 			Labels:       labelsToWire(sp.Labels),
 			Fields:       fieldsToWire(sp.Fields),
 		})
@@ -120,13 +118,11 @@ func (t *Trace) UnmarshalBinary(data []byte) error {
 
 	for _, sp := range wt.Spans {
 		t.spans[sp.Context.SpanID] = RawSpan{
-			Context: SpanContext{
-				TraceID: sp.Context.TraceID,
-				SpanID:  sp.Context.SpanID,
-			},
+			Context:      SpanContext(sp.Context),
 			ParentSpanID: sp.ParentSpanID,
 			Name:         sp.Name,
 			Start:        sp.Start,
+			Duration:     sp.Duration,
 			Labels:       labels.New(sp.Labels...),
 			Fields:       wireToFields(sp.Fields),
 		}
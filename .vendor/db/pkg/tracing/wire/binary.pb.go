@@ -66,8 +66,9 @@ func (Field_FieldType) EnumDescriptor() ([]byte, []int) {
 }
 
 type SpanContext struct {
-	TraceID uint64 `protobuf:"varint,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
-	SpanID  uint64 `protobuf:"varint,2,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	TraceID     uint64 `protobuf:"varint,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	SpanID      uint64 `protobuf:"varint,2,opt,name=span_id,json=spanId,proto3" json:"span_id,omitempty"`
+	TraceIDHigh uint64 `protobuf:"varint,3,opt,name=trace_id_high,json=traceIdHigh,proto3" json:"trace_id_high,omitempty"`
 }
 
 func (m *SpanContext) Reset()         { *m = SpanContext{} }
@@ -117,13 +118,21 @@ func (m *SpanContext) GetSpanID() uint64 {
 	return 0
 }
 
+func (m *SpanContext) GetTraceIDHigh() uint64 {
+	if m != nil {
+		return m.TraceIDHigh
+	}
+	return 0
+}
+
 type Span struct {
-	Context      SpanContext `protobuf:"bytes,1,opt,name=context,proto3" json:"context"`
-	ParentSpanID uint64      `protobuf:"varint,2,opt,name=parent_span_id,json=parentSpanId,proto3" json:"parent_span_id,omitempty"`
-	Name         string      `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
-	Start        time.Time   `protobuf:"bytes,4,opt,name=start_time,json=startTime,proto3,stdtime" json:"start_time"`
-	Labels       []string    `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty"`
-	Fields       []Field     `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields"`
+	Context      SpanContext   `protobuf:"bytes,1,opt,name=context,proto3" json:"context"`
+	ParentSpanID uint64        `protobuf:"varint,2,opt,name=parent_span_id,json=parentSpanId,proto3" json:"parent_span_id,omitempty"`
+	Name         string        `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Start        time.Time     `protobuf:"bytes,4,opt,name=start_time,json=startTime,proto3,stdtime" json:"start_time"`
+	Labels       []string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty"`
+	Fields       []Field       `protobuf:"bytes,6,rep,name=fields,proto3" json:"fields"`
+	Duration     time.Duration `protobuf:"bytes,7,opt,name=duration,proto3,stdduration" json:"duration"`
 }
 
 func (m *Span) Reset()         { *m = Span{} }
@@ -201,6 +210,13 @@ func (m *Span) GetFields() []Field {
 	return nil
 }
 
+func (m *Span) GetDuration() time.Duration {
+	if m != nil {
+		return m.Duration
+	}
+	return 0
+}
+
 type Trace struct {
 	Spans []*Span `protobuf:"bytes,1,rep,name=spans,proto3" json:"spans,omitempty"`
 }
@@ -357,46 +373,50 @@ func init() {
 func init() { proto.RegisterFile("binary.proto", fileDescriptor_3aeef8c45497084a) }
 
 var fileDescriptor_3aeef8c45497084a = []byte{
-	// 621 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x5c, 0x52, 0x3f, 0x6f, 0x9b, 0x5e,
-	0x14, 0x85, 0x18, 0xe3, 0x70, 0x9d, 0xf8, 0x87, 0xdf, 0x2f, 0xa9, 0x10, 0x95, 0x00, 0x39, 0x52,
-	0xe5, 0x2c, 0x44, 0x49, 0x23, 0xef, 0x21, 0x56, 0x5a, 0xa4, 0xc8, 0xae, 0xb0, 0xd3, 0xa1, 0x8b,
-	0xf5, 0x1c, 0xbf, 0x58, 0xa8, 0x18, 0x10, 0x3c, 0xa7, 0xf5, 0x37, 0xa8, 0x3c, 0x65, 0xea, 0xe6,
-	0xa9, 0x43, 0xbf, 0x4a, 0xc6, 0x8c, 0x55, 0x07, 0x5a, 0xe1, 0x2f, 0x52, 0xbd, 0x87, 0xff, 0xa4,
-	0x5d, 0xd0, 0xbd, 0xf7, 0x9c, 0x7b, 0xce, 0xbb, 0x47, 0xc0, 0xde, 0xd0, 0x0f, 0x71, 0x32, 0xb3,
-	0xe3, 0x24, 0xa2, 0x11, 0x92, 0x3e, 0xf9, 0x09, 0xd1, 0x0f, 0xc6, 0xd1, 0x38, 0xe2, 0x83, 0x13,
-	0x56, 0x15, 0x98, 0x6e, 0x8e, 0xa3, 0x68, 0x1c, 0x90, 0x13, 0xde, 0x0d, 0xa7, 0x77, 0x27, 0xd4,
-	0x9f, 0x90, 0x94, 0xe2, 0x49, 0x5c, 0x10, 0x1a, 0x1f, 0xa0, 0xda, 0x8b, 0x71, 0x78, 0x19, 0x85,
-	0x94, 0x7c, 0xa6, 0xe8, 0x15, 0xec, 0xd2, 0x04, 0xdf, 0x92, 0x81, 0x3f, 0xd2, 0x44, 0x4b, 0x6c,
-	0x4a, 0x4e, 0x35, 0xcf, 0xcc, 0x4a, 0x9f, 0xcd, 0xdc, 0xb6, 0x57, 0xe1, 0xa0, 0x3b, 0x42, 0x47,
-	0x50, 0x49, 0x63, 0x1c, 0x32, 0xda, 0x0e, 0xa7, 0x41, 0x9e, 0x99, 0x32, 0x53, 0x72, 0xdb, 0x9e,
-	0xcc, 0x20, 0x77, 0xd4, 0xf8, 0xba, 0x03, 0x12, 0x1b, 0xa1, 0x53, 0xa8, 0xdc, 0x16, 0x06, 0x5c,
-	0xb4, 0x7a, 0x56, 0xb7, 0xd9, 0x9b, 0xed, 0x67, 0xce, 0x8e, 0xf4, 0x98, 0x99, 0x82, 0xb7, 0xe6,
-	0xa1, 0x16, 0xd4, 0x62, 0x9c, 0x90, 0x90, 0x0e, 0xfe, 0xf6, 0x51, 0xf3, 0xcc, 0xdc, 0x7b, 0xc7,
-	0x91, 0x95, 0xdb, 0x5e, 0xbc, 0xed, 0x46, 0x08, 0x81, 0x14, 0xe2, 0x09, 0xd1, 0x4a, 0x96, 0xd8,
-	0x54, 0x3c, 0x5e, 0xa3, 0x6b, 0x80, 0x94, 0xe2, 0x84, 0x0e, 0xd8, 0xf1, 0x9a, 0xc4, 0x5f, 0xa0,
-	0xdb, 0x45, 0x32, 0xf6, 0x3a, 0x19, 0xbb, 0xbf, 0x4e, 0xc6, 0xa9, 0xb3, 0xa7, 0xe4, 0x99, 0x59,
-	0xee, 0xb1, 0xad, 0x87, 0x5f, 0xa6, 0xe8, 0x29, 0x5c, 0x80, 0x51, 0xd0, 0x0b, 0x90, 0x03, 0x3c,
-	0x24, 0x41, 0xaa, 0x95, 0xad, 0x52, 0x53, 0xf1, 0x56, 0x1d, 0x3a, 0x06, 0xf9, 0xce, 0x27, 0xc1,
-	0x28, 0xd5, 0x64, 0xab, 0xd4, 0xac, 0x9e, 0x55, 0x8b, 0x1b, 0xaf, 0xd8, 0x6c, 0x75, 0xdd, 0x8a,
-	0xd0, 0x38, 0x86, 0x32, 0x4f, 0x14, 0x59, 0x50, 0x66, 0xe7, 0xa5, 0x9a, 0xc8, 0x57, 0x60, 0x1b,
-	0x8b, 0x57, 0x00, 0x8d, 0xef, 0x25, 0x28, 0x73, 0x09, 0xa4, 0x42, 0xe9, 0x23, 0x99, 0xf1, 0x00,
-	0x15, 0x8f, 0x95, 0xe8, 0x12, 0x80, 0x0b, 0x0e, 0xe8, 0x2c, 0x26, 0x3c, 0x9f, 0xda, 0xd9, 0xe1,
-	0x33, 0xd7, 0xe2, 0xdb, 0x9f, 0xc5, 0xc4, 0xd9, 0xcf, 0x33, 0x53, 0xd9, 0xb4, 0x9e, 0x72, 0xb7,
-	0x2e, 0xd1, 0x29, 0x54, 0xc3, 0xe9, 0x84, 0x24, 0xfe, 0xed, 0xe0, 0x1e, 0x07, 0x3c, 0x37, 0xd5,
-	0xa9, 0xe5, 0x99, 0x09, 0x9d, 0x62, 0xfc, 0x1e, 0x07, 0x6f, 0x05, 0x0f, 0xc2, 0x4d, 0x87, 0x6c,
-	0x96, 0x67, 0xe2, 0x87, 0x63, 0xbe, 0xc1, 0xf2, 0x54, 0x0a, 0x83, 0x1e, 0x9f, 0x16, 0x0b, 0x4a,
-	0xba, 0x6e, 0x1a, 0x3f, 0x45, 0xd8, 0x7a, 0x23, 0x13, 0xe4, 0x5e, 0xdf, 0x73, 0x3b, 0x6f, 0x54,
-	0x41, 0xff, 0x7f, 0xbe, 0xb0, 0xfe, 0xdb, 0x40, 0xc5, 0x3a, 0x7a, 0x09, 0x92, 0xd3, 0xed, 0x5e,
-	0xab, 0xa2, 0x5e, 0x9f, 0x2f, 0xac, 0xfd, 0xed, 0x11, 0x51, 0x14, 0x20, 0x03, 0x64, 0xb7, 0xd3,
-	0x1f, 0xb4, 0xce, 0xd5, 0x1d, 0x1d, 0xcd, 0x17, 0x56, 0x6d, 0x03, 0xbb, 0x21, 0x6d, 0x9d, 0x23,
-	0x0b, 0x2a, 0x37, 0x2b, 0x42, 0xe9, 0x1f, 0xf9, 0x1b, 0x9f, 0x33, 0x8e, 0x60, 0xb7, 0x7d, 0xe3,
-	0x5d, 0xf4, 0xdd, 0x6e, 0x47, 0x95, 0xf4, 0xc3, 0xf9, 0xc2, 0xaa, 0x6f, 0x28, 0xed, 0x69, 0x82,
-	0xa9, 0x1f, 0x85, 0xa8, 0x01, 0xbb, 0x57, 0xd7, 0xdd, 0x0b, 0xae, 0x23, 0xeb, 0x07, 0xf3, 0x85,
-	0xa5, 0x6e, 0x48, 0x57, 0x41, 0x84, 0x69, 0xeb, 0x5c, 0x97, 0xbe, 0x7c, 0x33, 0x04, 0xa7, 0x02,
-	0xe5, 0x7b, 0x1c, 0x4c, 0x89, 0xa3, 0x3d, 0xe6, 0x86, 0xf8, 0x94, 0x1b, 0xe2, 0xef, 0xdc, 0x10,
-	0x1f, 0x96, 0x86, 0xf0, 0xb4, 0x34, 0x84, 0x1f, 0x4b, 0x43, 0x18, 0xca, 0xfc, 0x1f, 0x7b, 0xfd,
-	0x27, 0x00, 0x00, 0xff, 0xff, 0xbf, 0x66, 0xcd, 0x47, 0xb7, 0x03, 0x00, 0x00,
+	// 677 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x64, 0x52, 0xcd, 0x6e, 0x9b, 0x4c,
+	0x14, 0x35, 0x31, 0xc6, 0xe6, 0x92, 0x38, 0x78, 0xbe, 0xe4, 0x13, 0xa5, 0x12, 0x20, 0x47, 0xaa,
+	0x92, 0x8d, 0xa3, 0xfc, 0xc8, 0xdb, 0x2a, 0xc4, 0x4a, 0x83, 0x14, 0xd9, 0x15, 0x76, 0xba, 0xb5,
+	0xc6, 0xf1, 0xc4, 0x41, 0xc5, 0x80, 0x30, 0x4e, 0xeb, 0x37, 0xa8, 0xbc, 0x69, 0x96, 0xdd, 0x78,
+	0xd5, 0x45, 0x5f, 0x25, 0xcb, 0xec, 0x5a, 0x75, 0x41, 0x2b, 0xf2, 0x22, 0xd5, 0x0c, 0x60, 0xa7,
+	0xe9, 0x06, 0xcd, 0xbd, 0xe7, 0xdc, 0x33, 0x67, 0xce, 0x05, 0xd6, 0x07, 0x8e, 0x87, 0xc3, 0x59,
+	0x23, 0x08, 0xfd, 0xc8, 0x47, 0xfc, 0x07, 0x27, 0x24, 0xea, 0xd6, 0xc8, 0x1f, 0xf9, 0xac, 0xb1,
+	0x4f, 0x4f, 0x29, 0xa6, 0xea, 0x23, 0xdf, 0x1f, 0xb9, 0x64, 0x9f, 0x55, 0x83, 0xe9, 0xf5, 0x7e,
+	0xe4, 0x8c, 0xc9, 0x24, 0xc2, 0xe3, 0x20, 0x23, 0x68, 0xcf, 0x09, 0xc3, 0x69, 0x88, 0x23, 0xc7,
+	0xf7, 0x52, 0xbc, 0xfe, 0x99, 0x03, 0xa9, 0x1b, 0x60, 0xef, 0xd4, 0xf7, 0x22, 0xf2, 0x31, 0x42,
+	0xaf, 0xa0, 0x12, 0x85, 0xf8, 0x8a, 0xf4, 0x9d, 0xa1, 0xc2, 0x19, 0xdc, 0x2e, 0x6f, 0x4a, 0x49,
+	0xac, 0x97, 0x7b, 0xb4, 0x67, 0xb5, 0xec, 0x32, 0x03, 0xad, 0x21, 0xda, 0x81, 0xf2, 0x24, 0xc0,
+	0x1e, 0xa5, 0xad, 0x31, 0x1a, 0x24, 0xb1, 0x2e, 0x50, 0x25, 0xab, 0x65, 0x0b, 0x14, 0xb2, 0x86,
+	0xe8, 0x08, 0x36, 0x72, 0xb1, 0xfe, 0x8d, 0x33, 0xba, 0x51, 0x8a, 0x8c, 0xba, 0x99, 0xc4, 0xba,
+	0x94, 0x29, 0x9e, 0x3b, 0xa3, 0x1b, 0x5b, 0xca, 0x54, 0x69, 0x51, 0xff, 0xbe, 0x06, 0x3c, 0xd5,
+	0x41, 0x07, 0x50, 0xbe, 0x4a, 0x5d, 0x31, 0x27, 0xd2, 0x61, 0xad, 0x41, 0x93, 0x68, 0x3c, 0xb1,
+	0x6b, 0xf2, 0xf7, 0xb1, 0x5e, 0xb0, 0x73, 0x1e, 0x6a, 0x42, 0x35, 0xc0, 0x21, 0xf1, 0xa2, 0xfe,
+	0xdf, 0xe6, 0xe4, 0x24, 0xd6, 0xd7, 0xdf, 0x32, 0x24, 0xb3, 0xb8, 0x1e, 0xac, 0xaa, 0x21, 0x42,
+	0xc0, 0x7b, 0x78, 0x4c, 0x98, 0x3f, 0xd1, 0x66, 0x67, 0x74, 0x01, 0x30, 0x89, 0x70, 0x18, 0xf5,
+	0x69, 0xa4, 0x0a, 0xcf, 0x1c, 0xa8, 0x8d, 0x34, 0xce, 0x46, 0x1e, 0x67, 0xa3, 0x97, 0xe7, 0x6d,
+	0xd6, 0xa8, 0x95, 0x24, 0xd6, 0x4b, 0x5d, 0x3a, 0x75, 0xf7, 0x4b, 0xe7, 0x6c, 0x91, 0x09, 0x50,
+	0x0a, 0xfa, 0x1f, 0x04, 0x17, 0x0f, 0x88, 0x3b, 0x51, 0x4a, 0x46, 0x71, 0x57, 0xb4, 0xb3, 0x0a,
+	0xed, 0x81, 0x70, 0xed, 0x10, 0x77, 0x38, 0x51, 0x04, 0xa3, 0xb8, 0x2b, 0x1d, 0x4a, 0xe9, 0x1b,
+	0xcf, 0x68, 0x2f, 0x7b, 0x5d, 0x46, 0x40, 0xaf, 0xa1, 0x92, 0x2f, 0x4f, 0x29, 0x33, 0x3b, 0x2f,
+	0xfe, 0xb1, 0xd3, 0xca, 0x08, 0x66, 0x85, 0x8e, 0x7e, 0xa1, 0x26, 0x96, 0x43, 0xf5, 0x3d, 0x28,
+	0xb1, 0xd4, 0x91, 0x01, 0x25, 0x9a, 0xcf, 0x44, 0xe1, 0xd8, 0x9d, 0xb0, 0xca, 0xd5, 0x4e, 0x81,
+	0xfa, 0xb7, 0x22, 0x94, 0x98, 0x07, 0x24, 0x43, 0xf1, 0x3d, 0x99, 0xb1, 0x0d, 0x88, 0x36, 0x3d,
+	0xa2, 0x53, 0x00, 0xe6, 0xa8, 0x1f, 0xcd, 0x02, 0xc2, 0x02, 0xae, 0x1e, 0x6e, 0x3f, 0xb1, 0x9d,
+	0x7e, 0x7b, 0xb3, 0x80, 0x98, 0x1b, 0x49, 0xac, 0x8b, 0xcb, 0xd2, 0x16, 0xaf, 0xf3, 0x23, 0x3a,
+	0x00, 0xc9, 0x9b, 0x8e, 0x49, 0xe8, 0x5c, 0xf5, 0x6f, 0xb1, 0xcb, 0x82, 0x97, 0xcd, 0x6a, 0x12,
+	0xeb, 0xd0, 0x4e, 0xdb, 0xef, 0xb0, 0x7b, 0x5e, 0xb0, 0xc1, 0x5b, 0x56, 0xa8, 0x41, 0x17, 0x12,
+	0x3a, 0xde, 0x88, 0x4d, 0xd0, 0x85, 0x88, 0xe9, 0x05, 0x5d, 0xd6, 0x4d, 0x07, 0xc4, 0x49, 0x5e,
+	0xd4, 0x7f, 0x72, 0xb0, 0xba, 0x1b, 0xe9, 0x20, 0x74, 0x7b, 0xb6, 0xd5, 0x7e, 0x23, 0x17, 0xd4,
+	0xff, 0xe6, 0x0b, 0x63, 0x73, 0x09, 0xa5, 0xe3, 0xe8, 0x25, 0xf0, 0x66, 0xa7, 0x73, 0x21, 0x73,
+	0x6a, 0x6d, 0xbe, 0x30, 0x36, 0x56, 0x8f, 0xf0, 0x7d, 0x17, 0x69, 0x20, 0x58, 0xed, 0x5e, 0xbf,
+	0x79, 0x2c, 0xaf, 0xa9, 0x68, 0xbe, 0x30, 0xaa, 0x4b, 0xd8, 0xf2, 0xa2, 0xe6, 0x31, 0x32, 0xa0,
+	0x7c, 0x99, 0x11, 0x8a, 0xcf, 0xe4, 0x2f, 0x1d, 0xc6, 0xd8, 0x81, 0x4a, 0xeb, 0xd2, 0x3e, 0xe9,
+	0x59, 0x9d, 0xb6, 0xcc, 0xab, 0xdb, 0xf3, 0x85, 0x51, 0x5b, 0x52, 0xf2, 0xad, 0xa1, 0x3a, 0x54,
+	0xce, 0x2e, 0x3a, 0x27, 0x4c, 0x47, 0x50, 0xb7, 0xe6, 0x0b, 0x43, 0x5e, 0x92, 0xce, 0x5c, 0x1f,
+	0x47, 0xcd, 0x63, 0x95, 0xff, 0xf4, 0x55, 0x2b, 0x98, 0x65, 0x28, 0xdd, 0x62, 0x77, 0x4a, 0x4c,
+	0xe5, 0x3e, 0xd1, 0xb8, 0x87, 0x44, 0xe3, 0x7e, 0x27, 0x1a, 0x77, 0xf7, 0xa8, 0x15, 0x1e, 0x1e,
+	0xb5, 0xc2, 0x8f, 0x47, 0xad, 0x30, 0x10, 0xd8, 0x5f, 0x71, 0xf4, 0x67, 0x00, 0xcd, 0x9f, 0xac,
+	0xee, 0x4e, 0x04, 0x00, 0x00,
 }
 
 func (m *SpanContext) Marshal() (dAtA []byte, err error) {
@@ -419,6 +439,11 @@ func (m *SpanContext) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.TraceIDHigh != 0 {
+		i = encodeVarintBinary(dAtA, i, uint64(m.TraceIDHigh))
+		i--
+		dAtA[i] = 0x18
+	}
 	if m.SpanID != 0 {
 		i = encodeVarintBinary(dAtA, i, uint64(m.SpanID))
 		i--
@@ -452,6 +477,14 @@ func (m *Span) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	n1, err1 := github_com_gogo_protobuf_types.StdDurationMarshalTo(m.Duration, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdDuration(m.Duration):])
+	if err1 != nil {
+		return 0, err1
+	}
+	i -= n1
+	i = encodeVarintBinary(dAtA, i, uint64(n1))
+	i--
+	dAtA[i] = 0x3a
 	if len(m.Fields) > 0 {
 		for iNdEx := len(m.Fields) - 1; iNdEx >= 0; iNdEx-- {
 			{
@@ -475,12 +508,12 @@ func (m *Span) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 			dAtA[i] = 0x2a
 		}
 	}
-	n1, err1 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
-	if err1 != nil {
-		return 0, err1
+	n2, err2 := github_com_gogo_protobuf_types.StdTimeMarshalTo(m.Start, dAtA[i-github_com_gogo_protobuf_types.SizeOfStdTime(m.Start):])
+	if err2 != nil {
+		return 0, err2
 	}
-	i -= n1
-	i = encodeVarintBinary(dAtA, i, uint64(n1))
+	i -= n2
+	i = encodeVarintBinary(dAtA, i, uint64(n2))
 	i--
 	dAtA[i] = 0x22
 	if len(m.Name) > 0 {
@@ -639,6 +672,9 @@ func (m *SpanContext) Size() (n int) {
 	if m.SpanID != 0 {
 		n += 1 + sovBinary(uint64(m.SpanID))
 	}
+	if m.TraceIDHigh != 0 {
+		n += 1 + sovBinary(uint64(m.TraceIDHigh))
+	}
 	return n
 }
 
@@ -671,6 +707,8 @@ func (m *Span) Size() (n int) {
 			n += 1 + l + sovBinary(uint64(l))
 		}
 	}
+	l = github_com_gogo_protobuf_types.SizeOfStdDuration(m.Duration)
+	n += 1 + l + sovBinary(uint64(l))
 	return n
 }
 
@@ -801,6 +839,25 @@ func (m *SpanContext) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TraceIDHigh", wireType)
+			}
+			m.TraceIDHigh = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBinary
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.TraceIDHigh |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBinary(dAtA[iNdEx:])
@@ -1034,6 +1091,39 @@ func (m *Span) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Duration", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBinary
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBinary
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBinary
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if err := github_com_gogo_protobuf_types.StdDurationUnmarshal(&m.Duration, dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipBinary(dAtA[iNdEx:])
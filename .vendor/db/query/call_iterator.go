@@ -1,6 +1,7 @@
 package query
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"sort"
@@ -53,6 +54,16 @@ func NewCallIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 		return newLastIterator(input, opt)
 	case "mean":
 		return newMeanIterator(input, opt)
+	case "quantile_approx":
+		return newSketchIterator(input, opt)
+	case "histogram":
+		return newHistogramIterator(input, opt)
+	case "rate", "irate", "increase":
+		return newRatePartialIterator(input, opt)
+	case "stddev":
+		return newMomentsIterator(input, opt)
+	case "spread":
+		return newMinMaxIterator(input, opt)
 	default:
 		return nil, fmt.Errorf("unsupported function call: %s", name)
 	}
@@ -536,6 +547,194 @@ func newMeanIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	}
 }
 
+// newSketchIterator returns an iterator reducing the points of a
+// quantile_approx() call to sketches, or merging the sketches of its input.
+func newSketchIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewSketchReducer()
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewSketchReducer()
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewSketchReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewSketchReducer()
+			fn.errs = errs
+			return fn, fn
+		}
+		return newPartialErrorIterator(newStringReduceStringIterator(input, opt, createFn), errs), nil
+	default:
+		return nil, fmt.Errorf("unsupported quantile_approx iterator type: %T", input)
+	}
+}
+
+// newRatePartialIterator returns an iterator reducing the points of a rate(),
+// irate() or increase() call to the counters of their series, or joining the
+// counters of its input.
+func newRatePartialIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	name := opt.Expr.(*cnosql.Call).Name
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewRatePartialReducer(name)
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewRatePartialReducer(name)
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewRatePartialReducer(name)
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewRatePartialReducer(name)
+			fn.errs = errs
+			return fn, fn
+		}
+		return newPartialErrorIterator(newStringReduceStringIterator(input, opt, createFn), errs), nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", name, input)
+	}
+}
+
+// newRateIterator returns an iterator for operating on a rate(), irate() or
+// increase() call from the counters of its input.
+func newRateIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	name := opt.Expr.(*cnosql.Call).Name
+	interval, extrapolate := opt.RateInterval(), opt.RateExtrapolate()
+	switch input := input.(type) {
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, FloatPointEmitter) {
+			fn := NewRateReducer(name, interval, extrapolate, opt)
+			fn.errs = errs
+			return fn, fn
+		}
+		return newPartialErrorIterator(newStringReduceFloatIterator(input, opt, createFn), errs), nil
+	case nil, *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported %s iterator type: %T", name, input)
+	}
+}
+
+// newQuantileApproxIterator returns an iterator for operating on a
+// quantile_approx() call from the sketches of its input.
+func newQuantileApproxIterator(input Iterator, opt IteratorOptions, q float64) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, FloatPointEmitter) {
+			fn := NewQuantileReducer(q)
+			fn.errs = errs
+			return fn, fn
+		}
+		return newPartialErrorIterator(newStringReduceFloatIterator(input, opt, createFn), errs), nil
+	case nil, *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported quantile_approx iterator type: %T", input)
+	}
+}
+
+// newHistogramIterator returns an iterator counting the points of a
+// histogram() call in buckets, or merging the bucket counts of its input.
+func newHistogramIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	bounds, err := histogramBounds(opt.Expr.(*cnosql.Call))
+	if err != nil {
+		return nil, err
+	}
+
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewHistogramReducer(bounds)
+			fn.errs = errs
+			return fn, fn
+		}
+		return newPartialErrorIterator(newStringReduceStringIterator(input, opt, createFn), errs), nil
+	default:
+		return nil, fmt.Errorf("unsupported histogram iterator type: %T", input)
+	}
+}
+
+// newHistogramCountIterator returns an iterator for operating on a histogram()
+// call from the bucket counts of its input.
+func newHistogramCountIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	bounds, err := histogramBounds(opt.Expr.(*cnosql.Call))
+	if err != nil {
+		return nil, err
+	}
+
+	switch input := input.(type) {
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, IntegerPointEmitter) {
+			fn := NewHistogramCountReducer(bounds)
+			fn.errs = errs
+			return fn, fn
+		}
+		return newPartialErrorIterator(newStringReduceIntegerIterator(input, opt, createFn), errs), nil
+	case nil, *nilFloatIterator:
+		return input, nil
+	default:
+		return nil, fmt.Errorf("unsupported histogram iterator type: %T", input)
+	}
+}
+
+// histogramBounds returns the bounds of the buckets of a histogram() call.
+func histogramBounds(call *cnosql.Call) ([]float64, error) {
+	if len(call.Args) != 2 {
+		return nil, fmt.Errorf("invalid number of arguments for histogram, expected 2, got %d", len(call.Args))
+	}
+	spec, ok := call.Args[1].(*cnosql.StringLiteral)
+	if !ok {
+		return nil, errors.New("expected string argument in histogram()")
+	}
+	return parseHistogramBuckets(spec.Val)
+}
+
 // NewMedianIterator returns an iterator for operating on a median() call.
 func NewMedianIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	return newMedianIterator(input, opt)
@@ -819,27 +1018,62 @@ func BooleanModeReduceSlice(a []BooleanPoint) []BooleanPoint {
 	return []BooleanPoint{{Time: ZeroTime, Value: mostMode}}
 }
 
-// newStddevIterator returns an iterator for operating on a stddev() call.
+// newStddevIterator returns an iterator for operating on a stddev() call. The
+// input may either be the points of a numeric field or their moments.
 func newStddevIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	switch input := input.(type) {
 	case FloatIterator:
 		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
-			fn := NewFloatSliceFuncReducer(FloatStddevReduceSlice)
+			fn := NewStddevReducer()
 			return fn, fn
 		}
 		return newFloatReduceFloatIterator(input, opt, createFn), nil
 	case IntegerIterator:
 		createFn := func() (IntegerPointAggregator, FloatPointEmitter) {
-			fn := NewIntegerSliceFuncFloatReducer(IntegerStddevReduceSlice)
+			fn := NewStddevReducer()
 			return fn, fn
 		}
 		return newIntegerReduceFloatIterator(input, opt, createFn), nil
 	case UnsignedIterator:
 		createFn := func() (UnsignedPointAggregator, FloatPointEmitter) {
-			fn := NewUnsignedSliceFuncFloatReducer(UnsignedStddevReduceSlice)
+			fn := NewStddevReducer()
 			return fn, fn
 		}
 		return newUnsignedReduceFloatIterator(input, opt, createFn), nil
+	case *momentsIterator:
+		createFn := func() (StringPointAggregator, FloatPointEmitter) {
+			fn := NewStddevReducer()
+			return fn, fn
+		}
+		return newStringReduceFloatIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported stddev iterator type: %T", input)
+	}
+}
+
+// newMomentsIterator returns an iterator for operating on a stddev() call on
+// a shard. It reduces the points to their moments that are merged by the
+// query.
+func newMomentsIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, StringPointEmitter) {
+			fn := NewStddevPartialReducer()
+			return fn, fn
+		}
+		return newFloatReduceStringIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, StringPointEmitter) {
+			fn := NewStddevPartialReducer()
+			return fn, fn
+		}
+		return newIntegerReduceStringIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, StringPointEmitter) {
+			fn := NewStddevPartialReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceStringIterator(input, opt, createFn), nil
 	default:
 		return nil, fmt.Errorf("unsupported stddev iterator type: %T", input)
 	}
@@ -955,6 +1189,34 @@ func newSpreadIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
 	}
 }
 
+// newMinMaxIterator returns an iterator for operating on a spread() call on
+// a shard. It reduces the points to their minimum and maximum so the spread
+// of its points is the spread of the input.
+func newMinMaxIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case FloatIterator:
+		createFn := func() (FloatPointAggregator, FloatPointEmitter) {
+			fn := NewFloatSpreadPartialReducer()
+			return fn, fn
+		}
+		return newFloatReduceFloatIterator(input, opt, createFn), nil
+	case IntegerIterator:
+		createFn := func() (IntegerPointAggregator, IntegerPointEmitter) {
+			fn := NewIntegerSpreadPartialReducer()
+			return fn, fn
+		}
+		return newIntegerReduceIntegerIterator(input, opt, createFn), nil
+	case UnsignedIterator:
+		createFn := func() (UnsignedPointAggregator, UnsignedPointEmitter) {
+			fn := NewUnsignedSpreadPartialReducer()
+			return fn, fn
+		}
+		return newUnsignedReduceUnsignedIterator(input, opt, createFn), nil
+	default:
+		return nil, fmt.Errorf("unsupported spread iterator type: %T", input)
+	}
+}
+
 func newTopIterator(input Iterator, opt IteratorOptions, n int, keepTags bool) (Iterator, error) {
 	switch input := input.(type) {
 	case FloatIterator:
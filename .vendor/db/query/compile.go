@@ -62,6 +62,9 @@ type compiledStatement struct {
 	// HasDistinct is set when the distinct() function is encountered.
 	HasDistinct bool
 
+	// HasHistogram is set when the histogram() function is encountered.
+	HasHistogram bool
+
 	// FillOption contains the fill option for aggregates.
 	FillOption cnosql.FillOption
 
@@ -106,6 +109,9 @@ func newCompiler(opt CompileOptions) *compiledStatement {
 func Compile(stmt *cnosql.SelectStatement, opt CompileOptions) (Statement, error) {
 	c := newCompiler(opt)
 	c.stmt = stmt.Clone()
+	if err := rewriteJoin(c.stmt); err != nil {
+		return nil, err
+	}
 	if err := c.preprocess(c.stmt); err != nil {
 		return nil, err
 	}
@@ -188,13 +194,23 @@ func (c *compiledStatement) compile(stmt *cnosql.SelectStatement) error {
 			if err := c.subquery(source.Statement); err != nil {
 				return err
 			}
+		case *cnosql.Join:
+			// The sides of a join have been rewritten into subqueries.
+			for _, side := range []cnosql.Source{source.Left, source.Right} {
+				if side, ok := side.(*cnosql.SubQuery); ok {
+					side.Statement.OmitTime = true
+					if err := c.subquery(side.Statement); err != nil {
+						return err
+					}
+				}
+			}
 		}
 	}
 	return nil
 }
 
 func (c *compiledStatement) compileFields(stmt *cnosql.SelectStatement) error {
-	valuer := MathValuer{}
+	valuer := cnosql.MultiValuer(MathValuer{}, StringValuer{})
 
 	c.Fields = make([]*compiledField, 0, len(stmt.Fields))
 	for _, f := range stmt.Fields {
@@ -211,7 +227,7 @@ func (c *compiledStatement) compileFields(stmt *cnosql.SelectStatement) error {
 		}
 
 		// Append this field to the list of processed fields and compile it.
-		f.Expr = cnosql.Reduce(f.Expr, &valuer)
+		f.Expr = cnosql.Reduce(f.Expr, valuer)
 		field := &compiledField{
 			global:        c,
 			Field:         f,
@@ -261,6 +277,8 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 	case *cnosql.Call:
 		if isMathFunction(expr) {
 			return c.compileMathFunction(expr)
+		} else if isStringFunction(expr) {
+			return c.compileStringFunction(expr)
 		}
 
 		// Register the function call in the list of function calls.
@@ -269,6 +287,10 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 		switch expr.Name {
 		case "percentile":
 			return c.compilePercentile(expr.Args)
+		case "quantile_approx":
+			return c.compileQuantileApprox(expr.Args)
+		case "histogram":
+			return c.compileHistogram(expr)
 		case "sample":
 			return c.compileSample(expr.Args)
 		case "distinct":
@@ -278,6 +300,8 @@ func (c *compiledField) compileExpr(expr cnosql.Expr) error {
 		case "derivative", "non_negative_derivative":
 			isNonNegative := expr.Name == "non_negative_derivative"
 			return c.compileDerivative(expr.Args, isNonNegative)
+		case "rate", "irate", "increase":
+			return c.compileRate(expr.Name, expr.Args)
 		case "difference", "non_negative_difference":
 			isNonNegative := expr.Name == "non_negative_difference"
 			return c.compileDifference(expr.Args, isNonNegative)
@@ -418,6 +442,36 @@ func (c *compiledField) compilePercentile(args []cnosql.Expr) error {
 	return c.compileSymbol("percentile", args[0])
 }
 
+func (c *compiledField) compileQuantileApprox(args []cnosql.Expr) error {
+	if exp, got := 2, len(args); got != exp {
+		return fmt.Errorf("invalid number of arguments for quantile_approx, expected %d, got %d", exp, got)
+	}
+
+	var q float64
+	switch arg1 := args[1].(type) {
+	case *cnosql.IntegerLiteral:
+		q = float64(arg1.Val)
+	case *cnosql.NumberLiteral:
+		q = arg1.Val
+	default:
+		return fmt.Errorf("expected float argument in quantile_approx()")
+	}
+	if q < 0 || q > 1 {
+		return fmt.Errorf("quantile must be between 0 and 1 in quantile_approx(), got %v", q)
+	}
+	c.global.OnlySelectors = false
+	return c.compileSymbol("quantile_approx", args[0])
+}
+
+func (c *compiledField) compileHistogram(call *cnosql.Call) error {
+	if _, err := histogramBounds(call); err != nil {
+		return err
+	}
+	c.global.HasHistogram = true
+	c.global.OnlySelectors = false
+	return c.compileSymbol("histogram", call.Args[0])
+}
+
 func (c *compiledField) compileSample(args []cnosql.Expr) error {
 	if exp, got := 2, len(args); got != exp {
 		return fmt.Errorf("invalid number of arguments for sample, expected %d, got %d", exp, got)
@@ -475,6 +529,57 @@ func (c *compiledField) compileDerivative(args []cnosql.Expr, isNonNegative bool
 	}
 }
 
+func (c *compiledField) compileRate(name string, args []cnosql.Expr) error {
+	// rate() takes a unit and whether to extrapolate, irate() only takes a
+	// unit and increase() only takes whether to extrapolate.
+	var unit, extrapolate bool
+	switch name {
+	case "rate":
+		unit, extrapolate = true, true
+	case "irate":
+		unit = true
+	case "increase":
+		extrapolate = true
+	}
+
+	max := 1
+	if unit {
+		max++
+	}
+	if extrapolate {
+		max++
+	}
+	if min, got := 1, len(args); got > max || got < min {
+		return fmt.Errorf("invalid number of arguments for %s, expected at least %d but no more than %d, got %d", name, min, max, got)
+	}
+
+	for i, arg := range args[1:] {
+		switch arg := arg.(type) {
+		case *cnosql.DurationLiteral:
+			if !unit || i > 0 {
+				return fmt.Errorf("unexpected duration argument in %s()", name)
+			} else if arg.Val <= 0 {
+				return fmt.Errorf("duration argument must be positive, got %s", cnosql.FormatDuration(arg.Val))
+			}
+		case *cnosql.BooleanLiteral:
+			if !extrapolate || i < len(args)-2 {
+				return fmt.Errorf("unexpected boolean argument in %s()", name)
+			}
+		default:
+			if extrapolate && unit {
+				return fmt.Errorf("expected a duration or a boolean argument in %s(), got %T", name, arg)
+			} else if unit {
+				return fmt.Errorf("second argument to %s must be a duration, got %T", name, arg)
+			}
+			return fmt.Errorf("second argument to %s must be a boolean, got %T", name, arg)
+		}
+	}
+	c.global.OnlySelectors = false
+
+	// Must be a variable reference, wildcard, or regexp.
+	return c.compileSymbol(name, args[0])
+}
+
 func (c *compiledField) compileElapsed(args []cnosql.Expr) error {
 	if min, max, got := 1, 2, len(args); got > max || got < min {
 		return fmt.Errorf("invalid number of arguments for elapsed, expected at least %d but no more than %d, got %d", min, max, got)
@@ -893,6 +998,45 @@ func (c *compiledField) compileMathFunction(expr *cnosql.Call) error {
 	return nil
 }
 
+func (c *compiledField) compileStringFunction(expr *cnosql.Call) error {
+	if err := validateStringFunction(expr); err != nil {
+		return err
+	}
+
+	// Compile all the argument expressions that are not just literals.
+	for _, arg := range expr.Args {
+		if _, ok := arg.(cnosql.Literal); ok {
+			continue
+		}
+		if err := c.compileExpr(arg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateStringFunction verifies the number of arguments of a string function
+// and that the regular expression functions are given a regex literal.
+func validateStringFunction(expr *cnosql.Call) error {
+	min, max := stringFunctionArgs(expr.Name)
+	if got := len(expr.Args); got < min || (max >= 0 && got > max) {
+		if min == max {
+			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, min, got)
+		} else if max < 0 {
+			return fmt.Errorf("invalid number of arguments for %s, expected at least %d, got %d", expr.Name, min, got)
+		}
+		return fmt.Errorf("invalid number of arguments for %s, expected %d or %d, got %d", expr.Name, min, max, got)
+	}
+
+	switch expr.Name {
+	case "regexp_extract", "regexp_match":
+		if _, ok := expr.Args[1].(*cnosql.RegexLiteral); !ok {
+			return fmt.Errorf("expected regex argument in %s()", expr.Name)
+		}
+	}
+	return nil
+}
+
 func (c *compiledStatement) compileDimensions(stmt *cnosql.SelectStatement) error {
 	for _, d := range stmt.Dimensions {
 		// Reduce the expression before attempting anything. Do not evaluate the call.
@@ -988,6 +1132,10 @@ func (c *compiledStatement) validateFields() error {
 	if c.HasDistinct && (len(c.FunctionCalls) != 1 || c.HasAuxiliaryFields) {
 		return errors.New("aggregate function distinct() cannot be combined with other functions or fields")
 	}
+	// A histogram() call emits a point for each bucket so it must be alone too.
+	if c.HasHistogram && (len(c.FunctionCalls) != 1 || c.HasAuxiliaryFields) {
+		return errors.New("aggregate function histogram() cannot be combined with other functions or fields")
+	}
 	// Validate we are using a selector or raw query if auxiliary fields are required.
 	if c.HasAuxiliaryFields {
 		if !c.OnlySelectors {
@@ -1016,20 +1164,24 @@ func (c *compiledStatement) validateCondition(expr cnosql.Expr) error {
 		}
 		return nil
 	case *cnosql.Call:
-		if !isMathFunction(expr) {
+		if isStringFunction(expr) {
+			if err := validateStringFunction(expr); err != nil {
+				return err
+			}
+		} else if !isMathFunction(expr) {
 			return fmt.Errorf("invalid function call in condition: %s", expr)
-		}
-
-		// How many arguments are we expecting?
-		nargs := 1
-		switch expr.Name {
-		case "atan2", "pow":
-			nargs = 2
-		}
+		} else {
+			// How many arguments are we expecting?
+			nargs := 1
+			switch expr.Name {
+			case "atan2", "pow":
+				nargs = 2
+			}
 
-		// Did we get the expected number of args?
-		if got := len(expr.Args); got != nargs {
-			return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, nargs, got)
+			// Did we get the expected number of args?
+			if got := len(expr.Args); got != nargs {
+				return fmt.Errorf("invalid number of arguments for %s, expected %d, got %d", expr.Name, nargs, got)
+			}
 		}
 
 		// Are all the args valid?
@@ -1058,6 +1210,7 @@ func (c *compiledStatement) subquery(stmt *cnosql.SelectStatement) error {
 	valuer := cnosql.MultiValuer(
 		&cnosql.NowValuer{Now: c.Options.Now, Location: stmt.Location},
 		&MathValuer{},
+		&StringValuer{},
 	)
 	stmt.Condition = cnosql.Reduce(stmt.Condition, valuer)
 
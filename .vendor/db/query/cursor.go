@@ -148,7 +148,7 @@ type scannerCursorBase struct {
 }
 
 func newScannerCursorBase(scan scannerFunc, fields []*cnosql.Field, loc *time.Location) scannerCursorBase {
-	typmap := FunctionTypeMapper{}
+	typmap := cnosql.MultiTypeMapper(StringTypeMapper{}, FunctionTypeMapper{})
 	exprs := make([]cnosql.Expr, len(fields))
 	columns := make([]cnosql.VarRef, len(fields))
 	for i, f := range fields {
@@ -192,6 +192,7 @@ func (cur *scannerCursorBase) Scan(row *Row) bool {
 	valuer := cnosql.ValuerEval{
 		Valuer: cnosql.MultiValuer(
 			MathValuer{},
+			StringValuer{},
 			cnosql.MapValuer(cur.m),
 		),
 		IntegerFloatDivision: true,
@@ -391,7 +392,11 @@ func (cur *filterCursor) Scan(row *Row) bool {
 		}
 
 		valuer := cnosql.ValuerEval{
-			Valuer: cnosql.MapValuer(cur.m),
+			Valuer: cnosql.MultiValuer(
+				MathValuer{},
+				StringValuer{},
+				cnosql.MapValuer(cur.m),
+			),
 		}
 		if valuer.EvalBool(cur.filter) {
 			// Passes the filter! Return true. We no longer need to
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/tracing"
 	"github.com/cnosdatabase/cnosql"
 	"go.uber.org/zap"
 )
@@ -155,6 +156,11 @@ type ExecutionOptions struct {
 
 	// AbortCh is a channel that signals when results are no longer desired by the caller.
 	AbortCh <-chan struct{}
+
+	// Trace and Span trace the execution of the query when set. Spans of the
+	// query are started as children of Span.
+	Trace *tracing.Trace
+	Span  *tracing.Span
 }
 
 type (
@@ -175,6 +181,12 @@ type StatementExecutor interface {
 	ExecuteStatement(ctx *ExecutionContext, stmt cnosql.Statement) error
 }
 
+// StatementRewriter rewrites a statement before it is executed, in place of
+// RewriteStatement.
+type StatementRewriter interface {
+	RewriteStatement(stmt cnosql.Statement) (cnosql.Statement, error)
+}
+
 // StatementNormalizer normalizes a statement before it is executed.
 type StatementNormalizer interface {
 	// NormalizeStatement adds a default database and time-to-live to the
@@ -321,7 +333,11 @@ LOOP:
 
 		// Rewrite statements, if necessary.
 		// This can occur on meta read statements which convert to SELECT statements.
-		newStmt, err := RewriteStatement(stmt)
+		rewrite := RewriteStatement
+		if rewriter, ok := e.StatementExecutor.(StatementRewriter); ok {
+			rewrite = rewriter.RewriteStatement
+		}
+		newStmt, err := rewrite(stmt)
 		if err != nil {
 			results <- &Result{Err: err}
 			break
@@ -11,19 +11,13 @@ import (
 )
 
 func (p *preparedStatement) Explain() (string, error) {
-	// Determine the cost of all iterators created as part of this plan.
-	ic := &explainIteratorCreator{ic: p.ic}
-	p.ic = ic
-	cur, err := p.Select(context.Background())
-	p.ic = ic.ic
-
+	nodes, err := p.plan()
 	if err != nil {
 		return "", err
 	}
-	cur.Close()
 
 	var buf bytes.Buffer
-	for i, node := range ic.nodes {
+	for i, node := range nodes {
 		if i > 0 {
 			buf.WriteString("\n")
 		}
@@ -50,6 +44,33 @@ func (p *preparedStatement) Explain() (string, error) {
 	return buf.String(), nil
 }
 
+func (p *preparedStatement) Cost() (IteratorCost, error) {
+	nodes, err := p.plan()
+	if err != nil {
+		return IteratorCost{}, err
+	}
+
+	var cost IteratorCost
+	for _, node := range nodes {
+		cost = cost.Combine(node.Cost)
+	}
+	return cost, nil
+}
+
+// plan determines the cost of all iterators created as part of this plan.
+func (p *preparedStatement) plan() ([]planNode, error) {
+	ic := &explainIteratorCreator{ic: p.ic}
+	p.ic = ic
+	cur, err := p.Select(context.Background())
+	p.ic = ic.ic
+
+	if err != nil {
+		return nil, err
+	}
+	cur.Close()
+	return ic.nodes, nil
+}
+
 type planNode struct {
 	Expr cnosql.Expr
 	Aux  []cnosql.VarRef
@@ -2,6 +2,7 @@ package query
 
 import (
 	"container/heap"
+	"fmt"
 	"math"
 	"sort"
 	"time"
@@ -52,6 +53,13 @@ func (CallTypeMapper) CallType(name string, args []cnosql.DataType) (cnosql.Data
 	case "min", "max", "sum", "first", "last":
 		// TODO: Verify the input type.
 		return args[0], nil
+	case "rate", "irate", "increase":
+		switch args[0] {
+		case cnosql.Float, cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+		default:
+			return cnosql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, args[0])
+		}
+		return cnosql.Float, nil
 	}
 	return cnosql.Unknown, nil
 }
@@ -86,6 +94,16 @@ func (m FunctionTypeMapper) CallType(name string, args []cnosql.DataType) (cnosq
 		"chande_momentum_oscillator",
 		"holt_winters", "holt_winters_with_fit":
 		return cnosql.Float, nil
+	case "quantile_approx", "histogram":
+		switch args[0] {
+		case cnosql.Float, cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+		default:
+			return cnosql.Unknown, fmt.Errorf("invalid argument type for the first argument in %s(): %s", name, args[0])
+		}
+		if name == "histogram" {
+			return cnosql.Integer, nil
+		}
+		return cnosql.Float, nil
 	case "elapsed":
 		return cnosql.Integer, nil
 	default:
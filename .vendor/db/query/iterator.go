@@ -136,6 +136,11 @@ func (a Iterators) Merge(opt IteratorOptions) (Iterator, error) {
 		return itr, nil
 	}
 
+	// When merging the stddev() function, merge the moments of the points.
+	if call.Name == "stddev" {
+		return newMomentsMergeIterator(itr, opt)
+	}
+
 	// When merging the count() function, use sum() to sum the counted points.
 	if call.Name == "count" {
 		opt.Expr = &cnosql.Call{
@@ -880,6 +885,32 @@ func (opt IteratorOptions) DerivativeInterval() Interval {
 	return Interval{Duration: time.Second}
 }
 
+// RateInterval returns the time interval for the rate and irate functions.
+func (opt IteratorOptions) RateInterval() Interval {
+	// Use the interval on the call, if specified.
+	if expr, ok := opt.Expr.(*cnosql.Call); ok {
+		for _, arg := range expr.Args[1:] {
+			if arg, ok := arg.(*cnosql.DurationLiteral); ok {
+				return Interval{Duration: arg.Val}
+			}
+		}
+	}
+	return Interval{Duration: time.Second}
+}
+
+// RateExtrapolate returns whether the rate and increase functions extrapolate
+// to the edges of the window.
+func (opt IteratorOptions) RateExtrapolate() bool {
+	if expr, ok := opt.Expr.(*cnosql.Call); ok {
+		for _, arg := range expr.Args[1:] {
+			if arg, ok := arg.(*cnosql.BooleanLiteral); ok {
+				return arg.Val
+			}
+		}
+	}
+	return false
+}
+
 // ElapsedInterval returns the time interval for the elapsed function.
 func (opt IteratorOptions) ElapsedInterval() Interval {
 	// Use the interval on the elapsed() call, if specified.
@@ -1363,6 +1394,8 @@ func (enc *IteratorEncoder) EncodeIterator(itr Iterator) error {
 		return enc.encodeFloatIterator(itr)
 	case IntegerIterator:
 		return enc.encodeIntegerIterator(itr)
+	case UnsignedIterator:
+		return enc.encodeUnsignedIterator(itr)
 	case StringIterator:
 		return enc.encodeStringIterator(itr)
 	case BooleanIterator:
@@ -0,0 +1,705 @@
+package query
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cnosdatabase/cnosql"
+)
+
+// rewriteJoin rewrites the sides of a join in stmt, and in its subqueries,
+// into subqueries reading the fields of each side grouped by the tags joined on.
+//
+// References to the fields of a side are qualified by the name of the metric,
+// as in "cpu.usage", and become the column names of the subqueries. When the
+// query groups by time and every function reads a single side, the functions
+// are computed by the subqueries so the join aligns the buckets of both sides.
+func rewriteJoin(stmt *cnosql.SelectStatement) error {
+	for _, source := range stmt.Sources {
+		if source, ok := source.(*cnosql.SubQuery); ok {
+			if err := rewriteJoin(source.Statement); err != nil {
+				return err
+			}
+		}
+	}
+
+	var join *cnosql.Join
+	for _, source := range stmt.Sources {
+		if source, ok := source.(*cnosql.Join); ok {
+			join = source
+		}
+	}
+	if join == nil {
+		return nil
+	} else if len(stmt.Sources) > 1 {
+		return errors.New("a JOIN cannot be used with other sources")
+	}
+
+	j, err := newJoinRewriter(stmt, join)
+	if err != nil {
+		return err
+	}
+	return j.rewrite()
+}
+
+// joinSide is a side of a join being rewritten.
+type joinSide struct {
+	metric *cnosql.Metric
+	prefix string // prefix of the references to the side
+
+	fields     cnosql.Fields
+	aliases    map[string]bool
+	conditions []cnosql.Expr
+}
+
+// add adds the reference to the field of the side to the fields of its subquery.
+func (s *joinSide) add(ref *cnosql.VarRef) {
+	if s.aliases[ref.Val] {
+		return
+	}
+	s.aliases[ref.Val] = true
+	s.fields = append(s.fields, &cnosql.Field{
+		Expr:  &cnosql.VarRef{Val: strings.TrimPrefix(ref.Val, s.prefix), Type: ref.Type},
+		Alias: ref.Val,
+	})
+}
+
+// addCall adds a function reading the side to the fields of its subquery and
+// returns the reference to its result.
+func (s *joinSide) addCall(call *cnosql.Call) *cnosql.VarRef {
+	alias := call.String()
+	if !s.aliases[alias] {
+		s.aliases[alias] = true
+		s.fields = append(s.fields, &cnosql.Field{
+			Expr:  s.unqualify(call),
+			Alias: alias,
+		})
+	}
+	return &cnosql.VarRef{Val: alias}
+}
+
+// unqualify removes the prefix of the side from the references in expr.
+func (s *joinSide) unqualify(expr cnosql.Expr) cnosql.Expr {
+	return cnosql.RewriteExpr(cnosql.CloneExpr(expr), func(e cnosql.Expr) cnosql.Expr {
+		if ref, ok := e.(*cnosql.VarRef); ok && strings.HasPrefix(ref.Val, s.prefix) {
+			return &cnosql.VarRef{Val: strings.TrimPrefix(ref.Val, s.prefix), Type: ref.Type}
+		}
+		return e
+	})
+}
+
+// Sides a reference or an expression reads.
+const (
+	joinNone  = 0
+	joinLeft  = 1 << 0
+	joinRight = 1 << 1
+)
+
+type joinRewriter struct {
+	stmt        *cnosql.SelectStatement
+	join        *cnosql.Join
+	left, right *joinSide
+	tags        map[string]bool
+}
+
+func newJoinRewriter(stmt *cnosql.SelectStatement, join *cnosql.Join) (*joinRewriter, error) {
+	j := &joinRewriter{
+		stmt: stmt,
+		join: join,
+		tags: make(map[string]bool),
+	}
+
+	var hasTime bool
+	for _, name := range join.On {
+		if name == "time" {
+			hasTime = true
+			continue
+		}
+		j.tags[name] = true
+	}
+	if !hasTime {
+		return nil, errors.New("a JOIN must be ON time")
+	}
+
+	j.left, j.right = newJoinSide(join.Left), newJoinSide(join.Right)
+	if j.left == nil || j.right == nil {
+		return nil, errors.New("only metrics can be joined")
+	} else if j.left.metric.Name == j.right.metric.Name {
+		return nil, fmt.Errorf("unable to join metric %q with itself", j.left.metric.Name)
+	}
+	return j, nil
+}
+
+func newJoinSide(source cnosql.Source) *joinSide {
+	m, ok := source.(*cnosql.Metric)
+	if !ok || m.Regex != nil || m.Name == "" || m.SystemIterator != "" {
+		return nil
+	}
+	return &joinSide{
+		metric:  m,
+		prefix:  m.Name + ".",
+		aliases: make(map[string]bool),
+	}
+}
+
+// sides returns the sides read by expr.
+func (j *joinRewriter) sides(expr cnosql.Expr) (int, error) {
+	sides := joinNone
+	for _, ref := range cnosql.ExprNames(expr) {
+		switch {
+		case ref.Val == "time" || j.tags[ref.Val]:
+		case strings.HasPrefix(ref.Val, j.left.prefix):
+			sides |= joinLeft
+		case strings.HasPrefix(ref.Val, j.right.prefix):
+			sides |= joinRight
+		default:
+			return joinNone, fmt.Errorf("%s must be qualified with the metric it belongs to in a JOIN", ref.String())
+		}
+	}
+	return sides, nil
+}
+
+// joinedTag returns the tag joined on referenced by a qualified reference, if any.
+func (j *joinRewriter) joinedTag(ref *cnosql.VarRef) (string, bool) {
+	for _, side := range []*joinSide{j.left, j.right} {
+		if name := strings.TrimPrefix(ref.Val, side.prefix); name != ref.Val && j.tags[name] {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (j *joinRewriter) rewrite() error {
+	stmt := j.stmt
+	if stmt.HasWildcard() {
+		return errors.New("wildcards cannot be used with a JOIN")
+	}
+
+	// References to a tag joined on are the same on both sides.
+	unqualifyTags := func(expr cnosql.Expr) cnosql.Expr {
+		return cnosql.RewriteExpr(expr, func(e cnosql.Expr) cnosql.Expr {
+			if ref, ok := e.(*cnosql.VarRef); ok {
+				if name, ok := j.joinedTag(ref); ok {
+					return &cnosql.VarRef{Val: name, Type: ref.Type}
+				}
+			}
+			return e
+		})
+	}
+	for _, f := range stmt.Fields {
+		f.Expr = unqualifyTags(f.Expr)
+		if _, err := j.sides(f.Expr); err != nil {
+			return err
+		}
+	}
+	if stmt.Condition != nil {
+		stmt.Condition = unqualifyTags(stmt.Condition)
+	}
+
+	// Push the conditions on a single side down to it. The conditions on the
+	// right side of a left join filter the joined rows instead.
+	var conditions []cnosql.Expr
+	for _, cond := range splitConjunction(stmt.Condition) {
+		sides, err := j.sides(cond)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case sides == joinNone && !cnosql.HasTimeExpr(cond):
+			j.left.conditions = append(j.left.conditions, cond)
+			j.right.conditions = append(j.right.conditions, cnosql.CloneExpr(cond))
+		case sides == joinLeft:
+			j.left.conditions = append(j.left.conditions, j.left.unqualify(cond))
+		case sides == joinRight && j.join.Type == cnosql.InnerJoin:
+			j.right.conditions = append(j.right.conditions, j.right.unqualify(cond))
+		default:
+			conditions = append(conditions, cond)
+		}
+	}
+	stmt.Condition = joinConjunction(conditions)
+
+	var timeDimensions cnosql.Dimensions
+	for _, d := range stmt.Dimensions {
+		switch expr := d.Expr.(type) {
+		case *cnosql.VarRef:
+			if !j.tags[expr.Val] {
+				return fmt.Errorf("unable to group by %s: it is not a tag of the JOIN", expr)
+			}
+		case *cnosql.Call:
+			if expr.Name == "time" {
+				timeDimensions = append(timeDimensions, d)
+			}
+		}
+	}
+
+	pushdown, err := j.canPushDown(timeDimensions, conditions)
+	if err != nil {
+		return err
+	}
+
+	if pushdown {
+		j.pushDown()
+	} else {
+		for _, f := range stmt.Fields {
+			j.addRefs(f.Expr)
+		}
+		for _, cond := range conditions {
+			j.addRefs(cond)
+		}
+		timeDimensions = nil
+	}
+
+	j.join.Left = &cnosql.SubQuery{Statement: j.subquery(j.left, timeDimensions)}
+	j.join.Right = &cnosql.SubQuery{Statement: j.subquery(j.right, timeDimensions)}
+	return nil
+}
+
+// canPushDown returns true if the functions of the query grouped by time can
+// be computed by the sides of the join.
+func (j *joinRewriter) canPushDown(timeDimensions cnosql.Dimensions, conditions []cnosql.Expr) (bool, error) {
+	if len(timeDimensions) == 0 {
+		return false, nil
+	}
+
+	// Conditions on the joined rows need the raw values.
+	for _, cond := range conditions {
+		if sides, err := j.sides(cond); err != nil {
+			return false, err
+		} else if sides != joinNone {
+			return false, nil
+		}
+	}
+
+	hasCall := false
+	for _, f := range j.stmt.Fields {
+		ok, err := j.pushable(f.Expr, &hasCall)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+	return hasCall, nil
+}
+
+// pushable returns true if every function of expr reads a single side and
+// every reference outside of a function is a tag joined on.
+func (j *joinRewriter) pushable(expr cnosql.Expr, hasCall *bool) (bool, error) {
+	switch expr := expr.(type) {
+	case *cnosql.Call:
+		if !isMathFunction(expr) && !isStringFunction(expr) {
+			*hasCall = true
+			sides, err := j.sides(expr)
+			return sides == joinLeft || sides == joinRight, err
+		}
+		for _, arg := range expr.Args {
+			if ok, err := j.pushable(arg, hasCall); err != nil || !ok {
+				return false, err
+			}
+		}
+		return true, nil
+	case *cnosql.BinaryExpr:
+		if ok, err := j.pushable(expr.LHS, hasCall); err != nil || !ok {
+			return false, err
+		}
+		return j.pushable(expr.RHS, hasCall)
+	case *cnosql.ParenExpr:
+		return j.pushable(expr.Expr, hasCall)
+	case *cnosql.VarRef:
+		return j.tags[expr.Val], nil
+	default:
+		return true, nil
+	}
+}
+
+// pushDown moves the functions of the query to the side they read and
+// replaces them with references to their results.
+func (j *joinRewriter) pushDown() {
+	for _, f := range j.stmt.Fields {
+		if f.Alias == "" {
+			f.Alias = f.Name()
+		}
+
+		f.Expr = cnosql.RewriteExpr(f.Expr, func(e cnosql.Expr) cnosql.Expr {
+			call, ok := e.(*cnosql.Call)
+			if !ok || isMathFunction(call) || isStringFunction(call) {
+				return e
+			}
+			if sides, _ := j.sides(call); sides == joinLeft {
+				return j.left.addCall(call)
+			}
+			return j.right.addCall(call)
+		})
+	}
+
+	// The query reads the buckets computed by the sides.
+	dimensions := make(cnosql.Dimensions, 0, len(j.stmt.Dimensions))
+	for _, d := range j.stmt.Dimensions {
+		if call, ok := d.Expr.(*cnosql.Call); ok && call.Name == "time" {
+			continue
+		}
+		dimensions = append(dimensions, d)
+	}
+	j.stmt.Dimensions = dimensions
+	j.stmt.Fill, j.stmt.FillValue = cnosql.NullFill, nil
+	j.stmt.IsRawQuery = true
+}
+
+// addRefs adds the references to the fields of the sides read by expr.
+func (j *joinRewriter) addRefs(expr cnosql.Expr) {
+	for _, ref := range cnosql.ExprNames(expr) {
+		ref := ref
+		if strings.HasPrefix(ref.Val, j.left.prefix) {
+			j.left.add(&ref)
+		} else if strings.HasPrefix(ref.Val, j.right.prefix) {
+			j.right.add(&ref)
+		}
+	}
+}
+
+// subquery returns the statement reading a side of the join, grouped by the
+// tags joined on and by timeDimensions when the functions are pushed down.
+func (j *joinRewriter) subquery(side *joinSide, timeDimensions cnosql.Dimensions) *cnosql.SelectStatement {
+	stmt := &cnosql.SelectStatement{
+		Fields:     side.fields,
+		Sources:    cnosql.Sources{side.metric},
+		Condition:  joinConjunction(side.conditions),
+		Location:   j.stmt.Location,
+		IsRawQuery: len(timeDimensions) == 0,
+	}
+
+	for _, name := range j.join.Tags() {
+		stmt.Dimensions = append(stmt.Dimensions, &cnosql.Dimension{Expr: &cnosql.VarRef{Val: name}})
+	}
+	if len(timeDimensions) > 0 {
+		for _, d := range timeDimensions {
+			stmt.Dimensions = append(stmt.Dimensions, &cnosql.Dimension{Expr: cnosql.CloneExpr(d.Expr)})
+		}
+		stmt.Fill, stmt.FillValue = j.stmt.Fill, j.stmt.FillValue
+	}
+
+	// A side whose fields are not read still decides which rows are joined.
+	if len(stmt.Fields) == 0 {
+		if len(timeDimensions) > 0 {
+			stmt.Fields = cnosql.Fields{{Expr: &cnosql.Call{Name: "count", Args: []cnosql.Expr{&cnosql.Wildcard{}}}}}
+		} else {
+			stmt.Fields = cnosql.Fields{{Expr: &cnosql.Wildcard{Type: cnosql.FIELD}}}
+		}
+	}
+	return stmt
+}
+
+// splitConjunction returns the expressions combined with AND in expr.
+func splitConjunction(expr cnosql.Expr) []cnosql.Expr {
+	switch e := expr.(type) {
+	case nil:
+		return nil
+	case *cnosql.ParenExpr:
+		return splitConjunction(e.Expr)
+	case *cnosql.BinaryExpr:
+		if e.Op == cnosql.AND {
+			return append(splitConjunction(e.LHS), splitConjunction(e.RHS)...)
+		}
+	}
+	return []cnosql.Expr{expr}
+}
+
+// joinConjunction combines exprs with AND.
+func joinConjunction(exprs []cnosql.Expr) cnosql.Expr {
+	var cond cnosql.Expr
+	for _, expr := range exprs {
+		if cond == nil {
+			cond = expr
+			continue
+		}
+		cond = &cnosql.BinaryExpr{Op: cnosql.AND, LHS: cond, RHS: expr}
+	}
+	return cond
+}
+
+type joinBuilder struct {
+	ic   IteratorCreator
+	join *cnosql.Join
+}
+
+// buildAuxIterator constructs an auxiliary Iterator from a join.
+func (b *joinBuilder) buildAuxIterator(ctx context.Context, opt IteratorOptions) (Iterator, error) {
+	cur, joinOpt, err := b.buildCursor(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+	indexes := b.mapAuxFields(cur.Columns(), opt.Aux)
+
+	// Filter the cursor by a condition if one was given.
+	if opt.Condition != nil {
+		cur = newFilterCursor(cur, opt.Condition)
+	}
+
+	itr := NewIteratorMapper(cur, nil, indexes, joinOpt)
+	if len(opt.GetDimensions()) != len(joinOpt.GetDimensions()) {
+		itr = NewTagSubsetIterator(itr, opt)
+	}
+	return itr, nil
+}
+
+func (b *joinBuilder) buildVarRefIterator(ctx context.Context, expr *cnosql.VarRef, opt IteratorOptions) (Iterator, error) {
+	cur, joinOpt, err := b.buildCursor(ctx, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Look for the field or tag that is driving this query.
+	driver := b.mapAuxField(cur.Columns(), expr)
+	if driver == nil {
+		// Exit immediately if there is no driver. If there is no driver, there
+		// are no results. Period.
+		cur.Close()
+		return nil, nil
+	}
+	indexes := b.mapAuxFields(cur.Columns(), opt.Aux)
+
+	// Filter the cursor by a condition if one was given.
+	if opt.Condition != nil {
+		cur = newFilterCursor(cur, opt.Condition)
+	}
+
+	itr := NewIteratorMapper(cur, driver, indexes, joinOpt)
+	if len(opt.GetDimensions()) != len(joinOpt.GetDimensions()) {
+		itr = NewTagSubsetIterator(itr, opt)
+	}
+	return itr, nil
+}
+
+// buildCursor returns the cursor joining the rows of both sides and the
+// options of the joined rows, which are grouped by the tags joined on.
+func (b *joinBuilder) buildCursor(ctx context.Context, opt IteratorOptions) (Cursor, IteratorOptions, error) {
+	joinOpt := opt
+	joinOpt.Dimensions = b.join.Tags()
+	joinOpt.GroupBy = make(map[string]struct{}, len(joinOpt.Dimensions))
+	for _, name := range joinOpt.Dimensions {
+		joinOpt.GroupBy[name] = struct{}{}
+	}
+
+	left, err := b.buildSide(ctx, b.join.Left, joinOpt)
+	if err != nil {
+		return nil, IteratorOptions{}, err
+	}
+	right, err := b.buildSide(ctx, b.join.Right, joinOpt)
+	if err != nil {
+		left.Close()
+		return nil, IteratorOptions{}, err
+	}
+	return newJoinCursor(left, right, b.join.Type, opt.Ascending), joinOpt, nil
+}
+
+func (b *joinBuilder) buildSide(ctx context.Context, source cnosql.Source, opt IteratorOptions) (Cursor, error) {
+	subquery, ok := source.(*cnosql.SubQuery)
+	if !ok {
+		return nil, fmt.Errorf("invalid source in JOIN: %s", source)
+	}
+
+	subOpt, err := newIteratorOptionsSubstatement(ctx, subquery.Statement, opt)
+	if err != nil {
+		return nil, err
+	}
+	return buildCursor(ctx, subquery.Statement, b.ic, subOpt)
+}
+
+func (b *joinBuilder) mapAuxFields(columns []cnosql.VarRef, auxFields []cnosql.VarRef) []IteratorMap {
+	indexes := make([]IteratorMap, len(auxFields))
+	for i, name := range auxFields {
+		m := b.mapAuxField(columns, &name)
+		if m == nil {
+			// If this field doesn't map to anything, use the NullMap so it
+			// shows up as null.
+			m = NullMap{}
+		}
+		indexes[i] = m
+	}
+	return indexes
+}
+
+func (b *joinBuilder) mapAuxField(columns []cnosql.VarRef, name *cnosql.VarRef) IteratorMap {
+	for i, col := range columns {
+		if col.Val == name.Val {
+			return FieldMap{
+				Index: i,
+				// Cast the result of the field into the desired type.
+				Type: name.Type,
+			}
+		}
+	}
+
+	// Look within the tags joined on.
+	for _, tag := range b.join.Tags() {
+		if tag == name.Val {
+			return TagMap(tag)
+		}
+	}
+	return nil
+}
+
+// joinCursor joins the rows of two cursors with the same tags and time. Both
+// cursors must return their rows ordered by tags, then by time, in the
+// direction of the query.
+type joinCursor struct {
+	left, right joinInput
+	typ         cnosql.JoinType
+	ascending   bool
+	columns     []cnosql.VarRef
+
+	// rows joined but not returned yet.
+	rows   []Row
+	series Series
+}
+
+func newJoinCursor(left, right Cursor, typ cnosql.JoinType, ascending bool) *joinCursor {
+	columns := make([]cnosql.VarRef, 0, len(left.Columns())+len(right.Columns()))
+	columns = append(columns, left.Columns()...)
+	columns = append(columns, right.Columns()...)
+
+	cur := &joinCursor{
+		left:      joinInput{cur: left},
+		right:     joinInput{cur: right},
+		typ:       typ,
+		ascending: ascending,
+		columns:   columns,
+	}
+	cur.left.next()
+	cur.right.next()
+	return cur
+}
+
+func (cur *joinCursor) Scan(row *Row) bool {
+	for len(cur.rows) == 0 {
+		if !cur.join() {
+			return false
+		}
+	}
+
+	*row = cur.rows[0]
+	cur.rows = cur.rows[1:]
+
+	if row.Series.Name != cur.series.Name || row.Series.Tags.ID() != cur.series.Tags.ID() {
+		cur.series.Name = row.Series.Name
+		cur.series.Tags = row.Series.Tags
+		cur.series.id++
+	}
+	row.Series = cur.series
+	return true
+}
+
+// join joins the rows of both sides with the next tags and time. It returns
+// false once there are no rows left to join.
+func (cur *joinCursor) join() bool {
+	if !cur.left.ok {
+		return false
+	}
+
+	cmp := 1
+	if cur.right.ok {
+		cmp = cur.compare(&cur.left.row, &cur.right.row)
+	}
+
+	switch {
+	case cmp < 0:
+		rows := cur.left.run(cur.compare)
+		if cur.typ == cnosql.LeftJoin {
+			nulls := make([]interface{}, len(cur.right.cur.Columns()))
+			for _, l := range rows {
+				cur.rows = append(cur.rows, cur.joined(&l, nulls))
+			}
+		}
+	case cmp > 0:
+		cur.right.run(cur.compare)
+	default:
+		lrows := cur.left.run(cur.compare)
+		rrows := cur.right.run(cur.compare)
+		for _, l := range lrows {
+			for _, r := range rrows {
+				cur.rows = append(cur.rows, cur.joined(&l, r.Values))
+			}
+		}
+	}
+	return true
+}
+
+// compare compares the tags, then the time, of two rows in the order the
+// cursors return them. A descending query returns its series as well as its
+// times in reverse order, as the merge iterators order series by tags in the
+// direction of the query, so both comparisons are reversed.
+func (cur *joinCursor) compare(x, y *Row) int {
+	cmp := strings.Compare(x.Series.Tags.ID(), y.Series.Tags.ID())
+	if cmp == 0 {
+		if x.Time < y.Time {
+			cmp = -1
+		} else if x.Time > y.Time {
+			cmp = 1
+		}
+	}
+	if !cur.ascending {
+		cmp = -cmp
+	}
+	return cmp
+}
+
+// joined returns the row joining a row of the left side with the values of the right side.
+func (cur *joinCursor) joined(l *Row, right []interface{}) Row {
+	values := make([]interface{}, 0, len(cur.columns))
+	values = append(values, l.Values...)
+	values = append(values, right...)
+	return Row{
+		Time:   l.Time,
+		Series: Series{Name: l.Series.Name, Tags: l.Series.Tags},
+		Values: values,
+	}
+}
+
+func (cur *joinCursor) Stats() IteratorStats {
+	stats := cur.left.cur.Stats()
+	stats.Add(cur.right.cur.Stats())
+	return stats
+}
+
+func (cur *joinCursor) Err() error {
+	if err := cur.left.cur.Err(); err != nil {
+		return err
+	}
+	return cur.right.cur.Err()
+}
+
+func (cur *joinCursor) Columns() []cnosql.VarRef {
+	return cur.columns
+}
+
+func (cur *joinCursor) Close() error {
+	lerr := cur.left.cur.Close()
+	if err := cur.right.cur.Close(); err != nil {
+		return err
+	}
+	return lerr
+}
+
+// joinInput reads the rows of a side of a join.
+type joinInput struct {
+	cur Cursor
+	row Row
+	ok  bool
+}
+
+// next reads the next row.
+func (in *joinInput) next() {
+	// Read into a new row as cursors may reuse the values.
+	in.row = Row{}
+	in.ok = in.cur.Scan(&in.row)
+}
+
+// run returns the rows with the same tags and time as the next row.
+func (in *joinInput) run(compare func(x, y *Row) int) []Row {
+	rows := []Row{in.row}
+	for in.next(); in.ok && compare(&rows[0], &in.row) == 0; in.next() {
+		rows = append(rows, in.row)
+	}
+	return rows
+}
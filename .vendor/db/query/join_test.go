@@ -0,0 +1,86 @@
+package query_test
+
+import (
+	"testing"
+)
+
+// joinRegion holds cpu for hosts a, b and c, and mem for hosts a and c only,
+// so that both sides of a join have series the other side does not have.
+var joinRegion = Region{{
+	{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 10 * Second, Fields: map[string]float64{"usage": 1}},
+	{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 20 * Second, Fields: map[string]float64{"usage": 2}},
+	{Name: "cpu", Tags: map[string]string{"host": "b"}, Time: 10 * Second, Fields: map[string]float64{"usage": 3}},
+	{Name: "cpu", Tags: map[string]string{"host": "b"}, Time: 20 * Second, Fields: map[string]float64{"usage": 4}},
+	{Name: "cpu", Tags: map[string]string{"host": "c"}, Time: 10 * Second, Fields: map[string]float64{"usage": 5}},
+	{Name: "cpu", Tags: map[string]string{"host": "c"}, Time: 20 * Second, Fields: map[string]float64{"usage": 6}},
+	{Name: "mem", Tags: map[string]string{"host": "a"}, Time: 10 * Second, Fields: map[string]float64{"total": 10}},
+	{Name: "mem", Tags: map[string]string{"host": "c"}, Time: 10 * Second, Fields: map[string]float64{"total": 50}},
+	{Name: "mem", Tags: map[string]string{"host": "c"}, Time: 20 * Second, Fields: map[string]float64{"total": 60}},
+	{Name: "mem", Tags: map[string]string{"host": "d"}, Time: 20 * Second, Fields: map[string]float64{"total": 70}},
+}}
+
+func TestSelect_Join(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		s    string
+		rows []string
+	}{
+		{
+			name: "inner ascending",
+			s:    `SELECT cpu.usage, mem.total FROM cpu JOIN mem ON time, host WHERE time >= 0s AND time < 30s GROUP BY host`,
+			rows: []string{
+				"host=a 10 [1 10]",
+				"host=c 10 [5 50]",
+				"host=c 20 [6 60]",
+			},
+		},
+		{
+			name: "inner descending",
+			s:    `SELECT cpu.usage, mem.total FROM cpu INNER JOIN mem ON time, host WHERE time >= 0s AND time < 30s GROUP BY host ORDER BY time DESC`,
+			rows: []string{
+				"host=c 20 [6 60]",
+				"host=c 10 [5 50]",
+				"host=a 10 [1 10]",
+			},
+		},
+		{
+			name: "left ascending",
+			s:    `SELECT cpu.usage, mem.total FROM cpu LEFT JOIN mem ON time, host WHERE time >= 0s AND time < 30s GROUP BY host`,
+			rows: []string{
+				"host=a 10 [1 10]",
+				"host=a 20 [2 <nil>]",
+				"host=b 10 [3 <nil>]",
+				"host=b 20 [4 <nil>]",
+				"host=c 10 [5 50]",
+				"host=c 20 [6 60]",
+			},
+		},
+		{
+			name: "left descending",
+			s:    `SELECT cpu.usage, mem.total FROM cpu LEFT JOIN mem ON time, host WHERE time >= 0s AND time < 30s GROUP BY host ORDER BY time DESC`,
+			rows: []string{
+				"host=c 20 [6 60]",
+				"host=c 10 [5 50]",
+				"host=b 20 [4 <nil>]",
+				"host=b 10 [3 <nil>]",
+				"host=a 20 [2 <nil>]",
+				"host=a 10 [1 10]",
+			},
+		},
+		{
+			name: "expression",
+			s:    `SELECT cpu.usage / mem.total FROM cpu JOIN mem ON time, host WHERE time >= 0s AND time < 30s GROUP BY host`,
+			rows: []string{
+				"host=a 10 [0.1]",
+				"host=c 10 [0.1]",
+				"host=c 20 [0.1]",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := diffRows(selectRows(t, tt.s, joinRegion), tt.rows); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,571 @@
+package query
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/cnosdatabase/cnosql"
+)
+
+// stddev() and spread() are pushed down to the shards like the other
+// aggregates that can be merged from partial results. The shards reduce their
+// points to a partial aggregate, the partial aggregates are merged as they are
+// in turn merged, and the query finally computes the result out of them.
+//
+// The partial aggregate of stddev() is the count, mean and sum of squared
+// differences from the mean of the points, encoded in a string point. The
+// partial aggregate of spread() is the minimum and the maximum of the points,
+// emitted as two points of the type of the field so the spread of the
+// partial aggregates is the spread of the points.
+//
+// The partial aggregate of rate(), irate() and increase() is, for each series,
+// its first, second to last and last points, the number of points and the
+// increase of the counter between the first and the last point, encoded in a
+// string point. The partial aggregates of a series from shards covering
+// successive time ranges are joined end to end, so the increase across the
+// boundary of the shards is counted, and the result is the sum of the results
+// of the series.
+
+// Versions of the encoding of the partial aggregates.
+const (
+	momentsVersion  = 1
+	countersVersion = 1
+)
+
+// moments holds the count, mean and sum of squared differences from the mean
+// of values. Two moments are merged with the parallel algorithm of Chan et al.
+type moments struct {
+	count uint64
+	mean  float64
+	m2    float64
+}
+
+func (m *moments) add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	m.count++
+	delta := v - m.mean
+	m.mean += delta / float64(m.count)
+	m.m2 += delta * (v - m.mean)
+}
+
+func (m *moments) merge(other *moments) {
+	if other.count == 0 {
+		return
+	} else if m.count == 0 {
+		*m = *other
+		return
+	}
+	count := m.count + other.count
+	delta := other.mean - m.mean
+	m.mean += delta * float64(other.count) / float64(count)
+	m.m2 += other.m2 + delta*delta*float64(m.count)*float64(other.count)/float64(count)
+	m.count = count
+}
+
+// stddev returns the sample standard deviation of the values or NaN if there
+// are less than two values.
+func (m *moments) stddev() float64 {
+	if m.count < 2 {
+		return math.NaN()
+	}
+	return math.Sqrt(m.m2 / float64(m.count-1))
+}
+
+// encode returns the binary encoding of the moments.
+func (m *moments) encode() string {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64+16)
+	buf = append(buf, momentsVersion)
+	buf = appendUvarint(buf, m.count)
+	buf = appendFloat(buf, m.mean)
+	buf = appendFloat(buf, m.m2)
+	return string(buf)
+}
+
+// decodeMoments decodes moments encoded with encode.
+func decodeMoments(data string) (*moments, error) {
+	r := &partialReader{data: data}
+	if v := r.byte(); v != momentsVersion {
+		return nil, fmt.Errorf("unsupported moments version: %d", v)
+	}
+
+	m := &moments{}
+	m.count = r.uvarint()
+	m.mean = r.float()
+	m.m2 = r.float()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return m, nil
+}
+
+// StddevPartialReducer reduces the points of stddev() to their moments encoded
+// in a string point. String points are moments that are merged.
+type StddevPartialReducer struct {
+	moments moments
+	errs    *partialError
+}
+
+// NewStddevPartialReducer creates a new StddevPartialReducer.
+func NewStddevPartialReducer() *StddevPartialReducer {
+	return &StddevPartialReducer{}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *StddevPartialReducer) AggregateFloat(p *FloatPoint) { r.moments.add(p.Value) }
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *StddevPartialReducer) AggregateInteger(p *IntegerPoint) { r.moments.add(float64(p.Value)) }
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *StddevPartialReducer) AggregateUnsigned(p *UnsignedPoint) { r.moments.add(float64(p.Value)) }
+
+// AggregateString merges the moments of a point into the reducer. A point that
+// is not moments fails the iterator of the reducer.
+func (r *StddevPartialReducer) AggregateString(p *StringPoint) {
+	m, err := decodeMoments(p.Value)
+	if err != nil {
+		r.errs.set("stddev", err)
+		return
+	}
+	r.moments.merge(m)
+}
+
+// Emit emits the moments of the aggregated points.
+func (r *StddevPartialReducer) Emit() []StringPoint {
+	return []StringPoint{{
+		Time:       ZeroTime,
+		Value:      r.moments.encode(),
+		Aggregated: uint32(r.moments.count),
+	}}
+}
+
+// StddevReducer computes the standard deviation out of the moments of the
+// aggregated points.
+type StddevReducer struct {
+	StddevPartialReducer
+}
+
+// NewStddevReducer creates a new StddevReducer.
+func NewStddevReducer() *StddevReducer {
+	return &StddevReducer{}
+}
+
+// Emit emits the standard deviation of the aggregated points.
+func (r *StddevReducer) Emit() []FloatPoint {
+	return []FloatPoint{{
+		Time:       ZeroTime,
+		Value:      r.moments.stddev(),
+		Aggregated: uint32(r.moments.count),
+	}}
+}
+
+// momentsIterator is a stream of the moments of stddev() points merged from
+// the partial aggregates of the shards. It is the only string input of
+// stddev(), so that stddev() of a string field fails.
+type momentsIterator struct {
+	StringIterator
+}
+
+// newMomentsMergeIterator returns an iterator merging the moments of its
+// input. A point that is not moments fails the iterator.
+func newMomentsMergeIterator(input Iterator, opt IteratorOptions) (Iterator, error) {
+	switch input := input.(type) {
+	case StringIterator:
+		errs := &partialError{}
+		createFn := func() (StringPointAggregator, StringPointEmitter) {
+			fn := NewStddevPartialReducer()
+			fn.errs = errs
+			return fn, fn
+		}
+		itr := newPartialErrorIterator(newStringReduceStringIterator(input, opt, createFn), errs)
+		return &momentsIterator{StringIterator: itr.(StringIterator)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported stddev iterator type: %T", input)
+	}
+}
+
+// FloatSpreadPartialReducer reduces the points of spread() to their minimum
+// and maximum.
+type FloatSpreadPartialReducer struct {
+	FloatSpreadReducer
+}
+
+// NewFloatSpreadPartialReducer creates a new FloatSpreadPartialReducer.
+func NewFloatSpreadPartialReducer() *FloatSpreadPartialReducer {
+	return &FloatSpreadPartialReducer{FloatSpreadReducer: *NewFloatSpreadReducer()}
+}
+
+// Emit emits the minimum and the maximum of the aggregated points.
+func (r *FloatSpreadPartialReducer) Emit() []FloatPoint {
+	return []FloatPoint{
+		{Time: ZeroTime, Value: r.min, Aggregated: r.count},
+		{Time: ZeroTime, Value: r.max},
+	}
+}
+
+// IntegerSpreadPartialReducer reduces the points of spread() to their minimum
+// and maximum.
+type IntegerSpreadPartialReducer struct {
+	IntegerSpreadReducer
+}
+
+// NewIntegerSpreadPartialReducer creates a new IntegerSpreadPartialReducer.
+func NewIntegerSpreadPartialReducer() *IntegerSpreadPartialReducer {
+	return &IntegerSpreadPartialReducer{IntegerSpreadReducer: *NewIntegerSpreadReducer()}
+}
+
+// Emit emits the minimum and the maximum of the aggregated points.
+func (r *IntegerSpreadPartialReducer) Emit() []IntegerPoint {
+	return []IntegerPoint{
+		{Time: ZeroTime, Value: r.min, Aggregated: r.count},
+		{Time: ZeroTime, Value: r.max},
+	}
+}
+
+// UnsignedSpreadPartialReducer reduces the points of spread() to their minimum
+// and maximum.
+type UnsignedSpreadPartialReducer struct {
+	UnsignedSpreadReducer
+}
+
+// NewUnsignedSpreadPartialReducer creates a new UnsignedSpreadPartialReducer.
+func NewUnsignedSpreadPartialReducer() *UnsignedSpreadPartialReducer {
+	return &UnsignedSpreadPartialReducer{UnsignedSpreadReducer: *NewUnsignedSpreadReducer()}
+}
+
+// Emit emits the minimum and the maximum of the aggregated points.
+func (r *UnsignedSpreadPartialReducer) Emit() []UnsignedPoint {
+	return []UnsignedPoint{
+		{Time: ZeroTime, Value: r.min, Aggregated: r.count},
+		{Time: ZeroTime, Value: r.max},
+	}
+}
+
+// counterPoint is a point of a counter.
+type counterPoint struct {
+	time  int64
+	value float64
+}
+
+// counter holds the points of a series of a counter that rate(), irate() and
+// increase() are computed from.
+type counter struct {
+	first counterPoint
+	prev  counterPoint // point before last, set when there are two points or more
+	last  counterPoint
+	count uint64
+	delta float64 // increase from first to last, counting the resets
+}
+
+// joinCounters returns the counter of the points of a and b, whose time
+// ranges do not overlap but may share a point at their boundary.
+func joinCounters(a, b counter) counter {
+	if a.count == 0 {
+		return b
+	} else if b.count == 0 {
+		return a
+	}
+	if b.first.time < a.first.time {
+		a, b = b, a
+	}
+
+	c := counter{
+		first: a.first,
+		prev:  b.prev,
+		last:  b.last,
+		count: a.count + b.count,
+		delta: a.delta + b.delta,
+	}
+	if b.first.time == a.last.time {
+		// Keep a single point for the time at the boundary.
+		c.count--
+		if b.count == 1 {
+			c.prev = a.prev
+		}
+	} else {
+		c.delta += counterDelta(a.last.value, b.first.value)
+		if b.count == 1 {
+			c.prev = a.last
+		}
+	}
+	return c
+}
+
+// counterDelta returns the increase of a counter from prev to curr. A counter
+// reset restarts the counter from zero.
+func counterDelta(prev, curr float64) float64 {
+	if curr < prev {
+		return curr
+	}
+	return curr - prev
+}
+
+// result returns the rate(), irate() or increase() of the counter in the
+// window of its points. It returns false when there are less than two points.
+// The interval is the unit of the rates and, when extrapolate is set, the
+// increase is extrapolated to the edges of the window like Prometheus does.
+func (c *counter) result(name string, interval Interval, extrapolate bool, opt IteratorOptions) (float64, bool) {
+	if c.count < 2 {
+		return 0, false
+	}
+
+	if name == "irate" {
+		return counterDelta(c.prev.value, c.last.value) / (float64(c.last.time-c.prev.time) / float64(interval.Duration)), true
+	}
+
+	sampled := float64(c.last.time - c.first.time)
+	start, end := opt.Window(c.first.time)
+	if !extrapolate || start <= cnosql.MinTime || end > cnosql.MaxTime {
+		if name == "rate" {
+			return c.delta / (sampled / float64(interval.Duration)), true
+		}
+		return c.delta, true
+	}
+
+	// Extrapolate the increase to the edges of the window. The increase is
+	// extended to an edge only if the points reach close enough to it,
+	// otherwise by half the average distance between points.
+	toStart := float64(c.first.time - start)
+	toEnd := float64(end - c.last.time)
+
+	// A counter cannot be extrapolated below zero.
+	if c.delta > 0 && c.first.value >= 0 {
+		if toZero := sampled * (c.first.value / c.delta); toZero < toStart {
+			toStart = toZero
+		}
+	}
+
+	average := sampled / float64(c.count-1)
+	threshold := average * 1.1
+	extended := sampled
+	if toStart < threshold {
+		extended += toStart
+	} else {
+		extended += average / 2
+	}
+	if toEnd < threshold {
+		extended += toEnd
+	} else {
+		extended += average / 2
+	}
+
+	value := c.delta * (extended / sampled)
+	if name == "rate" {
+		value /= float64(end-start) / float64(interval.Duration)
+	}
+	return value, true
+}
+
+// encodeCounters returns the binary encoding of the counters of series.
+func encodeCounters(counters map[string]*counter) string {
+	keys := make([]string, 0, len(counters))
+	for k := range counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64)
+	buf = append(buf, countersVersion)
+	buf = appendUvarint(buf, uint64(len(keys)))
+	for _, k := range keys {
+		c := counters[k]
+		buf = appendUvarint(buf, uint64(len(k)))
+		buf = append(buf, k...)
+		buf = appendUvarint(buf, c.count)
+		buf = appendFloat(buf, c.delta)
+		for _, p := range []counterPoint{c.first, c.prev, c.last} {
+			buf = appendVarint(buf, p.time)
+			buf = appendFloat(buf, p.value)
+		}
+	}
+	return string(buf)
+}
+
+// decodeCounters decodes the counters of series encoded with encodeCounters.
+func decodeCounters(data string) (map[string]*counter, error) {
+	r := &partialReader{data: data}
+	if v := r.byte(); v != countersVersion {
+		return nil, fmt.Errorf("unsupported counters version: %d", v)
+	}
+
+	n := r.uvarint()
+	counters := make(map[string]*counter)
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		k := r.string()
+		c := &counter{}
+		c.count = r.uvarint()
+		c.delta = r.float()
+		for _, p := range []*counterPoint{&c.first, &c.prev, &c.last} {
+			p.time = r.varint()
+			p.value = r.float()
+		}
+		counters[k] = c
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return counters, nil
+}
+
+// RatePartialReducer reduces the points of rate(), irate() and increase() to
+// the counters of their series encoded in a string point. String points are
+// counters that are joined.
+type RatePartialReducer struct {
+	counters map[string]*counter
+	errs     *partialError
+	name     string
+}
+
+// NewRatePartialReducer creates a new RatePartialReducer for the named function.
+func NewRatePartialReducer(name string) *RatePartialReducer {
+	return &RatePartialReducer{
+		counters: make(map[string]*counter),
+		name:     name,
+	}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *RatePartialReducer) AggregateFloat(p *FloatPoint) {
+	r.aggregate(p.Tags.ID(), p.Time, p.Value)
+}
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *RatePartialReducer) AggregateInteger(p *IntegerPoint) {
+	r.aggregate(p.Tags.ID(), p.Time, float64(p.Value))
+}
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *RatePartialReducer) AggregateUnsigned(p *UnsignedPoint) {
+	r.aggregate(p.Tags.ID(), p.Time, float64(p.Value))
+}
+
+// aggregate adds a point of the series with key. The points of a series are
+// read in the order of the query.
+func (r *RatePartialReducer) aggregate(key string, t int64, v float64) {
+	p := counterPoint{time: t, value: v}
+	r.join(key, counter{first: p, last: p, count: 1})
+}
+
+func (r *RatePartialReducer) join(key string, other counter) {
+	c := r.counters[key]
+	if c == nil {
+		c = &counter{}
+		r.counters[key] = c
+	}
+	*c = joinCounters(*c, other)
+}
+
+// AggregateString joins the counters of a point to the counters of the
+// reducer. A point that is not counters fails the iterator of the reducer.
+func (r *RatePartialReducer) AggregateString(p *StringPoint) {
+	counters, err := decodeCounters(p.Value)
+	if err != nil {
+		r.errs.set(r.name, err)
+		return
+	}
+	for k, c := range counters {
+		r.join(k, *c)
+	}
+}
+
+// Emit emits the counters of the aggregated points.
+func (r *RatePartialReducer) Emit() []StringPoint {
+	if len(r.counters) == 0 {
+		return nil
+	}
+	var n uint64
+	for _, c := range r.counters {
+		n += c.count
+	}
+	return []StringPoint{{
+		Time:       ZeroTime,
+		Value:      encodeCounters(r.counters),
+		Aggregated: uint32(n),
+	}}
+}
+
+// RateReducer computes the rate(), irate() or increase() out of the counters
+// of the aggregated points. The result is the sum of the results of the
+// series, and nothing is emitted when no series has two points in the window
+// so fill() applies to it.
+type RateReducer struct {
+	RatePartialReducer
+	interval    Interval
+	extrapolate bool
+	opt         IteratorOptions
+}
+
+// NewRateReducer creates a new RateReducer for the named function. The
+// interval is the unit of the rates and, when extrapolate is set, the increase
+// is extrapolated to the edges of the window.
+func NewRateReducer(name string, interval Interval, extrapolate bool, opt IteratorOptions) *RateReducer {
+	return &RateReducer{
+		RatePartialReducer: *NewRatePartialReducer(name),
+		interval:           interval,
+		extrapolate:        extrapolate,
+		opt:                opt,
+	}
+}
+
+// Emit emits the result of the aggregated points.
+func (r *RateReducer) Emit() []FloatPoint {
+	var (
+		value float64
+		n     uint64
+		ok    bool
+	)
+	keys := make([]string, 0, len(r.counters))
+	for k := range r.counters {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		c := r.counters[k]
+		if v, vok := c.result(r.name, r.interval, r.extrapolate, r.opt); vok {
+			value += v
+			n += c.count
+			ok = true
+		}
+	}
+	if !ok {
+		return nil
+	}
+	return []FloatPoint{{Time: ZeroTime, Value: value, Aggregated: uint32(n)}}
+}
+
+func appendFloat(buf []byte, v float64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], math.Float64bits(v))
+	return append(buf, b[:]...)
+}
+
+func (r *partialReader) float() float64 {
+	if len(r.data) < 8 {
+		if r.err == nil {
+			r.err = errors.New("invalid partial aggregate")
+		}
+		return 0
+	}
+	v := math.Float64frombits(binary.LittleEndian.Uint64([]byte(r.data[:8])))
+	r.data = r.data[8:]
+	return v
+}
+
+func (r *partialReader) string() string {
+	n := r.uvarint()
+	if uint64(len(r.data)) < n {
+		if r.err == nil {
+			r.err = errors.New("invalid partial aggregate")
+		}
+		return ""
+	}
+	v := r.data[:n]
+	r.data = r.data[n:]
+	return v
+}
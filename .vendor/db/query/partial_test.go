@@ -0,0 +1,176 @@
+package query_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/query"
+)
+
+// counterRegion holds a counter of host a spanning two shard groups, with a
+// reset in the second one, and a counter of host b in the first one only.
+var counterRegion = Region{
+	{
+		{Name: "requests", Tags: map[string]string{"host": "a"}, Time: 0 * Second, Fields: map[string]float64{"value": 0}},
+		{Name: "requests", Tags: map[string]string{"host": "a"}, Time: 10 * Second, Fields: map[string]float64{"value": 10}},
+		{Name: "requests", Tags: map[string]string{"host": "a"}, Time: 20 * Second, Fields: map[string]float64{"value": 20}},
+		{Name: "requests", Tags: map[string]string{"host": "b"}, Time: 0 * Second, Fields: map[string]float64{"value": 100}},
+		{Name: "requests", Tags: map[string]string{"host": "b"}, Time: 10 * Second, Fields: map[string]float64{"value": 110}},
+	},
+	{
+		{Name: "requests", Tags: map[string]string{"host": "a"}, Time: 30 * Second, Fields: map[string]float64{"value": 30}},
+		{Name: "requests", Tags: map[string]string{"host": "a"}, Time: 40 * Second, Fields: map[string]float64{"value": 5}},
+		{Name: "requests", Tags: map[string]string{"host": "a"}, Time: 50 * Second, Fields: map[string]float64{"value": 15}},
+	},
+}
+
+// Ensure the counter functions of a series spanning two shard groups are
+// computed from the points of both shards rather than summed per shard.
+func TestSelect_Rate(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		s    string
+		rows []string
+	}{
+		{
+			name: "increase",
+			s:    `SELECT increase(value) FROM requests WHERE time >= 0s AND time < 60s GROUP BY host`,
+			rows: []string{
+				"host=a 0 [45]",
+				"host=b 0 [10]",
+			},
+		},
+		{
+			name: "increase of all series",
+			s:    `SELECT increase(value) FROM requests WHERE time >= 0s AND time < 60s`,
+			rows: []string{
+				" 0 [55]",
+			},
+		},
+		{
+			name: "increase descending",
+			s:    `SELECT increase(value) FROM requests WHERE time >= 0s AND time < 60s GROUP BY host ORDER BY time DESC`,
+			rows: []string{
+				"host=b 0 [10]",
+				"host=a 0 [45]",
+			},
+		},
+		{
+			name: "increase extrapolated",
+			s:    `SELECT increase(value, true) FROM requests WHERE time >= 0s AND time < 60s GROUP BY time(60s), host`,
+			rows: []string{
+				"host=a 0 [54]",
+				"host=b 0 [15]",
+			},
+		},
+		{
+			name: "rate",
+			s:    `SELECT rate(value, 10s) FROM requests WHERE time >= 0s AND time < 60s GROUP BY host`,
+			rows: []string{
+				"host=a 0 [9]",
+				"host=b 0 [10]",
+			},
+		},
+		{
+			name: "irate",
+			s:    `SELECT irate(value) FROM requests WHERE time >= 0s AND time < 60s GROUP BY host`,
+			rows: []string{
+				"host=a 0 [1]",
+				"host=b 0 [1]",
+			},
+		},
+		{
+			name: "window across shards",
+			s:    `SELECT increase(value) FROM requests WHERE time >= 0s AND time < 60s AND host = 'a' GROUP BY time(40s)`,
+			rows: []string{
+				" 0 [30]",
+				" 40 [10]",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := diffRows(selectRows(t, tt.s, counterRegion), tt.rows); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+// Ensure stddev() and spread() of points spread over two shard groups are
+// merged from the partial aggregates of the shards.
+func TestSelect_StddevSpread(t *testing.T) {
+	region := Region{
+		{
+			{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 0 * Second, Fields: map[string]float64{"value": 2}},
+			{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 10 * Second, Fields: map[string]float64{"value": 4}},
+			{Name: "cpu", Tags: map[string]string{"host": "b"}, Time: 10 * Second, Fields: map[string]float64{"value": 4}},
+		},
+		{
+			{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 20 * Second, Fields: map[string]float64{"value": 4}},
+			{Name: "cpu", Tags: map[string]string{"host": "b"}, Time: 30 * Second, Fields: map[string]float64{"value": 5}},
+			{Name: "cpu", Tags: map[string]string{"host": "b"}, Time: 40 * Second, Fields: map[string]float64{"value": 9}},
+		},
+	}
+
+	for _, tt := range []struct {
+		name string
+		s    string
+		rows []string
+	}{
+		{
+			name: "all series",
+			s:    `SELECT stddev(value), spread(value) FROM cpu WHERE time >= 0s AND time < 60s`,
+			rows: []string{
+				" 0 [2.3380903889000244 7]",
+			},
+		},
+		{
+			name: "by series",
+			s:    `SELECT stddev(value), spread(value) FROM cpu WHERE time >= 0s AND time < 60s GROUP BY host`,
+			rows: []string{
+				"host=a 0 [1.1547005383792515 2]",
+				"host=b 0 [2.6457513110645907 5]",
+			},
+		},
+		{
+			name: "single point",
+			s:    `SELECT stddev(value), spread(value) FROM cpu WHERE time >= 0s AND time < 60s AND host = 'a' GROUP BY time(20s)`,
+			rows: []string{
+				" 0 [1.4142135623730951 2]",
+				" 20 [<nil> 0]",
+				" 40 [<nil> <nil>]",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := diffRows(selectRows(t, tt.s, region), tt.rows); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+// Ensure stddev() of a string field is rejected by the shards.
+func TestCallIterator_StddevString(t *testing.T) {
+	opt := query.IteratorOptions{Expr: cnosql.MustParseExpr(`stddev(value)`)}
+	_, err := query.NewCallIterator(&stringIterator{}, opt)
+	if err == nil || !strings.HasPrefix(err.Error(), "unsupported stddev iterator type: ") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure strings that are not the moments of stddev() fail the merge of the
+// partial aggregates of the shards.
+func TestIterators_MergeMoments(t *testing.T) {
+	opt := query.IteratorOptions{Expr: cnosql.MustParseExpr(`stddev(value)`)}
+	itr, err := query.Iterators{&stringIterator{points: []query.StringPoint{{Name: "cpu", Value: "x"}}}}.Merge(opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer itr.Close()
+
+	if _, err := itr.(query.StringIterator).Next(); err == nil || err.Error() != "invalid stddev() partial aggregate: unsupported moments version: 120" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -13,7 +13,11 @@ import (
 	"github.com/cnosdatabase/db/query/internal/gota"
 )
 
+// DefaultTypeMapper types the functions of the query engine. StringTypeMapper
+// comes first since FunctionTypeMapper types the functions it does not know as
+// their first argument.
 var DefaultTypeMapper = cnosql.MultiTypeMapper(
+	StringTypeMapper{},
 	FunctionTypeMapper{},
 	MathTypeMapper{},
 )
@@ -68,6 +72,9 @@ type PreparedStatement interface {
 	// Explain outputs the explain plan for this statement.
 	Explain() (string, error)
 
+	// Cost returns the estimated cost of reading the shards for this statement.
+	Cost() (IteratorCost, error)
+
 	// Close closes the resources associated with this prepared statement.
 	// This must be called as the mapped shards may hold open resources such
 	// as network connections.
@@ -188,6 +195,18 @@ func (b *exprIteratorBuilder) buildVarRefIterator(ctx context.Context, expr *cno
 				} else if input != nil {
 					inputs = append(inputs, input)
 				}
+			case *cnosql.Join:
+				join := joinBuilder{
+					ic:   b.ic,
+					join: source,
+				}
+
+				input, err := join.buildVarRefIterator(ctx, expr, b.opt)
+				if err != nil {
+					return err
+				} else if input != nil {
+					inputs = append(inputs, input)
+				}
 			}
 		}
 		return nil
@@ -226,6 +245,16 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 			return nil, err
 		}
 		return NewIntervalIterator(input, opt), nil
+	case "histogram":
+		input, err := b.callIterator(ctx, expr, opt)
+		if err != nil {
+			return nil, err
+		}
+		input, err = newHistogramCountIterator(input, opt)
+		if err != nil {
+			return nil, err
+		}
+		return NewIntervalIterator(input, opt), nil
 	case "sample":
 		opt.Ordered = true
 		input, err := buildExprIterator(ctx, expr.Args[0], b.ic, b.sources, opt, b.selector, false)
@@ -511,6 +540,12 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 			fallthrough
 		case "min", "max", "sum", "first", "last", "mean":
 			return b.callIterator(ctx, expr, opt)
+		case "rate", "irate", "increase":
+			input, err := b.callIterator(ctx, expr, opt)
+			if err != nil {
+				return nil, err
+			}
+			return newRateIterator(input, opt)
 		case "median":
 			opt.Ordered = true
 			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
@@ -525,14 +560,13 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 			}
 			return NewModeIterator(input, opt)
 		case "stddev":
-			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
+			input, err := b.callIterator(ctx, expr, opt)
 			if err != nil {
 				return nil, err
 			}
 			return newStddevIterator(input, opt)
 		case "spread":
-			// OPTIMIZE(benbjohnson): convert to map/reduce
-			input, err := buildExprIterator(ctx, expr.Args[0].(*cnosql.VarRef), b.ic, b.sources, opt, false, false)
+			input, err := b.callIterator(ctx, expr, opt)
 			if err != nil {
 				return nil, err
 			}
@@ -551,6 +585,19 @@ func (b *exprIteratorBuilder) buildCallIterator(ctx context.Context, expr *cnosq
 				percentile = float64(arg.Val)
 			}
 			return newPercentileIterator(input, opt, percentile)
+		case "quantile_approx":
+			input, err := b.callIterator(ctx, expr, opt)
+			if err != nil {
+				return nil, err
+			}
+			var q float64
+			switch arg := expr.Args[1].(type) {
+			case *cnosql.NumberLiteral:
+				q = arg.Val
+			case *cnosql.IntegerLiteral:
+				q = float64(arg.Val)
+			}
+			return newQuantileApproxIterator(input, opt, q)
 		default:
 			return nil, fmt.Errorf("unsupported call: %s", expr.Name)
 		}
@@ -583,7 +630,7 @@ func (b *exprIteratorBuilder) callIterator(ctx context.Context, expr *cnosql.Cal
 					return err
 				}
 				inputs = append(inputs, input)
-			case *cnosql.SubQuery:
+			case *cnosql.SubQuery, *cnosql.Join:
 				// Identify the name of the field we are using.
 				arg0 := expr.Args[0].(*cnosql.VarRef)
 
@@ -666,6 +713,9 @@ func buildCursor(ctx context.Context, stmt *cnosql.SelectStatement, ic IteratorC
 					nf := cnosql.Field{Expr: expr.Args[i]}
 					fields = append(fields, valueMapper.Map(&nf))
 				}
+			} else if expr.Name == "histogram" {
+				// The label of the bucket of each count is a column of its own.
+				fields = append(fields, &cnosql.Field{Expr: &histogramBucketRef})
 			}
 		}
 	}
@@ -742,9 +792,12 @@ func buildCursor(ctx context.Context, stmt *cnosql.SelectStatement, ic IteratorC
 			return nil, err
 		}
 
-		keys := make([]cnosql.VarRef, 0, len(auxKeys)+1)
+		keys := make([]cnosql.VarRef, 0, len(auxKeys)+2)
 		keys = append(keys, driver)
 		keys = append(keys, auxKeys...)
+		if call.Name == "histogram" {
+			keys = append(keys, histogramBucketRef)
+		}
 
 		scanner := NewIteratorScanner(itr, keys, opt.FillValue)
 		scanners = append(scanners, scanner)
@@ -794,6 +847,17 @@ func buildAuxIterator(ctx context.Context, ic IteratorCreator, sources cnosql.So
 				} else if input != nil {
 					inputs = append(inputs, input)
 				}
+			case *cnosql.Join:
+				b := joinBuilder{
+					ic:   ic,
+					join: source,
+				}
+
+				input, err := b.buildAuxIterator(ctx, opt)
+				if err != nil {
+					return err
+				}
+				inputs = append(inputs, input)
 			}
 		}
 		return nil
@@ -907,7 +971,7 @@ func (v *valueMapper) Visit(n cnosql.Node) cnosql.Visitor {
 		// as stored in the symbol table.
 		switch n := n.(type) {
 		case *cnosql.Call:
-			if isMathFunction(n) {
+			if isMathFunction(n) || isStringFunction(n) {
 				return v
 			}
 			v.calls[n] = struct{}{}
@@ -951,6 +1015,7 @@ func (v *valueMapper) rewriteExpr(expr cnosql.Expr) cnosql.Expr {
 func validateTypes(stmt *cnosql.SelectStatement) error {
 	valuer := cnosql.TypeValuerEval{
 		TypeMapper: cnosql.MultiTypeMapper(
+			StringTypeMapper{},
 			FunctionTypeMapper{},
 			MathTypeMapper{},
 		),
@@ -960,7 +1025,16 @@ func validateTypes(stmt *cnosql.SelectStatement) error {
 			return err
 		}
 	}
-	return nil
+
+	// The functions of the condition are only checked against their literal
+	// arguments since the references of the condition are not typed.
+	var err error
+	cnosql.WalkFunc(stmt.Condition, func(n cnosql.Node) {
+		if call, ok := n.(*cnosql.Call); ok && err == nil {
+			_, err = valuer.EvalType(call)
+		}
+	})
+	return err
 }
 
 // hasValidType returns true if there is at least one non-unknown type
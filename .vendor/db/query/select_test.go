@@ -0,0 +1,205 @@
+package query_test
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/query"
+)
+
+// Second is a second in nanoseconds.
+const Second = int64(1e9)
+
+// Point is a point with float fields stored in a Shard.
+type Point struct {
+	Name   string
+	Tags   map[string]string
+	Time   int64
+	Fields map[string]float64
+}
+
+// Shard is a shard holding points. Like the shards of the engine, it returns
+// the points of each series ordered by time and calls functions over them.
+type Shard []Point
+
+func (s Shard) CreateIterator(ctx context.Context, m *cnosql.Metric, opt query.IteratorOptions) (query.Iterator, error) {
+	if call, ok := opt.Expr.(*cnosql.Call); ok {
+		vopt := opt
+		vopt.Expr = call.Args[0]
+		input, err := s.CreateIterator(ctx, m, vopt)
+		if err != nil {
+			return nil, err
+		}
+		return query.NewCallIterator(input, opt)
+	}
+
+	var points []query.FloatPoint
+	for _, p := range s {
+		if p.Name != m.Name || p.Time < opt.StartTime || p.Time > opt.EndTime {
+			continue
+		}
+
+		valuer := make(map[string]interface{})
+		for k, v := range p.Fields {
+			valuer[k] = v
+		}
+		for k, v := range p.Tags {
+			valuer[k] = v
+		}
+		if opt.Condition != nil && !(&cnosql.ValuerEval{Valuer: cnosql.MapValuer(valuer)}).EvalBool(opt.Condition) {
+			continue
+		}
+
+		// Points carry the tags of their series, like the points of the engine.
+		point := query.FloatPoint{Name: p.Name, Tags: query.NewTags(p.Tags), Time: p.Time}
+		if ref, ok := opt.Expr.(*cnosql.VarRef); ok {
+			v, ok := p.Fields[ref.Val]
+			if !ok {
+				continue
+			}
+			point.Value = v
+		}
+		for _, ref := range opt.Aux {
+			point.Aux = append(point.Aux, valuer[ref.Val])
+		}
+		points = append(points, point)
+	}
+
+	// Series are ordered by the tags of the dimensions, then time, both
+	// reversed when descending.
+	sort.SliceStable(points, func(i, j int) bool {
+		a, b := points[i].Tags.Subset(opt.Dimensions), points[j].Tags.Subset(opt.Dimensions)
+		if a.ID() != b.ID() {
+			return (a.ID() < b.ID()) == opt.Ascending
+		}
+		a, b = points[i].Tags, points[j].Tags
+		if a.ID() != b.ID() {
+			return (a.ID() < b.ID()) == opt.Ascending
+		}
+		return (points[i].Time < points[j].Time) == opt.Ascending
+	})
+	return &floatIterator{points: points}, nil
+}
+
+// Region is a set of shards, merged like the shards of a shard mapping.
+type Region []Shard
+
+func (r Region) CreateIterator(ctx context.Context, m *cnosql.Metric, opt query.IteratorOptions) (query.Iterator, error) {
+	itrs := make([]query.Iterator, 0, len(r))
+	for _, s := range r {
+		itr, err := s.CreateIterator(ctx, m, opt)
+		if err != nil {
+			query.Iterators(itrs).Close()
+			return nil, err
+		}
+		itrs = append(itrs, itr)
+	}
+	return query.Iterators(itrs).Merge(opt)
+}
+
+func (r Region) IteratorCost(m *cnosql.Metric, opt query.IteratorOptions) (query.IteratorCost, error) {
+	return query.IteratorCost{}, nil
+}
+
+func (r Region) FieldDimensions(m *cnosql.Metric) (map[string]cnosql.DataType, map[string]struct{}, error) {
+	fields := make(map[string]cnosql.DataType)
+	dimensions := make(map[string]struct{})
+	for _, s := range r {
+		for _, p := range s {
+			if p.Name != m.Name {
+				continue
+			}
+			for k := range p.Fields {
+				fields[k] = cnosql.Float
+			}
+			for k := range p.Tags {
+				dimensions[k] = struct{}{}
+			}
+		}
+	}
+	return fields, dimensions, nil
+}
+
+func (r Region) MapType(m *cnosql.Metric, field string) cnosql.DataType {
+	fields, dimensions, _ := r.FieldDimensions(m)
+	if typ, ok := fields[field]; ok {
+		return typ
+	} else if _, ok := dimensions[field]; ok {
+		return cnosql.Tag
+	}
+	return cnosql.Unknown
+}
+
+func (r Region) CallType(name string, args []cnosql.DataType) (cnosql.DataType, error) {
+	return query.FunctionTypeMapper{}.CallType(name, args)
+}
+
+func (r Region) Close() error { return nil }
+
+// ShardMapper maps every source to its region.
+type ShardMapper struct {
+	Region Region
+}
+
+func (m *ShardMapper) MapShards(sources cnosql.Sources, t cnosql.TimeRange, opt query.SelectOptions) (query.Region, error) {
+	return m.Region, nil
+}
+
+type floatIterator struct {
+	points []query.FloatPoint
+}
+
+func (itr *floatIterator) Next() (*query.FloatPoint, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+func (itr *floatIterator) Stats() query.IteratorStats { return query.IteratorStats{} }
+func (itr *floatIterator) Close() error               { return nil }
+
+// selectRows executes s against the shards of r and returns its rows as
+// "tags time values" strings, with the time in seconds.
+func selectRows(t *testing.T, s string, r Region) []string {
+	t.Helper()
+
+	stmt, err := cnosql.ParseStatement(s)
+	if err != nil {
+		t.Fatalf("parse %s: %s", s, err)
+	}
+	cur, err := query.Select(context.Background(), stmt.(*cnosql.SelectStatement), &ShardMapper{Region: r}, query.SelectOptions{})
+	if err != nil {
+		t.Fatalf("select %s: %s", s, err)
+	}
+	defer cur.Close()
+
+	var rows []string
+	var row query.Row
+	for cur.Scan(&row) {
+		var tags []string
+		for _, k := range row.Series.Tags.Keys() {
+			tags = append(tags, k+"="+row.Series.Tags.Value(k))
+		}
+		// The first value is the time.
+		rows = append(rows, fmt.Sprintf("%s %d %v", strings.Join(tags, ","), row.Time/Second, row.Values[1:]))
+	}
+	if err := cur.Err(); err != nil {
+		t.Fatalf("select %s: %s", s, err)
+	}
+	return rows
+}
+
+// diffRows returns a description of the difference of the rows, or an empty string.
+func diffRows(got, exp []string) string {
+	if strings.Join(got, "\n") == strings.Join(exp, "\n") {
+		return ""
+	}
+	return fmt.Sprintf("unexpected rows:\ngot:\n  %s\nexp:\n  %s", strings.Join(got, "\n  "), strings.Join(exp, "\n  "))
+}
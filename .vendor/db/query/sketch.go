@@ -0,0 +1,599 @@
+package query
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cnosdatabase/cnosql"
+)
+
+// quantile_approx() and histogram() are computed in two steps so they can be
+// pushed down to the shards. Each shard reduces its points to a partial
+// aggregate encoded in a string point, the partial aggregates are merged as
+// they are in turn merged, and the query finally reads the quantile or the
+// bucket counts out of the merged partial aggregate.
+
+const (
+	// sketchRelativeAccuracy is the relative error of the quantiles estimated
+	// by a sketch.
+	sketchRelativeAccuracy = 0.01
+
+	// sketchMaxBins is the maximum number of bins of each sign kept by a
+	// sketch. The bins of the values closest to zero are collapsed beyond it.
+	sketchMaxBins = 2048
+
+	// histogramMaxBuckets is the maximum number of buckets of histogram().
+	histogramMaxBuckets = 1000
+)
+
+// Versions of the encoding of the partial aggregates.
+const (
+	sketchVersion    = 1
+	histogramVersion = 1
+)
+
+var (
+	sketchGamma    = (1 + sketchRelativeAccuracy) / (1 - sketchRelativeAccuracy)
+	sketchLogGamma = math.Log(sketchGamma)
+)
+
+// quantileSketch estimates the quantiles of values with a bounded relative
+// error using logarithmically sized bins (DDSketch). Two sketches are merged
+// by adding the counts of their bins.
+type quantileSketch struct {
+	pos   map[int32]uint64 // bins of the positive values
+	neg   map[int32]uint64 // bins of the absolute value of the negative values
+	zero  uint64
+	count uint64
+}
+
+func newQuantileSketch() *quantileSketch {
+	return &quantileSketch{
+		pos: make(map[int32]uint64),
+		neg: make(map[int32]uint64),
+	}
+}
+
+// sketchKey returns the index of the bin of a positive value.
+func sketchKey(v float64) int32 {
+	return int32(math.Ceil(math.Log(v) / sketchLogGamma))
+}
+
+// sketchValue returns the value representing the bin with key.
+func sketchValue(key int32) float64 {
+	return 2 * math.Pow(sketchGamma, float64(key)) / (sketchGamma + 1)
+}
+
+// add adds a value to the sketch. NaN and infinite values are ignored.
+func (s *quantileSketch) add(v float64) {
+	switch {
+	case math.IsNaN(v) || math.IsInf(v, 0):
+		return
+	case v > 0:
+		s.pos[sketchKey(v)]++
+	case v < 0:
+		s.neg[sketchKey(-v)]++
+	default:
+		s.zero++
+	}
+	s.count++
+}
+
+// merge adds the values of other to the sketch.
+func (s *quantileSketch) merge(other *quantileSketch) {
+	for k, n := range other.pos {
+		s.pos[k] += n
+	}
+	for k, n := range other.neg {
+		s.neg[k] += n
+	}
+	s.zero += other.zero
+	s.count += other.count
+}
+
+// collapseSketchBins merges the bins closest to zero until there are at most
+// sketchMaxBins bins.
+func collapseSketchBins(bins map[int32]uint64) {
+	if len(bins) <= sketchMaxBins {
+		return
+	}
+	keys := sortedSketchKeys(bins)
+	n := len(keys) - sketchMaxBins
+	for _, k := range keys[:n] {
+		bins[keys[n]] += bins[k]
+		delete(bins, k)
+	}
+}
+
+func sortedSketchKeys(bins map[int32]uint64) []int32 {
+	keys := make([]int32, 0, len(bins))
+	for k := range bins {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// quantile returns the estimated value at quantile q of the sketch.
+func (s *quantileSketch) quantile(q float64) float64 {
+	if s.count == 0 {
+		return math.NaN()
+	}
+	rank := uint64(q * float64(s.count-1))
+
+	var n uint64
+	neg := sortedSketchKeys(s.neg)
+	for i := len(neg) - 1; i >= 0; i-- {
+		if n += s.neg[neg[i]]; n > rank {
+			return -sketchValue(neg[i])
+		}
+	}
+	if n += s.zero; n > rank {
+		return 0
+	}
+	pos := sortedSketchKeys(s.pos)
+	for _, k := range pos {
+		if n += s.pos[k]; n > rank {
+			return sketchValue(k)
+		}
+	}
+	return sketchValue(pos[len(pos)-1])
+}
+
+// encode returns the binary encoding of the sketch.
+func (s *quantileSketch) encode() string {
+	collapseSketchBins(s.pos)
+	collapseSketchBins(s.neg)
+
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64*(1+2*(len(s.pos)+len(s.neg)+1)))
+	buf = append(buf, sketchVersion)
+	buf = appendUvarint(buf, s.zero)
+	for _, bins := range []map[int32]uint64{s.pos, s.neg} {
+		buf = appendUvarint(buf, uint64(len(bins)))
+		var prev int32
+		for _, k := range sortedSketchKeys(bins) {
+			buf = appendVarint(buf, int64(k-prev))
+			buf = appendUvarint(buf, bins[k])
+			prev = k
+		}
+	}
+	return string(buf)
+}
+
+// decodeQuantileSketch decodes a sketch encoded with encode.
+func decodeQuantileSketch(data string) (*quantileSketch, error) {
+	r := &partialReader{data: data}
+	if v := r.byte(); v != sketchVersion {
+		return nil, fmt.Errorf("unsupported sketch version: %d", v)
+	}
+
+	s := newQuantileSketch()
+	s.zero = r.uvarint()
+	s.count = s.zero
+	for _, bins := range []map[int32]uint64{s.pos, s.neg} {
+		var k int32
+		for i, n := 0, r.uvarint(); i < int(n) && r.err == nil; i++ {
+			k += int32(r.varint())
+			c := r.uvarint()
+			bins[k] += c
+			s.count += c
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return s, nil
+}
+
+// histogram counts values in buckets.
+type histogram struct {
+	bounds []float64 // upper bounds of the buckets but the last one
+	counts []uint64
+}
+
+func newHistogram(bounds []float64) *histogram {
+	return &histogram{
+		bounds: bounds,
+		counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// add counts a value in the bucket with the lowest upper bound greater than or
+// equal to it. NaN values are ignored.
+func (h *histogram) add(v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	h.counts[sort.SearchFloat64s(h.bounds, v)]++
+}
+
+func (h *histogram) merge(other *histogram) error {
+	if len(other.counts) != len(h.counts) {
+		return errors.New("histogram bucket mismatch")
+	}
+	for i, n := range other.counts {
+		h.counts[i] += n
+	}
+	return nil
+}
+
+// empty returns true if no value has been counted.
+func (h *histogram) empty() bool {
+	for _, n := range h.counts {
+		if n > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// encode returns the binary encoding of the counts of the histogram.
+func (h *histogram) encode() string {
+	buf := make([]byte, 0, 1+binary.MaxVarintLen64*(len(h.counts)+1))
+	buf = append(buf, histogramVersion)
+	buf = appendUvarint(buf, uint64(len(h.counts)))
+	for _, n := range h.counts {
+		buf = appendUvarint(buf, n)
+	}
+	return string(buf)
+}
+
+// decodeHistogram decodes the counts of a histogram with bounds encoded
+// with encode.
+func decodeHistogram(data string, bounds []float64) (*histogram, error) {
+	r := &partialReader{data: data}
+	if v := r.byte(); v != histogramVersion {
+		return nil, fmt.Errorf("unsupported histogram version: %d", v)
+	}
+
+	h := newHistogram(bounds)
+	if n := r.uvarint(); r.err == nil && n != uint64(len(h.counts)) {
+		return nil, errors.New("histogram bucket mismatch")
+	}
+	for i := range h.counts {
+		h.counts[i] = r.uvarint()
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return h, nil
+}
+
+// parseHistogramBuckets returns the upper bounds of the buckets described by
+// spec, which is either a list of increasing bounds such as "10,50,100",
+// linear(start, width, count) or exponential(start, factor, count). A last
+// bucket counts the values greater than the last bound.
+func parseHistogramBuckets(spec string) ([]float64, error) {
+	spec = strings.TrimSpace(spec)
+
+	var fn string
+	if i := strings.IndexByte(spec, '('); i >= 0 && strings.HasSuffix(spec, ")") {
+		fn, spec = strings.TrimSpace(spec[:i]), spec[i+1:len(spec)-1]
+	}
+
+	var args []float64
+	for _, s := range strings.Split(spec, ",") {
+		v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("invalid histogram bucket: %q", strings.TrimSpace(s))
+		}
+		args = append(args, v)
+	}
+
+	var bounds []float64
+	switch fn {
+	case "":
+		bounds = args
+	case "linear", "exponential":
+		if len(args) != 3 {
+			return nil, fmt.Errorf("%s() buckets expect 3 arguments, got %d", fn, len(args))
+		}
+		start, step, count := args[0], args[1], args[2]
+		if count < 1 || count > histogramMaxBuckets || count != math.Trunc(count) {
+			return nil, fmt.Errorf("%s() bucket count must be an integer between 1 and %d", fn, histogramMaxBuckets)
+		}
+		v := start
+		for i := 0; i < int(count); i++ {
+			bounds = append(bounds, v)
+			if fn == "linear" {
+				v += step
+			} else {
+				v *= step
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unknown histogram buckets: %s()", fn)
+	}
+
+	if len(bounds) > histogramMaxBuckets {
+		return nil, fmt.Errorf("histogram cannot have more than %d buckets", histogramMaxBuckets)
+	}
+	for i := 1; i < len(bounds); i++ {
+		if !(bounds[i] > bounds[i-1]) || math.IsInf(bounds[i], 0) {
+			return nil, errors.New("histogram bucket bounds must be finite and increasing")
+		}
+	}
+	return bounds, nil
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func appendVarint(buf []byte, v int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+// partialReader reads the binary encoding of a partial aggregate.
+type partialReader struct {
+	data string
+	err  error
+}
+
+func (r *partialReader) byte() byte {
+	if len(r.data) == 0 {
+		r.err = errors.New("partial aggregate too short")
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *partialReader) uvarint() uint64 {
+	v, n := binary.Uvarint([]byte(r.data))
+	if n <= 0 {
+		if r.err == nil {
+			r.err = errors.New("invalid partial aggregate")
+		}
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *partialReader) varint() int64 {
+	v, n := binary.Varint([]byte(r.data))
+	if n <= 0 {
+		if r.err == nil {
+			r.err = errors.New("invalid partial aggregate")
+		}
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+// partialError records the first partial aggregate merged by the reducers of
+// an iterator that could not be decoded or merged.
+type partialError struct {
+	err error
+}
+
+func (e *partialError) set(name string, err error) {
+	if e != nil && e.err == nil {
+		e.err = fmt.Errorf("invalid %s() partial aggregate: %s", name, err)
+	}
+}
+
+// SketchReducer reduces the points of quantile_approx() to a sketch encoded in
+// a string point. String points are sketches that are merged.
+type SketchReducer struct {
+	sketch *quantileSketch
+	errs   *partialError
+}
+
+// NewSketchReducer creates a new SketchReducer.
+func NewSketchReducer() *SketchReducer {
+	return &SketchReducer{sketch: newQuantileSketch()}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *SketchReducer) AggregateFloat(p *FloatPoint) { r.sketch.add(p.Value) }
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *SketchReducer) AggregateInteger(p *IntegerPoint) { r.sketch.add(float64(p.Value)) }
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *SketchReducer) AggregateUnsigned(p *UnsignedPoint) { r.sketch.add(float64(p.Value)) }
+
+// AggregateString merges the sketch of a point into the reducer. A point that
+// is not a sketch fails the iterator of the reducer.
+func (r *SketchReducer) AggregateString(p *StringPoint) {
+	s, err := decodeQuantileSketch(p.Value)
+	if err != nil {
+		r.errs.set("quantile_approx", err)
+		return
+	}
+	r.sketch.merge(s)
+}
+
+// Emit emits the sketch of the aggregated points.
+func (r *SketchReducer) Emit() []StringPoint {
+	if r.sketch.count == 0 {
+		return nil
+	}
+	return []StringPoint{{
+		Time:  ZeroTime,
+		Value: r.sketch.encode(),
+	}}
+}
+
+// QuantileReducer estimates a quantile from the sketches of the aggregated points.
+type QuantileReducer struct {
+	SketchReducer
+	q float64
+}
+
+// NewQuantileReducer creates a new QuantileReducer.
+func NewQuantileReducer(q float64) *QuantileReducer {
+	return &QuantileReducer{
+		SketchReducer: SketchReducer{sketch: newQuantileSketch()},
+		q:             q,
+	}
+}
+
+// Emit emits the estimated quantile of the aggregated points.
+func (r *QuantileReducer) Emit() []FloatPoint {
+	if r.sketch.count == 0 {
+		return nil
+	}
+	return []FloatPoint{{
+		Time:  ZeroTime,
+		Value: r.sketch.quantile(r.q),
+	}}
+}
+
+// HistogramReducer counts the points of histogram() in buckets encoded in a
+// string point. String points are bucket counts that are merged.
+type HistogramReducer struct {
+	histogram *histogram
+	errs      *partialError
+}
+
+// NewHistogramReducer creates a new HistogramReducer.
+func NewHistogramReducer(bounds []float64) *HistogramReducer {
+	return &HistogramReducer{histogram: newHistogram(bounds)}
+}
+
+// AggregateFloat aggregates a point into the reducer.
+func (r *HistogramReducer) AggregateFloat(p *FloatPoint) { r.histogram.add(p.Value) }
+
+// AggregateInteger aggregates a point into the reducer.
+func (r *HistogramReducer) AggregateInteger(p *IntegerPoint) { r.histogram.add(float64(p.Value)) }
+
+// AggregateUnsigned aggregates a point into the reducer.
+func (r *HistogramReducer) AggregateUnsigned(p *UnsignedPoint) { r.histogram.add(float64(p.Value)) }
+
+// AggregateString merges the bucket counts of a point into the reducer. A
+// point that is not bucket counts fails the iterator of the reducer.
+func (r *HistogramReducer) AggregateString(p *StringPoint) {
+	h, err := decodeHistogram(p.Value, r.histogram.bounds)
+	if err == nil {
+		err = r.histogram.merge(h)
+	}
+	if err != nil {
+		r.errs.set("histogram", err)
+	}
+}
+
+// Emit emits the bucket counts of the aggregated points.
+func (r *HistogramReducer) Emit() []StringPoint {
+	if r.histogram.empty() {
+		return nil
+	}
+	return []StringPoint{{
+		Time:  ZeroTime,
+		Value: r.histogram.encode(),
+	}}
+}
+
+// HistogramCountReducer emits the bucket counts of the aggregated points.
+type HistogramCountReducer struct {
+	HistogramReducer
+}
+
+// NewHistogramCountReducer creates a new HistogramCountReducer.
+func NewHistogramCountReducer(bounds []float64) *HistogramCountReducer {
+	return &HistogramCountReducer{HistogramReducer: HistogramReducer{histogram: newHistogram(bounds)}}
+}
+
+// Emit emits a point for each bucket, in the order of the buckets, with the
+// number of points it counts. The label of the upper bound of the bucket,
+// "+Inf" for the last one, is the auxiliary value of the point.
+func (r *HistogramCountReducer) Emit() []IntegerPoint {
+	if r.histogram.empty() {
+		return nil
+	}
+	points := make([]IntegerPoint, len(r.histogram.counts))
+	for i, n := range r.histogram.counts {
+		points[i] = IntegerPoint{
+			Time:  ZeroTime,
+			Value: int64(n),
+			Aux:   []interface{}{histogramBucketLabel(r.histogram.bounds, i)},
+		}
+	}
+	return points
+}
+
+// histogramBucketLabel returns the label of the upper bound of the bucket i.
+func histogramBucketLabel(bounds []float64, i int) string {
+	if i == len(bounds) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(bounds[i], 'g', -1, 64)
+}
+
+// histogramBucketRef is the symbol of the bucket labels of histogram() in the
+// iterator scanner of the call, after the symbol of the bucket counts.
+var histogramBucketRef = cnosql.VarRef{Val: "histogram_le", Type: cnosql.String}
+
+// newPartialErrorIterator returns an iterator that fails with the error of
+// errs once one of the reducers of input failed to merge a partial aggregate.
+func newPartialErrorIterator(input Iterator, errs *partialError) Iterator {
+	switch input := input.(type) {
+	case FloatIterator:
+		return &floatPartialErrorIterator{input: input, errs: errs}
+	case IntegerIterator:
+		return &integerPartialErrorIterator{input: input, errs: errs}
+	case StringIterator:
+		return &stringPartialErrorIterator{input: input, errs: errs}
+	default:
+		return input
+	}
+}
+
+type floatPartialErrorIterator struct {
+	input FloatIterator
+	errs  *partialError
+}
+
+func (itr *floatPartialErrorIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *floatPartialErrorIterator) Close() error         { return itr.input.Close() }
+
+func (itr *floatPartialErrorIterator) Next() (*FloatPoint, error) {
+	p, err := itr.input.Next()
+	if err == nil && itr.errs.err != nil {
+		return nil, itr.errs.err
+	}
+	return p, err
+}
+
+type integerPartialErrorIterator struct {
+	input IntegerIterator
+	errs  *partialError
+}
+
+func (itr *integerPartialErrorIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *integerPartialErrorIterator) Close() error         { return itr.input.Close() }
+
+func (itr *integerPartialErrorIterator) Next() (*IntegerPoint, error) {
+	p, err := itr.input.Next()
+	if err == nil && itr.errs.err != nil {
+		return nil, itr.errs.err
+	}
+	return p, err
+}
+
+type stringPartialErrorIterator struct {
+	input StringIterator
+	errs  *partialError
+}
+
+func (itr *stringPartialErrorIterator) Stats() IteratorStats { return itr.input.Stats() }
+func (itr *stringPartialErrorIterator) Close() error         { return itr.input.Close() }
+
+func (itr *stringPartialErrorIterator) Next() (*StringPoint, error) {
+	p, err := itr.input.Next()
+	if err == nil && itr.errs.err != nil {
+		return nil, itr.errs.err
+	}
+	return p, err
+}
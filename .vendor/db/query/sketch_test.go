@@ -0,0 +1,125 @@
+package query_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/query"
+)
+
+// sketchRegion holds the points of host a in two shards, so that the
+// partial aggregates of both shards are merged.
+var sketchRegion = Region{
+	{
+		{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 10 * Second, Fields: map[string]float64{"value": 1}},
+		{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 20 * Second, Fields: map[string]float64{"value": 20}},
+		{Name: "cpu", Tags: map[string]string{"host": "b"}, Time: 10 * Second, Fields: map[string]float64{"value": 70}},
+	},
+	{
+		{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 40 * Second, Fields: map[string]float64{"value": 5}},
+		{Name: "cpu", Tags: map[string]string{"host": "a"}, Time: 50 * Second, Fields: map[string]float64{"value": 50}},
+	},
+}
+
+func TestSelect_Histogram(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		s    string
+		rows []string
+	}{
+		{
+			name: "all",
+			s:    `SELECT histogram(value, '10,50') FROM cpu WHERE time >= 0s AND time < 60s`,
+			rows: []string{
+				" 0 [2 10]",
+				" 0 [2 50]",
+				" 0 [1 +Inf]",
+			},
+		},
+		{
+			name: "group by time",
+			s:    `SELECT histogram(value, '10,50') FROM cpu WHERE time >= 0s AND time < 60s GROUP BY time(30s)`,
+			rows: []string{
+				" 0 [1 10]",
+				" 0 [1 50]",
+				" 0 [1 +Inf]",
+				" 30 [1 10]",
+				" 30 [1 50]",
+				" 30 [0 +Inf]",
+			},
+		},
+		{
+			name: "group by host",
+			s:    `SELECT histogram(value, 'linear(0, 25, 2)') FROM cpu WHERE time >= 0s AND time < 60s GROUP BY host`,
+			rows: []string{
+				"host=a 0 [0 0]",
+				"host=a 0 [3 25]",
+				"host=a 0 [1 +Inf]",
+				"host=b 0 [0 0]",
+				"host=b 0 [0 25]",
+				"host=b 0 [1 +Inf]",
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if diff := diffRows(selectRows(t, tt.s, sketchRegion), tt.rows); diff != "" {
+				t.Fatal(diff)
+			}
+		})
+	}
+}
+
+func TestSelect_QuantileApprox(t *testing.T) {
+	rows := selectRows(t, `SELECT quantile_approx(value, 0.5) FROM cpu WHERE time >= 0s AND time < 60s GROUP BY host`, sketchRegion)
+	if diff := diffRows(rows, []string{
+		"host=a 0 [5.002829575110683]",
+		"host=b 0 [70.1118393914018]",
+	}); diff != "" {
+		t.Fatal(diff)
+	}
+}
+
+// Ensure a partial aggregate that cannot be decoded fails the query instead
+// of being dropped.
+func TestCallIterator_InvalidPartial(t *testing.T) {
+	for _, s := range []string{
+		`quantile_approx(value, 0.5)`,
+		`histogram(value, '10,50')`,
+	} {
+		expr, err := cnosql.ParseExpr(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		input := &stringIterator{points: []query.StringPoint{{Name: "cpu", Time: 10 * Second, Value: "garbage"}}}
+		itr, err := query.NewCallIterator(input, query.IteratorOptions{
+			Expr:      expr,
+			StartTime: cnosql.MinTime,
+			EndTime:   cnosql.MaxTime,
+			Ascending: true,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = itr.(query.StringIterator).Next()
+		if err == nil || !strings.Contains(err.Error(), "partial aggregate") {
+			t.Fatalf("%s: unexpected error: %v", s, err)
+		}
+	}
+}
+
+type stringIterator struct {
+	points []query.StringPoint
+}
+
+func (itr *stringIterator) Next() (*query.StringPoint, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+func (itr *stringIterator) Stats() query.IteratorStats { return query.IteratorStats{} }
+func (itr *stringIterator) Close() error               { return nil }
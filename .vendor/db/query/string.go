@@ -0,0 +1,319 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/cnosdatabase/cnosql"
+)
+
+func isStringFunction(call *cnosql.Call) bool {
+	switch call.Name {
+	case "str_len", "lower", "upper", "substr", "concat", "replace", "regexp_extract", "regexp_match", "split_part", "trim", "to_string", "to_float":
+		return true
+	}
+	return false
+}
+
+// stringFunctionArgs returns the minimum and maximum number of arguments of a
+// string function. The maximum is -1 when it is unbounded.
+func stringFunctionArgs(name string) (min, max int) {
+	switch name {
+	case "trim":
+		return 1, 2
+	case "substr", "regexp_extract":
+		return 2, 3
+	case "regexp_match":
+		return 2, 2
+	case "replace", "split_part":
+		return 3, 3
+	case "concat":
+		return 1, -1
+	default:
+		return 1, 1
+	}
+}
+
+type StringTypeMapper struct{}
+
+func (StringTypeMapper) MapType(metric *cnosql.Metric, field string) cnosql.DataType {
+	return cnosql.Unknown
+}
+
+func (StringTypeMapper) CallType(name string, args []cnosql.DataType) (cnosql.DataType, error) {
+	if !isStringFunction(&cnosql.Call{Name: name}) {
+		return cnosql.Unknown, nil
+	}
+
+	// Verify the arguments that must be strings and the ones that must be integers.
+	var strArgs, intArgs []int
+	switch name {
+	case "str_len", "lower", "upper", "trim", "regexp_match":
+		strArgs = []int{0, 1}
+	case "replace":
+		strArgs = []int{0, 1, 2}
+	case "substr":
+		strArgs, intArgs = []int{0}, []int{1, 2}
+	case "regexp_extract":
+		strArgs, intArgs = []int{0}, []int{2}
+	case "split_part":
+		strArgs, intArgs = []int{0, 1}, []int{2}
+	}
+	for _, i := range strArgs {
+		if i >= len(args) {
+			break
+		}
+		switch args[i] {
+		case cnosql.String, cnosql.Tag, cnosql.Unknown:
+		default:
+			return cnosql.Unknown, fmt.Errorf("invalid argument type for the %s argument in %s(): %s", ordinal(i), name, args[i])
+		}
+	}
+	for _, i := range intArgs {
+		if i >= len(args) {
+			break
+		}
+		switch args[i] {
+		case cnosql.Integer, cnosql.Unsigned, cnosql.Unknown:
+		default:
+			return cnosql.Unknown, fmt.Errorf("invalid argument type for the %s argument in %s(): %s", ordinal(i), name, args[i])
+		}
+	}
+
+	switch name {
+	case "str_len":
+		return cnosql.Integer, nil
+	case "regexp_match":
+		return cnosql.Boolean, nil
+	case "to_float":
+		return cnosql.Float, nil
+	default:
+		return cnosql.String, nil
+	}
+}
+
+// ordinal returns the name of the position of the i-th argument.
+func ordinal(i int) string {
+	switch i {
+	case 0:
+		return "first"
+	case 1:
+		return "second"
+	case 2:
+		return "third"
+	default:
+		return strconv.Itoa(i+1) + "th"
+	}
+}
+
+type StringValuer struct{}
+
+var _ cnosql.CallValuer = StringValuer{}
+
+func (StringValuer) Value(key string) (interface{}, bool) {
+	return nil, false
+}
+
+func (v StringValuer) Call(name string, args []interface{}) (interface{}, bool) {
+	if !isStringFunction(&cnosql.Call{Name: name}) {
+		return nil, false
+	}
+
+	switch name {
+	case "to_string":
+		if len(args) != 1 {
+			return nil, true
+		}
+		if s, ok := asString(args[0]); ok {
+			return s, true
+		}
+		return nil, true
+	case "to_float":
+		if len(args) != 1 {
+			return nil, true
+		}
+		switch arg0 := args[0].(type) {
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(arg0), 64); err == nil {
+				return f, true
+			}
+			return nil, true
+		case bool:
+			if arg0 {
+				return float64(1), true
+			}
+			return float64(0), true
+		default:
+			if f, ok := asFloat(arg0); ok {
+				return f, true
+			}
+			return nil, true
+		}
+	case "concat":
+		// Missing values are skipped.
+		var b strings.Builder
+		for _, arg := range args {
+			if s, ok := asString(arg); ok {
+				b.WriteString(s)
+			}
+		}
+		return b.String(), true
+	}
+
+	// All the other functions read a string as their first argument.
+	if len(args) == 0 {
+		return nil, true
+	}
+	arg0, ok := args[0].(string)
+	if !ok {
+		return nil, true
+	}
+
+	switch name {
+	case "str_len":
+		if len(args) == 1 {
+			return int64(utf8.RuneCountInString(arg0)), true
+		}
+	case "lower":
+		if len(args) == 1 {
+			return strings.ToLower(arg0), true
+		}
+	case "upper":
+		if len(args) == 1 {
+			return strings.ToUpper(arg0), true
+		}
+	case "trim":
+		if len(args) == 1 {
+			return strings.TrimSpace(arg0), true
+		} else if cutset, ok := args[1].(string); ok && len(args) == 2 {
+			return strings.Trim(arg0, cutset), true
+		}
+	case "substr":
+		// Positions are counted in characters from 1.
+		start, ok := asInt(args[1:])
+		if !ok {
+			return nil, true
+		}
+		runes := []rune(arg0)
+		if start < 1 {
+			start = 1
+		}
+		if start > int64(len(runes)) {
+			return "", true
+		}
+		end := int64(len(runes))
+		if len(args) == 3 {
+			length, ok := asInt(args[2:])
+			if !ok || length < 0 {
+				return nil, true
+			}
+			if length < end-start+1 {
+				end = start - 1 + length
+			}
+		}
+		return string(runes[start-1 : end]), true
+	case "replace":
+		if len(args) == 3 {
+			old, ok1 := args[1].(string)
+			repl, ok2 := args[2].(string)
+			if ok1 && ok2 {
+				return strings.ReplaceAll(arg0, old, repl), true
+			}
+		}
+	case "regexp_match":
+		if re, ok := asRegexp(args[1:]); ok && len(args) == 2 {
+			return re.MatchString(arg0), true
+		}
+	case "regexp_extract":
+		// Without a group, the first group is extracted or the whole match if
+		// the expression has no group.
+		re, ok := asRegexp(args[1:])
+		if !ok {
+			return nil, true
+		}
+		group := int64(0)
+		if re.NumSubexp() > 0 {
+			group = 1
+		}
+		if len(args) == 3 {
+			if group, ok = asInt(args[2:]); !ok {
+				return nil, true
+			}
+		}
+		if group < 0 || group > int64(re.NumSubexp()) {
+			return nil, true
+		}
+		m := re.FindStringSubmatchIndex(arg0)
+		if m == nil || m[2*group] < 0 {
+			return nil, true
+		}
+		return arg0[m[2*group]:m[2*group+1]], true
+	case "split_part":
+		// Parts are counted from 1 and a missing part is empty.
+		if len(args) != 3 {
+			return nil, true
+		}
+		delim, ok := args[1].(string)
+		if !ok || delim == "" {
+			return nil, true
+		}
+		n, ok := asInt(args[2:])
+		if !ok || n < 1 {
+			return nil, true
+		}
+		parts := strings.Split(arg0, delim)
+		if n > int64(len(parts)) {
+			return "", true
+		}
+		return parts[n-1], true
+	}
+	return nil, true
+}
+
+// asString formats a value as a string.
+func asString(x interface{}) (string, bool) {
+	switch x := x.(type) {
+	case string:
+		return x, true
+	case float64:
+		return strconv.FormatFloat(x, 'f', -1, 64), true
+	case int64:
+		return strconv.FormatInt(x, 10), true
+	case uint64:
+		return strconv.FormatUint(x, 10), true
+	case bool:
+		return strconv.FormatBool(x), true
+	default:
+		return "", false
+	}
+}
+
+// asInt returns the first of args as an integer.
+func asInt(args []interface{}) (int64, bool) {
+	if len(args) == 0 {
+		return 0, false
+	}
+	switch x := args[0].(type) {
+	case int64:
+		return x, true
+	case uint64:
+		if x > uint64(1<<63-1) {
+			return 0, false
+		}
+		return int64(x), true
+	default:
+		return 0, false
+	}
+}
+
+// asRegexp returns the first of args as a regular expression.
+func asRegexp(args []interface{}) (*regexp.Regexp, bool) {
+	if len(args) == 0 {
+		return nil, false
+	}
+	re, ok := args[0].(*regexp.Regexp)
+	return re, ok && re != nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/tracing"
 	"github.com/cnosdatabase/cnosql"
 	"go.uber.org/zap"
 )
@@ -210,8 +211,14 @@ func (t *TaskManager) AttachQuery(q *cnosql.Query, opt ExecutionOptions, interru
 	}
 	t.nextID++
 
+	ectx := context.Background()
+	if opt.Span != nil {
+		ectx = tracing.NewContextWithTrace(ectx, opt.Trace)
+		ectx = tracing.NewContextWithSpan(ectx, opt.Span)
+	}
+
 	ctx := &ExecutionContext{
-		Context:          context.Background(),
+		Context:          ectx,
 		QueryID:          qid,
 		task:             query,
 		ExecutionOptions: opt,
@@ -912,6 +912,8 @@ func (e *Engine) Free() error {
 // backup is running. For shards that are still actively getting writes, this
 // could cause the WAL to backup, increasing memory usage and eventually rejecting writes.
 func (e *Engine) Backup(w io.Writer, basePath string, since time.Time) error {
+	// Files modified after the snapshot time are in the next backup since then.
+	snapshotTime := time.Now().UTC()
 	path, err := e.CreateSnapshot()
 	if err != nil {
 		return err
@@ -919,7 +921,7 @@ func (e *Engine) Backup(w io.Writer, basePath string, since time.Time) error {
 	// Remove the temporary snapshot dir
 	defer os.RemoveAll(path)
 
-	return intar.Stream(w, path, basePath, intar.SinceFilterTarFile(since))
+	return intar.StreamSince(w, path, basePath, since, snapshotTime)
 }
 
 func (e *Engine) timeStampFilterTarFile(start, end time.Time) func(f os.FileInfo, shardRelativePath, fullPath string, tw *tar.Writer) error {
@@ -272,6 +272,7 @@ func (itr *floatIterator) Next() (*query.FloatPoint, error) {
 		valuer := cnosql.ValuerEval{
 			Valuer: cnosql.MultiValuer(
 				query.MathValuer{},
+				query.StringValuer{},
 				cnosql.MapValuer(itr.m),
 			),
 		}
@@ -750,6 +751,7 @@ func (itr *integerIterator) Next() (*query.IntegerPoint, error) {
 		valuer := cnosql.ValuerEval{
 			Valuer: cnosql.MultiValuer(
 				query.MathValuer{},
+				query.StringValuer{},
 				cnosql.MapValuer(itr.m),
 			),
 		}
@@ -1228,6 +1230,7 @@ func (itr *unsignedIterator) Next() (*query.UnsignedPoint, error) {
 		valuer := cnosql.ValuerEval{
 			Valuer: cnosql.MultiValuer(
 				query.MathValuer{},
+				query.StringValuer{},
 				cnosql.MapValuer(itr.m),
 			),
 		}
@@ -1706,6 +1709,7 @@ func (itr *stringIterator) Next() (*query.StringPoint, error) {
 		valuer := cnosql.ValuerEval{
 			Valuer: cnosql.MultiValuer(
 				query.MathValuer{},
+				query.StringValuer{},
 				cnosql.MapValuer(itr.m),
 			),
 		}
@@ -2184,6 +2188,7 @@ func (itr *booleanIterator) Next() (*query.BooleanPoint, error) {
 		valuer := cnosql.ValuerEval{
 			Valuer: cnosql.MultiValuer(
 				query.MathValuer{},
+				query.StringValuer{},
 				cnosql.MapValuer(itr.m),
 			),
 		}
@@ -197,6 +197,13 @@ func (s *Store) IndexBytes() int {
 // Path returns the store's root path.
 func (s *Store) Path() string { return s.path }
 
+// IsOpen returns true if the store has been opened and not closed.
+func (s *Store) IsOpen() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.opened
+}
+
 // Open initializes the store, creating all necessary directories, loading all
 // shards as well as initializing periodic maintenance of them.
 func (s *Store) Open() error {
@@ -1592,6 +1599,123 @@ func (s *Store) TagKeys(auth query.FineAuthorizer, shardIDs []uint64, cond cnosq
 	return results, nil
 }
 
+// SeriesKeys returns the sorted keys of the series in the provided shards
+// that match the condition and that auth is authorized to read.
+func (s *Store) SeriesKeys(auth query.FineAuthorizer, shardIDs []uint64, cond cnosql.Expr) ([]string, error) {
+	if len(shardIDs) == 0 {
+		return nil, nil
+	}
+
+	metricExpr := cnosql.CloneExpr(cond)
+	metricExpr = cnosql.Reduce(cnosql.RewriteExpr(metricExpr, func(e cnosql.Expr) cnosql.Expr {
+		switch e := e.(type) {
+		case *cnosql.BinaryExpr:
+			switch e.Op {
+			case cnosql.EQ, cnosql.NEQ, cnosql.EQREGEX, cnosql.NEQREGEX:
+				tag, ok := e.LHS.(*cnosql.VarRef)
+				if !ok || tag.Val != "_name" {
+					return nil
+				}
+			}
+		}
+		return e
+	}), nil)
+
+	filterExpr := cnosql.CloneExpr(cond)
+	filterExpr = cnosql.Reduce(cnosql.RewriteExpr(filterExpr, func(e cnosql.Expr) cnosql.Expr {
+		switch e := e.(type) {
+		case *cnosql.BinaryExpr:
+			switch e.Op {
+			case cnosql.EQ, cnosql.NEQ, cnosql.EQREGEX, cnosql.NEQREGEX:
+				tag, ok := e.LHS.(*cnosql.VarRef)
+				if !ok || cnosql.IsSystemName(tag.Val) {
+					return nil
+				}
+			}
+		}
+		return e
+	}), nil)
+
+	// Get all the shards we're interested in.
+	is := IndexSet{Indexes: make([]Index, 0, len(shardIDs))}
+	s.mu.RLock()
+	for _, sid := range shardIDs {
+		shard, ok := s.shards[sid]
+		if !ok {
+			continue
+		}
+
+		if is.SeriesFile == nil {
+			sfile, err := shard.SeriesFile()
+			if err != nil {
+				s.mu.RUnlock()
+				return nil, err
+			}
+			is.SeriesFile = sfile
+		}
+
+		index, err := shard.Index()
+		if err != nil {
+			s.mu.RUnlock()
+			return nil, err
+		}
+		is.Indexes = append(is.Indexes, index)
+	}
+	s.mu.RUnlock()
+
+	if len(is.Indexes) == 0 {
+		return nil, nil
+	}
+
+	// Determine list of metrics.
+	is = is.DedupeInmemIndexes()
+	names, err := is.MetricNamesByExpr(nil, metricExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the authorized series of each metric. A series held by
+	// several shards is only returned once.
+	set := make(map[string]struct{})
+	for _, name := range names {
+		itr, err := is.MetricSeriesByExprIterator(name, filterExpr)
+		if err != nil {
+			return nil, err
+		} else if itr == nil {
+			continue
+		}
+
+		for {
+			elem, err := itr.Next()
+			if err != nil {
+				itr.Close()
+				return nil, err
+			} else if elem.SeriesID == 0 {
+				break
+			}
+
+			key := is.SeriesFile.SeriesKey(elem.SeriesID)
+			if len(key) == 0 {
+				continue
+			}
+
+			name, tags := ParseSeriesKey(key)
+			if auth != nil && !auth.AuthorizeSeriesRead(is.Database(), name, tags) {
+				continue
+			}
+			set[string(models.MakeKey(name, tags))] = struct{}{}
+		}
+		itr.Close()
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 type TagValues struct {
 	Metric string
 	Values []KeyValue
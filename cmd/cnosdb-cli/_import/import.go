@@ -22,6 +22,9 @@ const (
 	// defaultPPS is the default points per second that the import will throttle at
 	// by default it's 0, which means it will not throttle
 	defaultPPS = 0
+
+	// defaultConcurrency is the default number of batches written concurrently
+	defaultConcurrency = 1
 )
 
 var (
@@ -75,6 +78,19 @@ func GetCommand() *cobra.Command {
 	flags.StringVar(&config.Path, "path", "", "Path to the file to import.")
 	flags.IntVar(&config.PPS, "pps", defaultPPS, "How many points per second the import will allow.  By default it is zero and will not throttle importing.")
 	flags.BoolVar(&config.Compressed, "compressed", false, "set to true if the import file is compressed")
+	flags.StringVar(&config.Format, "format", importer.FormatLineProtocol, "Format of the file to import: lp or csv.")
+	flags.StringVar(&config.Database, "database", "", "Database to import into, until the file sets another one.")
+	flags.StringVar(&config.TimeToLive, "ttl", "", "Time-to-live to import into, until the file sets another one.")
+	flags.IntVar(&config.Concurrency, "concurrency", defaultConcurrency, "How many batches are written concurrently.")
+	flags.StringVar(&config.Checkpoint, "checkpoint", "", "File recording the progress of the import, so that it can be resumed. Defaults to the path of the file with a .checkpoint suffix when resuming.")
+	flags.BoolVar(&config.Resume, "resume", false, "Resume an interrupted import from its checkpoint, recording the progress if there is none.")
+	flags.StringVar(&config.Rejects, "rejects", "", "File collecting the lines rejected by the server, which can be imported again once fixed.")
+	flags.StringVar(&config.CSV.Metric, "csv-metric", "", "Metric of the rows of a CSV file.")
+	flags.StringVar(&config.CSV.MetricColumn, "csv-metric-column", "", "Column holding the metric of the rows of a CSV file.")
+	flags.StringVar(&config.CSV.TimeColumn, "csv-time-column", "", `Column holding the time of the rows of a CSV file, RFC3339 or in precision (default "time").`)
+	flags.StringSliceVar(&config.CSV.TagColumns, "csv-tags", nil, "Columns of a CSV file imported as tags.")
+	flags.StringSliceVar(&config.CSV.FieldColumns, "csv-fields", nil, "Columns of a CSV file imported as fields, as name[:float|int|uint|bool|string]. Defaults to all the other columns.")
+	flags.StringVar(&config.CSV.Delimiter, "csv-delimiter", ",", "Delimiter of the columns of a CSV file.")
 	return c
 }
 
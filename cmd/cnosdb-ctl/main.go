@@ -15,6 +15,9 @@ func main() {
 	mainCmd.AddCommand(node.GetRemoveMetaCommand())
 	mainCmd.AddCommand(node.GetAddDataCommand())
 	mainCmd.AddCommand(node.GetRemoveDataCommand())
+	mainCmd.AddCommand(node.GetReplaceDataCommand())
+	mainCmd.AddCommand(node.GetDecommissionCommand())
+	mainCmd.AddCommand(node.GetHintedHandoffCommand())
 
 	if err := mainCmd.Execute(); err != nil {
 		fmt.Printf("Error : %+v\n", err)
@@ -2,6 +2,9 @@ package node
 
 import (
 	"fmt"
+	"strconv"
+	"text/tabwriter"
+	"time"
 
 	"github.com/cnosdatabase/cnosdb/cmd/cnosdb-ctl/options"
 	"github.com/cnosdatabase/cnosdb/meta"
@@ -42,13 +45,36 @@ func GetShowCommand() *cobra.Command {
 				return err
 			}
 
-			fmt.Fprintln(cmd.OutOrStdout(), "Data Nodes:\n==========\n")
+			// The statuses are unknown if the meta leader is unreachable.
+			statuses, _ := metaClient.DataNodeStatuses()
+			byID := make(map[uint64]meta.DataNodeStatus, len(statuses))
+			for _, s := range statuses {
+				byID[s.ID] = s
+			}
+
+			fmt.Fprint(cmd.OutOrStdout(), "Data Nodes:\n==========\n\n")
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 1, 4, ' ', 0)
+			fmt.Fprintln(w, "ID\tTCP Address\tStatus\tLast Seen\tVersion\tDisk Bytes")
 			for _, n := range dataNodes {
-				fmt.Fprintln(cmd.OutOrStdout(), n.ID, "    ", n.TCPHost)
+				s, ok := byID[n.ID]
+				if !ok {
+					fmt.Fprintf(w, "%d\t%s\tunknown\t\t\t\n", n.ID, n.TCPHost)
+					continue
+				}
+				var lastSeen string
+				if !s.LastSeen.IsZero() {
+					lastSeen = s.LastSeen.UTC().Format(time.RFC3339)
+				}
+				state := s.State
+				if n.Leaving {
+					state = fmt.Sprintf("%s, leaving: %s", state, decommissionMessage(s.Decommission))
+				}
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%d\n", n.ID, n.TCPHost, state, lastSeen, s.Version, s.DiskBytes)
 			}
+			w.Flush()
 			fmt.Fprintln(cmd.OutOrStdout(), "")
 
-			fmt.Fprintln(cmd.OutOrStdout(), "Meta Nodes:\n==========\n")
+			fmt.Fprint(cmd.OutOrStdout(), "Meta Nodes:\n==========\n\n")
 			for _, n := range metaNodes {
 				fmt.Fprintln(cmd.OutOrStdout(), n.ID, "    ", n.Host)
 			}
@@ -130,3 +156,104 @@ func GetRemoveDataCommand() *cobra.Command {
 		},
 	}
 }
+
+func GetReplaceDataCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "replace-data",
+		Short: "replaces a failed data node",
+		Long: `Replaces a failed data node with a new data node, which takes the ID and the
+shards of the failed node and copies the shards from their other owners.
+The failed node must be down: its heartbeats must have stopped.`,
+		Example: "  cnosdb-ctl replace-data 2 localhost:8088",
+		Args:    cobra.ExactArgs(2),
+		PreRun: func(cmd *cobra.Command, args []string) {
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			oldNodeID, err := strconv.ParseUint(args[0], 10, 64)
+			if err != nil {
+				fmt.Printf("invalid node ID: %s\n", args[0])
+				return
+			}
+			newNodeAddr := args[1]
+			if err := replaceDataServer(options.Env.Bind, oldNodeID, newNodeAddr); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+}
+
+func GetDecommissionCommand() *cobra.Command {
+	var cancel, wait bool
+	c := &cobra.Command{
+		Use:   "decommission",
+		Short: "decommissions a data node",
+		Long: `Decommissions a data node. The node stops receiving new shards, copies its
+shards to the other data nodes, sends its hinted handoff queues and removes
+itself from the cluster. The progress is shown by 'cnosdb-ctl show'.`,
+		Example: "  cnosdb-ctl decommission --wait localhost:8088",
+		PreRun: func(cmd *cobra.Command, args []string) {
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			remoteNodeAddr := args[0]
+			err := decommissionDataServer(options.Env.Bind, remoteNodeAddr, cancel, wait)
+			if err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+
+	c.Flags().BoolVar(&cancel, "cancel", false, "cancel the decommission of the node")
+	c.Flags().BoolVar(&wait, "wait", false, "print the progress until the node is removed")
+	return c
+}
+
+func GetHintedHandoffCommand() *cobra.Command {
+	var username, password string
+	c := &cobra.Command{
+		Use:   "hh",
+		Short: "manages hinted handoff queues",
+		Long: `Manages the hinted handoff queues the data nodes keep for the writes to a
+data node that failed. The queues are shown by 'SHOW HINTED HANDOFF'.`,
+	}
+
+	c.PersistentFlags().StringVarP(&username, "username", "u", "", "admin user, if authentication is enabled")
+	c.PersistentFlags().StringVarP(&password, "password", "p", "", "password of the admin user")
+
+	c.AddCommand(getHintedHandoffPurgeCommand(&username, &password))
+	c.AddCommand(getHintedHandoffReplayCommand(&username, &password))
+	return c
+}
+
+func getHintedHandoffPurgeCommand(username, password *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "purge",
+		Short: "deletes the data queued for a data node",
+		Long: `Deletes the hinted handoff data queued for a data node on all data nodes.
+The node is given by its ID or its TCP address. The deleted writes are lost
+for the node.`,
+		Example: "  cnosdb-ctl hh purge -u admin -p secret 2",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := manageHintedHandoff(options.Env.Bind, args[0], "purge", *username, *password); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+}
+
+func getHintedHandoffReplayCommand(username, password *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "replay",
+		Short: "sends the data queued for a data node now",
+		Long: `Sends the hinted handoff data queued for a data node on all data nodes
+immediately, instead of waiting for the retry interval to expire. The node is
+given by its ID or its TCP address.`,
+		Example: "  cnosdb-ctl hh replay -u admin -p secret localhost:8088",
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := manageHintedHandoff(options.Env.Bind, args[0], "replay", *username, *password); err != nil {
+				fmt.Println(err)
+			}
+		},
+	}
+}
@@ -5,9 +5,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/server"
@@ -17,6 +22,10 @@ var (
 	ErrEmptyPeers = errors.New("Failed to get MetaServerInfo: empty Peers")
 )
 
+// decommissionPollInterval is the interval at which the progress of a
+// decommission is printed.
+const decommissionPollInterval = 5 * time.Second
+
 func getNodeInfo(metaAddr string) (*meta.NodeInfo, error) {
 	resp, err := http.Get(fmt.Sprintf("http://%s/node", metaAddr))
 	if err != nil {
@@ -43,6 +52,28 @@ func getNodeInfo(metaAddr string) (*meta.NodeInfo, error) {
 	return &node, err
 }
 
+func getDataNodeStatuses(metaAddr string) ([]meta.DataNodeStatus, error) {
+	resp, err := http.Get(fmt.Sprintf("http://%s/data-node-status", metaAddr))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		b, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf(string(b))
+	}
+
+	statuses := []meta.DataNodeStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(&statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
 func getMetaServers(metaAddr string) ([]string, error) {
 	resp, err := http.Get(fmt.Sprintf("http://%s/meta-servers", metaAddr))
 	if err != nil {
@@ -157,11 +188,16 @@ func dial(network, address, header string) (net.Conn, error) {
 	return conn, nil
 }
 
-const RequestClusterJoin = 0x01
+const (
+	RequestClusterJoin    = 0x01
+	RequestClusterReplace = 0x02
+)
 
 type Request struct {
 	Type  uint8
 	Peers []string
+
+	NodeID uint64 `json:",omitempty"`
 }
 
 func addDataServer(metaAddr, newNodeAddr string) error {
@@ -198,6 +234,54 @@ func addDataServer(metaAddr, newNodeAddr string) error {
 	return nil
 }
 
+func replaceDataServer(metaAddr string, oldNodeID uint64, newNodeAddr string) error {
+	peers, err := getMetaServers(metaAddr)
+	if err != nil {
+		return err
+	}
+
+	if len(peers) == 0 {
+		return ErrEmptyPeers
+	}
+
+	metaClient := meta.NewRemoteClient()
+	metaClient.SetMetaServers(peers)
+	if err := metaClient.Open(); err != nil {
+		return err
+	}
+	old, err := metaClient.DataNode(oldNodeID)
+	metaClient.Close()
+	if err != nil {
+		return fmt.Errorf("data node %d: %s", oldNodeID, err)
+	}
+
+	r := Request{}
+	r.Type = RequestClusterReplace
+	r.Peers = peers
+	r.NodeID = oldNodeID
+
+	conn, err := dial("tcp", newNodeAddr, server.NodeMuxHeader)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(r); err != nil {
+		return fmt.Errorf("Encode replace request: %s", err)
+	}
+
+	node := meta.NodeInfo{}
+	if err := json.NewDecoder(conn).Decode(&node); err == io.EOF {
+		return fmt.Errorf("data node at %s did not replace node %d, it must be a new node, see its logs", newNodeAddr, oldNodeID)
+	} else if err != nil {
+		return err
+	}
+
+	fmt.Printf("Replaced data node %d at %s with %s, copying its shards from their other owners\n", node.ID, old.TCPHost, node.TCPHost)
+
+	return nil
+}
+
 func remoteDataServer(metaAddr, remoteNodeAddr string) error {
 	peers, err := getMetaServers(metaAddr)
 	if err != nil {
@@ -228,3 +312,185 @@ func remoteDataServer(metaAddr, remoteNodeAddr string) error {
 
 	return nil
 }
+
+func decommissionDataServer(metaAddr, remoteNodeAddr string, cancel, wait bool) error {
+	peers, err := getMetaServers(metaAddr)
+	if err != nil {
+		return err
+	}
+
+	if len(peers) == 0 {
+		return ErrEmptyPeers
+	}
+
+	metaClient := meta.NewRemoteClient()
+	metaClient.SetMetaServers(peers)
+	if err := metaClient.Open(); err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	n, err := metaClient.DataNodeByTCPHost(remoteNodeAddr)
+	if err != nil {
+		return err
+	}
+
+	if err := metaClient.SetDataNodeLeaving(n.ID, !cancel); err != nil {
+		return err
+	}
+
+	if cancel {
+		fmt.Printf("Canceled decommission of data node %d at %s\n", n.ID, n.TCPHost)
+		return nil
+	}
+	fmt.Printf("Decommissioning data node %d at %s\n", n.ID, n.TCPHost)
+
+	if !wait {
+		return nil
+	}
+	return waitDecommission(metaAddr, n.ID)
+}
+
+// waitDecommission prints the progress of the decommission of a data node
+// until it is removed from the cluster.
+func waitDecommission(metaAddr string, id uint64) error {
+	var last string
+	for {
+		time.Sleep(decommissionPollInterval)
+
+		statuses, err := getDataNodeStatuses(metaAddr)
+		if err != nil {
+			return err
+		}
+
+		var status *meta.DataNodeStatus
+		for i := range statuses {
+			if statuses[i].ID == id {
+				status = &statuses[i]
+			}
+		}
+		if status == nil {
+			fmt.Printf("Removed data node %d\n", id)
+			return nil
+		}
+
+		if msg := decommissionMessage(status.Decommission); msg != last {
+			fmt.Println(msg)
+			last = msg
+		}
+	}
+}
+
+// decommissionMessage describes the progress of a decommission.
+func decommissionMessage(p *meta.DecommissionProgress) string {
+	if p == nil {
+		return "waiting for the node to start"
+	}
+
+	msg := p.Phase
+	if p.Phase == meta.DecommissionCopyingShards {
+		msg = fmt.Sprintf("%s %d/%d", msg, p.ShardsCopied, p.ShardsTotal)
+	} else if p.Phase == meta.DecommissionDrainingHH && len(p.HintedHandoffNodes) > 0 {
+		msg = fmt.Sprintf("%s, queued on nodes %s", msg, joinNodeIDs(p.HintedHandoffNodes))
+	}
+	if p.Error != "" {
+		msg = fmt.Sprintf("%s (error: %s)", msg, p.Error)
+	}
+	return msg
+}
+
+// joinNodeIDs returns a comma-delimited string of node IDs.
+func joinNodeIDs(ids []uint64) string {
+	a := make([]string, len(ids))
+	for i, id := range ids {
+		a[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(a, ", ")
+}
+
+// manageHintedHandoff applies the action ("purge" or "replay") to the queues of
+// the node on every data node, as the given admin user.
+func manageHintedHandoff(metaAddr, node, action, username, password string) error {
+	peers, err := getMetaServers(metaAddr)
+	if err != nil {
+		return err
+	}
+
+	if len(peers) == 0 {
+		return ErrEmptyPeers
+	}
+
+	metaClient := meta.NewRemoteClient()
+	metaClient.SetMetaServers(peers)
+	if err := metaClient.Open(); err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	target, err := dataNodeID(metaClient, node)
+	if err != nil {
+		return err
+	}
+
+	nodes, err := metaClient.DataNodes()
+	if err != nil {
+		return err
+	}
+
+	for _, n := range nodes {
+		if n.ID == target {
+			continue
+		}
+		if err := postHintedHandoffAction(n.Host, action, target, username, password); err != nil {
+			fmt.Printf("Data node %d at %s: %s\n", n.ID, n.TCPHost, err)
+			continue
+		}
+		fmt.Printf("Data node %d at %s: %s done for node %d\n", n.ID, n.TCPHost, action, target)
+	}
+	return nil
+}
+
+// dataNodeID returns the ID of the data node given by its ID or its TCP
+// address. An ID is accepted for a node already removed from the cluster.
+func dataNodeID(metaClient *meta.RemoteClient, node string) (uint64, error) {
+	if id, err := strconv.ParseUint(node, 10, 64); err == nil {
+		return id, nil
+	}
+	n, err := metaClient.DataNodeByTCPHost(node)
+	if err != nil {
+		return 0, err
+	}
+	return n.ID, nil
+}
+
+// postHintedHandoffAction asks the data node at httpAddr to apply the action
+// to its queue for the node.
+func postHintedHandoffAction(httpAddr, action string, nodeID uint64, username, password string) error {
+	u := url.URL{
+		Scheme:   "http",
+		Host:     httpAddr,
+		Path:     "/hh/" + action,
+		RawQuery: url.Values{"node": []string{strconv.FormatUint(nodeID, 10)}}.Encode(),
+	}
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		if msg := resp.Header.Get("X-CnosDB-Error"); msg != "" {
+			return errors.New(msg)
+		}
+		return fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	return nil
+}
@@ -1,6 +1,8 @@
 package backup
 
 import (
+	"archive/tar"
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
@@ -10,18 +12,28 @@ import (
 	"log"
 	"math"
 	"os"
-	"path/filepath"
+	"path"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/cnosdatabase/cnosdb/cmd/cnosdb/backup_util"
+	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/pkg/network"
 	"github.com/cnosdatabase/cnosdb/server/snapshotter"
+	intar "github.com/cnosdatabase/db/pkg/tar"
+	"github.com/cnosdatabase/db/tsdb/engine/tsm1"
 	gzip "github.com/klauspost/pgzip"
 	"github.com/spf13/cobra"
 )
 
 const (
+	// downloadAttempts is the number of attempts to download a snapshot
+	// from a host, and failoverAttempts when another host can be tried.
+	downloadAttempts = 10
+	failoverAttempts = 3
+
 	// Suffix is a suffix added to the backup while it's in-process.
 	Suffix = ".pending"
 
@@ -33,7 +45,11 @@ const (
 	BackupFilePattern = "%s.%s.%05d"
 )
 
-var backup_examples = `  cnosdb backup --start 2021-10-10T12:12:00Z`
+var backup_examples = `  cnosdb backup --start 2021-10-10T12:12:00Z
+  cnosdb backup --incremental /var/backups/cnosdb
+  cnosdb backup --target s3://backups/cnosdb
+  cnosdb backup --passphrase-file /etc/cnosdb/backup.pass /var/backups/cnosdb
+  cnosdb backup verify /var/backups/cnosdb`
 
 // options represents the program execution for "cnosdb backup".
 type options struct {
@@ -47,6 +63,9 @@ type options struct {
 
 	host       string
 	path       string
+	target     string
+	storage    backup_util.Storage
+	parallel   int
 	database   string
 	timeToLive string
 	shardID    string
@@ -61,16 +80,39 @@ type options struct {
 	portableFileBase string
 	continueOnError  bool
 
+	// key encrypts the backup files if a key file or a passphrase file
+	// is given.
+	keyFile        string
+	passphraseFile string
+	key            *backup_util.Key
+
+	// incremental backs up only the files changed since the last backup in
+	// path, which is recorded as the parent of the backup.
+	incremental bool
+
+	// parentFiles maps the shards of the parent backup and its ancestors to
+	// their most recent entries.
+	parentFiles map[uint64]backup_util.Entry
+
+	// Meta data backed up with the shards, for their owners.
+	metaData *meta.Data
+
+	// mu guards the manifest and the backup files of the shards backed up
+	// in parallel.
+	mu          sync.Mutex
 	BackupFiles []string
 }
 
-var env = options{}
+var env = options{
+	Stderr: os.Stderr,
+	Stdout: os.Stdout,
+}
 
 func GetCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:     "backup [flags] PATH",
 		Short:   "downloads a snapshot of a data node and saves it to disk",
-		Long:    "Creates a backup copy of specified CnosDB database(s) and saves the files to PATH (directory where backups are saved).",
+		Long:    "Creates a backup copy of specified CnosDB database(s) and saves the files to PATH (directory where backups are saved) or to an S3-compatible object storage.",
 		Example: backup_examples,
 		CompletionOptions: cobra.CompletionOptions{
 			DisableDefaultCmd:   true,
@@ -80,8 +122,9 @@ func GetCommand() *cobra.Command {
 		PreRunE: func(cmd *cobra.Command, args []string) error {
 			env.BackupFiles = []string{}
 
-			// for portable saving, if needed
-			env.portableFileBase = time.Now().UTC().Format(backup_util.PortableFileNamePattern)
+			// The files of the backup are named after the time it started.
+			env.manifest.Created = time.Now().UTC()
+			env.portableFileBase = env.manifest.Created.Format(backup_util.PortableFileNamePattern)
 
 			var err error
 			if env.startArg != "" {
@@ -103,190 +146,232 @@ func GetCommand() *cobra.Command {
 				}
 			}
 
-			// Ensure that only one arg is specified.
-			if len(args) != 1 {
-				return errors.New("Exactly one backup path is required.")
+			if env.target != "" {
+				if len(args) != 0 {
+					return errors.New("PATH is not compatible with --target")
+				}
+				env.path = env.target
+			} else {
+				// Ensure that only one arg is specified.
+				if len(args) != 1 {
+					return errors.New("Exactly one backup path is required.")
+				}
+				env.path = args[0]
 			}
-			env.path = args[0]
 
-			err = os.MkdirAll(env.path, 0700)
+			if env.parallel < 1 {
+				return errors.New("--parallel must be at least 1")
+			}
 
-			return err
+			env.key, err = backup_util.NewKey(env.keyFile, env.passphraseFile)
+			if err != nil {
+				return err
+			}
+			if env.key != nil {
+				env.manifest.Encryption = backup_util.Encryption
+			}
+
+			env.storage, err = backup_util.NewStorage(env.path)
+			if err != nil {
+				return err
+			}
+
+			if env.incremental {
+				if env.startArg != "" || env.endArg != "" {
+					return errors.New("--incremental is not compatible with --start and --end")
+				}
+				return env.loadParent()
+			}
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// Set up logger.
 			env.StdoutLogger = log.New(env.Stdout, "", log.LstdFlags)
 			env.StderrLogger = log.New(env.Stderr, "", log.LstdFlags)
 
-			var err error
+			// always backup the metastore
+			if err := env.backupMetastore(); err != nil {
+				return err
+			}
 
-			if env.shardID != "" {
-				// always backup the metastore
-				if err := env.backupMetastore(); err != nil {
-					return err
-				}
-				err = env.backupShard(env.database, env.timeToLive, env.shardID)
+			var err error
 
+			if len(env.metaData.DataNodes) > 0 {
+				// The shards of a cluster are spread over its data nodes.
+				err = env.backupCluster()
+			} else if env.shardID != "" {
+				err = env.backupShard(env.database, env.timeToLive, env.shardID, nil)
 			} else if env.timeToLive != "" {
-				// always backup the metastore
-				if err := env.backupMetastore(); err != nil {
-					return err
-				}
 				err = env.backupTimeToLive()
 			} else if env.database != "" {
-				// always backup the metastore
-				if err := env.backupMetastore(); err != nil {
-					return err
-				}
 				err = env.backupDatabase()
 			} else {
-				// always backup the metastore
-				if err := env.backupMetastore(); err != nil {
-					return err
-				}
-
-				env.StdoutLogger.Println("No database, time to live or shard ID given. Full meta store backed up.")
-				if env.portable {
-					env.StdoutLogger.Println("Backing up all databases in portable format")
-					if err := env.backupDatabase(); err != nil {
-						env.StderrLogger.Printf("backup failed: %v", err)
-						return err
-					}
-
-				}
-
-			}
-
-			if env.portable {
-				filename := env.portableFileBase + ".manifest"
-				if err := env.manifest.Save(filepath.Join(env.path, filename)); err != nil {
-					env.StderrLogger.Printf("manifest save failed: %v", err)
-					return err
-				}
-				env.BackupFiles = append(env.BackupFiles, filename)
+				env.StdoutLogger.Println("No database, time to live or shard ID given. Backing up all databases.")
+				err = env.backupDatabase()
 			}
 
 			if err != nil {
 				env.StderrLogger.Printf("backup failed: %v", err)
 				return err
 			}
+
+			// Save the manifest last, so that a failed backup is never the
+			// parent of an incremental backup.
+			env.manifest.Limited = env.database != "" || env.timeToLive != "" || env.shardID != ""
+			env.manifest.Database = env.database
+			env.manifest.Policy = env.timeToLive
+			env.manifest.ShardID, _ = strconv.ParseUint(env.shardID, 10, 64)
+			filename := env.portableFileBase + backup_util.ManifestSuffix
+			if err := env.manifest.Store(env.storage, filename); err != nil {
+				env.StderrLogger.Printf("manifest save failed: %v", err)
+				return err
+			}
+			env.BackupFiles = append(env.BackupFiles, filename)
+
 			env.StdoutLogger.Println("backup complete:")
 			for _, v := range env.BackupFiles {
-				env.StdoutLogger.Println("\t" + filepath.Join(env.path, v))
+				env.StdoutLogger.Println("\t" + env.storage.Path(v))
 			}
 
 			return nil
 		},
 	}
 
-	c.Flags().StringVar(&env.host, "host", "localhost:8088", "CnosDB host to back up from. Optional. Defaults to 127.0.0.1:8088.")
+	c.Flags().StringVar(&env.host, "host", "localhost:8088", "CnosDB host to back up from. Optional. Defaults to 127.0.0.1:8088."+
+		" In a cluster, the meta data is backed up from the host and each shard from one of the data nodes owning it.")
+	c.Flags().StringVar(&env.target, "target", "", "Where to save the backup: s3://bucket/prefix or a directory. Optional. If specified, PATH is not allowed."+
+		" The S3 credentials, region and endpoint are read from the AWS_* environment variables.")
+	c.Flags().IntVar(&env.parallel, "parallel", 4, "Number of shards backed up in parallel. Optional. Defaults to 4.")
 	c.Flags().StringVar(&env.database, "db", "", "CnosDB database name to back up. Optional. If not specified, all databases are backed up.")
 	c.Flags().StringVar(&env.timeToLive, "ttl", "", "Time-to-live to use for the backup. Optional. If not specified, all time-to-lives are used by default.")
 	c.Flags().StringVar(&env.shardID, "shard", "", "The identifier of the shard to back up. Optional. If specified, '--ttl <ttl_name>' is required.")
 	c.Flags().StringVar(&env.startArg, "start", "", "Include all points starting with specified timestamp (RFC3339 format).")
 	c.Flags().StringVar(&env.endArg, "end", "", "Exclude all points after timestamp (RFC3339 format).")
 	c.Flags().BoolVar(&env.continueOnError, "skip-errors", false, "Optional flag to continue backing up the remaining shards when the current shard fails to backup.")
+	c.Flags().BoolVar(&env.incremental, "incremental", false, "Back up only the files changed since the last backup in PATH. Optional. Not compatible with '--start' and '--end'.")
+	c.Flags().StringVar(&env.keyFile, "key-file", "", "File holding a 32 byte key, raw or hex encoded, to encrypt the backup with AES-256-GCM. Optional.")
+	c.Flags().StringVar(&env.passphraseFile, "passphrase-file", "", "File holding a passphrase to encrypt the backup with AES-256-GCM. Optional. Not compatible with '--key-file'.")
+
+	c.AddCommand(getVerifyCommand())
 
 	return c
 }
 
-func (cmd *options) backupShard(db, ttl, sid string) error {
-	id, err := strconv.ParseUint(sid, 10, 64)
+// loadParent makes the backup an incremental backup of the last backup in
+// the backup storage holding all the shards the backup is of.
+func (cmd *options) loadParent() error {
+	shardID, _ := strconv.ParseUint(cmd.shardID, 10, 64)
+	parentName, err := backup_util.LatestCoveringManifest(cmd.storage, cmd.database, cmd.timeToLive, shardID)
 	if err != nil {
 		return err
 	}
+	if parentName == "" {
+		return fmt.Errorf("no backup manifest in %s covering the backup to base the incremental backup on", cmd.path)
+	}
 
-	shardArchivePath, err := cmd.nextPath(filepath.Join(cmd.path, fmt.Sprintf(backup_util.BackupFilePattern, db, ttl, id)))
+	chain, err := backup_util.LoadChain(cmd.storage, parentName)
 	if err != nil {
 		return err
 	}
+	cmd.manifest.Parent = parentName
+	cmd.parentFiles = backup_util.LatestEntries(chain)
+	return nil
+}
 
-	cmd.StdoutLogger.Printf("backing up db=%v ttl=%v shard=%v to %s with boundaries start=%s, end=%s",
-		db, ttl, sid, shardArchivePath, cmd.start.Format(time.RFC3339), cmd.end.Format(time.RFC3339))
-
-	req := &snapshotter.Request{
-		Type:             snapshotter.RequestShardExport,
-		BackupDatabase:   db,
-		BackupTimeToLive: ttl,
-		ShardID:          id,
-		ExportStart:      cmd.start,
-		ExportEnd:        cmd.end,
-	}
-
-	// TODO: verify shard backup data
-	err = cmd.downloadAndVerify(req, shardArchivePath, nil)
+// backupShard backs up a shard from the first of its sources that succeeds,
+// or from the host if there are none.
+func (cmd *options) backupShard(db, ttl, sid string, sources []shardSource) error {
+	id, err := strconv.ParseUint(sid, 10, 64)
 	if err != nil {
-		os.Remove(shardArchivePath)
 		return err
 	}
-	if !cmd.portable {
-		cmd.BackupFiles = append(cmd.BackupFiles, shardArchivePath)
-	}
 
+	var name string
 	if cmd.portable {
-		f, err := os.Open(shardArchivePath)
-		if err != nil {
-			return err
-		}
-		defer f.Close()
-		defer os.Remove(shardArchivePath)
-
-		filePrefix := cmd.portableFileBase + ".s" + sid
-		filename := filePrefix + ".tar.gz"
-		out, err := os.OpenFile(filepath.Join(cmd.path, filename), os.O_CREATE|os.O_RDWR, 0600)
+		name = cmd.portableFileBase + ".s" + sid + ".tar.gz"
+	} else {
+		name, err = cmd.nextName(fmt.Sprintf(backup_util.BackupFilePattern, db, ttl, id))
 		if err != nil {
 			return err
 		}
+	}
+	shardArchivePath := cmd.storage.Path(name)
 
-		zw := gzip.NewWriter(out)
-		zw.Name = filePrefix + ".tar"
+	req := &snapshotter.Request{
+		Type:             snapshotter.RequestShardBackup,
+		BackupDatabase:   db,
+		BackupTimeToLive: ttl,
+		ShardID:          id,
+	}
+	if !cmd.start.IsZero() || !cmd.end.IsZero() {
+		cmd.StdoutLogger.Printf("backing up db=%v ttl=%v shard=%v to %s with boundaries start=%s, end=%s",
+			db, ttl, sid, shardArchivePath, cmd.start.Format(time.RFC3339), cmd.end.Format(time.RFC3339))
+		req.Type = snapshotter.RequestShardExport
+		req.ExportStart = cmd.start
+		req.ExportEnd = cmd.end
+	}
 
-		cw := backup_util.CountingWriter{Writer: zw}
+	if len(sources) == 0 {
+		sources = []shardSource{{host: cmd.host}}
+	}
 
-		_, err = io.Copy(&cw, f)
-		if err != nil {
-			if err := zw.Close(); err != nil {
-				return err
-			}
+	entry := backup_util.Entry{
+		Database: db,
+		Policy:   ttl,
+		ShardID:  id,
+		FileName: name,
+		Owners:   cmd.shardOwners(id),
+	}
 
-			if err := out.Close(); err != nil {
-				return err
-			}
-			return err
+	// TODO: verify shard backup data
+	parent, hasParent := cmd.parentFiles[id]
+	for i, src := range sources {
+		// Fail over to the next source quickly.
+		attempts := downloadAttempts
+		if i < len(sources)-1 {
+			attempts = failoverAttempts
 		}
 
-		shardid, err := strconv.ParseUint(sid, 10, 64)
-		if err != nil {
-			if err := zw.Close(); err != nil {
-				return err
+		if req.Type == snapshotter.RequestShardBackup {
+			// The files changed since the shard was snapshotted for the
+			// parent backup are backed up. The files of another node differ,
+			// so all of them are backed up from it.
+			req.Since = time.Time{}
+			if hasParent && parent.Node == src.node {
+				req.Since = parent.SnapshotTime
 			}
+			entry.Full = req.Since.IsZero()
 
-			if err := out.Close(); err != nil {
-				return err
+			if entry.Full {
+				cmd.StdoutLogger.Printf("backing up db=%v ttl=%v shard=%v to %s", db, ttl, sid, shardArchivePath)
+			} else {
+				cmd.StdoutLogger.Printf("backing up db=%v ttl=%v shard=%v to %s with files changed since %s",
+					db, ttl, sid, shardArchivePath, req.Since.Format(time.RFC3339Nano))
 			}
-			return err
-		}
-		cmd.manifest.Files = append(cmd.manifest.Files, backup_util.Entry{
-			Database:     db,
-			Policy:       ttl,
-			ShardID:      shardid,
-			FileName:     filename,
-			Size:         cw.Total,
-			LastModified: 0,
-		})
-
-		if err := zw.Close(); err != nil {
-			return err
 		}
 
-		if err := out.Close(); err != nil {
-			return err
+		err = cmd.downloadFile(src.host, attempts, req, name, cmd.portable, &entry)
+		if err == nil {
+			entry.Node = src.node
+			break
 		}
+		if i < len(sources)-1 {
+			cmd.StderrLogger.Printf("backing up shard %s from node %d failed: %v. trying node %d", sid, src.node, err, sources[i+1].node)
+		}
+	}
+	if err != nil {
+		return err
+	}
 
-		cmd.BackupFiles = append(cmd.BackupFiles, filename)
+	if !cmd.portable {
+		entry.LastModified = cmd.manifest.Created.UnixNano()
 	}
-	return nil
 
+	cmd.mu.Lock()
+	defer cmd.mu.Unlock()
+	cmd.manifest.Files = append(cmd.manifest.Files, entry)
+	cmd.BackupFiles = append(cmd.BackupFiles, name)
+	return nil
 }
 
 // backupDatabase will request the database information from the server and then backup
@@ -299,7 +384,7 @@ func (cmd *options) backupDatabase() error {
 		BackupDatabase: cmd.database,
 	}
 
-	response, err := cmd.requestInfo(req)
+	response, err := cmd.requestInfo(cmd.host, req)
 	if err != nil {
 		return err
 	}
@@ -319,7 +404,7 @@ func (cmd *options) backupTimeToLive() error {
 		BackupTimeToLive: cmd.timeToLive,
 	}
 
-	response, err := cmd.requestInfo(req)
+	response, err := cmd.requestInfo(cmd.host, req)
 	if err != nil {
 		return err
 	}
@@ -329,160 +414,327 @@ func (cmd *options) backupTimeToLive() error {
 
 // backupResponsePaths will backup all shards identified by shard paths in the response struct
 func (cmd *options) backupResponsePaths(response *snapshotter.Response) error {
-
-	// loop through the returned paths and back up each shard
+	jobs := make([]shardJob, 0, len(response.Paths))
 	for _, path := range response.Paths {
 		db, ttl, id, err := backup_util.DBTimeToLiveAndShardFromPath(path)
 		if err != nil {
 			return err
 		}
+		jobs = append(jobs, shardJob{db: db, ttl: ttl, id: id})
+	}
 
-		err = cmd.backupShard(db, ttl, id)
+	return cmd.backupShards(jobs)
+}
 
-		if err != nil && !cmd.continueOnError {
-			cmd.StderrLogger.Printf("error (%s) when backing up db: %s, ttl %s, shard %s. continuing backup on remaining shards", err, db, ttl, id)
-			return err
+// shardSource is a data node a shard can be backed up from.
+type shardSource struct {
+	node uint64
+	host string
+}
+
+// shardJob is a shard to back up from the first of its sources that
+// succeeds, or from the host if there are none.
+type shardJob struct {
+	db, ttl, id string
+	sources     []shardSource
+}
+
+// backupShards backs up shards in parallel. It stops at the first failure
+// unless errors are skipped.
+func (cmd *options) backupShards(jobs []shardJob) error {
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		failed error
+	)
+	ch := make(chan shardJob)
+	for i := 0; i < cmd.parallel; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range ch {
+				err := cmd.backupShard(job.db, job.ttl, job.id, job.sources)
+				if err == nil {
+					continue
+				}
+				if cmd.continueOnError {
+					cmd.StderrLogger.Printf("error (%s) when backing up db: %s, ttl %s, shard %s. continuing backup on remaining shards", err, job.db, job.ttl, job.id)
+					continue
+				}
+
+				mu.Lock()
+				if failed == nil {
+					failed = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, job := range jobs {
+		mu.Lock()
+		stop := failed != nil
+		mu.Unlock()
+		if stop {
+			break
 		}
+		ch <- job
 	}
+	close(ch)
+	wg.Wait()
 
-	return nil
+	return failed
 }
 
 // backupMetastore will backup the whole metastore on the host to the backup path
 // if useDB is non-empty, it will backup metadata only for the named database.
 func (cmd *options) backupMetastore() error {
-	metastoreArchivePath, err := cmd.nextPath(filepath.Join(cmd.path, backup_util.Metafile))
-	if err != nil {
-		return err
+	var name string
+	if cmd.portable {
+		name = cmd.portableFileBase + ".meta"
+	} else {
+		var err error
+		name, err = cmd.nextName(backup_util.Metafile)
+		if err != nil {
+			return err
+		}
 	}
 
-	cmd.StdoutLogger.Printf("backing up metastore to %s", metastoreArchivePath)
+	cmd.StdoutLogger.Printf("backing up metastore to %s", cmd.storage.Path(name))
 
 	req := &snapshotter.Request{
 		Type: snapshotter.RequestMetastoreBackup,
 	}
 
-	err = cmd.downloadAndVerify(req, metastoreArchivePath, func(file string) error {
-		f, err := os.Open(file)
-		if err != nil {
-			return err
+	// The metastore is small, so it is verified in memory before being stored.
+	var buf bytes.Buffer
+	err := cmd.download(cmd.host, downloadAttempts, req, func(r io.Reader) error {
+		buf.Reset()
+		if n, err := io.Copy(&buf, r); err != nil || n == 0 {
+			return fmt.Errorf("copy backup to file: err=%v, n=%d", err, n)
 		}
-		defer f.Close()
-
-		var magicByte [8]byte
-		n, err := io.ReadFull(f, magicByte[:])
-		if err != nil {
-			return err
-		}
-
-		if n < 8 {
-			return errors.New("Not enough bytes data to verify")
-		}
-
-		magic := binary.BigEndian.Uint64(magicByte[:])
-		if magic != snapshotter.BackupMagicHeader {
-			cmd.StderrLogger.Println("Invalid metadata blob, ensure the metadata service is running (default port 8088)")
-			return errors.New("invalid metadata received")
-		}
-
 		return nil
 	})
-
 	if err != nil {
 		return err
 	}
 
-	if !cmd.portable {
-		cmd.BackupFiles = append(cmd.BackupFiles, metastoreArchivePath)
+	if buf.Len() < 8 {
+		return errors.New("Not enough bytes data to verify")
+	}
+	magic := binary.BigEndian.Uint64(buf.Bytes()[:8])
+	if magic != snapshotter.BackupMagicHeader {
+		cmd.StderrLogger.Println("Invalid metadata blob, ensure the metadata service is running (default port 8088)")
+		return errors.New("invalid metadata received")
 	}
 
-	if cmd.portable {
-		metaBytes, err := backup_util.GetMetaBytes(metastoreArchivePath)
-		defer os.Remove(metastoreArchivePath)
+	metaBytes, err := backup_util.ParseMetaBytes(buf.Bytes())
+	if err != nil {
+		return err
+	}
+	cmd.metaData = &meta.Data{}
+	if err := cmd.metaData.UnmarshalBinary(metaBytes); err != nil {
+		return fmt.Errorf("unmarshal meta: %s", err)
+	}
+	cmd.manifest.Meta.Index = cmd.metaData.Index
+
+	if !cmd.portable {
+		size, checksum, digest, err := cmd.storeFile(name, buf.Bytes())
 		if err != nil {
 			return err
 		}
-		filename := cmd.portableFileBase + ".meta"
+		cmd.manifest.Meta.FileName = name
+		cmd.manifest.Meta.Size = size
+		cmd.manifest.Meta.Checksum = checksum
+		cmd.manifest.Meta.Digest = digest
+		cmd.BackupFiles = append(cmd.BackupFiles, name)
+	}
+
+	if cmd.portable {
 		ep := backup_util.PortablePacker{Data: metaBytes, MaxNodeID: 0}
 		protoBytes, err := ep.MarshalBinary()
 		if err != nil {
 			return err
 		}
-		if err := ioutil.WriteFile(filepath.Join(cmd.path, filename), protoBytes, 0644); err != nil {
+		if _, _, _, err := cmd.storeFile(name, protoBytes); err != nil {
 			fmt.Fprintln(cmd.Stdout, "Error.")
 			return err
 		}
 
-		cmd.manifest.Meta.FileName = filename
+		cmd.manifest.Meta.FileName = name
 		cmd.manifest.Meta.Size = int64(len(metaBytes))
-		cmd.BackupFiles = append(cmd.BackupFiles, filename)
+		cmd.BackupFiles = append(cmd.BackupFiles, name)
 	}
 
 	return nil
 }
 
-// nextPath returns the next file to write to.
-func (cmd *options) nextPath(path string) (string, error) {
+// shardOwners returns the IDs of the data nodes owning a shard when the meta
+// data was backed up.
+func (cmd *options) shardOwners(id uint64) []uint64 {
+	if cmd.metaData == nil {
+		return nil
+	}
+	return backup_util.ShardOwners(cmd.metaData, id)
+}
+
+// nextName returns the next file of the backup storage to write to.
+func (cmd *options) nextName(base string) (string, error) {
 	// Iterate through incremental files until one is available.
 	for i := 0; ; i++ {
-		s := fmt.Sprintf(path+".%02d", i)
-		if _, err := os.Stat(s); os.IsNotExist(err) {
-			return s, nil
-		} else if err != nil {
+		name := fmt.Sprintf(base+".%02d", i)
+		if ok, err := cmd.storage.Exists(name); err != nil {
 			return "", err
+		} else if !ok {
+			return name, nil
 		}
 	}
 }
 
-// downloadAndVerify will download either the metastore or shard to a temp file and then
-// rename it to a good backup file name after complete
-func (cmd *options) downloadAndVerify(req *snapshotter.Request, path string, validator func(string) error) error {
-	tmppath := path + backup_util.Suffix
-	if err := cmd.download(req, tmppath); err != nil {
-		return err
+// storeFile stores a file in the backup storage, encrypted if the backup is,
+// and returns the size, the checksum and the digest of the stored file.
+func (cmd *options) storeFile(name string, b []byte) (size int64, checksum, digest string, err error) {
+	w, err := cmd.storage.Create(name)
+	if err != nil {
+		return 0, "", "", err
 	}
 
-	if validator != nil {
-		if err := validator(tmppath); err != nil {
-			if rmErr := os.Remove(tmppath); rmErr != nil {
-				cmd.StderrLogger.Printf("Error cleaning up temporary file: %v", rmErr)
-			}
-			return err
+	h, d := backup_util.NewChecksum(), backup_util.NewDigest()
+	cw := &backup_util.CountingWriter{Writer: io.MultiWriter(w, h, d)}
+	var dst io.WriteCloser = nopCloser{cw}
+	if cmd.key != nil {
+		if dst, err = backup_util.NewEncryptWriter(cw, cmd.key); err != nil {
+			w.Abort()
+			return 0, "", "", err
 		}
 	}
 
-	f, err := os.Stat(tmppath)
+	if _, err = dst.Write(b); err == nil {
+		err = dst.Close()
+	}
 	if err != nil {
-		return err
+		w.Abort()
+		return 0, "", "", err
 	}
-
-	// There was nothing downloaded, don't create an empty backup file.
-	if f.Size() == 0 {
-		return os.Remove(tmppath)
+	if err := w.Close(); err != nil {
+		return 0, "", "", err
 	}
+	return cw.Total, backup_util.FormatChecksum(h), backup_util.FormatDigest(d), nil
+}
 
-	// Rename temporary file to final path.
-	if err := os.Rename(tmppath, path); err != nil {
-		return fmt.Errorf("rename: %s", err)
-	}
+// downloadFile streams a snapshot of a shard from a host into a file of the
+// backup storage, compressed with gzip if compress is set and encrypted if
+// the backup is, without writing it to local disk first. It records the size,
+// the checksum and the digest of the stored file in the entry, with the
+// snapshot time and the TSM files listed by the snapshot.
+func (cmd *options) downloadFile(host string, attempts int, req *snapshotter.Request, name string, compress bool, entry *backup_util.Entry) error {
+	return cmd.download(host, attempts, req, func(r io.Reader) error {
+		w, err := cmd.storage.Create(name)
+		if err != nil {
+			return err
+		}
 
-	return nil
+		h, d := backup_util.NewChecksum(), backup_util.NewDigest()
+		cw := &backup_util.CountingWriter{Writer: io.MultiWriter(w, h, d)}
+		var ew io.WriteCloser = nopCloser{cw}
+		if cmd.key != nil {
+			if ew, err = backup_util.NewEncryptWriter(cw, cmd.key); err != nil {
+				w.Abort()
+				return err
+			}
+		}
+		var dst io.Writer = ew
+		var zw *gzip.Writer
+		if compress {
+			zw = gzip.NewWriter(ew)
+			zw.Name = strings.TrimSuffix(name, ".gz")
+			dst = zw
+		}
+
+		// The snapshot is read for its file list while it is stored.
+		pr, pw := io.Pipe()
+		lc := make(chan snapshotList, 1)
+		go func() {
+			lc <- readSnapshotList(pr)
+		}()
+		n, err := io.Copy(io.MultiWriter(dst, pw), r)
+		pw.Close()
+		list := <-lc
+		if err == nil && n == 0 {
+			err = errors.New("empty snapshot")
+		}
+		if err == nil && zw != nil {
+			err = zw.Close()
+		}
+		if err == nil {
+			err = ew.Close()
+		}
+		if err != nil {
+			w.Abort()
+			return fmt.Errorf("copy backup to file: err=%v, n=%d", err, n)
+		}
+		if err := w.Close(); err != nil {
+			return err
+		}
+
+		entry.Size, entry.Checksum, entry.Digest = cw.Total, backup_util.FormatChecksum(h), backup_util.FormatDigest(d)
+		entry.SnapshotTime, entry.TSMFiles = list.time, list.tsmFiles
+		return nil
+	})
 }
 
-// download downloads a snapshot of either the metastore or a shard from a host to a given path.
-func (cmd *options) download(req *snapshotter.Request, path string) error {
-	// Create local file to write to.
-	f, err := os.Create(path)
-	if err != nil {
-		return fmt.Errorf("open temp file: %s", err)
+// snapshotList is the file list ending the snapshot of a shard.
+type snapshotList struct {
+	time     time.Time
+	tsmFiles []string
+}
+
+// readSnapshotList reads the snapshot of a shard to its end and returns its
+// file list, or a zero list if the snapshot has none.
+func readSnapshotList(r io.Reader) snapshotList {
+	// Drain the snapshot so that it is stored whole.
+	defer io.Copy(ioutil.Discard, r)
+
+	var list snapshotList
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			return list
+		}
+		if path.Base(hdr.Name) != intar.FileList {
+			continue
+		}
+
+		b, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return snapshotList{}
+		}
+		list = snapshotList{time: hdr.ModTime.UTC()}
+		for _, name := range strings.Split(string(b), "\n") {
+			if strings.HasSuffix(name, "."+tsm1.TSMFileExtension) {
+				list.tsmFiles = append(list.tsmFiles, name)
+			}
+		}
 	}
-	defer f.Close()
+}
+
+// nopCloser is a writer whose Close does nothing.
+type nopCloser struct {
+	io.Writer
+}
 
+func (nopCloser) Close() error { return nil }
+
+// download requests a snapshot of either the metastore or a shard from a host and
+// passes it to fn, making up to attempts attempts until the request and fn succeed.
+func (cmd *options) download(host string, attempts int, req *snapshotter.Request, fn func(io.Reader) error) error {
+	var err error
 	min := 2 * time.Second
-	for i := 0; i < 10; i++ {
+	for i := 0; i < attempts; i++ {
 		if err = func() error {
 			// Connect to snapshotter service.
-			conn, err := network.Dial("tcp", cmd.host, snapshotter.MuxHeader)
+			conn, err := network.Dial("tcp", host, snapshotter.MuxHeader)
 			if err != nil {
 				return err
 			}
@@ -499,13 +751,10 @@ func (cmd *options) download(req *snapshotter.Request, path string) error {
 			}
 
 			// Read snapshot from the connection
-			if n, err := io.Copy(f, conn); err != nil || n == 0 {
-				return fmt.Errorf("copy backup to file: err=%v, n=%d", err, n)
-			}
-			return nil
+			return fn(conn)
 		}(); err == nil {
 			break
-		} else if err != nil {
+		} else if i < attempts-1 {
 			backoff := time.Duration(math.Pow(3.8, float64(i))) * time.Millisecond
 			if backoff < min {
 				backoff = min
@@ -518,11 +767,11 @@ func (cmd *options) download(req *snapshotter.Request, path string) error {
 	return err
 }
 
-// requestInfo will request the database or time to live information from the host
-func (cmd *options) requestInfo(request *snapshotter.Request) (*snapshotter.Response, error) {
+// requestInfo will request the database or time to live information from a host
+func (cmd *options) requestInfo(host string, request *snapshotter.Request) (*snapshotter.Response, error) {
 	// Connect to snapshotter service.
 	var r snapshotter.Response
-	conn, err := network.Dial("tcp", cmd.host, snapshotter.MuxHeader)
+	conn, err := network.Dial("tcp", host, snapshotter.MuxHeader)
 	if err != nil {
 		return nil, err
 	}
@@ -557,7 +806,8 @@ Usage: cnosdb backup [options] PATH
           Required to generate backup files in a portable format that can be restored to CnosDB OSS or CnosDB
           Enterprise. Use unless the legacy backup is required.
   -host <host:port>
-          CnosDB OSS host to back up from. Optional. Defaults to 127.0.0.1:8088.
+          CnosDB OSS host to back up from. Optional. Defaults to 127.0.0.1:8088. In a cluster, the meta
+          data is backed up from the host and each shard from one of the data nodes owning it.
   -db <name>
           CnosDB OSS database name to back up. Optional. If not specified, all databases are backed up when
           using '-portable'.
@@ -0,0 +1,140 @@
+package backup
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+
+	"github.com/cnosdatabase/cnosdb"
+	"github.com/cnosdatabase/cnosdb/meta"
+	"github.com/cnosdatabase/cnosdb/server/snapshotter"
+)
+
+// backupCluster backs up the shards of a cluster, each from one of the data
+// nodes owning it, into a single backup. Each owner is asked for the shards
+// it holds, and every shard is planned on the owner with the least shards
+// planned so far, preferring the node a shard was backed up from by the
+// parent backup and nodes not leaving the cluster. The other owners holding
+// the shard are tried if the download fails.
+func (cmd *options) backupCluster() error {
+	if cmd.database != "" && cmd.metaData.Database(cmd.database) == nil {
+		return cnosdb.ErrDatabaseNotFound(cmd.database)
+	}
+
+	held := cmd.heldShards()
+
+	nodes := make(map[uint64]meta.NodeInfo, len(cmd.metaData.DataNodes))
+	for _, n := range cmd.metaData.DataNodes {
+		nodes[n.ID] = n
+	}
+
+	load := make(map[uint64]int)
+	var jobs []shardJob
+	for _, db := range cmd.metaData.Databases {
+		if cmd.database != "" && db.Name != cmd.database {
+			continue
+		}
+		for _, ttl := range db.TimeToLives {
+			if cmd.timeToLive != "" && ttl.Name != cmd.timeToLive {
+				continue
+			}
+			for _, rg := range ttl.Regions {
+				if rg.Deleted() {
+					continue
+				}
+				for _, sh := range rg.Shards {
+					id := strconv.FormatUint(sh.ID, 10)
+					if cmd.shardID != "" && id != cmd.shardID {
+						continue
+					}
+
+					var sources []shardSource
+					reachable := false
+					for _, o := range sh.Owners {
+						n, ok := nodes[o.NodeID]
+						if !ok {
+							continue
+						}
+						shards, ok := held[o.NodeID]
+						if !ok {
+							continue
+						}
+						reachable = true
+						if shards[sh.ID] {
+							sources = append(sources, shardSource{node: n.ID, host: n.TCPHost})
+						}
+					}
+
+					if len(sources) == 0 {
+						if reachable {
+							// The shard has not been written to.
+							continue
+						}
+						err := fmt.Errorf("no owner of shard %d is reachable", sh.ID)
+						if !cmd.continueOnError {
+							return err
+						}
+						cmd.StderrLogger.Printf("error (%s) when backing up db: %s, ttl %s, shard %d. continuing backup on remaining shards", err, db.Name, ttl.Name, sh.ID)
+						continue
+					}
+
+					parentFile, hasParent := cmd.parentFiles[sh.ID]
+					parent := parentFile.Node
+					sort.SliceStable(sources, func(i, j int) bool {
+						a, b := sources[i], sources[j]
+						if hasParent && (a.node == parent) != (b.node == parent) {
+							return a.node == parent
+						}
+						if la, lb := nodes[a.node].Leaving, nodes[b.node].Leaving; la != lb {
+							return !la
+						}
+						if load[a.node] != load[b.node] {
+							return load[a.node] < load[b.node]
+						}
+						return a.node < b.node
+					})
+					load[sources[0].node]++
+
+					jobs = append(jobs, shardJob{db: db.Name, ttl: ttl.Name, id: id, sources: sources})
+				}
+			}
+		}
+	}
+
+	for _, n := range cmd.metaData.DataNodes {
+		if _, ok := held[n.ID]; ok {
+			cmd.StdoutLogger.Printf("backing up %d shards from node %d at %s", load[n.ID], n.ID, n.TCPHost)
+		}
+	}
+
+	return cmd.backupShards(jobs)
+}
+
+// heldShards returns the IDs of the shards held by each reachable data node.
+// Unreachable nodes are left out.
+func (cmd *options) heldShards() map[uint64]map[uint64]bool {
+	held := make(map[uint64]map[uint64]bool)
+	for _, n := range cmd.metaData.DataNodes {
+		req := &snapshotter.Request{
+			Type:           snapshotter.RequestDatabaseInfo,
+			BackupDatabase: cmd.database,
+		}
+		response, err := cmd.requestInfo(n.TCPHost, req)
+		if err != nil {
+			cmd.StderrLogger.Printf("error (%s) when requesting shards of node %d at %s. skipping node", err, n.ID, n.TCPHost)
+			continue
+		}
+
+		shards := make(map[uint64]bool, len(response.Paths))
+		for _, path := range response.Paths {
+			id, err := strconv.ParseUint(filepath.Base(path), 10, 64)
+			if err != nil {
+				continue
+			}
+			shards[id] = true
+		}
+		held[n.ID] = shards
+	}
+	return held
+}
@@ -0,0 +1,259 @@
+package backup
+
+import (
+	"archive/tar"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/cnosdatabase/cnosdb/cmd/cnosdb/backup_util"
+	"github.com/cnosdatabase/cnosdb/meta"
+	gzip "github.com/klauspost/pgzip"
+	"github.com/spf13/cobra"
+
+	"github.com/cnosdatabase/db/tsdb/engine/tsm1"
+)
+
+var verify_examples = `  cnosdb backup verify /var/backups/cnosdb
+  cnosdb backup verify --manifest 20211010T121200Z.manifest --key-file backup.key s3://backups/cnosdb`
+
+// verifyOptions represents the program execution for "cnosdb backup verify".
+type verifyOptions struct {
+	Stderr io.Writer
+	Stdout io.Writer
+
+	manifest       string
+	keyFile        string
+	passphraseFile string
+
+	storage backup_util.Storage
+	key     *backup_util.Key
+}
+
+func getVerifyCommand() *cobra.Command {
+	opts := verifyOptions{
+		Stderr: os.Stderr,
+		Stdout: os.Stdout,
+	}
+
+	c := &cobra.Command{
+		Use:   "verify [flags] PATH",
+		Short: "verifies the files of a backup without restoring it",
+		Long: `Verifies the backups in PATH, a directory or s3://bucket/prefix. The checksum and digest of every
+file are compared with the manifests, the meta data is decoded and the index and blocks of every TSM file
+are read. Encrypted backups are decrypted with the key file or passphrase file they were created with.`,
+		Example: verify_examples,
+		Args:    cobra.ExactArgs(1),
+		CompletionOptions: cobra.CompletionOptions{
+			DisableDefaultCmd:   true,
+			DisableDescriptions: true,
+			DisableNoDescFlag:   true,
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := args[0]
+			if !strings.HasPrefix(path, "s3://") {
+				if fi, err := os.Stat(path); err != nil || !fi.IsDir() {
+					return fmt.Errorf("backup path should be a valid directory: %s", path)
+				}
+			}
+
+			var err error
+			if opts.storage, err = backup_util.NewStorage(path); err != nil {
+				return err
+			}
+			if opts.key, err = backup_util.NewKey(opts.keyFile, opts.passphraseFile); err != nil {
+				return err
+			}
+			return opts.run()
+		},
+	}
+
+	c.Flags().StringVar(&opts.manifest, "manifest", "", "Name of the manifest of the backup to verify. Optional. If not specified, every backup in PATH is verified.")
+	c.Flags().StringVar(&opts.keyFile, "key-file", "", "File holding the key the backup was encrypted with. Optional.")
+	c.Flags().StringVar(&opts.passphraseFile, "passphrase-file", "", "File holding the passphrase the backup was encrypted with. Optional.")
+
+	return c
+}
+
+// run verifies the files of the manifests, each file once.
+func (opts *verifyOptions) run() error {
+	manifests := []string{opts.manifest}
+	if opts.manifest == "" {
+		var err error
+		if manifests, err = opts.storage.List(backup_util.ManifestSuffix); err != nil {
+			return err
+		}
+		if len(manifests) == 0 {
+			return fmt.Errorf("no manifest files found in: %s", opts.storage.Path(""))
+		}
+	}
+
+	var files, failed int
+	seen := make(map[string]bool)
+	check := func(name, checksum, digest string, isMeta, encrypted bool) {
+		if name == "" || seen[name] {
+			return
+		}
+		seen[name] = true
+		files++
+
+		if err := opts.verifyFile(name, checksum, digest, isMeta, encrypted); err != nil {
+			failed++
+			fmt.Fprintf(opts.Stdout, "%s: FAILED: %v\n", opts.storage.Path(name), err)
+			return
+		}
+		fmt.Fprintf(opts.Stdout, "%s: OK\n", opts.storage.Path(name))
+	}
+
+	for _, name := range manifests {
+		m, err := backup_util.LoadManifest(opts.storage, name)
+		if err != nil {
+			return err
+		}
+		if m.Encryption != "" && opts.key == nil {
+			return backup_util.ErrKeyRequired
+		}
+
+		encrypted := m.Encryption != ""
+		check(m.Meta.FileName, m.Meta.Checksum, m.Meta.Digest, true, encrypted)
+		for _, f := range m.Files {
+			check(f.FileName, f.Checksum, f.Digest, false, encrypted)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d files failed verification", failed, files)
+	}
+	fmt.Fprintf(opts.Stdout, "verified %d files\n", files)
+	return nil
+}
+
+// verifyFile reads a file of the backup once, computing its checksum and
+// digest while its content is decrypted and validated. The file must be
+// encrypted if the backup is.
+func (opts *verifyOptions) verifyFile(name, checksum, digest string, isMeta, encrypted bool) error {
+	f, err := opts.storage.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h, d := backup_util.NewChecksum(), backup_util.NewDigest()
+	tr := io.TeeReader(f, io.MultiWriter(h, d))
+
+	r, err := backup_util.NewDecryptReader(tr, opts.key, encrypted)
+	if err != nil {
+		return err
+	}
+	if isMeta {
+		err = verifyMeta(r, name)
+	} else {
+		err = verifyShard(r, name)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Hash what the validation did not read.
+	if _, err := io.Copy(ioutil.Discard, tr); err != nil {
+		return err
+	}
+	if sum := backup_util.FormatChecksum(h); checksum != "" && sum != checksum {
+		return fmt.Errorf("checksum mismatch: got %s, expected %s", sum, checksum)
+	}
+	if sum := backup_util.FormatDigest(d); digest != "" && sum != digest {
+		return fmt.Errorf("digest mismatch: got %s, expected %s", sum, digest)
+	}
+	return nil
+}
+
+// verifyMeta decodes a metastore backup.
+func verifyMeta(r io.Reader, name string) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	var metaBytes []byte
+	if strings.HasSuffix(name, ".meta") {
+		// Portable backups store the meta data in a PortablePacker.
+		var ep backup_util.PortablePacker
+		if err := ep.UnmarshalBinary(b); err != nil {
+			return fmt.Errorf("invalid metadata file: %v", err)
+		}
+		metaBytes = ep.Data
+	} else if metaBytes, err = backup_util.ParseMetaBytes(b); err != nil {
+		return err
+	}
+
+	var data meta.Data
+	if err := data.UnmarshalBinary(metaBytes); err != nil {
+		return fmt.Errorf("unmarshal meta: %v", err)
+	}
+	return nil
+}
+
+// verifyShard reads the archive of a shard and validates its TSM files.
+func verifyShard(r io.Reader, name string) error {
+	if strings.HasSuffix(name, ".gz") {
+		zr, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		r = zr
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("read archive: %v", err)
+		}
+
+		if strings.HasSuffix(hdr.Name, "."+tsm1.TSMFileExtension) {
+			if err := verifyTSM(tr); err != nil {
+				return fmt.Errorf("%s: %v", hdr.Name, err)
+			}
+		}
+	}
+}
+
+// verifyTSM reads the index of a TSM file and checks the checksum of each
+// of its blocks. The file is copied to a temporary file to be mapped.
+func verifyTSM(r io.Reader) error {
+	f, err := ioutil.TempFile("", "cnosdb-verify-*.tsm")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	reader, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("invalid index: %v", err)
+	}
+	defer reader.Close()
+
+	iter := reader.BlockIterator()
+	for iter.Next() {
+		key, _, _, _, checksum, buf, err := iter.Read()
+		if err != nil {
+			return err
+		}
+		if crc32.ChecksumIEEE(buf) != checksum {
+			return fmt.Errorf("checksum mismatch in block of key %q", key)
+		}
+	}
+	return iter.Err()
+}
@@ -2,18 +2,24 @@ package backup_util
 
 import (
 	"bytes"
+	"crypto/sha256"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"hash"
+	"hash/crc32"
 	"io"
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	"io/ioutil"
 	"path/filepath"
 
 	internal "github.com/cnosdatabase/cnosdb/cmd/cnosdb/backup_util/internal"
+	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/server/snapshotter"
 	"github.com/gogo/protobuf/proto"
 )
@@ -32,6 +38,9 @@ const (
 	BackupFilePattern = "%s.%s.%05d"
 
 	PortableFileNamePattern = "20060102T150405Z"
+
+	// ManifestSuffix is the extension of the manifest files.
+	ManifestSuffix = ".manifest"
 )
 
 type PortablePacker struct {
@@ -55,21 +64,36 @@ func (ep *PortablePacker) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-func GetMetaBytes(fname string) ([]byte, error) {
+// GetMetaBytes returns the meta data of a metastore backup file, decrypted
+// with the key if the file is encrypted.
+func GetMetaBytes(fname string, k *Key) ([]byte, error) {
 	f, err := os.Open(fname)
 	if err != nil {
 		return []byte{}, err
 	}
+	defer f.Close()
+
+	r, err := NewDecryptReader(f, k, false)
+	if err != nil {
+		return []byte{}, err
+	}
 
 	var buf bytes.Buffer
-	if _, err := io.Copy(&buf, f); err != nil {
+	if _, err := io.Copy(&buf, r); err != nil {
 		return []byte{}, fmt.Errorf("copy: %s", err)
 	}
 
-	b := buf.Bytes()
+	return ParseMetaBytes(buf.Bytes())
+}
+
+// ParseMetaBytes returns the meta data of a metastore backup.
+func ParseMetaBytes(b []byte) ([]byte, error) {
 	var i int
 
 	// Make sure the file is actually a meta store backup file
+	if len(b) < 16 {
+		return []byte{}, fmt.Errorf("invalid metadata file")
+	}
 	magic := binary.BigEndian.Uint64(b[:8])
 	if magic != snapshotter.BackupMagicHeader {
 		return []byte{}, fmt.Errorf("invalid metadata file")
@@ -79,6 +103,9 @@ func GetMetaBytes(fname string) ([]byte, error) {
 	// Size of the meta store bytes
 	length := int(binary.BigEndian.Uint64(b[i : i+8]))
 	i += 8
+	if length < 0 || len(b)-i < length {
+		return []byte{}, fmt.Errorf("truncated metadata file")
+	}
 	metaBytes := b[i : i+length]
 
 	return metaBytes, nil
@@ -87,11 +114,23 @@ func GetMetaBytes(fname string) ([]byte, error) {
 // Manifest lists the meta and shard file information contained in the backup.
 // If Limited is false, the manifest contains a full backup, otherwise
 // it is a partial backup.
+//
+// An incremental backup names the manifest of the backup it is based on in
+// Parent, and holds only the files of each shard changed since the shard was
+// snapshotted for the parent or one of its ancestors.
+//
+// If Encryption is set, the files are encrypted with it. The checksums and
+// digests are those of the stored files.
 type Manifest struct {
 	Meta    MetaEntry `json:"meta"`
 	Limited bool      `json:"limited"`
 	Files   []Entry   `json:"files"`
 
+	Encryption string `json:"encryption,omitempty"`
+
+	Created time.Time `json:"created"`
+	Parent  string    `json:"parent,omitempty"`
+
 	// If limited is true, then one (or all) of the following fields will be set
 
 	Database string `json:"database,omitempty"`
@@ -107,6 +146,22 @@ type Entry struct {
 	FileName     string `json:"fileName"`
 	Size         int64  `json:"size"`
 	LastModified int64  `json:"lastModified"`
+
+	Owners   []uint64 `json:"owners,omitempty"`
+	Node     uint64   `json:"node,omitempty"`
+	Checksum string   `json:"checksum,omitempty"`
+	Digest   string   `json:"digest,omitempty"`
+
+	// SnapshotTime is the time the shard was snapshotted, by the clock of
+	// the data node it was backed up from, and TSMFiles the TSM files of the
+	// shard at that time. Backups of older data nodes have neither.
+	SnapshotTime time.Time `json:"snapshotTime"`
+	TSMFiles     []string  `json:"tsmFiles,omitempty"`
+
+	// Full is set if the file holds all the files of the shard rather than
+	// those changed since the parent backup, so that it replaces the files
+	// of the shard in the backups it is based on.
+	Full bool `json:"full,omitempty"`
 }
 
 func (e *Entry) SizeOrZero() int64 {
@@ -120,6 +175,21 @@ func (e *Entry) SizeOrZero() int64 {
 type MetaEntry struct {
 	FileName string `json:"fileName"`
 	Size     int64  `json:"size"`
+
+	Index    uint64 `json:"index,omitempty"`
+	Checksum string `json:"checksum,omitempty"`
+	Digest   string `json:"digest,omitempty"`
+}
+
+// Covers returns whether the backup of the manifest holds the shards of a
+// backup of a database, a time to live and a shard, all of them if empty.
+func (m *Manifest) Covers(database, policy string, shardID uint64) bool {
+	if !m.Limited {
+		return true
+	}
+	return (m.Database == "" || m.Database == database) &&
+		(m.Policy == "" || m.Policy == policy) &&
+		(m.ShardID == 0 || m.ShardID == shardID)
 }
 
 // Size returns the size of the manifest.
@@ -145,9 +215,184 @@ func (manifest *Manifest) Save(filename string) error {
 	return ioutil.WriteFile(filename, b, 0600)
 }
 
+// Store stores a manifest as a file of a backup.
+func (manifest *Manifest) Store(s Storage, name string) error {
+	b, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("create manifest: %v", err)
+	}
+
+	w, err := s.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(b); err != nil {
+		w.Abort()
+		return err
+	}
+	return w.Close()
+}
+
+// LoadManifest loads a manifest file of a backup.
+func LoadManifest(s Storage, name string) (*Manifest, error) {
+	r, err := s.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var manifest Manifest
+	if err := json.NewDecoder(r).Decode(&manifest); err != nil {
+		return nil, fmt.Errorf("read manifest %s: %v", s.Path(name), err)
+	}
+	return &manifest, nil
+}
+
+// LatestManifest returns the name of the most recent manifest of a backup
+// storage, or an empty name if there is none.
+func LatestManifest(s Storage) (string, error) {
+	manifests, err := s.List(ManifestSuffix)
+	if err != nil {
+		return "", err
+	}
+	if len(manifests) == 0 {
+		return "", nil
+	}
+
+	// The names start with the time of the backup, so the last is the most recent.
+	return manifests[len(manifests)-1], nil
+}
+
+// LatestCoveringManifest returns the name of the most recent manifest of a
+// backup storage covering a backup of a database, a time to live and a
+// shard, all of them if empty, or an empty name if there is none.
+func LatestCoveringManifest(s Storage, database, policy string, shardID uint64) (string, error) {
+	manifests, err := s.List(ManifestSuffix)
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(manifests) - 1; i >= 0; i-- {
+		manifest, err := LoadManifest(s, manifests[i])
+		if err != nil {
+			return "", err
+		}
+		if manifest.Covers(database, policy, shardID) {
+			return manifests[i], nil
+		}
+	}
+	return "", nil
+}
+
+// LoadChain loads a manifest and the manifests of the backups it is based
+// on, from the same storage. The manifests are returned from the full backup
+// to the given one.
+func LoadChain(s Storage, name string) ([]*Manifest, error) {
+	seen := make(map[string]bool)
+
+	var chain []*Manifest
+	for name != "" {
+		if seen[name] {
+			return nil, fmt.Errorf("manifest %s is its own ancestor", name)
+		}
+		seen[name] = true
+
+		manifest, err := LoadManifest(s, name)
+		if err != nil {
+			return nil, err
+		}
+		chain = append([]*Manifest{manifest}, chain...)
+		name = manifest.Parent
+	}
+	return chain, nil
+}
+
+// LatestEntries returns the most recent entry of each shard of a chain of
+// manifests returned by LoadChain.
+func LatestEntries(chain []*Manifest) map[uint64]Entry {
+	entries := make(map[uint64]Entry)
+	for _, m := range chain {
+		for _, f := range m.Files {
+			entries[f.ShardID] = f
+		}
+	}
+	return entries
+}
+
+// NewChecksum returns a hash computing the CRC32C checksums recorded in
+// manifests.
+func NewChecksum() hash.Hash32 {
+	return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+}
+
+// FormatChecksum returns the checksum computed by a hash returned by
+// NewChecksum, as recorded in a manifest.
+func FormatChecksum(h hash.Hash32) string {
+	return fmt.Sprintf("crc32c:%08x", h.Sum32())
+}
+
+// NewDigest returns a hash computing the SHA-256 digests recorded in
+// manifests.
+func NewDigest() hash.Hash {
+	return sha256.New()
+}
+
+// FormatDigest returns the digest computed by a hash returned by NewDigest,
+// as recorded in a manifest.
+func FormatDigest(h hash.Hash) string {
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyFile returns an error if the checksum or the digest of a file of a
+// backup does not match the one recorded in a manifest. Missing checksums
+// and digests are not checked.
+func VerifyFile(s Storage, name, checksum, digest string) error {
+	if checksum == "" && digest == "" {
+		return nil
+	}
+	r, err := s.Open(name)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	h, d := NewChecksum(), NewDigest()
+	if _, err := io.Copy(io.MultiWriter(h, d), r); err != nil {
+		return err
+	}
+	if sum := FormatChecksum(h); checksum != "" && sum != checksum {
+		return fmt.Errorf("checksum mismatch for %s: got %s, expected %s", s.Path(name), sum, checksum)
+	}
+	if sum := FormatDigest(d); digest != "" && sum != digest {
+		return fmt.Errorf("digest mismatch for %s: got %s, expected %s", s.Path(name), sum, digest)
+	}
+	return nil
+}
+
+// ShardOwners returns the IDs of the data nodes owning a shard.
+func ShardOwners(data *meta.Data, shardID uint64) []uint64 {
+	for _, db := range data.Databases {
+		for _, ttl := range db.TimeToLives {
+			for _, rg := range ttl.Regions {
+				for _, sh := range rg.Shards {
+					if sh.ID != shardID {
+						continue
+					}
+					owners := make([]uint64, 0, len(sh.Owners))
+					for _, o := range sh.Owners {
+						owners = append(owners, o.NodeID)
+					}
+					return owners
+				}
+			}
+		}
+	}
+	return nil
+}
+
 // LoadIncremental loads multiple manifest files from a given directory.
 func LoadIncremental(dir string) (*MetaEntry, map[uint64]*Entry, error) {
-	manifests, err := filepath.Glob(filepath.Join(dir, "*.manifest"))
+	manifests, err := filepath.Glob(filepath.Join(dir, "*"+ManifestSuffix))
 	if err != nil {
 		return nil, nil, err
 	}
@@ -0,0 +1,353 @@
+package backup_util
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+// An encrypted file of a backup starts with a header:
+//
+//	┌────────────┬─────┬──────────┬──────┬──────────────┐
+//	│   Magic    │ KDF │ Salt Len │ Salt │ Nonce Prefix │
+//	│  8 bytes   │  1  │    1     │  N   │   7 bytes    │
+//	└────────────┴─────┴──────────┴──────┴──────────────┘
+//
+// followed by chunks of up to 64KB of data, each sealed with AES-256-GCM
+// and prefixed with its 4 byte sealed length. The nonce of a chunk is the
+// nonce prefix, the 4 byte index of the chunk and a byte set on the last
+// chunk, so that chunks cannot be reordered and a truncated file is detected.
+// The header is authenticated with every chunk.
+const (
+	// EncryptionMagic is written as the first bytes of an encrypted file.
+	EncryptionMagic = "CNOSAES1"
+
+	// Encryption is the cipher recorded in the manifest of an encrypted backup.
+	Encryption = "aes-256-gcm"
+
+	kdfNone   byte = 0
+	kdfScrypt byte = 1
+
+	keySize         = 32
+	saltSize        = 16
+	noncePrefixSize = 7
+	chunkSize       = 64 * 1024
+)
+
+var (
+	// ErrKeyRequired is returned when reading an encrypted file without key.
+	ErrKeyRequired = errors.New("backup is encrypted: a key file or passphrase file is required")
+
+	// ErrDecrypt is returned when an encrypted file cannot be authenticated.
+	ErrDecrypt = errors.New("decrypt: wrong key or corrupted file")
+
+	// ErrNotEncrypted is returned when a file of an encrypted backup is not
+	// encrypted.
+	ErrNotEncrypted = errors.New("backup is encrypted but the file is not")
+)
+
+// Key is the key encrypting the files of a backup, read from a key file or
+// derived from a passphrase with scrypt.
+type Key struct {
+	key        []byte
+	passphrase []byte
+
+	// salt is the salt of the keys derived to encrypt files.
+	salt []byte
+
+	mu      sync.Mutex
+	derived map[string][]byte
+}
+
+// NewKey returns the key of a backup. A key file holds 32 bytes, raw or hex
+// encoded; a passphrase file holds a passphrase, whose trailing newline is
+// ignored. It returns nil if neither file is given.
+func NewKey(keyFile, passphraseFile string) (*Key, error) {
+	if keyFile != "" && passphraseFile != "" {
+		return nil, errors.New("a key file and a passphrase file are mutually exclusive")
+	}
+
+	if keyFile != "" {
+		b, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(b) != keySize {
+			b, err = hex.DecodeString(strings.TrimSpace(string(b)))
+			if err != nil || len(b) != keySize {
+				return nil, fmt.Errorf("key file %s must hold %d bytes, raw or hex encoded", keyFile, keySize)
+			}
+		}
+		return &Key{key: b}, nil
+	}
+
+	if passphraseFile != "" {
+		b, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, err
+		}
+		b = bytes.TrimRight(b, "\r\n")
+		if len(b) == 0 {
+			return nil, fmt.Errorf("passphrase file %s is empty", passphraseFile)
+		}
+		return &Key{passphrase: b}, nil
+	}
+
+	return nil, nil
+}
+
+// derive returns the AES key for a salt.
+func (k *Key) derive(kdf byte, salt []byte) ([]byte, error) {
+	switch kdf {
+	case kdfNone:
+		if k.key == nil {
+			return nil, errors.New("file is encrypted with a key file, not a passphrase")
+		}
+		return k.key, nil
+	case kdfScrypt:
+		if k.passphrase == nil {
+			return nil, errors.New("file is encrypted with a passphrase, not a key file")
+		}
+	default:
+		return nil, fmt.Errorf("unknown key derivation %d", kdf)
+	}
+
+	// Deriving a key is slow on purpose, so it is done once per salt.
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if key, ok := k.derived[string(salt)]; ok {
+		return key, nil
+	}
+	key, err := scrypt.Key(k.passphrase, salt, 1<<15, 8, 1, keySize)
+	if err != nil {
+		return nil, err
+	}
+	if k.derived == nil {
+		k.derived = make(map[string][]byte)
+	}
+	k.derived[string(salt)] = key
+	return key, nil
+}
+
+// header returns a new header and the cipher of a file.
+func (k *Key) header() ([]byte, cipher.AEAD, error) {
+	kdf := kdfNone
+	var salt []byte
+	if k.passphrase != nil {
+		kdf = kdfScrypt
+		k.mu.Lock()
+		if k.salt == nil {
+			k.salt = make([]byte, saltSize)
+			if _, err := rand.Read(k.salt); err != nil {
+				k.mu.Unlock()
+				return nil, nil, err
+			}
+		}
+		salt = k.salt
+		k.mu.Unlock()
+	}
+
+	hdr := append([]byte(EncryptionMagic), kdf, byte(len(salt)))
+	hdr = append(hdr, salt...)
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, nil, err
+	}
+	hdr = append(hdr, prefix...)
+
+	aead, err := k.cipher(kdf, salt)
+	if err != nil {
+		return nil, nil, err
+	}
+	return hdr, aead, nil
+}
+
+func (k *Key) cipher(kdf byte, salt []byte) (cipher.AEAD, error) {
+	key, err := k.derive(kdf, salt)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// chunkNonce returns the nonce of a chunk of a file.
+func chunkNonce(hdr []byte, index uint32, last bool) []byte {
+	nonce := make([]byte, 0, noncePrefixSize+5)
+	nonce = append(nonce, hdr[len(hdr)-noncePrefixSize:]...)
+	nonce = append(nonce, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], index)
+	if last {
+		nonce[len(nonce)-1] = 1
+	}
+	return nonce
+}
+
+// NewEncryptWriter returns a writer encrypting to w. The last chunk is
+// written when the writer is closed, which does not close w.
+func NewEncryptWriter(w io.Writer, k *Key) (io.WriteCloser, error) {
+	hdr, aead, err := k.header()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{w: w, aead: aead, hdr: hdr, buf: make([]byte, 0, chunkSize)}, nil
+}
+
+type encryptWriter struct {
+	w     io.Writer
+	aead  cipher.AEAD
+	hdr   []byte
+	buf   []byte
+	index uint32
+}
+
+func (w *encryptWriter) Write(p []byte) (int, error) {
+	var n int
+	for len(p) > 0 {
+		// A full chunk is only sealed once more data follows, as the
+		// last chunk is sealed differently.
+		if len(w.buf) == chunkSize {
+			if err := w.seal(false); err != nil {
+				return n, err
+			}
+		}
+		m := copy(w.buf[len(w.buf):chunkSize], p)
+		w.buf = w.buf[:len(w.buf)+m]
+		p = p[m:]
+		n += m
+	}
+	return n, nil
+}
+
+func (w *encryptWriter) seal(last bool) error {
+	sealed := w.aead.Seal(make([]byte, 4, 4+len(w.buf)+w.aead.Overhead()), chunkNonce(w.hdr, w.index, last), w.buf, w.hdr)
+	binary.BigEndian.PutUint32(sealed[:4], uint32(len(sealed)-4))
+	if _, err := w.w.Write(sealed); err != nil {
+		return err
+	}
+	w.buf = w.buf[:0]
+	w.index++
+	return nil
+}
+
+func (w *encryptWriter) Close() error {
+	return w.seal(true)
+}
+
+// IsEncrypted returns whether a file starts like an encrypted file.
+func IsEncrypted(b []byte) bool {
+	return bytes.HasPrefix(b, []byte(EncryptionMagic))
+}
+
+// NewDecryptReader returns a reader of a file of a backup. Encrypted files
+// are decrypted with the key, other files are read as is unless encryption
+// is required, as for the files of a backup whose manifest sets Encryption.
+func NewDecryptReader(r io.Reader, k *Key, required bool) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	b, err := br.Peek(len(EncryptionMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if !IsEncrypted(b) {
+		if required {
+			return nil, ErrNotEncrypted
+		}
+		return br, nil
+	}
+	if k == nil {
+		return nil, ErrKeyRequired
+	}
+
+	hdr := make([]byte, len(EncryptionMagic)+2)
+	if _, err := io.ReadFull(br, hdr); err != nil {
+		return nil, fmt.Errorf("read encryption header: %v", err)
+	}
+	rest := make([]byte, int(hdr[len(hdr)-1])+noncePrefixSize)
+	if _, err := io.ReadFull(br, rest); err != nil {
+		return nil, fmt.Errorf("read encryption header: %v", err)
+	}
+	kdf, salt := hdr[len(hdr)-2], rest[:len(rest)-noncePrefixSize]
+	hdr = append(hdr, rest...)
+
+	aead, err := k.cipher(kdf, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptReader{r: br, aead: aead, hdr: hdr}, nil
+}
+
+type decryptReader struct {
+	r     *bufio.Reader
+	aead  cipher.AEAD
+	hdr   []byte
+	buf   []byte
+	index uint32
+	last  bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.last {
+			return 0, io.EOF
+		}
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+// open reads and opens the next chunk.
+func (r *decryptReader) open() error {
+	var size [4]byte
+	if _, err := io.ReadFull(r.r, size[:]); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("decrypt: %v", io.ErrUnexpectedEOF)
+		}
+		return fmt.Errorf("decrypt: %v", err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n < uint32(r.aead.Overhead()) || n > uint32(chunkSize+r.aead.Overhead()) {
+		return ErrDecrypt
+	}
+	sealed := make([]byte, n)
+	if _, err := io.ReadFull(r.r, sealed); err != nil {
+		return fmt.Errorf("decrypt: %v", err)
+	}
+
+	// The last chunk is the one followed by the end of the file.
+	_, err := r.r.Peek(1)
+	last := err == io.EOF
+	if err != nil && !last {
+		return fmt.Errorf("decrypt: %v", err)
+	}
+
+	b, err := r.aead.Open(sealed[:0], chunkNonce(r.hdr, r.index, last), sealed, r.hdr)
+	if err != nil {
+		return ErrDecrypt
+	}
+	r.buf = b
+	r.index++
+	r.last = last
+	return nil
+}
@@ -0,0 +1,164 @@
+package backup_util
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+)
+
+// Ensure files of any size are decrypted to what was encrypted, with a key
+// and with a passphrase.
+func TestCrypt_RoundTrip(t *testing.T) {
+	for _, k := range []*Key{testKey(1), {passphrase: []byte("correct horse battery staple")}} {
+		for _, n := range []int{0, 1, chunkSize - 1, chunkSize, chunkSize + 1, 3*chunkSize + 100} {
+			data := testData(n)
+			enc := encrypt(t, k, data)
+			if !IsEncrypted(enc) {
+				t.Fatalf("size %d: file not encrypted", n)
+			}
+			if n > 0 && bytes.Contains(enc, data) {
+				t.Fatalf("size %d: plaintext in encrypted file", n)
+			}
+
+			got, err := decrypt(enc, k, true)
+			if err != nil {
+				t.Fatalf("size %d: %v", n, err)
+			}
+			if !bytes.Equal(got, data) {
+				t.Fatalf("size %d: unexpected plaintext of %d bytes", n, len(got))
+			}
+		}
+	}
+}
+
+// Ensure the files of a backup are only read as is when encryption is not
+// required, and never without a key or with another key.
+func TestCrypt_Required(t *testing.T) {
+	data := testData(100)
+	if got, err := decrypt(data, testKey(1), false); err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("unexpected plaintext read: err=%v", err)
+	}
+	if _, err := decrypt(data, testKey(1), true); err != ErrNotEncrypted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := decrypt(data, nil, true); err != ErrNotEncrypted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	enc := encrypt(t, testKey(1), data)
+	if _, err := decrypt(enc, nil, false); err != ErrKeyRequired {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := decrypt(enc, testKey(2), true); err != ErrDecrypt {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure a truncated file is detected, including at a chunk boundary.
+func TestCrypt_Truncated(t *testing.T) {
+	k := testKey(1)
+	enc := encrypt(t, k, testData(2*chunkSize+10))
+
+	hdrSize := len(EncryptionMagic) + 2 + noncePrefixSize
+	firstChunk := hdrSize + 4 + int(binary.BigEndian.Uint32(enc[hdrSize:]))
+	secondChunk := firstChunk + 4 + int(binary.BigEndian.Uint32(enc[firstChunk:]))
+
+	for _, n := range []int{
+		hdrSize - 1,
+		hdrSize,
+		hdrSize + 2,
+		hdrSize + 100,
+		firstChunk,
+		firstChunk + 3,
+		secondChunk,
+		len(enc) - 1,
+	} {
+		if _, err := decrypt(enc[:n], k, true); err == nil {
+			t.Fatalf("truncation to %d of %d bytes not detected", n, len(enc))
+		}
+	}
+}
+
+// Ensure a modified file is detected, whether its header, a chunk or the
+// order of its chunks is modified.
+func TestCrypt_Tampered(t *testing.T) {
+	k := testKey(1)
+	enc := encrypt(t, k, testData(3*chunkSize))
+
+	hdrSize := len(EncryptionMagic) + 2 + noncePrefixSize
+	chunk := 4 + int(binary.BigEndian.Uint32(enc[hdrSize:]))
+
+	for _, i := range []int{
+		len(EncryptionMagic) + 2,
+		hdrSize - 1,
+		hdrSize + 4,
+		hdrSize + chunk/2,
+		hdrSize + chunk + 10,
+		len(enc) - 1,
+	} {
+		b := append([]byte(nil), enc...)
+		b[i] ^= 0x01
+		if _, err := decrypt(b, k, true); err != ErrDecrypt {
+			t.Fatalf("modification of byte %d: unexpected error: %v", i, err)
+		}
+	}
+
+	// Swap the first two chunks, which have the same size.
+	b := append([]byte(nil), enc[:hdrSize]...)
+	b = append(b, enc[hdrSize+chunk:hdrSize+2*chunk]...)
+	b = append(b, enc[hdrSize:hdrSize+chunk]...)
+	b = append(b, enc[hdrSize+2*chunk:]...)
+	if _, err := decrypt(b, k, true); err != ErrDecrypt {
+		t.Fatalf("reordered chunks: unexpected error: %v", err)
+	}
+
+	// Drop the first chunk.
+	b = append(append([]byte(nil), enc[:hdrSize]...), enc[hdrSize+chunk:]...)
+	if _, err := decrypt(b, k, true); err != ErrDecrypt {
+		t.Fatalf("dropped chunk: unexpected error: %v", err)
+	}
+}
+
+func testKey(seed byte) *Key {
+	return &Key{key: bytes.Repeat([]byte{seed}, keySize)}
+}
+
+func testData(n int) []byte {
+	b := make([]byte, n)
+	rand.New(rand.NewSource(int64(n))).Read(b)
+	return b
+}
+
+func encrypt(t *testing.T, k *Key, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewEncryptWriter(&buf, k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Write in pieces not aligned with the chunks.
+	for len(data) > 0 {
+		n := 1000
+		if n > len(data) {
+			n = len(data)
+		}
+		if _, err := w.Write(data[:n]); err != nil {
+			t.Fatal(err)
+		}
+		data = data[n:]
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func decrypt(b []byte, k *Key, required bool) ([]byte, error) {
+	r, err := NewDecryptReader(bytes.NewReader(b), k, required)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
@@ -0,0 +1,468 @@
+package backup_util
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// s3PartSize is the size of the first parts of a multipart upload. The
+	// size doubles every s3PartsPerSize parts, as an upload has at most
+	// 10000 parts.
+	s3PartSize     = 16 << 20
+	s3PartsPerSize = 1000
+
+	// s3Attempts is the number of attempts of a request failing with a
+	// network or server error.
+	s3Attempts = 3
+)
+
+// S3Storage stores the files of a backup under a prefix of a bucket of an
+// S3-compatible object storage. Files are uploaded in parts and the storage
+// checks the MD5 and the SHA-256 of each part it receives.
+//
+// The credentials, region and endpoint are read from the AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION and
+// AWS_ENDPOINT_URL_S3 (or AWS_ENDPOINT_URL) environment variables. Requests
+// use path-style URLs, so any endpoint serving the S3 API can be used.
+type S3Storage struct {
+	Bucket string
+	Prefix string
+
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	Client *http.Client
+}
+
+// NewS3Storage returns the storage of a s3://bucket/prefix location.
+func NewS3Storage(location string) (*S3Storage, error) {
+	u, err := url.Parse(location)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "s3" || u.Host == "" {
+		return nil, fmt.Errorf("invalid S3 location %s, expected s3://bucket/prefix", location)
+	}
+
+	s := &S3Storage{
+		Bucket:          u.Host,
+		Prefix:          strings.Trim(u.Path, "/"),
+		Region:          firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		Endpoint:        firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Client:          http.DefaultClient,
+	}
+	if s.Prefix != "" {
+		s.Prefix += "/"
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if s.Endpoint == "" {
+		s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+	}
+	s.Endpoint = strings.TrimSuffix(s.Endpoint, "/")
+	return s, nil
+}
+
+func firstEnv(keys ...string) string {
+	for _, k := range keys {
+		if v := os.Getenv(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// Create returns a writer to a new object. The object is uploaded in one
+// request if it is smaller than a part, else in a multipart upload completed
+// when the writer is closed.
+func (s *S3Storage) Create(name string) (FileWriter, error) {
+	return &s3FileWriter{s: s, key: s.Prefix + name, partSize: s3PartSize}, nil
+}
+
+// Open opens an object for reading.
+func (s *S3Storage) Open(name string) (io.ReadCloser, error) {
+	resp, err := s.do("GET", s.Prefix+name, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Exists returns whether an object exists.
+func (s *S3Storage) Exists(name string) (bool, error) {
+	resp, err := s.do("HEAD", s.Prefix+name, nil, nil)
+	if err, ok := err.(*S3Error); ok && err.StatusCode == http.StatusNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return true, nil
+}
+
+// List returns the sorted names of the objects directly under the prefix
+// ending with a suffix.
+func (s *S3Storage) List(suffix string) ([]string, error) {
+	var names []string
+	var token string
+	for {
+		query := url.Values{
+			"list-type": {"2"},
+			"prefix":    {s.Prefix},
+			"delimiter": {"/"},
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do("GET", "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		var result struct {
+			Contents []struct {
+				Key string
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("decode list of %s: %s", s.Path(""), err)
+		}
+
+		for _, c := range result.Contents {
+			if strings.HasSuffix(c.Key, suffix) {
+				names = append(names, strings.TrimPrefix(c.Key, s.Prefix))
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Path returns the URL of an object.
+func (s *S3Storage) Path(name string) string {
+	return "s3://" + s.Bucket + "/" + s.Prefix + name
+}
+
+// S3Error is an error response of the object storage.
+type S3Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+	Resource   string
+}
+
+func (e *S3Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("s3 %s: %s", e.Resource, http.StatusText(e.StatusCode))
+	}
+	return fmt.Sprintf("s3 %s: %s: %s", e.Resource, e.Code, e.Message)
+}
+
+// do sends a signed request for an object, or for the bucket if key is
+// empty. Requests failing with a network or server error are retried. An
+// error response is returned as an *S3Error.
+func (s *S3Storage) do(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	var err error
+	for i := 0; i < s3Attempts; i++ {
+		if i > 0 {
+			time.Sleep(time.Duration(i) * time.Second)
+		}
+
+		var resp *http.Response
+		resp, err = s.send(method, key, query, body)
+		if err != nil {
+			continue
+		}
+		if resp.StatusCode < 300 {
+			return resp, nil
+		}
+
+		err = readS3Error(resp, "/"+s.Bucket+"/"+key)
+		if resp.StatusCode < 500 {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func (s *S3Storage) send(method, key string, query url.Values, body []byte) (*http.Response, error) {
+	path := "/" + s3Escape(s.Bucket, false)
+	if key != "" {
+		path += "/" + s3Escape(key, false)
+	}
+	rawQuery := s3Query(query)
+	u, err := url.Parse(s.Endpoint + path)
+	if err != nil {
+		return nil, err
+	}
+	u.RawQuery = rawQuery
+
+	req, err := http.NewRequest(method, u.String(), http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > 0 {
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+		req.ContentLength = int64(len(body))
+		sum := md5.Sum(body)
+		req.Header.Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+	}
+	s.sign(req, path, rawQuery, body, time.Now().UTC())
+	return s.Client.Do(req)
+}
+
+// sign signs a request with AWS Signature Version 4.
+func (s *S3Storage) sign(req *http.Request, path, rawQuery string, body []byte, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+	req.Header.Set("X-Amz-Date", now.Format("20060102T150405Z"))
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.AccessKeyID == "" {
+		// Anonymous access to a public bucket.
+		return
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") || k == "content-md5" || k == "content-type" || k == "range" {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		rawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		req.Header.Get("X-Amz-Content-Sha256"),
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	date := now.Format("20060102")
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + req.Header.Get("X-Amz-Date") + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape URI-encodes a string as required by the signature, keeping the
+// slashes of object keys.
+func s3Escape(s string, escapeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !escapeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3Query returns the canonical query string of a request.
+func s3Query(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, true)+"="+s3Escape(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func readS3Error(resp *http.Response, resource string) error {
+	defer resp.Body.Close()
+	e := &S3Error{StatusCode: resp.StatusCode, Resource: resource}
+	b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	xml.Unmarshal(b, e)
+	return e
+}
+
+type s3Part struct {
+	PartNumber int
+	ETag       string
+}
+
+// s3FileWriter uploads an object in parts of growing size.
+type s3FileWriter struct {
+	s   *S3Storage
+	key string
+
+	buf      bytes.Buffer
+	partSize int
+
+	uploadID string
+	parts    []s3Part
+}
+
+func (w *s3FileWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for w.buf.Len() >= w.partSize {
+		if err := w.uploadPart(w.buf.Next(w.partSize)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Close uploads the rest of the object and completes its upload.
+func (w *s3FileWriter) Close() error {
+	if w.uploadID == "" {
+		resp, err := w.s.do("PUT", w.key, nil, w.buf.Bytes())
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if w.buf.Len() > 0 {
+		if err := w.uploadPart(w.buf.Next(w.buf.Len())); err != nil {
+			w.Abort()
+			return err
+		}
+	}
+
+	var complete struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}
+	complete.Parts = w.parts
+	body, err := xml.Marshal(complete)
+	if err != nil {
+		return err
+	}
+	resp, err := w.s.do("POST", w.key, url.Values{"uploadId": {w.uploadID}}, body)
+	if err != nil {
+		w.Abort()
+		return err
+	}
+
+	// The completion can fail after the response status was sent.
+	b, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		w.Abort()
+		return err
+	}
+	if bytes.Contains(b, []byte("<Error>")) {
+		e := &S3Error{StatusCode: resp.StatusCode, Resource: "/" + w.s.Bucket + "/" + w.key}
+		xml.Unmarshal(b, e)
+		w.Abort()
+		return e
+	}
+	return nil
+}
+
+// Abort discards the uploaded parts.
+func (w *s3FileWriter) Abort() error {
+	w.buf.Reset()
+	if w.uploadID == "" {
+		return nil
+	}
+	resp, err := w.s.do("DELETE", w.key, url.Values{"uploadId": {w.uploadID}}, nil)
+	w.uploadID = ""
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (w *s3FileWriter) uploadPart(b []byte) error {
+	if w.uploadID == "" {
+		resp, err := w.s.do("POST", w.key, url.Values{"uploads": {""}}, nil)
+		if err != nil {
+			return err
+		}
+		var result struct {
+			UploadID string `xml:"UploadId"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("decode multipart upload of %s: %s", w.key, err)
+		}
+		w.uploadID = result.UploadID
+	}
+
+	n := len(w.parts) + 1
+	resp, err := w.s.do("PUT", w.key, url.Values{"partNumber": {strconv.Itoa(n)}, "uploadId": {w.uploadID}}, b)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	w.parts = append(w.parts, s3Part{PartNumber: n, ETag: resp.Header.Get("ETag")})
+
+	if n%s3PartsPerSize == 0 {
+		w.partSize *= 2
+	}
+	return nil
+}
@@ -0,0 +1,456 @@
+package backup_util
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Ensure a file smaller than a part is uploaded in one request and can be
+// read back.
+func TestS3Storage_Put(t *testing.T) {
+	srv := newS3Server()
+	defer srv.Close()
+	s := srv.storage()
+
+	w, err := s.Create("meta.00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("meta data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := srv.requests(), []string{"PUT /bucket/backups/meta.00"}; !equalStrings(got, exp) {
+		t.Fatalf("unexpected requests:\ngot=%v\nexp=%v", got, exp)
+	}
+	if got, exp := srv.readFile(t, s, "meta.00"), "meta data"; got != exp {
+		t.Fatalf("unexpected object: got=%q exp=%q", got, exp)
+	}
+
+	if ok, err := s.Exists("meta.00"); err != nil || !ok {
+		t.Fatalf("unexpected existence of meta.00: ok=%v err=%v", ok, err)
+	}
+	if ok, err := s.Exists("meta.01"); err != nil || ok {
+		t.Fatalf("unexpected existence of meta.01: ok=%v err=%v", ok, err)
+	}
+}
+
+// Ensure a file larger than a part is uploaded in a multipart upload
+// completed when the writer is closed.
+func TestS3Storage_Multipart(t *testing.T) {
+	srv := newS3Server()
+	defer srv.Close()
+	s := srv.storage()
+
+	w, _ := s.Create("db0.autogen.00001.00")
+	w.(*s3FileWriter).partSize = 4
+	for _, p := range []string{"abcdef", "ghij", "k"} {
+		if _, err := w.Write([]byte(p)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, exp := srv.requests(), []string{
+		"POST /bucket/backups/db0.autogen.00001.00?uploads=",
+		"PUT /bucket/backups/db0.autogen.00001.00?partNumber=1&uploadId=upload-1",
+		"PUT /bucket/backups/db0.autogen.00001.00?partNumber=2&uploadId=upload-1",
+		"PUT /bucket/backups/db0.autogen.00001.00?partNumber=3&uploadId=upload-1",
+		"POST /bucket/backups/db0.autogen.00001.00?uploadId=upload-1",
+	}; !equalStrings(got, exp) {
+		t.Fatalf("unexpected requests:\ngot=%v\nexp=%v", got, exp)
+	}
+	if got, exp := srv.readFile(t, s, "db0.autogen.00001.00"), "abcdefghijk"; got != exp {
+		t.Fatalf("unexpected object: got=%q exp=%q", got, exp)
+	}
+	if n := srv.uploadN(); n != 0 {
+		t.Fatalf("unexpected pending uploads: %d", n)
+	}
+}
+
+// Ensure an aborted multipart upload discards its parts.
+func TestS3Storage_Abort(t *testing.T) {
+	srv := newS3Server()
+	defer srv.Close()
+	s := srv.storage()
+
+	w, _ := s.Create("db0.autogen.00001.00")
+	w.(*s3FileWriter).partSize = 4
+	if _, err := w.Write([]byte("abcdefgh")); err != nil {
+		t.Fatal(err)
+	}
+	if n := srv.uploadN(); n != 1 {
+		t.Fatalf("unexpected pending uploads: %d", n)
+	}
+	if err := w.Abort(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := srv.uploadN(); n != 0 {
+		t.Fatalf("unexpected pending uploads: %d", n)
+	}
+	if ok, err := s.Exists("db0.autogen.00001.00"); err != nil || ok {
+		t.Fatalf("unexpected existence: ok=%v err=%v", ok, err)
+	}
+}
+
+// Ensure the objects are listed across all the pages of the listing, sorted
+// and without those of nested prefixes.
+func TestS3Storage_List(t *testing.T) {
+	srv := newS3Server()
+	defer srv.Close()
+	srv.pageSize = 2
+	s := srv.storage()
+
+	for _, key := range []string{
+		"backups/20211010T121500Z.manifest",
+		"backups/20211010T120000Z.manifest",
+		"backups/meta.00",
+		"backups/20211010T123000Z.manifest",
+		"backups/old/20211009T120000Z.manifest",
+		"other/20211010T120000Z.manifest",
+		"backups/db0.autogen.00001.00",
+	} {
+		srv.objects[key] = []byte("x")
+	}
+
+	names, err := s.List(ManifestSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{
+		"20211010T120000Z.manifest",
+		"20211010T121500Z.manifest",
+		"20211010T123000Z.manifest",
+	}; !equalStrings(names, exp) {
+		t.Fatalf("unexpected names:\ngot=%v\nexp=%v", names, exp)
+	}
+	if n := len(srv.requests()); n != 3 {
+		t.Fatalf("unexpected list requests: %d", n)
+	}
+}
+
+// Ensure the requests failing with a server error are retried and those
+// failing with a client error are not.
+func TestS3Storage_Retry(t *testing.T) {
+	srv := newS3Server()
+	defer srv.Close()
+	srv.failures = 1
+	s := srv.storage()
+
+	w, _ := s.Create("meta.00")
+	w.Write([]byte("meta data"))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if got, exp := srv.requests(), []string{
+		"PUT /bucket/backups/meta.00",
+		"PUT /bucket/backups/meta.00",
+	}; !equalStrings(got, exp) {
+		t.Fatalf("unexpected requests:\ngot=%v\nexp=%v", got, exp)
+	}
+
+	srv.reset()
+	_, err := s.Open("meta.01")
+	if e, ok := err.(*S3Error); !ok || e.StatusCode != http.StatusNotFound || e.Code != "NoSuchKey" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if n := len(srv.requests()); n != 1 {
+		t.Fatalf("unexpected requests: %d", n)
+	}
+}
+
+// Ensure a multipart upload whose completion fails after a 200 OK status is
+// reported as failed and aborted.
+func TestS3Storage_CompleteError(t *testing.T) {
+	srv := newS3Server()
+	defer srv.Close()
+	srv.completeError = true
+	s := srv.storage()
+
+	w, _ := s.Create("db0.autogen.00001.00")
+	w.(*s3FileWriter).partSize = 4
+	w.Write([]byte("abcdefgh"))
+	err := w.Close()
+	if e, ok := err.(*S3Error); !ok || e.Code != "InternalError" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if n := srv.uploadN(); n != 0 {
+		t.Fatalf("unexpected pending uploads: %d", n)
+	}
+	if ok, err := s.Exists("db0.autogen.00001.00"); err != nil || ok {
+		t.Fatalf("unexpected existence: ok=%v err=%v", ok, err)
+	}
+}
+
+// s3Server is a stand-in for an S3-compatible object storage serving a
+// single bucket.
+type s3Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+	nextID  int
+	log     []string
+
+	// pageSize is the number of objects per page of a listing, failures the
+	// number of requests to fail with a server error and completeError
+	// whether the completions fail after a 200 OK status.
+	pageSize      int
+	failures      int
+	completeError bool
+}
+
+func newS3Server() *s3Server {
+	srv := &s3Server{
+		objects:  make(map[string][]byte),
+		uploads:  make(map[string]map[int][]byte),
+		pageSize: 1000,
+	}
+	srv.Server = httptest.NewServer(http.HandlerFunc(srv.serveHTTP))
+	return srv
+}
+
+// storage returns a storage of the backups prefix of the bucket.
+func (srv *s3Server) storage() *S3Storage {
+	return &S3Storage{
+		Bucket:          "bucket",
+		Prefix:          "backups/",
+		Endpoint:        srv.URL,
+		Region:          "us-east-1",
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+		Client:          srv.Client(),
+	}
+}
+
+// requests returns the requests received since the last reset.
+func (srv *s3Server) requests() []string {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return append([]string(nil), srv.log...)
+}
+
+func (srv *s3Server) reset() {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	srv.log = nil
+}
+
+// uploadN returns the number of multipart uploads neither completed nor
+// aborted.
+func (srv *s3Server) uploadN() int {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	return len(srv.uploads)
+}
+
+// readFile reads a file of a storage without logging the requests.
+func (srv *s3Server) readFile(t *testing.T, s *S3Storage, name string) string {
+	t.Helper()
+	log := srv.requests()
+	defer func() {
+		srv.mu.Lock()
+		srv.log = log
+		srv.mu.Unlock()
+	}()
+
+	r, err := s.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func (srv *s3Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	line := r.Method + " " + r.URL.Path
+	if r.URL.RawQuery != "" && r.Method != "GET" {
+		line += "?" + r.URL.RawQuery
+	}
+	srv.log = append(srv.log, line)
+
+	if srv.failures > 0 {
+		srv.failures--
+		writeS3Error(w, http.StatusServiceUnavailable, "SlowDown")
+		return
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		writeS3Error(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	if sum := sha256.Sum256(body); r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+		writeS3Error(w, http.StatusBadRequest, "XAmzContentSHA256Mismatch")
+		return
+	}
+	if len(body) > 0 {
+		if sum := md5.Sum(body); r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			writeS3Error(w, http.StatusBadRequest, "BadDigest")
+			return
+		}
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	if r.URL.Path == "/bucket" {
+		key = ""
+	} else if key == r.URL.Path {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	q := r.URL.Query()
+	uploadID := q.Get("uploadId")
+
+	switch {
+	case key == "" && r.Method == "GET":
+		srv.list(w, q.Get("prefix"), q.Get("delimiter"), q.Get("continuation-token"))
+
+	case r.Method == "POST" && q["uploads"] != nil:
+		srv.nextID++
+		id := "upload-" + strconv.Itoa(srv.nextID)
+		srv.uploads[id] = make(map[int][]byte)
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == "PUT" && uploadID != "":
+		parts, ok := srv.uploads[uploadID]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		n, _ := strconv.Atoi(q.Get("partNumber"))
+		parts[n] = body
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, n))
+
+	case r.Method == "POST" && uploadID != "":
+		parts, ok := srv.uploads[uploadID]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		if srv.completeError {
+			fmt.Fprint(w, "<Error><Code>InternalError</Code><Message>We encountered an internal error.</Message></Error>")
+			return
+		}
+		var complete struct {
+			Parts []s3Part `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			writeS3Error(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var b []byte
+		for i, p := range complete.Parts {
+			if p.PartNumber != i+1 || p.ETag != fmt.Sprintf(`"etag-%d"`, p.PartNumber) {
+				writeS3Error(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			b = append(b, parts[p.PartNumber]...)
+		}
+		srv.objects[key] = b
+		delete(srv.uploads, uploadID)
+		fmt.Fprint(w, "<CompleteMultipartUploadResult></CompleteMultipartUploadResult>")
+
+	case r.Method == "DELETE" && uploadID != "":
+		delete(srv.uploads, uploadID)
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == "PUT":
+		srv.objects[key] = body
+
+	case r.Method == "GET" || r.Method == "HEAD":
+		b, ok := srv.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(b)))
+		if r.Method == "GET" {
+			w.Write(b)
+		}
+
+	default:
+		writeS3Error(w, http.StatusMethodNotAllowed, "MethodNotAllowed")
+	}
+}
+
+// list writes a page of a listing of the objects under a prefix, starting
+// at the key given as continuation token.
+func (srv *s3Server) list(w http.ResponseWriter, prefix, delimiter, token string) {
+	var keys []string
+	for key := range srv.objects {
+		if !strings.HasPrefix(key, prefix) || key < token {
+			continue
+		}
+		if delimiter != "" && strings.Contains(strings.TrimPrefix(key, prefix), delimiter) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var result struct {
+		XMLName  xml.Name `xml:"ListBucketResult"`
+		Contents []struct {
+			Key string
+		}
+		IsTruncated           bool
+		NextContinuationToken string `xml:",omitempty"`
+	}
+	if len(keys) > srv.pageSize {
+		result.IsTruncated = true
+		result.NextContinuationToken = keys[srv.pageSize]
+		keys = keys[:srv.pageSize]
+	}
+	for _, key := range keys {
+		result.Contents = append(result.Contents, struct{ Key string }{key})
+	}
+
+	var buf bytes.Buffer
+	xml.NewEncoder(&buf).Encode(result)
+	w.Write(buf.Bytes())
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, http.StatusText(status))
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
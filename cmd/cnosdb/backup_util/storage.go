@@ -0,0 +1,122 @@
+package backup_util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Storage stores the files of a backup, in a local directory or under a
+// prefix of an S3-compatible bucket.
+type Storage interface {
+	// Create returns a writer to a new file. The file is stored when the
+	// writer is closed, Abort discards it.
+	Create(name string) (FileWriter, error)
+
+	// Open opens a file for reading.
+	Open(name string) (io.ReadCloser, error)
+
+	// Exists returns whether a file exists.
+	Exists(name string) (bool, error)
+
+	// List returns the sorted names of the files ending with a suffix.
+	List(suffix string) ([]string, error)
+
+	// Path returns the location of a file, for logging.
+	Path(name string) string
+}
+
+// FileWriter writes a new file of a Storage.
+type FileWriter interface {
+	io.WriteCloser
+
+	// Abort discards the file.
+	Abort() error
+}
+
+// NewStorage returns the storage of a backup location: s3://bucket/prefix
+// or a local directory, which is created if missing.
+func NewStorage(location string) (Storage, error) {
+	if strings.HasPrefix(location, "s3://") {
+		return NewS3Storage(location)
+	}
+	if err := os.MkdirAll(location, 0700); err != nil {
+		return nil, err
+	}
+	return &LocalStorage{Dir: location}, nil
+}
+
+// LocalStorage stores the files of a backup in a local directory.
+type LocalStorage struct {
+	Dir string
+}
+
+// Create returns a writer to a new file, written to a pending file that is
+// renamed when the writer is closed.
+func (s *LocalStorage) Create(name string) (FileWriter, error) {
+	path := filepath.Join(s.Dir, name)
+	f, err := os.OpenFile(path+Suffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("open temp file: %s", err)
+	}
+	return &localFileWriter{File: f, path: path}, nil
+}
+
+// Open opens a file for reading.
+func (s *LocalStorage) Open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(s.Dir, name))
+}
+
+// Exists returns whether a file exists.
+func (s *LocalStorage) Exists(name string) (bool, error) {
+	_, err := os.Stat(filepath.Join(s.Dir, name))
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// List returns the sorted names of the files ending with a suffix.
+func (s *LocalStorage) List(suffix string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(s.Dir, "*"+suffix))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(paths))
+	for _, p := range paths {
+		names = append(names, filepath.Base(p))
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Path returns the path of a file.
+func (s *LocalStorage) Path(name string) string {
+	return filepath.Join(s.Dir, name)
+}
+
+type localFileWriter struct {
+	*os.File
+	path string
+}
+
+func (w *localFileWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		os.Remove(w.File.Name())
+		return err
+	}
+	if err := os.Rename(w.File.Name(), w.path); err != nil {
+		return fmt.Errorf("rename: %s", err)
+	}
+	return nil
+}
+
+func (w *localFileWriter) Abort() error {
+	w.File.Close()
+	return os.Remove(w.File.Name())
+}
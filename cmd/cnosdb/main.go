@@ -31,6 +31,7 @@ func init() {
 	if branch == "" {
 		branch = "unknown"
 	}
+	options.Env.Version = version
 }
 
 var cnosdb_examples = `  cnosdb
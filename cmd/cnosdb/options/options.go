@@ -7,6 +7,9 @@ type options struct {
 	PidFile    string
 	CpuProfile string
 	MemProfile string
+
+	// Version is the version of the build.
+	Version string
 }
 
 var Env = options{}
@@ -17,13 +17,16 @@ import (
 	"github.com/cnosdatabase/cnosdb/cmd/cnosdb/backup_util"
 	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/server/snapshotter"
+	"github.com/cnosdatabase/db/tsdb/engine/tsm1"
 	gzip "github.com/klauspost/pgzip"
 	"github.com/spf13/cobra"
 
 	tarstream "github.com/cnosdatabase/db/pkg/tar"
 )
 
-var restore_examples = `  cnosdb restore`
+var restore_examples = `  cnosdb restore
+  cnosdb restore --manifest /var/backups/cnosdb/20211010T121200Z.manifest
+  cnosdb restore --source s3://backups/cnosdb --manifest 20211010T121200Z.manifest`
 
 // options represents the program execution for "cnosdb restore".
 type options struct {
@@ -51,19 +54,33 @@ type options struct {
 	manifestMeta        *backup_util.MetaEntry
 	manifestFiles       map[uint64]*backup_util.Entry
 
+	// manifestPath is the manifest of the backup restored with the backups
+	// it is based on, in the storage of the backup.
+	manifestPath string
+	source       string
+	storage      backup_util.Storage
+
+	// key decrypts the files of encrypted backups.
+	keyFile        string
+	passphraseFile string
+	key            *backup_util.Key
+
 	// TODO: when the new meta stuff is done this should not be exported or be gone
 	MetaConfig *meta.Config
 
 	shardIDMap map[uint64]uint64
 }
 
-var env = options{}
+var env = options{
+	Stderr: os.Stderr,
+	Stdout: os.Stdout,
+}
 
 func GetCommand() *cobra.Command {
 	c := &cobra.Command{
 		Use:     "restore [flags] PATH",
 		Short:   "uses a snapshot of a data node to rebuild a cluster",
-		Long:    "Uses backup copies from the specified PATH to restore databases or specific shards from CnosDB to an CnosDB instance.",
+		Long:    "Uses backup copies from the specified PATH or object storage to restore databases or specific shards from CnosDB to an CnosDB instance.",
 		Example: restore_examples,
 		CompletionOptions: cobra.CompletionOptions{
 			DisableDefaultCmd:   true,
@@ -75,6 +92,33 @@ func GetCommand() *cobra.Command {
 			env.MetaConfig.Dir = env.metadir
 			env.client = snapshotter.NewClient(env.host)
 
+			var err error
+			env.key, err = backup_util.NewKey(env.keyFile, env.passphraseFile)
+			if err != nil {
+				return err
+			}
+
+			if env.manifestPath != "" || env.source != "" {
+				if env.metadir != "" || env.datadir != "" {
+					return fmt.Errorf("offline parameters metadir and datadir are not compatible with --manifest and --source")
+				}
+				if len(args) != 0 {
+					return fmt.Errorf("PATH is not compatible with --manifest and --source")
+				}
+				if env.restoreTimeToLive == "" {
+					env.restoreTimeToLive = env.backupTimeToLive
+				}
+
+				// Without source, the manifest is a local path.
+				if env.source != "" {
+					env.storage, err = backup_util.NewStorage(env.source)
+				} else {
+					env.storage = &backup_util.LocalStorage{Dir: filepath.Dir(env.manifestPath)}
+					env.manifestPath = filepath.Base(env.manifestPath)
+				}
+				return err
+			}
+
 			// Require output path.
 			if len(args) != 1 {
 				return errors.New("path with backup files required")
@@ -144,7 +188,9 @@ func GetCommand() *cobra.Command {
 			env.StdoutLogger = log.New(env.Stdout, "", log.LstdFlags)
 			env.StderrLogger = log.New(env.Stderr, "", log.LstdFlags)
 
-			if env.portable {
+			if env.storage != nil {
+				return env.runManifest()
+			} else if env.portable {
 				return env.runOnlinePortable()
 			} else if env.online {
 				return env.runOnlineLegacy()
@@ -165,6 +211,12 @@ func GetCommand() *cobra.Command {
 		" Requires that '-ttl <ttl_name>' is set. If not given, the '--ttl <ttl_name>' value is used.")
 	c.Flags().Uint64Var(&env.shard, "shard", 0, "Identifier of the shard to be restored. Optional. If specified, then '-db <db_name>' and '-0ttl <ttl_name>' are required.")
 	c.Flags().BoolVar(&env.online, "online", false, "")
+	c.Flags().StringVar(&env.manifestPath, "manifest", "", "Manifest of the backup to restore. Optional. The backups it is based on are restored first,"+
+		" from the directory of the manifest, or from '--source' where the manifest is a name. If specified, PATH is not required.")
+	c.Flags().StringVar(&env.source, "source", "", "Where the backup is stored: s3://bucket/prefix or a directory. Optional. If '--manifest' is not specified,"+
+		" the latest backup is restored. The S3 credentials, region and endpoint are read from the AWS_* environment variables.")
+	c.Flags().StringVar(&env.keyFile, "key-file", "", "File holding the key the backup was encrypted with. Optional.")
+	c.Flags().StringVar(&env.passphraseFile, "passphrase-file", "", "File holding the passphrase the backup was encrypted with. Optional.")
 
 	// Continue on flag errors.
 	c.SetFlagErrorFunc(func(command *cobra.Command, err error) error {
@@ -219,6 +271,209 @@ func (cmd *options) runOnlineLegacy() error {
 	return nil
 }
 
+// runManifest restores the backup of a manifest live. The meta data of the
+// backup is restored, then the shard files of the full backup the manifest
+// is based on and of each incremental backup up to the manifest, in order.
+// A full copy of a shard replaces the files of the shard in the backups
+// before it, and only the TSM files a shard had when it was last backed up
+// are restored, so that the files compacted away meanwhile do not come back.
+// The files are streamed from the backup storage.
+func (cmd *options) runManifest() error {
+	if cmd.manifestPath == "" {
+		name, err := backup_util.LatestManifest(cmd.storage)
+		if err != nil {
+			return err
+		}
+		if name == "" {
+			return fmt.Errorf("No manifest files found in: %s\n", cmd.storage.Path(""))
+		}
+		cmd.manifestPath = name
+	}
+
+	chain, err := backup_util.LoadChain(cmd.storage, cmd.manifestPath)
+	if err != nil {
+		return err
+	}
+	cmd.StdoutLogger.Printf("Restoring %d backup(s) up to %s", len(chain), cmd.storage.Path(cmd.manifestPath))
+
+	// Check every file before changing anything.
+	latest := chain[len(chain)-1]
+	if latest.Meta.FileName == "" {
+		return fmt.Errorf("no meta data in backup %s", cmd.storage.Path(cmd.manifestPath))
+	}
+	if err := backup_util.VerifyFile(cmd.storage, latest.Meta.FileName, latest.Meta.Checksum, latest.Meta.Digest); err != nil {
+		return err
+	}
+	for _, m := range chain {
+		if m.Encryption != "" && cmd.key == nil {
+			return backup_util.ErrKeyRequired
+		}
+		for _, file := range m.Files {
+			if !cmd.restoresShard(file) {
+				continue
+			}
+			if err := backup_util.VerifyFile(cmd.storage, file.FileName, file.Checksum, file.Digest); err != nil {
+				return err
+			}
+		}
+	}
+
+	r, err := cmd.openFile(latest.Meta.FileName, latest.Encryption != "")
+	if err != nil {
+		return err
+	}
+	b, err := ioutil.ReadAll(r)
+	r.Close()
+	if err != nil {
+		return err
+	}
+	metaBytes, err := backup_util.ParseMetaBytes(b)
+	if err != nil {
+		return err
+	}
+	req := &snapshotter.Request{
+		Type:              snapshotter.RequestMetaStoreUpdate,
+		BackupDatabase:    cmd.sourceDatabase,
+		RestoreDatabase:   cmd.destinationDatabase,
+		BackupTimeToLive:  cmd.backupTimeToLive,
+		RestoreTimeToLive: cmd.restoreTimeToLive,
+		UploadSize:        int64(len(metaBytes)),
+	}
+	cmd.shardIDMap, err = cmd.client.UpdateMeta(req, bytes.NewReader(metaBytes))
+	if err != nil {
+		cmd.StderrLogger.Printf("error updating meta: %v", err)
+		return err
+	}
+
+	// The restored shards are assigned to the current data nodes, so they
+	// are uploaded to each of their new owners.
+	data, err := cmd.client.MetastoreBackup()
+	if err != nil {
+		return err
+	}
+
+	latestFiles := backup_util.LatestEntries(chain)
+	fullCopies := make(map[uint64]int)
+	for i, m := range chain {
+		for _, file := range m.Files {
+			if file.Full {
+				fullCopies[file.ShardID] = i
+			}
+		}
+	}
+	for i, m := range chain {
+		for _, file := range m.Files {
+			if !cmd.restoresShard(file) || i < fullCopies[file.ShardID] {
+				continue
+			}
+			// if newID not found then the shard was dropped before the
+			// backup of the manifest and should be skipped
+			newID, ok := cmd.shardIDMap[file.ShardID]
+			if !ok {
+				continue
+			}
+			for _, host := range cmd.shardHosts(data, newID) {
+				cmd.StdoutLogger.Printf("Restoring shard %d live from backup %s to %s\n", file.ShardID, file.FileName, host)
+				if err := cmd.uploadShardFile(host, file.ShardID, newID, file.FileName, m.Encryption != "", liveFiles(latestFiles[file.ShardID])); err != nil {
+					cmd.StderrLogger.Printf("error updating shards: %v", err)
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// restoresShard returns whether the shard of a manifest entry is selected by
+// the database, time to live and shard options.
+func (cmd *options) restoresShard(file backup_util.Entry) bool {
+	return (cmd.sourceDatabase == "" || cmd.sourceDatabase == file.Database) &&
+		(cmd.backupTimeToLive == "" || cmd.backupTimeToLive == file.Policy) &&
+		(cmd.shard == 0 || cmd.shard == file.ShardID)
+}
+
+// shardHosts returns the TCP hosts of the data nodes owning a shard, or the
+// host restored to if the shard has no owner in a cluster.
+func (cmd *options) shardHosts(data *meta.Data, shardID uint64) []string {
+	var hosts []string
+	for _, id := range backup_util.ShardOwners(data, shardID) {
+		if n := data.DataNode(id); n != nil {
+			hosts = append(hosts, n.TCPHost)
+		}
+	}
+	if len(hosts) == 0 {
+		return []string{cmd.host}
+	}
+	return hosts
+}
+
+// liveFiles returns a filter of the files of the archives of a shard keeping
+// only the TSM files listed by its latest entry, or nil if it lists none.
+func liveFiles(latest backup_util.Entry) func(name string) bool {
+	if latest.TSMFiles == nil {
+		return nil
+	}
+	live := make(map[string]bool, len(latest.TSMFiles))
+	for _, name := range latest.TSMFiles {
+		live[name] = true
+	}
+	return func(name string) bool {
+		return !strings.HasSuffix(name, "."+tsm1.TSMFileExtension) || live[name]
+	}
+}
+
+// uploadShardFile uploads the files of a shard file of the backup storage
+// kept by keep, all of them if it is nil, to the shard newID on a host.
+func (cmd *options) uploadShardFile(host string, shardID, newID uint64, name string, encrypted bool, keep func(name string) bool) error {
+	r, err := cmd.openFile(name, encrypted)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	client := cmd.client
+	if host != cmd.host {
+		client = snapshotter.NewClient(host)
+	}
+
+	tr := tar.NewReader(r)
+	return client.UploadShardFiles(shardID, newID, cmd.destinationDatabase, cmd.restoreTimeToLive, tr, keep)
+}
+
+// openFile opens a file of the backup storage listed by a manifest,
+// decrypted if it is encrypted. The file must be encrypted if the backup is.
+func (cmd *options) openFile(name string, encrypted bool) (io.ReadCloser, error) {
+	f, err := cmd.storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return cmd.decryptFile(f, encrypted)
+}
+
+// decrypt returns a reader of an opened file of the backup, decrypted if the
+// file is encrypted. It closes the file on error.
+func (cmd *options) decrypt(f io.ReadCloser, err error) (io.ReadCloser, error) {
+	if err != nil {
+		return nil, err
+	}
+	return cmd.decryptFile(f, false)
+}
+
+// decryptFile returns a reader of an opened file of the backup, decrypted if
+// the file is encrypted, which it must be if required is set. It closes the
+// file on error.
+func (cmd *options) decryptFile(f io.ReadCloser, required bool) (io.ReadCloser, error) {
+	r, err := backup_util.NewDecryptReader(f, cmd.key, required)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{r, f}, nil
+}
+
 // unpackMeta reads the metadata from the backup directory and initializes a raft
 // cluster and replaces the root metadata.
 func (cmd *options) unpackMeta() error {
@@ -236,10 +491,11 @@ func (cmd *options) unpackMeta() error {
 
 	fmt.Fprintf(cmd.Stdout, "Using metastore snapshot: %v\n", latest)
 	// Read the metastore backup
-	f, err := os.Open(latest)
+	f, err := cmd.decrypt(os.Open(latest))
 	if err != nil {
 		return err
 	}
+	defer f.Close()
 
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, f); err != nil {
@@ -323,7 +579,12 @@ func (cmd *options) updateMetaPortable() error {
 	var metaBytes []byte
 	fileName := filepath.Join(cmd.backupFilesPath, cmd.manifestMeta.FileName)
 
-	fileBytes, err := ioutil.ReadFile(fileName)
+	f, err := cmd.decrypt(os.Open(fileName))
+	if err != nil {
+		return err
+	}
+	fileBytes, err := ioutil.ReadAll(f)
+	f.Close()
 	if err != nil {
 		return err
 	}
@@ -366,7 +627,7 @@ func (cmd *options) updateMetaLegacy() error {
 
 	fileName := metaFiles[len(metaFiles)-1]
 	cmd.StdoutLogger.Printf("Using metastore snapshot: %v\n", fileName)
-	metaBytes, err = backup_util.GetMetaBytes(fileName)
+	metaBytes, err = backup_util.GetMetaBytes(fileName, cmd.key)
 	if err != nil {
 		return err
 	}
@@ -399,9 +660,8 @@ func (cmd *options) uploadShardsPortable() error {
 						continue
 					}
 					cmd.StdoutLogger.Printf("Restoring shard %d live from backup %s\n", file.ShardID, file.FileName)
-					f, err := os.Open(filepath.Join(cmd.backupFilesPath, file.FileName))
+					f, err := cmd.decrypt(os.Open(filepath.Join(cmd.backupFilesPath, file.FileName)))
 					if err != nil {
-						f.Close()
 						return err
 					}
 					gr, err := gzip.NewReader(f)
@@ -458,7 +718,7 @@ func (cmd *options) uploadShardsLegacy() error {
 			cmd.StdoutLogger.Printf("Meta info not found for shard %d. Skipping shard file %s", shardID, fn)
 			continue
 		}
-		f, err := os.Open(fn)
+		f, err := cmd.decrypt(os.Open(fn))
 		if err != nil {
 			return err
 		}
@@ -545,7 +805,7 @@ func (cmd *options) unpackFiles(pat string) error {
 
 // unpackTar will restore a single tar archive to the data dir
 func (cmd *options) unpackTar(tarFile string) error {
-	f, err := os.Open(tarFile)
+	f, err := cmd.decrypt(os.Open(tarFile))
 	if err != nil {
 		return err
 	}
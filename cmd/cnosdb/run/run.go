@@ -47,6 +47,7 @@ func GetCommand() *cobra.Command {
 				Server: server.NewServer(config),
 				Logger: logger.BgLogger(),
 			}
+			d.Server.Version = options.Env.Version
 
 			if err := d.Server.Open(); err != nil {
 				fmt.Printf("open server: %s\n", err)
@@ -4,11 +4,13 @@ go 1.16
 
 require (
 	github.com/BurntSushi/toml v0.4.1
+	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516
 	github.com/cnosdatabase/cnosql v0.0.0
 	github.com/cnosdatabase/common v0.0.0
 	github.com/cnosdatabase/db v0.0.0
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
 	github.com/gogo/protobuf v1.3.2
+	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db
 	github.com/gorilla/mux v1.8.0
 	github.com/hashicorp/go-hclog v0.9.1
 	github.com/hashicorp/raft v1.3.1
@@ -23,6 +25,7 @@ require (
 	go.uber.org/zap v1.19.0
 	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 	golang.org/x/text v0.3.7
+	golang.org/x/xerrors v0.0.0-20220907171357-04be3eba64a2 // indirect
 	gopkg.in/fatih/pool.v2 v2.0.0
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
 )
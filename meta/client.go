@@ -61,6 +61,12 @@ type MetaClient interface {
 	DataNodeByHTTPHost(httpAddr string) (*NodeInfo, error)
 	DataNodeByTCPHost(tcpAddr string) (*NodeInfo, error)
 	DeleteDataNode(id uint64) error
+	SetDataNodeLeaving(id uint64, leaving bool) error
+	ReplaceDataNode(id uint64, httpAddr, tcpAddr string) (*NodeInfo, error)
+
+	Heartbeat(hb *Heartbeat) error
+	DataNodeStatuses() ([]DataNodeStatus, error)
+	DataNodeDown(id uint64) bool
 
 	MetaNodes() ([]NodeInfo, error)
 	MetaNodeByAddr(addr string) *NodeInfo
@@ -97,6 +103,7 @@ type MetaClient interface {
 	RegionsByTimeRange(database, ttl string, min, max time.Time) (a []RegionInfo, err error)
 	ShardsByTimeRange(sources cnosql.Sources, tmin, tmax time.Time) (a []ShardInfo, err error)
 	DropShard(id uint64) error
+	AddShardOwner(id, nodeID uint64) error
 	TruncateRegions(t time.Time) error
 	PruneRegions() error
 	CreateRegion(database, ttl string, timestamp time.Time) (*RegionInfo, error)
@@ -233,11 +240,19 @@ func (c *Client) CreateDataNode(httpAddr, tcpAddr string) (*NodeInfo, error) { r
 func (c *Client) DataNodeByHTTPHost(httpAddr string) (*NodeInfo, error)      { return nil, nil }
 func (c *Client) DataNodeByTCPHost(tcpAddr string) (*NodeInfo, error)        { return nil, nil }
 func (c *Client) DeleteDataNode(id uint64) error                             { return nil }
+func (c *Client) SetDataNodeLeaving(id uint64, leaving bool) error           { return nil }
+func (c *Client) Heartbeat(hb *Heartbeat) error                              { return nil }
+func (c *Client) DataNodeStatuses() ([]DataNodeStatus, error)                { return nil, nil }
+func (c *Client) DataNodeDown(id uint64) bool                                { return false }
 func (c *Client) MetaNodes() ([]NodeInfo, error)                             { return nil, nil }
 func (c *Client) MetaNodeByAddr(addr string) *NodeInfo                       { return nil }
 func (c *Client) CreateMetaNode(httpAddr, tcpAddr string) (*NodeInfo, error) { return nil, nil }
 func (c *Client) DeleteMetaNode(id uint64) error                             { return nil }
 
+func (c *Client) ReplaceDataNode(id uint64, httpAddr, tcpAddr string) (*NodeInfo, error) {
+	return nil, nil
+}
+
 // Database returns info for the requested database.
 func (c *Client) Database(name string) *DatabaseInfo {
 	c.mu.RLock()
@@ -783,6 +798,18 @@ func (c *Client) DropShard(id uint64) error {
 	return c.commit(data)
 }
 
+// AddShardOwner adds a data node to the owners of a shard.
+func (c *Client) AddShardOwner(id, nodeID uint64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	data := c.cacheData.Clone()
+	if err := data.AddShardOwner(id, nodeID); err != nil {
+		return err
+	}
+	return c.commit(data)
+}
+
 // TruncateRegions truncates any region that could contain timestamps beyond t.
 func (c *Client) TruncateRegions(t time.Time) error {
 	c.mu.Lock()
@@ -10,9 +10,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/BurntSushi/toml"
 	"github.com/cnosdatabase/cnosdb/pkg/logger"
+	itoml "github.com/cnosdatabase/common/pkg/toml"
 	"golang.org/x/text/encoding/unicode"
 	"golang.org/x/text/transform"
 )
@@ -20,6 +22,10 @@ import (
 const (
 	// DefaultLoggingEnabled determines if log messages are printed for the meta service.
 	DefaultLoggingEnabled = true
+
+	// DefaultHeartbeatInterval is the default interval of the heartbeats of
+	// a data node to the meta leader.
+	DefaultHeartbeatInterval = 2 * time.Second
 )
 
 // Config represents the meta configuration.
@@ -27,6 +33,8 @@ type Config struct {
 	Dir                  string `toml:"dir"`
 	TimeToLiveAutoCreate bool   `toml:"time-to-live-autocreate"`
 
+	HeartbeatInterval itoml.Duration `toml:"heartbeat-interval"`
+
 	HTTPD *ServerConfig
 	Log   *logger.Config
 }
@@ -35,6 +43,7 @@ type Config struct {
 func NewConfig() *Config {
 	return &Config{
 		TimeToLiveAutoCreate: true,
+		HeartbeatInterval:    itoml.Duration(DefaultHeartbeatInterval),
 	}
 }
 
@@ -192,6 +192,26 @@ func (data *Data) CreateDataNode(host, tcpHost string) error {
 	return nil
 }
 
+// ReplaceDataNode binds a data node to new addresses. The node keeps its ID
+// and the shards it owns, so that a failed node can be replaced.
+func (data *Data) ReplaceDataNode(id uint64, host, tcpHost string) error {
+	// Ensure another node with the same host doesn't already exist.
+	for _, n := range data.DataNodes {
+		if n.ID != id && n.TCPHost == tcpHost {
+			return ErrNodeExists
+		}
+	}
+
+	n := data.DataNode(id)
+	if n == nil {
+		return ErrNodeNotFound
+	}
+	n.Host = host
+	n.TCPHost = tcpHost
+	n.Leaving = false
+	return nil
+}
+
 // setDataNode adds a data node with a pre-specified nodeID.
 // this should only be used when the cluster is upgrading from 0.9 to 0.10
 func (data *Data) setDataNode(nodeID uint64, host, tcpHost string) error {
@@ -302,6 +322,43 @@ func (data *Data) DeleteDataNode(id uint64) error {
 	return nil
 }
 
+// SetDataNodeLeaving marks a data node as leaving the cluster, or not.
+func (data *Data) SetDataNodeLeaving(id uint64, leaving bool) error {
+	n := data.DataNode(id)
+	if n == nil {
+		return ErrNodeNotFound
+	}
+	n.Leaving = leaving
+	return nil
+}
+
+// AddShardOwner adds a data node to the owners of a shard. It does nothing
+// if the node already owns the shard.
+func (data *Data) AddShardOwner(id, nodeID uint64) error {
+	if data.DataNode(nodeID) == nil {
+		return ErrNodeNotFound
+	}
+
+	for di := range data.Databases {
+		for ti := range data.Databases[di].TimeToLives {
+			ttli := &data.Databases[di].TimeToLives[ti]
+			for ri := range ttli.Regions {
+				for si := range ttli.Regions[ri].Shards {
+					sh := &ttli.Regions[ri].Shards[si]
+					if sh.ID != id {
+						continue
+					}
+					if !sh.OwnedBy(nodeID) {
+						sh.Owners = append(sh.Owners, ShardOwner{NodeID: nodeID})
+					}
+					return nil
+				}
+			}
+		}
+	}
+	return ErrShardNotFound
+}
+
 // newShardOwner sets the owner of the provided shard to the data node
 // that currently owns the fewest number of shards. If multiple nodes
 // own the same (fewest) number of shards, then one of those nodes
@@ -671,13 +728,46 @@ func (data *Data) CreateRegionDeprecated(database, ttl string, timestamp time.Ti
 	return nil
 }
 
+// ownerDataNodes returns the data nodes new shards are assigned to. Shards
+// are not assigned to the nodes leaving the cluster, unless all of them are
+// leaving.
+func (data *Data) ownerDataNodes() []NodeInfo {
+	var dataNodes []NodeInfo
+	for _, n := range data.DataNodes {
+		if !n.Leaving {
+			dataNodes = append(dataNodes, n)
+		}
+	}
+	if len(dataNodes) == 0 {
+		dataNodes = data.DataNodes
+	}
+	return dataNodes
+}
+
+// assignShardOwners assigns replicaN data nodes to each shard via round
+// robin, starting from the node at nodeIndex, and returns the index of the
+// next node. Without data nodes, the shards are owned by the single node.
+func assignShardOwners(shards []ShardInfo, dataNodes []NodeInfo, replicaN, nodeIndex int) int {
+	for i := range shards {
+		si := &shards[i]
+		for j := 0; j < replicaN; j++ {
+			var nodeID uint64
+			if len(dataNodes) > 0 {
+				nodeID = dataNodes[nodeIndex%len(dataNodes)].ID
+				nodeIndex++
+			}
+			si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
+		}
+	}
+	return nodeIndex
+}
+
 // CreateRegion creates a region on a database and time-to-live for a given timestamp.
 func (data *Data) CreateRegion(database, ttl string, timestamp time.Time) error {
-	singleMode := false
-	dataNodeCount := len(data.DataNodes)
+	dataNodes := data.ownerDataNodes()
+	dataNodeCount := len(dataNodes)
 	if dataNodeCount == 0 {
 		dataNodeCount = 1
-		singleMode = true
 	}
 
 	// Find time-to-live.
@@ -729,26 +819,8 @@ func (data *Data) CreateRegion(database, ttl string, timestamp time.Time) error
 		sgi.Shards[i] = ShardInfo{ID: data.MaxShardID}
 	}
 
-	if singleMode {
-		for i := range sgi.Shards {
-			si := &sgi.Shards[i]
-			for j := 0; j < replicaN; j++ {
-				si.Owners = append(si.Owners, ShardOwner{NodeID: 0})
-			}
-		}
-	} else {
-		// Assign data nodes to shards via round robin.
-		// Start from a repeatably "random" place in the node list.
-		nodeIndex := int(data.Index % uint64(dataNodeCount))
-		for i := range sgi.Shards {
-			si := &sgi.Shards[i]
-			for j := 0; j < replicaN; j++ {
-				nodeID := data.DataNodes[nodeIndex%dataNodeCount].ID
-				si.Owners = append(si.Owners, ShardOwner{NodeID: nodeID})
-				nodeIndex++
-			}
-		}
-	}
+	// Start from a repeatably "random" place in the node list.
+	assignShardOwners(sgi.Shards, dataNodes, replicaN, int(data.Index%uint64(dataNodeCount)))
 
 	// Time-to-live has a new region, so update the time-to-live. Regions
 	// must be stored in sorted order, as other parts of the system
@@ -1152,6 +1224,228 @@ func (data *Data) UnmarshalBinary(buf []byte) error {
 	return nil
 }
 
+// marshalDelta serializes the parts of data changed as described by c.
+func (data *Data) marshalDelta(c *dataChange) *internal.DataDelta {
+	pb := &internal.DataDelta{
+		Term:      proto.Uint64(data.Term),
+		Index:     proto.Uint64(data.Index),
+		ClusterID: proto.Uint64(data.ClusterID),
+
+		MaxNodeID:   proto.Uint64(data.MaxNodeID),
+		MaxRegionID: proto.Uint64(data.MaxRegionID),
+		MaxShardID:  proto.Uint64(data.MaxShardID),
+	}
+
+	if c.nodes {
+		pb.NodesChanged = proto.Bool(true)
+
+		pb.DataNodes = make([]*internal.NodeInfo, len(data.DataNodes))
+		for i := range data.DataNodes {
+			pb.DataNodes[i] = data.DataNodes[i].marshal()
+		}
+
+		pb.MetaNodes = make([]*internal.NodeInfo, len(data.MetaNodes))
+		for i := range data.MetaNodes {
+			pb.MetaNodes[i] = data.MetaNodes[i].marshal()
+		}
+	}
+
+	if c.users {
+		pb.UsersChanged = proto.Bool(true)
+
+		pb.Users = make([]*internal.UserInfo, len(data.Users))
+		for i := range data.Users {
+			pb.Users[i] = data.Users[i].marshal()
+		}
+	}
+
+	pb.DatabaseNames = make([]string, len(data.Databases))
+	for i := range data.Databases {
+		di := &data.Databases[i]
+		pb.DatabaseNames[i] = di.Name
+		if _, ok := c.databases[di.Name]; ok {
+			pb.Databases = append(pb.Databases, di.marshal())
+		} else if c.timeToLives[di.Name] != nil || c.regions[di.Name] != nil {
+			pb.DatabaseDeltas = append(pb.DatabaseDeltas, di.marshalDelta(c.timeToLives[di.Name], c.regions[di.Name]))
+		}
+	}
+
+	return pb
+}
+
+// marshalDelta serializes the time to lives of the database named in ttls and
+// the regions with the IDs in regions, keyed by time to live.
+func (di *DatabaseInfo) marshalDelta(ttls map[string]struct{}, regions map[string]map[uint64]struct{}) *internal.DatabaseDelta {
+	pb := &internal.DatabaseDelta{
+		Name:            proto.String(di.Name),
+		TimeToLiveNames: make([]string, len(di.TimeToLives)),
+	}
+	for i := range di.TimeToLives {
+		ttli := &di.TimeToLives[i]
+		pb.TimeToLiveNames[i] = ttli.Name
+		if _, ok := ttls[ttli.Name]; ok {
+			pb.TimeToLives = append(pb.TimeToLives, ttli.marshal())
+		} else if ids, ok := regions[ttli.Name]; ok {
+			pb.TimeToLiveDeltas = append(pb.TimeToLiveDeltas, ttli.marshalDelta(ids))
+		}
+	}
+	return pb
+}
+
+// marshalDelta serializes the regions of the time to live with the IDs in ids.
+func (ttli *TimeToLiveInfo) marshalDelta(ids map[uint64]struct{}) *internal.TimeToLiveDelta {
+	pb := &internal.TimeToLiveDelta{
+		Name:      proto.String(ttli.Name),
+		RegionIDs: make([]uint64, len(ttli.Regions)),
+	}
+	for i := range ttli.Regions {
+		rgi := &ttli.Regions[i]
+		pb.RegionIDs[i] = rgi.ID
+		if _, ok := ids[rgi.ID]; ok {
+			pb.Regions = append(pb.Regions, rgi.marshal())
+		}
+	}
+	return pb
+}
+
+// applyDelta returns a copy of data updated with a delta serialized by
+// marshalDelta. The parts that did not change are shared with data.
+func (data *Data) applyDelta(pb *internal.DataDelta) (*Data, error) {
+	other := *data
+	other.Term = pb.GetTerm()
+	other.Index = pb.GetIndex()
+	other.ClusterID = pb.GetClusterID()
+
+	other.MaxNodeID = pb.GetMaxNodeID()
+	other.MaxRegionID = pb.GetMaxRegionID()
+	other.MaxShardID = pb.GetMaxShardID()
+
+	if pb.GetNodesChanged() {
+		other.DataNodes = make([]NodeInfo, len(pb.GetDataNodes()))
+		for i, x := range pb.GetDataNodes() {
+			other.DataNodes[i].unmarshal(x)
+		}
+
+		other.MetaNodes = make([]NodeInfo, len(pb.GetMetaNodes()))
+		for i, x := range pb.GetMetaNodes() {
+			other.MetaNodes[i].unmarshal(x)
+		}
+	}
+
+	if pb.GetUsersChanged() {
+		other.Users = make([]UserInfo, len(pb.GetUsers()))
+		for i, x := range pb.GetUsers() {
+			other.Users[i].unmarshal(x)
+		}
+		other.adminUserExists = other.hasAdminUser()
+	}
+
+	changed := make(map[string]*internal.DatabaseInfo, len(pb.GetDatabases()))
+	for _, x := range pb.GetDatabases() {
+		changed[x.GetName()] = x
+	}
+	deltas := make(map[string]*internal.DatabaseDelta, len(pb.GetDatabaseDeltas()))
+	for _, x := range pb.GetDatabaseDeltas() {
+		deltas[x.GetName()] = x
+	}
+	current := make(map[string]int, len(data.Databases))
+	for i := range data.Databases {
+		current[data.Databases[i].Name] = i
+	}
+
+	other.Databases = make([]DatabaseInfo, len(pb.GetDatabaseNames()))
+	for i, name := range pb.GetDatabaseNames() {
+		if x, ok := changed[name]; ok {
+			other.Databases[i].unmarshal(x)
+			continue
+		}
+
+		j, ok := current[name]
+		if !ok {
+			return nil, fmt.Errorf("delta is missing database %q", name)
+		}
+		if x, ok := deltas[name]; ok {
+			di, err := data.Databases[j].applyDelta(x)
+			if err != nil {
+				return nil, err
+			}
+			other.Databases[i] = *di
+		} else {
+			other.Databases[i] = data.Databases[j]
+		}
+	}
+
+	return &other, nil
+}
+
+// applyDelta returns a copy of the database with the time to lives updated
+// from a delta serialized by marshalDelta.
+func (di DatabaseInfo) applyDelta(pb *internal.DatabaseDelta) (*DatabaseInfo, error) {
+	changed := make(map[string]*internal.TimeToLiveInfo, len(pb.GetTimeToLives()))
+	for _, x := range pb.GetTimeToLives() {
+		changed[x.GetName()] = x
+	}
+	current := make(map[string]int, len(di.TimeToLives))
+	for i := range di.TimeToLives {
+		current[di.TimeToLives[i].Name] = i
+	}
+
+	deltas := make(map[string]*internal.TimeToLiveDelta, len(pb.GetTimeToLiveDeltas()))
+	for _, x := range pb.GetTimeToLiveDeltas() {
+		deltas[x.GetName()] = x
+	}
+
+	ttls := make([]TimeToLiveInfo, len(pb.GetTimeToLiveNames()))
+	for i, name := range pb.GetTimeToLiveNames() {
+		if x, ok := changed[name]; ok {
+			ttls[i].unmarshal(x)
+			continue
+		}
+
+		j, ok := current[name]
+		if !ok {
+			return nil, fmt.Errorf("delta is missing time to live %q of database %q", name, di.Name)
+		}
+		if x, ok := deltas[name]; ok {
+			ttli, err := di.TimeToLives[j].applyDelta(x)
+			if err != nil {
+				return nil, fmt.Errorf("%s of database %q", err, di.Name)
+			}
+			ttls[i] = *ttli
+		} else {
+			ttls[i] = di.TimeToLives[j]
+		}
+	}
+	di.TimeToLives = ttls
+	return &di, nil
+}
+
+// applyDelta returns a copy of the time to live with the regions updated from
+// a delta serialized by marshalDelta.
+func (ttli TimeToLiveInfo) applyDelta(pb *internal.TimeToLiveDelta) (*TimeToLiveInfo, error) {
+	changed := make(map[uint64]*internal.RegionInfo, len(pb.GetRegions()))
+	for _, x := range pb.GetRegions() {
+		changed[x.GetID()] = x
+	}
+	current := make(map[uint64]int, len(ttli.Regions))
+	for i := range ttli.Regions {
+		current[ttli.Regions[i].ID] = i
+	}
+
+	regions := make([]RegionInfo, len(pb.GetRegionIDs()))
+	for i, id := range pb.GetRegionIDs() {
+		if x, ok := changed[id]; ok {
+			regions[i].unmarshal(x)
+		} else if j, ok := current[id]; ok {
+			regions[i] = ttli.Regions[j]
+		} else {
+			return nil, fmt.Errorf("delta is missing region %d of time to live %q", id, ttli.Name)
+		}
+	}
+	ttli.Regions = regions
+	return &ttli, nil
+}
+
 // TruncateRegions truncates any region that could contain timestamps beyond t.
 func (data *Data) TruncateRegions(t time.Time) {
 	for i := range data.Databases {
@@ -1270,8 +1564,25 @@ func (data *Data) importOneDB(other Data, backupDBName, restoreDBName, backupTTL
 
 	}
 
+	// The shards are distributed over the current data nodes, according to
+	// the current replication factor of their time to live, as the nodes
+	// that owned them may not be in this cluster.
+	dataNodes := data.ownerDataNodes()
+	dataNodeCount := len(dataNodes)
+	if dataNodeCount == 0 {
+		dataNodeCount = 1
+	}
+	nodeIndex := int(data.Index % uint64(dataNodeCount))
+
 	// renumber the regions and shards for the new time to live(ies)
 	for _, ttlImport := range dbImport.TimeToLives {
+		replicaN := ttlImport.ReplicaN
+		if replicaN == 0 {
+			replicaN = 1
+		} else if replicaN > dataNodeCount {
+			replicaN = dataNodeCount
+		}
+
 		for j, sgImport := range ttlImport.Regions {
 			data.MaxRegionID++
 			ttlImport.Regions[j].ID = data.MaxRegionID
@@ -1279,10 +1590,9 @@ func (data *Data) importOneDB(other Data, backupDBName, restoreDBName, backupTTL
 				data.MaxShardID++
 				shardIDMap[sgImport.Shards[k].ID] = data.MaxShardID
 				sgImport.Shards[k].ID = data.MaxShardID
-				// OSS doesn't use Owners but if we are importing this from Enterprise, we'll want to clear it out
-				// to avoid any issues if they ever export this DB again to bring back to Enterprise.
-				sgImport.Shards[k].Owners = []ShardOwner{}
+				sgImport.Shards[k].Owners = nil
 			}
+			nodeIndex = assignShardOwners(sgImport.Shards, dataNodes, replicaN, nodeIndex)
 		}
 	}
 
@@ -1294,6 +1604,10 @@ type NodeInfo struct {
 	ID      uint64
 	Host    string
 	TCPHost string
+
+	// Leaving is set on a data node being decommissioned. No new shards
+	// are assigned to it.
+	Leaving bool
 }
 
 // NodeInfos is a slice of NodeInfo used for sorting
@@ -1317,6 +1631,9 @@ func (n NodeInfo) marshal() *internal.NodeInfo {
 	pb.ID = proto.Uint64(n.ID)
 	pb.Host = proto.String(n.Host)
 	pb.TCPHost = proto.String(n.TCPHost)
+	if n.Leaving {
+		pb.Leaving = proto.Bool(true)
+	}
 	return pb
 }
 
@@ -1325,6 +1642,7 @@ func (n *NodeInfo) unmarshal(pb *internal.NodeInfo) {
 	n.ID = pb.GetID()
 	n.Host = pb.GetHost()
 	n.TCPHost = pb.GetTCPHost()
+	n.Leaving = pb.GetLeaving()
 }
 
 // DatabaseInfo represents information about a database in the system.
@@ -2140,6 +2458,134 @@ func (leases *Leases) Acquire(name string, nodeID uint64) (*Lease, error) {
 	return l, nil
 }
 
+// Liveness states of a data node.
+const (
+	NodeStateUp      = "up"
+	NodeStateSuspect = "suspect"
+	NodeStateDown    = "down"
+)
+
+// Heartbeat is sent periodically by a data node to the meta leader.
+type Heartbeat struct {
+	NodeID    uint64 `json:"nodeID"`
+	Version   string `json:"version"`
+	DiskBytes int64  `json:"diskBytes"`
+
+	// Decommission is set while the node is leaving the cluster.
+	Decommission *DecommissionProgress `json:"decommission,omitempty"`
+
+	// HintedHandoff holds the size of the hinted handoff queues of the node
+	// that are not empty, keyed by the node they are queued for.
+	HintedHandoff map[uint64]int64 `json:"hintedHandoff,omitempty"`
+}
+
+// Phases of the decommission of a data node.
+const (
+	DecommissionCopyingShards = "copying shards"
+	DecommissionDrainingHH    = "draining hinted handoff"
+	DecommissionRemoving      = "removing"
+)
+
+// DecommissionProgress is the progress of a data node leaving the cluster.
+type DecommissionProgress struct {
+	Phase        string `json:"phase"`
+	ShardsTotal  int    `json:"shardsTotal"`
+	ShardsCopied int    `json:"shardsCopied"`
+	Error        string `json:"error,omitempty"`
+
+	// HintedHandoffNodes are the nodes still holding hinted handoff for the
+	// node while it is drained.
+	HintedHandoffNodes []uint64 `json:"hintedHandoffNodes,omitempty"`
+}
+
+// DataNodeStatus is the liveness state of a data node as seen by the meta
+// leader, with the content of its last heartbeat.
+type DataNodeStatus struct {
+	ID        uint64    `json:"id"`
+	State     string    `json:"state"`
+	LastSeen  time.Time `json:"lastSeen"`
+	Version   string    `json:"version"`
+	DiskBytes int64     `json:"diskBytes"`
+
+	Decommission  *DecommissionProgress `json:"decommission,omitempty"`
+	HintedHandoff map[uint64]int64      `json:"hintedHandoff,omitempty"`
+}
+
+// DataNodeHeartbeats is a concurrency-safe collection of the last heartbeats
+// of the data nodes, keyed by node ID.
+type DataNodeHeartbeats struct {
+	mu      sync.Mutex
+	m       map[uint64]*DataNodeStatus
+	since   time.Time
+	suspect time.Duration
+	down    time.Duration
+}
+
+// NewDataNodeHeartbeats returns a new instance of DataNodeHeartbeats. A node
+// is suspect when no heartbeat was received for the suspect duration and down
+// when none was received for the down duration.
+func NewDataNodeHeartbeats(suspect, down time.Duration) *DataNodeHeartbeats {
+	return &DataNodeHeartbeats{
+		m:       make(map[uint64]*DataNodeStatus),
+		since:   time.Now(),
+		suspect: suspect,
+		down:    down,
+	}
+}
+
+// Reset forgets the received heartbeats. It is called when the leadership
+// changes, as the heartbeats received by a previous leader are unknown.
+func (hbs *DataNodeHeartbeats) Reset() {
+	hbs.mu.Lock()
+	defer hbs.mu.Unlock()
+	hbs.m = make(map[uint64]*DataNodeStatus)
+	hbs.since = time.Now()
+}
+
+// Record records a heartbeat.
+func (hbs *DataNodeHeartbeats) Record(hb *Heartbeat) {
+	hbs.mu.Lock()
+	defer hbs.mu.Unlock()
+	hbs.m[hb.NodeID] = &DataNodeStatus{
+		ID:        hb.NodeID,
+		LastSeen:  time.Now(),
+		Version:   hb.Version,
+		DiskBytes: hb.DiskBytes,
+
+		Decommission:  hb.Decommission,
+		HintedHandoff: hb.HintedHandoff,
+	}
+}
+
+// Statuses returns the statuses of the nodes. A node that has not sent a
+// heartbeat since the last reset is considered to have sent one at the reset.
+func (hbs *DataNodeHeartbeats) Statuses(nodes []NodeInfo) []DataNodeStatus {
+	hbs.mu.Lock()
+	defer hbs.mu.Unlock()
+
+	now := time.Now()
+	statuses := make([]DataNodeStatus, 0, len(nodes))
+	for _, n := range nodes {
+		s := DataNodeStatus{ID: n.ID}
+		last := hbs.since
+		if hb := hbs.m[n.ID]; hb != nil {
+			s = *hb
+			last = hb.LastSeen
+		}
+
+		switch d := now.Sub(last); {
+		case d >= hbs.down:
+			s.State = NodeStateDown
+		case d >= hbs.suspect:
+			s.State = NodeStateSuspect
+		default:
+			s.State = NodeStateUp
+		}
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
 // MarshalTime converts t to nanoseconds since epoch. A zero time returns 0.
 func MarshalTime(t time.Time) int64 {
 	if t.IsZero() {
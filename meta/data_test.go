@@ -0,0 +1,178 @@
+package meta
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	internal "github.com/cnosdatabase/cnosdb/meta/internal"
+	"github.com/gogo/protobuf/proto"
+)
+
+// Ensure the delta of a command changing a region holds only that region,
+// and that applying it to the previous data gives the new data.
+func TestData_ApplyDelta_Region(t *testing.T) {
+	t0 := time.Unix(0, 0).UTC()
+
+	for _, tt := range []struct {
+		name  string
+		cmd   *internal.Command
+		apply func(data *Data) error
+		id    uint64
+	}{
+		{
+			name: "create region",
+			cmd: newTestCommand(internal.Command_CreateRegionCommand, internal.E_CreateRegionCommand_Command, &internal.CreateRegionCommand{
+				Database:   proto.String("db0"),
+				TimeToLive: proto.String("autogen"),
+				Timestamp:  proto.Int64(t0.Add(2 * time.Hour).UnixNano()),
+			}),
+			apply: func(data *Data) error { return data.CreateRegion("db0", "autogen", t0.Add(2*time.Hour)) },
+			id:    3,
+		},
+		{
+			name: "delete region",
+			cmd: newTestCommand(internal.Command_DeleteRegionCommand, internal.E_DeleteRegionCommand_Command, &internal.DeleteRegionCommand{
+				Database:   proto.String("db0"),
+				TimeToLive: proto.String("autogen"),
+				RegionID:   proto.Uint64(1),
+			}),
+			apply: func(data *Data) error { return data.DeleteRegion("db0", "autogen", 1) },
+			id:    1,
+		},
+	} {
+		prev := newTestData(t, t0)
+		data := prev.Clone()
+		if err := tt.apply(data); err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		data.Index++
+
+		c := commandChange(tt.cmd, data, data.Index)
+		buf, err := proto.Marshal(data.marshalDelta(&c))
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		var pb internal.DataDelta
+		if err := proto.Unmarshal(buf, &pb); err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+
+		if n := len(pb.GetDatabases()); n != 0 {
+			t.Fatalf("%s: unexpected databases: %d", tt.name, n)
+		} else if n := len(pb.GetDatabaseDeltas()); n != 1 {
+			t.Fatalf("%s: unexpected database deltas: %d", tt.name, n)
+		}
+		dd := pb.GetDatabaseDeltas()[0]
+		if n := len(dd.GetTimeToLives()); n != 0 {
+			t.Fatalf("%s: unexpected time to lives: %d", tt.name, n)
+		} else if n := len(dd.GetTimeToLiveDeltas()); n != 1 {
+			t.Fatalf("%s: unexpected time to live deltas: %d", tt.name, n)
+		}
+		regions := dd.GetTimeToLiveDeltas()[0].GetRegions()
+		if len(regions) != 1 || regions[0].GetID() != tt.id {
+			t.Fatalf("%s: unexpected regions: %v", tt.name, regions)
+		}
+
+		other, err := prev.applyDelta(&pb)
+		if err != nil {
+			t.Fatalf("%s: %s", tt.name, err)
+		}
+		if got, exp := mustMarshalData(t, other), mustMarshalData(t, data); !bytes.Equal(got, exp) {
+			t.Fatalf("%s: unexpected data:\ngot=%v\nexp=%v", tt.name, other, data)
+		}
+	}
+}
+
+// Ensure a delta naming a region unknown to the data is rejected.
+func TestData_ApplyDelta_MissingRegion(t *testing.T) {
+	data := newTestData(t, time.Unix(0, 0).UTC())
+	_, err := data.applyDelta(&internal.DataDelta{
+		DatabaseNames: []string{"db0"},
+		DatabaseDeltas: []*internal.DatabaseDelta{{
+			Name:            proto.String("db0"),
+			TimeToLiveNames: []string{"autogen"},
+			TimeToLiveDeltas: []*internal.TimeToLiveDelta{{
+				Name:      proto.String("autogen"),
+				RegionIDs: []uint64{1, 2, 3},
+			}},
+		}},
+	})
+	if exp := `delta is missing region 3 of time to live "autogen" of database "db0"`; err == nil || err.Error() != exp {
+		t.Fatalf("unexpected error: got=%v exp=%s", err, exp)
+	}
+}
+
+// Ensure the client gets a snapshot when the meta server does not know the
+// changes since its index or does not send deltas.
+func TestRemoteClient_GetUpdate_Snapshot(t *testing.T) {
+	snapshot := newTestData(t, time.Unix(0, 0).UTC())
+	snapshot.Index = 5
+
+	for _, code := range []int{http.StatusGone, http.StatusNotFound} {
+		var deltas, snapshots int
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/delta" {
+				deltas++
+				w.WriteHeader(code)
+				return
+			}
+			snapshots++
+			w.Write(mustMarshalData(t, snapshot))
+		}))
+
+		c := NewRemoteClient()
+		c.cacheData = &Data{Index: 3}
+		data, err := c.getUpdate(strings.TrimPrefix(srv.URL, "http://"), 3)
+		srv.Close()
+		if err != nil {
+			t.Fatalf("%d: %s", code, err)
+		} else if deltas != 1 || snapshots != 1 {
+			t.Fatalf("%d: unexpected requests: deltas=%d snapshots=%d", code, deltas, snapshots)
+		} else if data.Index != 5 || data.Database("db0") == nil {
+			t.Fatalf("%d: unexpected data: %v", code, data)
+		}
+	}
+}
+
+// newTestData returns data with a data node and the database db0 holding
+// two one hour regions from t0 in its autogen time to live.
+func newTestData(t *testing.T, t0 time.Time) *Data {
+	t.Helper()
+
+	data := &Data{Index: 1}
+	if err := data.CreateDataNode("localhost:8086", "localhost:8088"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateDatabase("db0"); err != nil {
+		t.Fatal(err)
+	} else if err := data.CreateTimeToLive("db0", &TimeToLiveInfo{Name: "autogen", ReplicaN: 1, RegionDuration: time.Hour}, true); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := data.CreateRegion("db0", "autogen", t0.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return data
+}
+
+// newTestCommand returns a command of type typ with the extension value.
+func newTestCommand(typ internal.Command_Type, desc *proto.ExtensionDesc, value interface{}) *internal.Command {
+	cmd := &internal.Command{Type: &typ}
+	if err := proto.SetExtension(cmd, desc, value); err != nil {
+		panic(err)
+	}
+	return cmd
+}
+
+func mustMarshalData(t *testing.T, data *Data) []byte {
+	t.Helper()
+	buf, err := data.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf
+}
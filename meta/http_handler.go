@@ -45,9 +45,11 @@ type Handler struct {
 	store          interface {
 		afterIndex(index uint64) <-chan struct{}
 		index() uint64
+		isLeader() bool
 		leader() string
 		leaderHTTP() string
 		snapshot() (*Data, error)
+		delta(index uint64) (*internal.DataDelta, bool)
 		apply(b []byte) error
 		joinCluster(peers []string) (*NodeInfo, error)
 		addMetaNode(n *NodeInfo) (*NodeInfo, error)
@@ -56,6 +58,8 @@ type Handler struct {
 		otherMetaServersHTTP() []string
 		peers() []string
 		getNode() *NodeInfo
+		heartbeat(hb *Heartbeat) []DataNodeStatus
+		dataNodeStatuses() []DataNodeStatus
 	}
 	s *Server
 
@@ -76,6 +80,10 @@ func NewHandler(conf *ServerConfig) *Handler {
 			"snapshot", http.MethodGet, "/", true, true,
 			h.serveSnapshot,
 		},
+		{
+			"delta", http.MethodGet, "/delta", true, true,
+			h.serveDelta,
+		},
 		{
 			"ping", http.MethodGet, "/ping", true, true,
 			h.servePing,
@@ -88,6 +96,14 @@ func NewHandler(conf *ServerConfig) *Handler {
 			"lease", http.MethodGet, "/lease", true, true,
 			h.serveLease,
 		},
+		{
+			"heartbeat", http.MethodPost, "/heartbeat", true, false,
+			h.serveHeartbeat,
+		},
+		{
+			"data-node-status", http.MethodGet, "/data-node-status", true, true,
+			h.serveDataNodeStatus,
+		},
 		{
 			"peers", http.MethodGet, "/peers", true, true,
 			h.servePeers,
@@ -173,6 +189,47 @@ func (h *Handler) serveSnapshot(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// serveDelta is a long polling http connection to send the changes of the
+// data since the index the client has. It returns StatusGone if the changes
+// are no longer known, the client must then get a snapshot.
+func (h *Handler) serveDelta(w http.ResponseWriter, r *http.Request) {
+	if h.isClosed() {
+		h.httpError(fmt.Errorf("server closed"), w, http.StatusInternalServerError)
+		return
+	}
+
+	// get the current index that client has
+	index, err := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+	if err != nil {
+		http.Error(w, "error parsing index", http.StatusBadRequest)
+		return
+	}
+
+	select {
+	case <-h.store.afterIndex(index):
+		// Send the changes to client.
+		delta, ok := h.store.delta(index)
+		if !ok {
+			http.Error(w, "changes since index are not known", http.StatusGone)
+			return
+		}
+		b, err := proto.Marshal(delta)
+		if err != nil {
+			h.httpError(err, w, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Add("Content-Type", "application/octet-stream")
+		w.Write(b)
+		return
+	case <-w.(http.CloseNotifier).CloseNotify():
+		// Client closed the connection so we're done.
+		return
+	case <-h.closing:
+		h.httpError(fmt.Errorf("server closed"), w, http.StatusInternalServerError)
+		return
+	}
+}
+
 // servePing will return if the server is up, or if specified will check the status
 // of the other meta-servers as well
 func (h *Handler) servePing(w http.ResponseWriter, r *http.Request) {
@@ -295,6 +352,63 @@ func (h *Handler) serveLease(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// serveHeartbeat records the heartbeat of a data node and returns the
+// statuses of all data nodes. Heartbeats are redirected to the leader.
+func (h *Handler) serveHeartbeat(w http.ResponseWriter, r *http.Request) {
+	if h.redirectToLeader(w, r) {
+		return
+	}
+
+	hb := &Heartbeat{}
+	if err := json.NewDecoder(r.Body).Decode(hb); err != nil {
+		h.httpError(err, w, http.StatusBadRequest)
+		return
+	} else if hb.NodeID == 0 {
+		http.Error(w, "node ID required", http.StatusBadRequest)
+		return
+	}
+
+	h.writeDataNodeStatuses(w, h.store.heartbeat(hb))
+}
+
+// serveDataNodeStatus returns the statuses of the data nodes as seen by the
+// leader.
+func (h *Handler) serveDataNodeStatus(w http.ResponseWriter, r *http.Request) {
+	if h.redirectToLeader(w, r) {
+		return
+	}
+	h.writeDataNodeStatuses(w, h.store.dataNodeStatuses())
+}
+
+func (h *Handler) writeDataNodeStatuses(w http.ResponseWriter, statuses []DataNodeStatus) {
+	w.Header().Add("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(statuses); err != nil {
+		h.httpError(err, w, http.StatusInternalServerError)
+	}
+}
+
+// redirectToLeader redirects the request to the leader if the store is not
+// the leader. It returns true if the request was answered.
+func (h *Handler) redirectToLeader(w http.ResponseWriter, r *http.Request) bool {
+	if h.store.isLeader() {
+		return false
+	}
+
+	l := h.store.leaderHTTP()
+	if l == "" {
+		// No cluster leader. Client will have to try again later.
+		h.httpError(errors.New("no leader"), w, http.StatusServiceUnavailable)
+		return true
+	}
+	scheme := "http://"
+	if h.config.HTTPSEnabled {
+		scheme = "https://"
+	}
+
+	http.Redirect(w, r, scheme+l+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	return true
+}
+
 func (h *Handler) servePeers(w http.ResponseWriter, r *http.Request) {
 	w.Header().Add("Content-Type", "application/json")
 	enc := json.NewEncoder(w)
@@ -1,5 +1,5 @@
 // Code generated by protoc-gen-gogo. DO NOT EDIT.
-// source: meta.proto
+// source: internal/meta.proto
 
 package meta
 
@@ -52,6 +52,9 @@ const (
 	Command_DeleteDataNodeCommand        Command_Type = 28
 	Command_SetMetaNodeCommand           Command_Type = 29
 	Command_DropShardCommand             Command_Type = 30
+	Command_SetDataNodeLeavingCommand    Command_Type = 31
+	Command_AddShardOwnerCommand         Command_Type = 32
+	Command_ReplaceDataNodeCommand       Command_Type = 33
 )
 
 var Command_Type_name = map[int32]string{
@@ -84,6 +87,9 @@ var Command_Type_name = map[int32]string{
 	28: "DeleteDataNodeCommand",
 	29: "SetMetaNodeCommand",
 	30: "DropShardCommand",
+	31: "SetDataNodeLeavingCommand",
+	32: "AddShardOwnerCommand",
+	33: "ReplaceDataNodeCommand",
 }
 
 var Command_Type_value = map[string]int32{
@@ -116,6 +122,9 @@ var Command_Type_value = map[string]int32{
 	"DeleteDataNodeCommand":        28,
 	"SetMetaNodeCommand":           29,
 	"DropShardCommand":             30,
+	"SetDataNodeLeavingCommand":    31,
+	"AddShardOwnerCommand":         32,
+	"ReplaceDataNodeCommand":       33,
 }
 
 func (x Command_Type) Enum() *Command_Type {
@@ -138,7 +147,7 @@ func (x *Command_Type) UnmarshalJSON(data []byte) error {
 }
 
 func (Command_Type) EnumDescriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{12, 0}
+	return fileDescriptor_59b0956366e72083, []int{15, 0}
 }
 
 type Data struct {
@@ -163,7 +172,7 @@ func (m *Data) Reset()         { *m = Data{} }
 func (m *Data) String() string { return proto.CompactTextString(m) }
 func (*Data) ProtoMessage()    {}
 func (*Data) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{0}
+	return fileDescriptor_59b0956366e72083, []int{0}
 }
 func (m *Data) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Data.Unmarshal(m, b)
@@ -260,10 +269,280 @@ func (m *Data) GetMetaNodes() []*NodeInfo {
 	return nil
 }
 
+// DataDelta is the change of the Data since an index. The nodes and users
+// are set only if they changed. DatabaseNames holds the names of all
+// databases, in order, Databases the databases that changed as a whole and
+// DatabaseDeltas the databases of which only some time to lives changed.
+type DataDelta struct {
+	Term                 *uint64          `protobuf:"varint,1,req,name=Term" json:"Term,omitempty"`
+	Index                *uint64          `protobuf:"varint,2,req,name=Index" json:"Index,omitempty"`
+	ClusterID            *uint64          `protobuf:"varint,3,req,name=ClusterID" json:"ClusterID,omitempty"`
+	MaxNodeID            *uint64          `protobuf:"varint,4,req,name=MaxNodeID" json:"MaxNodeID,omitempty"`
+	MaxRegionID          *uint64          `protobuf:"varint,5,req,name=MaxRegionID" json:"MaxRegionID,omitempty"`
+	MaxShardID           *uint64          `protobuf:"varint,6,req,name=MaxShardID" json:"MaxShardID,omitempty"`
+	NodesChanged         *bool            `protobuf:"varint,7,opt,name=NodesChanged" json:"NodesChanged,omitempty"`
+	DataNodes            []*NodeInfo      `protobuf:"bytes,8,rep,name=DataNodes" json:"DataNodes,omitempty"`
+	MetaNodes            []*NodeInfo      `protobuf:"bytes,9,rep,name=MetaNodes" json:"MetaNodes,omitempty"`
+	UsersChanged         *bool            `protobuf:"varint,10,opt,name=UsersChanged" json:"UsersChanged,omitempty"`
+	Users                []*UserInfo      `protobuf:"bytes,11,rep,name=Users" json:"Users,omitempty"`
+	DatabaseNames        []string         `protobuf:"bytes,12,rep,name=DatabaseNames" json:"DatabaseNames,omitempty"`
+	Databases            []*DatabaseInfo  `protobuf:"bytes,13,rep,name=Databases" json:"Databases,omitempty"`
+	DatabaseDeltas       []*DatabaseDelta `protobuf:"bytes,14,rep,name=DatabaseDeltas" json:"DatabaseDeltas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}         `json:"-"`
+	XXX_unrecognized     []byte           `json:"-"`
+	XXX_sizecache        int32            `json:"-"`
+}
+
+func (m *DataDelta) Reset()         { *m = DataDelta{} }
+func (m *DataDelta) String() string { return proto.CompactTextString(m) }
+func (*DataDelta) ProtoMessage()    {}
+func (*DataDelta) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{1}
+}
+func (m *DataDelta) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DataDelta.Unmarshal(m, b)
+}
+func (m *DataDelta) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DataDelta.Marshal(b, m, deterministic)
+}
+func (m *DataDelta) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DataDelta.Merge(m, src)
+}
+func (m *DataDelta) XXX_Size() int {
+	return xxx_messageInfo_DataDelta.Size(m)
+}
+func (m *DataDelta) XXX_DiscardUnknown() {
+	xxx_messageInfo_DataDelta.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DataDelta proto.InternalMessageInfo
+
+func (m *DataDelta) GetTerm() uint64 {
+	if m != nil && m.Term != nil {
+		return *m.Term
+	}
+	return 0
+}
+
+func (m *DataDelta) GetIndex() uint64 {
+	if m != nil && m.Index != nil {
+		return *m.Index
+	}
+	return 0
+}
+
+func (m *DataDelta) GetClusterID() uint64 {
+	if m != nil && m.ClusterID != nil {
+		return *m.ClusterID
+	}
+	return 0
+}
+
+func (m *DataDelta) GetMaxNodeID() uint64 {
+	if m != nil && m.MaxNodeID != nil {
+		return *m.MaxNodeID
+	}
+	return 0
+}
+
+func (m *DataDelta) GetMaxRegionID() uint64 {
+	if m != nil && m.MaxRegionID != nil {
+		return *m.MaxRegionID
+	}
+	return 0
+}
+
+func (m *DataDelta) GetMaxShardID() uint64 {
+	if m != nil && m.MaxShardID != nil {
+		return *m.MaxShardID
+	}
+	return 0
+}
+
+func (m *DataDelta) GetNodesChanged() bool {
+	if m != nil && m.NodesChanged != nil {
+		return *m.NodesChanged
+	}
+	return false
+}
+
+func (m *DataDelta) GetDataNodes() []*NodeInfo {
+	if m != nil {
+		return m.DataNodes
+	}
+	return nil
+}
+
+func (m *DataDelta) GetMetaNodes() []*NodeInfo {
+	if m != nil {
+		return m.MetaNodes
+	}
+	return nil
+}
+
+func (m *DataDelta) GetUsersChanged() bool {
+	if m != nil && m.UsersChanged != nil {
+		return *m.UsersChanged
+	}
+	return false
+}
+
+func (m *DataDelta) GetUsers() []*UserInfo {
+	if m != nil {
+		return m.Users
+	}
+	return nil
+}
+
+func (m *DataDelta) GetDatabaseNames() []string {
+	if m != nil {
+		return m.DatabaseNames
+	}
+	return nil
+}
+
+func (m *DataDelta) GetDatabases() []*DatabaseInfo {
+	if m != nil {
+		return m.Databases
+	}
+	return nil
+}
+
+func (m *DataDelta) GetDatabaseDeltas() []*DatabaseDelta {
+	if m != nil {
+		return m.DatabaseDeltas
+	}
+	return nil
+}
+
+// DatabaseDelta is the change of the time to lives of a database, such as the
+// creation of a subscription. TimeToLiveNames holds the names of all its time
+// to lives, in order, TimeToLives the time to lives that changed and
+// TimeToLiveDeltas the time to lives of which only regions changed.
+type DatabaseDelta struct {
+	Name                 *string            `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	TimeToLiveNames      []string           `protobuf:"bytes,2,rep,name=TimeToLiveNames" json:"TimeToLiveNames,omitempty"`
+	TimeToLives          []*TimeToLiveInfo  `protobuf:"bytes,3,rep,name=TimeToLives" json:"TimeToLives,omitempty"`
+	TimeToLiveDeltas     []*TimeToLiveDelta `protobuf:"bytes,4,rep,name=TimeToLiveDeltas" json:"TimeToLiveDeltas,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *DatabaseDelta) Reset()         { *m = DatabaseDelta{} }
+func (m *DatabaseDelta) String() string { return proto.CompactTextString(m) }
+func (*DatabaseDelta) ProtoMessage()    {}
+func (*DatabaseDelta) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{2}
+}
+func (m *DatabaseDelta) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DatabaseDelta.Unmarshal(m, b)
+}
+func (m *DatabaseDelta) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DatabaseDelta.Marshal(b, m, deterministic)
+}
+func (m *DatabaseDelta) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DatabaseDelta.Merge(m, src)
+}
+func (m *DatabaseDelta) XXX_Size() int {
+	return xxx_messageInfo_DatabaseDelta.Size(m)
+}
+func (m *DatabaseDelta) XXX_DiscardUnknown() {
+	xxx_messageInfo_DatabaseDelta.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DatabaseDelta proto.InternalMessageInfo
+
+func (m *DatabaseDelta) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *DatabaseDelta) GetTimeToLiveNames() []string {
+	if m != nil {
+		return m.TimeToLiveNames
+	}
+	return nil
+}
+
+func (m *DatabaseDelta) GetTimeToLives() []*TimeToLiveInfo {
+	if m != nil {
+		return m.TimeToLives
+	}
+	return nil
+}
+
+func (m *DatabaseDelta) GetTimeToLiveDeltas() []*TimeToLiveDelta {
+	if m != nil {
+		return m.TimeToLiveDeltas
+	}
+	return nil
+}
+
+// TimeToLiveDelta is the change of the regions of a time to live, such as the
+// creation of a region. RegionIDs holds the IDs of all its regions, in order,
+// and Regions the regions that changed.
+type TimeToLiveDelta struct {
+	Name                 *string       `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	RegionIDs            []uint64      `protobuf:"varint,2,rep,name=RegionIDs" json:"RegionIDs,omitempty"`
+	Regions              []*RegionInfo `protobuf:"bytes,3,rep,name=Regions" json:"Regions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *TimeToLiveDelta) Reset()         { *m = TimeToLiveDelta{} }
+func (m *TimeToLiveDelta) String() string { return proto.CompactTextString(m) }
+func (*TimeToLiveDelta) ProtoMessage()    {}
+func (*TimeToLiveDelta) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{3}
+}
+func (m *TimeToLiveDelta) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TimeToLiveDelta.Unmarshal(m, b)
+}
+func (m *TimeToLiveDelta) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TimeToLiveDelta.Marshal(b, m, deterministic)
+}
+func (m *TimeToLiveDelta) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TimeToLiveDelta.Merge(m, src)
+}
+func (m *TimeToLiveDelta) XXX_Size() int {
+	return xxx_messageInfo_TimeToLiveDelta.Size(m)
+}
+func (m *TimeToLiveDelta) XXX_DiscardUnknown() {
+	xxx_messageInfo_TimeToLiveDelta.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TimeToLiveDelta proto.InternalMessageInfo
+
+func (m *TimeToLiveDelta) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *TimeToLiveDelta) GetRegionIDs() []uint64 {
+	if m != nil {
+		return m.RegionIDs
+	}
+	return nil
+}
+
+func (m *TimeToLiveDelta) GetRegions() []*RegionInfo {
+	if m != nil {
+		return m.Regions
+	}
+	return nil
+}
+
 type NodeInfo struct {
 	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
 	Host                 *string  `protobuf:"bytes,2,req,name=Host" json:"Host,omitempty"`
 	TCPHost              *string  `protobuf:"bytes,3,opt,name=TCPHost" json:"TCPHost,omitempty"`
+	Leaving              *bool    `protobuf:"varint,4,opt,name=Leaving" json:"Leaving,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -273,7 +552,7 @@ func (m *NodeInfo) Reset()         { *m = NodeInfo{} }
 func (m *NodeInfo) String() string { return proto.CompactTextString(m) }
 func (*NodeInfo) ProtoMessage()    {}
 func (*NodeInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{1}
+	return fileDescriptor_59b0956366e72083, []int{4}
 }
 func (m *NodeInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_NodeInfo.Unmarshal(m, b)
@@ -314,6 +593,13 @@ func (m *NodeInfo) GetTCPHost() string {
 	return ""
 }
 
+func (m *NodeInfo) GetLeaving() bool {
+	if m != nil && m.Leaving != nil {
+		return *m.Leaving
+	}
+	return false
+}
+
 type DatabaseInfo struct {
 	Name                 *string                `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
 	DefaultTimeToLive    *string                `protobuf:"bytes,2,req,name=DefaultTimeToLive" json:"DefaultTimeToLive,omitempty"`
@@ -328,7 +614,7 @@ func (m *DatabaseInfo) Reset()         { *m = DatabaseInfo{} }
 func (m *DatabaseInfo) String() string { return proto.CompactTextString(m) }
 func (*DatabaseInfo) ProtoMessage()    {}
 func (*DatabaseInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{2}
+	return fileDescriptor_59b0956366e72083, []int{5}
 }
 func (m *DatabaseInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DatabaseInfo.Unmarshal(m, b)
@@ -390,7 +676,7 @@ func (m *TimeToLiveSpec) Reset()         { *m = TimeToLiveSpec{} }
 func (m *TimeToLiveSpec) String() string { return proto.CompactTextString(m) }
 func (*TimeToLiveSpec) ProtoMessage()    {}
 func (*TimeToLiveSpec) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{3}
+	return fileDescriptor_59b0956366e72083, []int{6}
 }
 func (m *TimeToLiveSpec) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TimeToLiveSpec.Unmarshal(m, b)
@@ -454,7 +740,7 @@ func (m *TimeToLiveInfo) Reset()         { *m = TimeToLiveInfo{} }
 func (m *TimeToLiveInfo) String() string { return proto.CompactTextString(m) }
 func (*TimeToLiveInfo) ProtoMessage()    {}
 func (*TimeToLiveInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{4}
+	return fileDescriptor_59b0956366e72083, []int{7}
 }
 func (m *TimeToLiveInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_TimeToLiveInfo.Unmarshal(m, b)
@@ -532,7 +818,7 @@ func (m *RegionInfo) Reset()         { *m = RegionInfo{} }
 func (m *RegionInfo) String() string { return proto.CompactTextString(m) }
 func (*RegionInfo) ProtoMessage()    {}
 func (*RegionInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{5}
+	return fileDescriptor_59b0956366e72083, []int{8}
 }
 func (m *RegionInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RegionInfo.Unmarshal(m, b)
@@ -607,7 +893,7 @@ func (m *ShardInfo) Reset()         { *m = ShardInfo{} }
 func (m *ShardInfo) String() string { return proto.CompactTextString(m) }
 func (*ShardInfo) ProtoMessage()    {}
 func (*ShardInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{6}
+	return fileDescriptor_59b0956366e72083, []int{9}
 }
 func (m *ShardInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardInfo.Unmarshal(m, b)
@@ -662,7 +948,7 @@ func (m *SubscriptionInfo) Reset()         { *m = SubscriptionInfo{} }
 func (m *SubscriptionInfo) String() string { return proto.CompactTextString(m) }
 func (*SubscriptionInfo) ProtoMessage()    {}
 func (*SubscriptionInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{7}
+	return fileDescriptor_59b0956366e72083, []int{10}
 }
 func (m *SubscriptionInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SubscriptionInfo.Unmarshal(m, b)
@@ -714,7 +1000,7 @@ func (m *ShardOwner) Reset()         { *m = ShardOwner{} }
 func (m *ShardOwner) String() string { return proto.CompactTextString(m) }
 func (*ShardOwner) ProtoMessage()    {}
 func (*ShardOwner) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{8}
+	return fileDescriptor_59b0956366e72083, []int{11}
 }
 func (m *ShardOwner) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ShardOwner.Unmarshal(m, b)
@@ -753,7 +1039,7 @@ func (m *ContinuousQueryInfo) Reset()         { *m = ContinuousQueryInfo{} }
 func (m *ContinuousQueryInfo) String() string { return proto.CompactTextString(m) }
 func (*ContinuousQueryInfo) ProtoMessage()    {}
 func (*ContinuousQueryInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{9}
+	return fileDescriptor_59b0956366e72083, []int{12}
 }
 func (m *ContinuousQueryInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_ContinuousQueryInfo.Unmarshal(m, b)
@@ -801,7 +1087,7 @@ func (m *UserInfo) Reset()         { *m = UserInfo{} }
 func (m *UserInfo) String() string { return proto.CompactTextString(m) }
 func (*UserInfo) ProtoMessage()    {}
 func (*UserInfo) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{10}
+	return fileDescriptor_59b0956366e72083, []int{13}
 }
 func (m *UserInfo) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UserInfo.Unmarshal(m, b)
@@ -861,7 +1147,7 @@ func (m *UserPrivilege) Reset()         { *m = UserPrivilege{} }
 func (m *UserPrivilege) String() string { return proto.CompactTextString(m) }
 func (*UserPrivilege) ProtoMessage()    {}
 func (*UserPrivilege) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{11}
+	return fileDescriptor_59b0956366e72083, []int{14}
 }
 func (m *UserPrivilege) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UserPrivilege.Unmarshal(m, b)
@@ -907,7 +1193,7 @@ func (m *Command) Reset()         { *m = Command{} }
 func (m *Command) String() string { return proto.CompactTextString(m) }
 func (*Command) ProtoMessage()    {}
 func (*Command) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{12}
+	return fileDescriptor_59b0956366e72083, []int{15}
 }
 
 var extRange_Command = []proto.ExtensionRange{
@@ -957,7 +1243,7 @@ func (m *CreateNodeCommand) Reset()         { *m = CreateNodeCommand{} }
 func (m *CreateNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateNodeCommand) ProtoMessage()    {}
 func (*CreateNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{13}
+	return fileDescriptor_59b0956366e72083, []int{16}
 }
 func (m *CreateNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateNodeCommand.Unmarshal(m, b)
@@ -997,7 +1283,7 @@ var E_CreateNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         101,
 	Name:          "meta.CreateNodeCommand.command",
 	Tag:           "bytes,101,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DeleteNodeCommand struct {
@@ -1012,7 +1298,7 @@ func (m *DeleteNodeCommand) Reset()         { *m = DeleteNodeCommand{} }
 func (m *DeleteNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteNodeCommand) ProtoMessage()    {}
 func (*DeleteNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{14}
+	return fileDescriptor_59b0956366e72083, []int{17}
 }
 func (m *DeleteNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteNodeCommand.Unmarshal(m, b)
@@ -1052,7 +1338,7 @@ var E_DeleteNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         102,
 	Name:          "meta.DeleteNodeCommand.command",
 	Tag:           "bytes,102,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateDatabaseCommand struct {
@@ -1067,7 +1353,7 @@ func (m *CreateDatabaseCommand) Reset()         { *m = CreateDatabaseCommand{} }
 func (m *CreateDatabaseCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateDatabaseCommand) ProtoMessage()    {}
 func (*CreateDatabaseCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{15}
+	return fileDescriptor_59b0956366e72083, []int{18}
 }
 func (m *CreateDatabaseCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateDatabaseCommand.Unmarshal(m, b)
@@ -1107,7 +1393,7 @@ var E_CreateDatabaseCommand_Command = &proto.ExtensionDesc{
 	Field:         103,
 	Name:          "meta.CreateDatabaseCommand.command",
 	Tag:           "bytes,103,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DropDatabaseCommand struct {
@@ -1121,7 +1407,7 @@ func (m *DropDatabaseCommand) Reset()         { *m = DropDatabaseCommand{} }
 func (m *DropDatabaseCommand) String() string { return proto.CompactTextString(m) }
 func (*DropDatabaseCommand) ProtoMessage()    {}
 func (*DropDatabaseCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{16}
+	return fileDescriptor_59b0956366e72083, []int{19}
 }
 func (m *DropDatabaseCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropDatabaseCommand.Unmarshal(m, b)
@@ -1154,7 +1440,7 @@ var E_DropDatabaseCommand_Command = &proto.ExtensionDesc{
 	Field:         104,
 	Name:          "meta.DropDatabaseCommand.command",
 	Tag:           "bytes,104,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateTimeToLiveCommand struct {
@@ -1170,7 +1456,7 @@ func (m *CreateTimeToLiveCommand) Reset()         { *m = CreateTimeToLiveCommand
 func (m *CreateTimeToLiveCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateTimeToLiveCommand) ProtoMessage()    {}
 func (*CreateTimeToLiveCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{17}
+	return fileDescriptor_59b0956366e72083, []int{20}
 }
 func (m *CreateTimeToLiveCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateTimeToLiveCommand.Unmarshal(m, b)
@@ -1217,7 +1503,7 @@ var E_CreateTimeToLiveCommand_Command = &proto.ExtensionDesc{
 	Field:         105,
 	Name:          "meta.CreateTimeToLiveCommand.command",
 	Tag:           "bytes,105,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DropTimeToLiveCommand struct {
@@ -1232,7 +1518,7 @@ func (m *DropTimeToLiveCommand) Reset()         { *m = DropTimeToLiveCommand{} }
 func (m *DropTimeToLiveCommand) String() string { return proto.CompactTextString(m) }
 func (*DropTimeToLiveCommand) ProtoMessage()    {}
 func (*DropTimeToLiveCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{18}
+	return fileDescriptor_59b0956366e72083, []int{21}
 }
 func (m *DropTimeToLiveCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropTimeToLiveCommand.Unmarshal(m, b)
@@ -1272,7 +1558,7 @@ var E_DropTimeToLiveCommand_Command = &proto.ExtensionDesc{
 	Field:         106,
 	Name:          "meta.DropTimeToLiveCommand.command",
 	Tag:           "bytes,106,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type SetDefaultTimeToLiveCommand struct {
@@ -1287,7 +1573,7 @@ func (m *SetDefaultTimeToLiveCommand) Reset()         { *m = SetDefaultTimeToLiv
 func (m *SetDefaultTimeToLiveCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDefaultTimeToLiveCommand) ProtoMessage()    {}
 func (*SetDefaultTimeToLiveCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{19}
+	return fileDescriptor_59b0956366e72083, []int{22}
 }
 func (m *SetDefaultTimeToLiveCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetDefaultTimeToLiveCommand.Unmarshal(m, b)
@@ -1327,7 +1613,7 @@ var E_SetDefaultTimeToLiveCommand_Command = &proto.ExtensionDesc{
 	Field:         107,
 	Name:          "meta.SetDefaultTimeToLiveCommand.command",
 	Tag:           "bytes,107,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type UpdateTimeToLiveCommand struct {
@@ -1346,7 +1632,7 @@ func (m *UpdateTimeToLiveCommand) Reset()         { *m = UpdateTimeToLiveCommand
 func (m *UpdateTimeToLiveCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateTimeToLiveCommand) ProtoMessage()    {}
 func (*UpdateTimeToLiveCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{20}
+	return fileDescriptor_59b0956366e72083, []int{23}
 }
 func (m *UpdateTimeToLiveCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateTimeToLiveCommand.Unmarshal(m, b)
@@ -1414,7 +1700,7 @@ var E_UpdateTimeToLiveCommand_Command = &proto.ExtensionDesc{
 	Field:         108,
 	Name:          "meta.UpdateTimeToLiveCommand.command",
 	Tag:           "bytes,108,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateRegionCommand struct {
@@ -1430,7 +1716,7 @@ func (m *CreateRegionCommand) Reset()         { *m = CreateRegionCommand{} }
 func (m *CreateRegionCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateRegionCommand) ProtoMessage()    {}
 func (*CreateRegionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{21}
+	return fileDescriptor_59b0956366e72083, []int{24}
 }
 func (m *CreateRegionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateRegionCommand.Unmarshal(m, b)
@@ -1477,7 +1763,7 @@ var E_CreateRegionCommand_Command = &proto.ExtensionDesc{
 	Field:         109,
 	Name:          "meta.CreateRegionCommand.command",
 	Tag:           "bytes,109,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DeleteRegionCommand struct {
@@ -1493,7 +1779,7 @@ func (m *DeleteRegionCommand) Reset()         { *m = DeleteRegionCommand{} }
 func (m *DeleteRegionCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteRegionCommand) ProtoMessage()    {}
 func (*DeleteRegionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{22}
+	return fileDescriptor_59b0956366e72083, []int{25}
 }
 func (m *DeleteRegionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteRegionCommand.Unmarshal(m, b)
@@ -1540,7 +1826,7 @@ var E_DeleteRegionCommand_Command = &proto.ExtensionDesc{
 	Field:         110,
 	Name:          "meta.DeleteRegionCommand.command",
 	Tag:           "bytes,110,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateContinuousQueryCommand struct {
@@ -1556,7 +1842,7 @@ func (m *CreateContinuousQueryCommand) Reset()         { *m = CreateContinuousQu
 func (m *CreateContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateContinuousQueryCommand) ProtoMessage()    {}
 func (*CreateContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{23}
+	return fileDescriptor_59b0956366e72083, []int{26}
 }
 func (m *CreateContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateContinuousQueryCommand.Unmarshal(m, b)
@@ -1603,7 +1889,7 @@ var E_CreateContinuousQueryCommand_Command = &proto.ExtensionDesc{
 	Field:         111,
 	Name:          "meta.CreateContinuousQueryCommand.command",
 	Tag:           "bytes,111,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DropContinuousQueryCommand struct {
@@ -1618,7 +1904,7 @@ func (m *DropContinuousQueryCommand) Reset()         { *m = DropContinuousQueryC
 func (m *DropContinuousQueryCommand) String() string { return proto.CompactTextString(m) }
 func (*DropContinuousQueryCommand) ProtoMessage()    {}
 func (*DropContinuousQueryCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{24}
+	return fileDescriptor_59b0956366e72083, []int{27}
 }
 func (m *DropContinuousQueryCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropContinuousQueryCommand.Unmarshal(m, b)
@@ -1658,7 +1944,7 @@ var E_DropContinuousQueryCommand_Command = &proto.ExtensionDesc{
 	Field:         112,
 	Name:          "meta.DropContinuousQueryCommand.command",
 	Tag:           "bytes,112,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateUserCommand struct {
@@ -1674,7 +1960,7 @@ func (m *CreateUserCommand) Reset()         { *m = CreateUserCommand{} }
 func (m *CreateUserCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateUserCommand) ProtoMessage()    {}
 func (*CreateUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{25}
+	return fileDescriptor_59b0956366e72083, []int{28}
 }
 func (m *CreateUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateUserCommand.Unmarshal(m, b)
@@ -1721,7 +2007,7 @@ var E_CreateUserCommand_Command = &proto.ExtensionDesc{
 	Field:         113,
 	Name:          "meta.CreateUserCommand.command",
 	Tag:           "bytes,113,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DropUserCommand struct {
@@ -1735,7 +2021,7 @@ func (m *DropUserCommand) Reset()         { *m = DropUserCommand{} }
 func (m *DropUserCommand) String() string { return proto.CompactTextString(m) }
 func (*DropUserCommand) ProtoMessage()    {}
 func (*DropUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{26}
+	return fileDescriptor_59b0956366e72083, []int{29}
 }
 func (m *DropUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropUserCommand.Unmarshal(m, b)
@@ -1768,7 +2054,7 @@ var E_DropUserCommand_Command = &proto.ExtensionDesc{
 	Field:         114,
 	Name:          "meta.DropUserCommand.command",
 	Tag:           "bytes,114,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type UpdateUserCommand struct {
@@ -1783,7 +2069,7 @@ func (m *UpdateUserCommand) Reset()         { *m = UpdateUserCommand{} }
 func (m *UpdateUserCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateUserCommand) ProtoMessage()    {}
 func (*UpdateUserCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{27}
+	return fileDescriptor_59b0956366e72083, []int{30}
 }
 func (m *UpdateUserCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateUserCommand.Unmarshal(m, b)
@@ -1823,7 +2109,7 @@ var E_UpdateUserCommand_Command = &proto.ExtensionDesc{
 	Field:         115,
 	Name:          "meta.UpdateUserCommand.command",
 	Tag:           "bytes,115,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type SetPrivilegeCommand struct {
@@ -1839,7 +2125,7 @@ func (m *SetPrivilegeCommand) Reset()         { *m = SetPrivilegeCommand{} }
 func (m *SetPrivilegeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetPrivilegeCommand) ProtoMessage()    {}
 func (*SetPrivilegeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{28}
+	return fileDescriptor_59b0956366e72083, []int{31}
 }
 func (m *SetPrivilegeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetPrivilegeCommand.Unmarshal(m, b)
@@ -1886,7 +2172,7 @@ var E_SetPrivilegeCommand_Command = &proto.ExtensionDesc{
 	Field:         116,
 	Name:          "meta.SetPrivilegeCommand.command",
 	Tag:           "bytes,116,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type SetDataCommand struct {
@@ -1900,7 +2186,7 @@ func (m *SetDataCommand) Reset()         { *m = SetDataCommand{} }
 func (m *SetDataCommand) String() string { return proto.CompactTextString(m) }
 func (*SetDataCommand) ProtoMessage()    {}
 func (*SetDataCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{29}
+	return fileDescriptor_59b0956366e72083, []int{32}
 }
 func (m *SetDataCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetDataCommand.Unmarshal(m, b)
@@ -1933,7 +2219,7 @@ var E_SetDataCommand_Command = &proto.ExtensionDesc{
 	Field:         117,
 	Name:          "meta.SetDataCommand.command",
 	Tag:           "bytes,117,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type SetAdminPrivilegeCommand struct {
@@ -1948,7 +2234,7 @@ func (m *SetAdminPrivilegeCommand) Reset()         { *m = SetAdminPrivilegeComma
 func (m *SetAdminPrivilegeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetAdminPrivilegeCommand) ProtoMessage()    {}
 func (*SetAdminPrivilegeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{30}
+	return fileDescriptor_59b0956366e72083, []int{33}
 }
 func (m *SetAdminPrivilegeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetAdminPrivilegeCommand.Unmarshal(m, b)
@@ -1988,7 +2274,7 @@ var E_SetAdminPrivilegeCommand_Command = &proto.ExtensionDesc{
 	Field:         118,
 	Name:          "meta.SetAdminPrivilegeCommand.command",
 	Tag:           "bytes,118,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type UpdateNodeCommand struct {
@@ -2003,7 +2289,7 @@ func (m *UpdateNodeCommand) Reset()         { *m = UpdateNodeCommand{} }
 func (m *UpdateNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateNodeCommand) ProtoMessage()    {}
 func (*UpdateNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{31}
+	return fileDescriptor_59b0956366e72083, []int{34}
 }
 func (m *UpdateNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateNodeCommand.Unmarshal(m, b)
@@ -2043,7 +2329,7 @@ var E_UpdateNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         119,
 	Name:          "meta.UpdateNodeCommand.command",
 	Tag:           "bytes,119,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateSubscriptionCommand struct {
@@ -2061,7 +2347,7 @@ func (m *CreateSubscriptionCommand) Reset()         { *m = CreateSubscriptionCom
 func (m *CreateSubscriptionCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateSubscriptionCommand) ProtoMessage()    {}
 func (*CreateSubscriptionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{32}
+	return fileDescriptor_59b0956366e72083, []int{35}
 }
 func (m *CreateSubscriptionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateSubscriptionCommand.Unmarshal(m, b)
@@ -2122,7 +2408,7 @@ var E_CreateSubscriptionCommand_Command = &proto.ExtensionDesc{
 	Field:         121,
 	Name:          "meta.CreateSubscriptionCommand.command",
 	Tag:           "bytes,121,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DropSubscriptionCommand struct {
@@ -2138,7 +2424,7 @@ func (m *DropSubscriptionCommand) Reset()         { *m = DropSubscriptionCommand
 func (m *DropSubscriptionCommand) String() string { return proto.CompactTextString(m) }
 func (*DropSubscriptionCommand) ProtoMessage()    {}
 func (*DropSubscriptionCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{33}
+	return fileDescriptor_59b0956366e72083, []int{36}
 }
 func (m *DropSubscriptionCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropSubscriptionCommand.Unmarshal(m, b)
@@ -2185,7 +2471,7 @@ var E_DropSubscriptionCommand_Command = &proto.ExtensionDesc{
 	Field:         122,
 	Name:          "meta.DropSubscriptionCommand.command",
 	Tag:           "bytes,122,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type RemovePeerCommand struct {
@@ -2200,7 +2486,7 @@ func (m *RemovePeerCommand) Reset()         { *m = RemovePeerCommand{} }
 func (m *RemovePeerCommand) String() string { return proto.CompactTextString(m) }
 func (*RemovePeerCommand) ProtoMessage()    {}
 func (*RemovePeerCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{34}
+	return fileDescriptor_59b0956366e72083, []int{37}
 }
 func (m *RemovePeerCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_RemovePeerCommand.Unmarshal(m, b)
@@ -2240,7 +2526,7 @@ var E_RemovePeerCommand_Command = &proto.ExtensionDesc{
 	Field:         123,
 	Name:          "meta.RemovePeerCommand.command",
 	Tag:           "bytes,123,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateMetaNodeCommand struct {
@@ -2256,7 +2542,7 @@ func (m *CreateMetaNodeCommand) Reset()         { *m = CreateMetaNodeCommand{} }
 func (m *CreateMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateMetaNodeCommand) ProtoMessage()    {}
 func (*CreateMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{35}
+	return fileDescriptor_59b0956366e72083, []int{38}
 }
 func (m *CreateMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateMetaNodeCommand.Unmarshal(m, b)
@@ -2303,7 +2589,7 @@ var E_CreateMetaNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         124,
 	Name:          "meta.CreateMetaNodeCommand.command",
 	Tag:           "bytes,124,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type CreateDataNodeCommand struct {
@@ -2318,7 +2604,7 @@ func (m *CreateDataNodeCommand) Reset()         { *m = CreateDataNodeCommand{} }
 func (m *CreateDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*CreateDataNodeCommand) ProtoMessage()    {}
 func (*CreateDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{36}
+	return fileDescriptor_59b0956366e72083, []int{39}
 }
 func (m *CreateDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_CreateDataNodeCommand.Unmarshal(m, b)
@@ -2358,7 +2644,7 @@ var E_CreateDataNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         125,
 	Name:          "meta.CreateDataNodeCommand.command",
 	Tag:           "bytes,125,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type UpdateDataNodeCommand struct {
@@ -2374,7 +2660,7 @@ func (m *UpdateDataNodeCommand) Reset()         { *m = UpdateDataNodeCommand{} }
 func (m *UpdateDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*UpdateDataNodeCommand) ProtoMessage()    {}
 func (*UpdateDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{37}
+	return fileDescriptor_59b0956366e72083, []int{40}
 }
 func (m *UpdateDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_UpdateDataNodeCommand.Unmarshal(m, b)
@@ -2421,7 +2707,7 @@ var E_UpdateDataNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         126,
 	Name:          "meta.UpdateDataNodeCommand.command",
 	Tag:           "bytes,126,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DeleteMetaNodeCommand struct {
@@ -2435,7 +2721,7 @@ func (m *DeleteMetaNodeCommand) Reset()         { *m = DeleteMetaNodeCommand{} }
 func (m *DeleteMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteMetaNodeCommand) ProtoMessage()    {}
 func (*DeleteMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{38}
+	return fileDescriptor_59b0956366e72083, []int{41}
 }
 func (m *DeleteMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteMetaNodeCommand.Unmarshal(m, b)
@@ -2468,7 +2754,7 @@ var E_DeleteMetaNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         127,
 	Name:          "meta.DeleteMetaNodeCommand.command",
 	Tag:           "bytes,127,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DeleteDataNodeCommand struct {
@@ -2482,7 +2768,7 @@ func (m *DeleteDataNodeCommand) Reset()         { *m = DeleteDataNodeCommand{} }
 func (m *DeleteDataNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*DeleteDataNodeCommand) ProtoMessage()    {}
 func (*DeleteDataNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{39}
+	return fileDescriptor_59b0956366e72083, []int{42}
 }
 func (m *DeleteDataNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DeleteDataNodeCommand.Unmarshal(m, b)
@@ -2515,7 +2801,7 @@ var E_DeleteDataNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         128,
 	Name:          "meta.DeleteDataNodeCommand.command",
 	Tag:           "bytes,128,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type Response struct {
@@ -2531,7 +2817,7 @@ func (m *Response) Reset()         { *m = Response{} }
 func (m *Response) String() string { return proto.CompactTextString(m) }
 func (*Response) ProtoMessage()    {}
 func (*Response) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{40}
+	return fileDescriptor_59b0956366e72083, []int{43}
 }
 func (m *Response) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_Response.Unmarshal(m, b)
@@ -2587,7 +2873,7 @@ func (m *SetMetaNodeCommand) Reset()         { *m = SetMetaNodeCommand{} }
 func (m *SetMetaNodeCommand) String() string { return proto.CompactTextString(m) }
 func (*SetMetaNodeCommand) ProtoMessage()    {}
 func (*SetMetaNodeCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{41}
+	return fileDescriptor_59b0956366e72083, []int{44}
 }
 func (m *SetMetaNodeCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_SetMetaNodeCommand.Unmarshal(m, b)
@@ -2634,7 +2920,7 @@ var E_SetMetaNodeCommand_Command = &proto.ExtensionDesc{
 	Field:         129,
 	Name:          "meta.SetMetaNodeCommand.command",
 	Tag:           "bytes,129,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
 }
 
 type DropShardCommand struct {
@@ -2648,7 +2934,7 @@ func (m *DropShardCommand) Reset()         { *m = DropShardCommand{} }
 func (m *DropShardCommand) String() string { return proto.CompactTextString(m) }
 func (*DropShardCommand) ProtoMessage()    {}
 func (*DropShardCommand) Descriptor() ([]byte, []int) {
-	return fileDescriptor_3b5ea8fe65782bcc, []int{42}
+	return fileDescriptor_59b0956366e72083, []int{45}
 }
 func (m *DropShardCommand) XXX_Unmarshal(b []byte) error {
 	return xxx_messageInfo_DropShardCommand.Unmarshal(m, b)
@@ -2681,12 +2967,188 @@ var E_DropShardCommand_Command = &proto.ExtensionDesc{
 	Field:         130,
 	Name:          "meta.DropShardCommand.command",
 	Tag:           "bytes,130,opt,name=command",
-	Filename:      "meta.proto",
+	Filename:      "internal/meta.proto",
+}
+
+type SetDataNodeLeavingCommand struct {
+	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	Leaving              *bool    `protobuf:"varint,2,req,name=Leaving" json:"Leaving,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SetDataNodeLeavingCommand) Reset()         { *m = SetDataNodeLeavingCommand{} }
+func (m *SetDataNodeLeavingCommand) String() string { return proto.CompactTextString(m) }
+func (*SetDataNodeLeavingCommand) ProtoMessage()    {}
+func (*SetDataNodeLeavingCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{46}
+}
+func (m *SetDataNodeLeavingCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SetDataNodeLeavingCommand.Unmarshal(m, b)
+}
+func (m *SetDataNodeLeavingCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SetDataNodeLeavingCommand.Marshal(b, m, deterministic)
+}
+func (m *SetDataNodeLeavingCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SetDataNodeLeavingCommand.Merge(m, src)
+}
+func (m *SetDataNodeLeavingCommand) XXX_Size() int {
+	return xxx_messageInfo_SetDataNodeLeavingCommand.Size(m)
+}
+func (m *SetDataNodeLeavingCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_SetDataNodeLeavingCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SetDataNodeLeavingCommand proto.InternalMessageInfo
+
+func (m *SetDataNodeLeavingCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *SetDataNodeLeavingCommand) GetLeaving() bool {
+	if m != nil && m.Leaving != nil {
+		return *m.Leaving
+	}
+	return false
+}
+
+var E_SetDataNodeLeavingCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*SetDataNodeLeavingCommand)(nil),
+	Field:         131,
+	Name:          "meta.SetDataNodeLeavingCommand.command",
+	Tag:           "bytes,131,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type AddShardOwnerCommand struct {
+	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	NodeID               *uint64  `protobuf:"varint,2,req,name=NodeID" json:"NodeID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddShardOwnerCommand) Reset()         { *m = AddShardOwnerCommand{} }
+func (m *AddShardOwnerCommand) String() string { return proto.CompactTextString(m) }
+func (*AddShardOwnerCommand) ProtoMessage()    {}
+func (*AddShardOwnerCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{47}
+}
+func (m *AddShardOwnerCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddShardOwnerCommand.Unmarshal(m, b)
+}
+func (m *AddShardOwnerCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddShardOwnerCommand.Marshal(b, m, deterministic)
+}
+func (m *AddShardOwnerCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddShardOwnerCommand.Merge(m, src)
+}
+func (m *AddShardOwnerCommand) XXX_Size() int {
+	return xxx_messageInfo_AddShardOwnerCommand.Size(m)
+}
+func (m *AddShardOwnerCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddShardOwnerCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddShardOwnerCommand proto.InternalMessageInfo
+
+func (m *AddShardOwnerCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *AddShardOwnerCommand) GetNodeID() uint64 {
+	if m != nil && m.NodeID != nil {
+		return *m.NodeID
+	}
+	return 0
+}
+
+var E_AddShardOwnerCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*AddShardOwnerCommand)(nil),
+	Field:         132,
+	Name:          "meta.AddShardOwnerCommand.command",
+	Tag:           "bytes,132,opt,name=command",
+	Filename:      "internal/meta.proto",
+}
+
+type ReplaceDataNodeCommand struct {
+	ID                   *uint64  `protobuf:"varint,1,req,name=ID" json:"ID,omitempty"`
+	HTTPAddr             *string  `protobuf:"bytes,2,req,name=HTTPAddr" json:"HTTPAddr,omitempty"`
+	TCPAddr              *string  `protobuf:"bytes,3,req,name=TCPAddr" json:"TCPAddr,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ReplaceDataNodeCommand) Reset()         { *m = ReplaceDataNodeCommand{} }
+func (m *ReplaceDataNodeCommand) String() string { return proto.CompactTextString(m) }
+func (*ReplaceDataNodeCommand) ProtoMessage()    {}
+func (*ReplaceDataNodeCommand) Descriptor() ([]byte, []int) {
+	return fileDescriptor_59b0956366e72083, []int{48}
+}
+func (m *ReplaceDataNodeCommand) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ReplaceDataNodeCommand.Unmarshal(m, b)
+}
+func (m *ReplaceDataNodeCommand) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ReplaceDataNodeCommand.Marshal(b, m, deterministic)
+}
+func (m *ReplaceDataNodeCommand) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ReplaceDataNodeCommand.Merge(m, src)
+}
+func (m *ReplaceDataNodeCommand) XXX_Size() int {
+	return xxx_messageInfo_ReplaceDataNodeCommand.Size(m)
+}
+func (m *ReplaceDataNodeCommand) XXX_DiscardUnknown() {
+	xxx_messageInfo_ReplaceDataNodeCommand.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ReplaceDataNodeCommand proto.InternalMessageInfo
+
+func (m *ReplaceDataNodeCommand) GetID() uint64 {
+	if m != nil && m.ID != nil {
+		return *m.ID
+	}
+	return 0
+}
+
+func (m *ReplaceDataNodeCommand) GetHTTPAddr() string {
+	if m != nil && m.HTTPAddr != nil {
+		return *m.HTTPAddr
+	}
+	return ""
+}
+
+func (m *ReplaceDataNodeCommand) GetTCPAddr() string {
+	if m != nil && m.TCPAddr != nil {
+		return *m.TCPAddr
+	}
+	return ""
+}
+
+var E_ReplaceDataNodeCommand_Command = &proto.ExtensionDesc{
+	ExtendedType:  (*Command)(nil),
+	ExtensionType: (*ReplaceDataNodeCommand)(nil),
+	Field:         133,
+	Name:          "meta.ReplaceDataNodeCommand.command",
+	Tag:           "bytes,133,opt,name=command",
+	Filename:      "internal/meta.proto",
 }
 
 func init() {
 	proto.RegisterEnum("meta.Command_Type", Command_Type_name, Command_Type_value)
 	proto.RegisterType((*Data)(nil), "meta.Data")
+	proto.RegisterType((*DataDelta)(nil), "meta.DataDelta")
+	proto.RegisterType((*DatabaseDelta)(nil), "meta.DatabaseDelta")
+	proto.RegisterType((*TimeToLiveDelta)(nil), "meta.TimeToLiveDelta")
 	proto.RegisterType((*NodeInfo)(nil), "meta.NodeInfo")
 	proto.RegisterType((*DatabaseInfo)(nil), "meta.DatabaseInfo")
 	proto.RegisterType((*TimeToLiveSpec)(nil), "meta.TimeToLiveSpec")
@@ -2758,123 +3220,147 @@ func init() {
 	proto.RegisterType((*SetMetaNodeCommand)(nil), "meta.SetMetaNodeCommand")
 	proto.RegisterExtension(E_DropShardCommand_Command)
 	proto.RegisterType((*DropShardCommand)(nil), "meta.DropShardCommand")
-}
-
-func init() { proto.RegisterFile("meta.proto", fileDescriptor_3b5ea8fe65782bcc) }
-
-var fileDescriptor_3b5ea8fe65782bcc = []byte{
-	// 1807 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x59, 0x4f, 0x6f, 0xdb, 0xc6,
-	0x12, 0x07, 0x49, 0x49, 0x96, 0xc6, 0xb1, 0x63, 0xaf, 0xff, 0xd1, 0xb1, 0xe3, 0xe8, 0x11, 0x41,
-	0x9e, 0x10, 0x04, 0xc1, 0x83, 0xde, 0xc3, 0x3b, 0xf5, 0x5f, 0x62, 0x25, 0xb1, 0x9a, 0xda, 0x71,
-	0x29, 0xe5, 0x5a, 0x80, 0xb1, 0x36, 0xb1, 0x5a, 0x8b, 0x54, 0x49, 0xca, 0xb1, 0x9b, 0xba, 0x75,
-	0x8b, 0x1e, 0x7a, 0xe9, 0xa9, 0x28, 0x8a, 0xa2, 0xb7, 0x5e, 0x7a, 0x2c, 0x8a, 0x9e, 0xf3, 0x11,
-	0x7a, 0xe8, 0x07, 0x69, 0xbf, 0x41, 0xd1, 0x62, 0x77, 0xb9, 0xdc, 0x25, 0xb9, 0xcb, 0xd8, 0x4d,
-	0x6e, 0xda, 0x99, 0xd9, 0x99, 0xdf, 0xec, 0xce, 0x0c, 0x67, 0x56, 0x00, 0x23, 0x1c, 0x7b, 0x37,
-	0xc7, 0x61, 0x10, 0x07, 0xa8, 0x42, 0x7e, 0x3b, 0x5f, 0x5a, 0x50, 0xe9, 0x78, 0xb1, 0x87, 0x10,
-	0x54, 0xfa, 0x38, 0x1c, 0xd9, 0x46, 0xd3, 0x6c, 0x55, 0x5c, 0xfa, 0x1b, 0x2d, 0x42, 0xb5, 0xeb,
-	0x0f, 0xf0, 0x91, 0x6d, 0x52, 0x22, 0x5b, 0xa0, 0x75, 0x68, 0x6c, 0x1e, 0x4c, 0xa2, 0x18, 0x87,
-	0xdd, 0x8e, 0x6d, 0x51, 0x8e, 0x20, 0xa0, 0xab, 0x50, 0xdd, 0x09, 0x06, 0x38, 0xb2, 0x2b, 0x4d,
-	0xab, 0x35, 0xdd, 0x9e, 0xbd, 0x49, 0x4d, 0x12, 0x52, 0xd7, 0x7f, 0x1c, 0xb8, 0x8c, 0x89, 0xfe,
-	0x03, 0x0d, 0x62, 0xf5, 0x91, 0x17, 0xe1, 0xc8, 0xae, 0x52, 0x49, 0xc4, 0x24, 0x39, 0x99, 0x4a,
-	0x0b, 0x21, 0xa2, 0xf7, 0x61, 0x84, 0xc3, 0xc8, 0xae, 0xc9, 0x7a, 0x09, 0x89, 0xe9, 0xa5, 0x4c,
-	0x82, 0x6d, 0xdb, 0x3b, 0xa2, 0xd6, 0x3a, 0xf6, 0x14, 0xc3, 0x96, 0x12, 0x50, 0x13, 0xa6, 0xb7,
-	0xbd, 0x23, 0x17, 0x3f, 0x19, 0x06, 0x7e, 0xb7, 0x63, 0xd7, 0x29, 0x5f, 0x26, 0xa1, 0x0d, 0x80,
-	0x6d, 0xef, 0xa8, 0xb7, 0xef, 0x85, 0x83, 0x6e, 0xc7, 0x6e, 0x50, 0x01, 0x89, 0x82, 0x6e, 0x30,
-	0xdc, 0xcc, 0x43, 0x50, 0x7a, 0x28, 0x04, 0x88, 0xf4, 0x36, 0xe6, 0xd2, 0xd3, 0x6a, 0xe9, 0x54,
-	0xc0, 0xd9, 0x82, 0x3a, 0x27, 0xa3, 0x59, 0x30, 0xbb, 0x9d, 0xe4, 0x2e, 0xcc, 0x6e, 0x87, 0xdc,
-	0xce, 0x56, 0x10, 0xc5, 0xf4, 0x22, 0x1a, 0x2e, 0xfd, 0x8d, 0x6c, 0x98, 0xea, 0x6f, 0xee, 0x52,
-	0xb2, 0xd5, 0x34, 0x5a, 0x0d, 0x97, 0x2f, 0x9d, 0xdf, 0x0c, 0xb8, 0x20, 0x9f, 0x23, 0xd9, 0xbe,
-	0xe3, 0x8d, 0x30, 0x55, 0xd8, 0x70, 0xe9, 0x6f, 0x74, 0x03, 0xe6, 0x3b, 0xf8, 0xb1, 0x37, 0x39,
-	0x88, 0xfb, 0xc3, 0x11, 0xee, 0x07, 0xef, 0x0c, 0x0f, 0x71, 0xa2, 0xbf, 0xc8, 0x40, 0xff, 0x87,
-	0x69, 0xb1, 0x8a, 0x6c, 0x8b, 0x3a, 0xb3, 0xc8, 0x9c, 0x11, 0x0c, 0xea, 0x92, 0x2c, 0x88, 0xee,
-	0xc1, 0xfc, 0x66, 0xe0, 0xc7, 0x43, 0x7f, 0x12, 0x4c, 0xa2, 0x77, 0x27, 0x38, 0x1c, 0xa6, 0xa1,
-	0xb1, 0xca, 0x76, 0x67, 0xd9, 0xc7, 0x54, 0x45, 0x71, 0x8f, 0xf3, 0x85, 0x01, 0xb3, 0x42, 0x71,
-	0x6f, 0x8c, 0xf7, 0x24, 0xaf, 0x8c, 0xd4, 0xab, 0x4b, 0x50, 0xef, 0x4c, 0x42, 0x2f, 0x1e, 0x06,
-	0xbe, 0x6d, 0x36, 0x8d, 0x96, 0xe5, 0xa6, 0x6b, 0x74, 0x0d, 0x66, 0xd9, 0x45, 0xa7, 0x12, 0x16,
-	0x95, 0xc8, 0x51, 0x89, 0x0e, 0x17, 0x8f, 0x0f, 0x86, 0x7b, 0xde, 0x8e, 0x5d, 0x69, 0x1a, 0xad,
-	0x19, 0x37, 0x5d, 0x3b, 0x7f, 0x64, 0x60, 0x68, 0x0f, 0x37, 0x0b, 0xc3, 0x7c, 0x21, 0x0c, 0xf3,
-	0x85, 0x30, 0x4c, 0x19, 0x06, 0xba, 0x0e, 0x53, 0x4c, 0x9a, 0x67, 0xcf, 0x1c, 0x3b, 0xcc, 0x24,
-	0x90, 0xc9, 0x19, 0x72, 0x01, 0xf4, 0x1a, 0xcc, 0xf4, 0x26, 0x8f, 0xa2, 0xbd, 0x70, 0x38, 0x8e,
-	0xe9, 0x0e, 0x96, 0x41, 0xcb, 0x6c, 0x87, 0xcc, 0xa2, 0xfb, 0xb2, 0xc2, 0xce, 0x73, 0x03, 0x40,
-	0x68, 0x2d, 0x04, 0xe6, 0x3a, 0x34, 0x7a, 0xb1, 0x17, 0xd2, 0x50, 0x49, 0x3c, 0x15, 0x04, 0x12,
-	0xa2, 0x77, 0xfc, 0x01, 0xe5, 0x31, 0x1f, 0xf9, 0x92, 0xec, 0xeb, 0xe0, 0x03, 0x1c, 0xe3, 0xc1,
-	0xad, 0x98, 0x7a, 0x67, 0xb9, 0x82, 0x80, 0xfe, 0x0d, 0x35, 0x9a, 0x71, 0xdc, 0xbb, 0x8b, 0x09,
-	0x56, 0x9a, 0x85, 0x04, 0x64, 0xc2, 0x26, 0x19, 0xdd, 0x0f, 0x27, 0xfe, 0x9e, 0xc7, 0x14, 0xd5,
-	0xe8, 0x7d, 0xca, 0x24, 0x07, 0x43, 0x23, 0xdd, 0x56, 0x40, 0xbf, 0x01, 0xf5, 0x07, 0x4f, 0x7d,
-	0x52, 0xb7, 0x22, 0xdb, 0x6c, 0x5a, 0xad, 0xca, 0x6d, 0xd3, 0x36, 0xdc, 0x94, 0x86, 0x5a, 0x50,
-	0xa3, 0xbf, 0x79, 0xc0, 0xcf, 0x49, 0x38, 0x28, 0xc3, 0x4d, 0xf8, 0xce, 0x7b, 0x30, 0x97, 0x3f,
-	0x49, 0x65, 0x60, 0x20, 0xa8, 0x6c, 0x07, 0x03, 0x9e, 0x68, 0xf4, 0x37, 0x72, 0xe0, 0x42, 0x07,
-	0x47, 0xf1, 0xd0, 0xf7, 0xd8, 0xfd, 0x10, 0x5b, 0x0d, 0x37, 0x43, 0x73, 0xae, 0x02, 0x08, 0xab,
-	0x68, 0x19, 0x6a, 0x49, 0x8d, 0x63, 0xbe, 0x24, 0x2b, 0xe7, 0x4d, 0x58, 0x50, 0xa4, 0x93, 0x12,
-	0xc8, 0x22, 0x54, 0xa9, 0x40, 0x82, 0x84, 0x2d, 0x9c, 0x13, 0xa8, 0xf3, 0x92, 0xaa, 0x83, 0xbf,
-	0xe5, 0x45, 0xfb, 0x69, 0x1d, 0xf2, 0xa2, 0x7d, 0xa2, 0xe9, 0xd6, 0x60, 0x34, 0x64, 0x61, 0x5c,
-	0x77, 0xd9, 0x02, 0xfd, 0x17, 0x60, 0x37, 0x1c, 0x1e, 0x0e, 0x0f, 0xf0, 0x93, 0x34, 0xe3, 0x17,
-	0x44, 0xd1, 0x4e, 0x79, 0xae, 0x24, 0xe6, 0x74, 0x61, 0x26, 0xc3, 0xa4, 0x79, 0x94, 0x14, 0xb2,
-	0x04, 0x47, 0xba, 0x26, 0x21, 0x94, 0x0a, 0x52, 0x40, 0x55, 0x57, 0x10, 0x9c, 0xe7, 0x35, 0x98,
-	0xda, 0x0c, 0x46, 0x23, 0xcf, 0x1f, 0xa0, 0x6b, 0x50, 0x89, 0x8f, 0xc7, 0x4c, 0xc3, 0x2c, 0xff,
-	0xd0, 0x24, 0xcc, 0x9b, 0xfd, 0xe3, 0x31, 0x76, 0x29, 0xdf, 0xf9, 0xab, 0x0a, 0x15, 0xb2, 0x44,
-	0x4b, 0x30, 0xbf, 0x19, 0x62, 0x2f, 0xc6, 0xe4, 0x5c, 0x13, 0xc1, 0x39, 0x83, 0x90, 0x59, 0x8c,
-	0xca, 0x64, 0x13, 0xad, 0xc2, 0x12, 0x93, 0xe6, 0xd0, 0x38, 0xcb, 0x42, 0x2b, 0xb0, 0xd0, 0x09,
-	0x83, 0x71, 0x9e, 0x51, 0x41, 0x6b, 0xb0, 0xc2, 0xf6, 0x88, 0x62, 0xc2, 0x99, 0x55, 0xa2, 0x90,
-	0xec, 0x2a, 0xb2, 0x6a, 0xe8, 0x0a, 0xac, 0xf5, 0x70, 0x5c, 0xa8, 0xcf, 0x5c, 0x60, 0x8a, 0x28,
-	0x7e, 0x38, 0x1e, 0x28, 0x15, 0xd7, 0x09, 0x1c, 0x66, 0x95, 0x65, 0x34, 0x67, 0x34, 0x28, 0x4e,
-	0xea, 0x59, 0x96, 0x01, 0xa8, 0x09, 0xeb, 0x6c, 0x47, 0x2e, 0xae, 0xb8, 0xc4, 0x34, 0xda, 0x80,
-	0x4b, 0x04, 0xac, 0x86, 0x7f, 0x41, 0x9c, 0x25, 0xb9, 0x59, 0x4e, 0x9e, 0x41, 0x0b, 0x70, 0x91,
-	0x6c, 0x93, 0x89, 0xb3, 0x44, 0x96, 0x81, 0x97, 0xc9, 0x17, 0x09, 0xba, 0x1e, 0x8e, 0xd3, 0xbb,
-	0xe5, 0x8c, 0x39, 0x84, 0x60, 0x96, 0x9c, 0x86, 0x17, 0x7b, 0x9c, 0x36, 0x8f, 0xd6, 0xc1, 0xee,
-	0xe1, 0x98, 0x06, 0x61, 0x61, 0x07, 0x12, 0x16, 0xe4, 0x2b, 0x5c, 0x40, 0x97, 0x61, 0x95, 0x81,
-	0x94, 0x93, 0x98, 0xb3, 0x97, 0xc8, 0xa1, 0x12, 0xb0, 0x2a, 0xe6, 0x32, 0x51, 0xe9, 0xe2, 0x51,
-	0x70, 0x88, 0x77, 0xb1, 0x00, 0xbd, 0x22, 0xa2, 0x82, 0x7f, 0xe1, 0x39, 0xcb, 0xce, 0x06, 0x8c,
-	0xcc, 0x5a, 0x25, 0x2c, 0x86, 0x2f, 0xcf, 0xba, 0x44, 0xa3, 0x82, 0xde, 0x51, 0x5e, 0xe1, 0x9a,
-	0x60, 0xe5, 0x77, 0xad, 0xa3, 0x65, 0x40, 0x3d, 0x1c, 0xe7, 0xb7, 0x5c, 0x46, 0x8b, 0x30, 0x47,
-	0x5d, 0x22, 0x45, 0x85, 0x53, 0x37, 0xae, 0xd7, 0xeb, 0x83, 0xb9, 0xd3, 0xd3, 0xd3, 0x53, 0xd3,
-	0x39, 0x51, 0xa4, 0x40, 0xda, 0x86, 0x18, 0x52, 0x1b, 0x82, 0xa0, 0xe2, 0x7a, 0xfe, 0x20, 0xe9,
-	0x11, 0xe9, 0xef, 0xf6, 0x5b, 0x30, 0xb5, 0x97, 0x6c, 0x99, 0xc9, 0x64, 0x9b, 0x8d, 0x9b, 0x46,
-	0x6b, 0xba, 0xbd, 0x92, 0x10, 0xf3, 0x06, 0x5c, 0xbe, 0xcd, 0x79, 0xa6, 0x48, 0xb5, 0x42, 0xf9,
-	0x5e, 0x84, 0xea, 0xdd, 0x20, 0xdc, 0x63, 0xd9, 0x5f, 0x77, 0xd9, 0xa2, 0xc4, 0xf8, 0x63, 0xd9,
-	0x78, 0x41, 0xbd, 0x30, 0xfe, 0xa3, 0xa1, 0xc9, 0x68, 0x65, 0x4d, 0xfc, 0x1f, 0x40, 0xa6, 0x83,
-	0x32, 0xb4, 0x9d, 0x91, 0x24, 0xd7, 0xee, 0x68, 0x51, 0x3e, 0xa1, 0x1a, 0xd6, 0xe4, 0x23, 0xca,
-	0xc1, 0x10, 0x48, 0x47, 0xca, 0xfa, 0xa2, 0x82, 0xd9, 0xbe, 0xad, 0x35, 0xb8, 0x4f, 0x0d, 0x26,
-	0xed, 0x98, 0x42, 0x9d, 0x30, 0xf7, 0xab, 0xa1, 0x2d, 0x5b, 0xa5, 0xa5, 0x3a, 0x7f, 0x44, 0xe6,
-	0x59, 0x8e, 0x88, 0x74, 0x0f, 0x49, 0xa1, 0x4b, 0x3e, 0x2d, 0x7c, 0xd9, 0xbe, 0xab, 0xf5, 0x65,
-	0x48, 0x7d, 0xb9, 0x2c, 0x1f, 0x5e, 0x01, 0xaa, 0xf0, 0xe7, 0x2b, 0x43, 0x53, 0x69, 0x4b, 0xbd,
-	0xe1, 0xa7, 0x6b, 0x4a, 0xa7, 0xab, 0xbf, 0xce, 0xf7, 0xe5, 0xeb, 0x54, 0x1a, 0x13, 0x78, 0xbe,
-	0x33, 0x4a, 0xcb, 0xfb, 0xb9, 0x51, 0xbd, 0xad, 0x45, 0xf5, 0x01, 0x45, 0xf5, 0xaf, 0xa4, 0x9f,
-	0xd1, 0x9b, 0x14, 0xd8, 0xfe, 0x34, 0xb4, 0x5f, 0x96, 0xf3, 0xe2, 0x22, 0x37, 0xbb, 0x83, 0x9f,
-	0x52, 0x72, 0x32, 0xba, 0x24, 0xcb, 0x4c, 0xe3, 0x5c, 0xc9, 0xf5, 0xef, 0x72, 0x43, 0x5c, 0xcd,
-	0xf6, 0xe5, 0x72, 0xac, 0xd4, 0xce, 0x1a, 0x2b, 0x07, 0x72, 0xac, 0x68, 0x5c, 0x13, 0xfe, 0xff,
-	0x62, 0x28, 0x3f, 0x9e, 0xa5, 0xbe, 0x6f, 0x14, 0xe2, 0xbe, 0x91, 0x89, 0xf0, 0x75, 0x68, 0x90,
-	0x55, 0x14, 0x7b, 0xa3, 0x71, 0xd2, 0x21, 0x0b, 0x42, 0x49, 0xc6, 0x8e, 0xe4, 0x8c, 0x55, 0x80,
-	0x12, 0xa8, 0x7f, 0x36, 0x94, 0x5f, 0xf6, 0x97, 0x42, 0x4d, 0xef, 0x21, 0x99, 0xa1, 0xd9, 0xfc,
-	0x9f, 0xae, 0x4b, 0x30, 0xfb, 0x99, 0x2a, 0x53, 0x84, 0x94, 0xc1, 0x5c, 0xda, 0x74, 0x9c, 0x3b,
-	0xdc, 0xd2, 0x5e, 0xd7, 0x92, 0x7a, 0xdd, 0xf6, 0x7d, 0x2d, 0xd4, 0x80, 0x42, 0x75, 0xe4, 0xe3,
-	0x55, 0x23, 0x11, 0x98, 0xbf, 0x35, 0xca, 0xda, 0xa0, 0x73, 0x27, 0x6e, 0x57, 0x8b, 0x6d, 0x4c,
-	0xb1, 0x35, 0x45, 0x39, 0x79, 0x11, 0xb2, 0xaf, 0x0d, 0x45, 0x03, 0xf6, 0x72, 0xcd, 0x7d, 0xc9,
-	0x27, 0xf6, 0xc3, 0xe2, 0xf7, 0x5d, 0x32, 0x2b, 0x50, 0xe1, 0x42, 0xfb, 0xa7, 0xfc, 0x68, 0xbd,
-	0xa1, 0x35, 0x14, 0x52, 0x43, 0x4b, 0xe2, 0x1c, 0x94, 0x66, 0x4e, 0x14, 0x0d, 0xe5, 0x59, 0x7d,
-	0x2f, 0xf1, 0x32, 0x92, 0xbd, 0x2c, 0x18, 0x10, 0xe6, 0x7f, 0x32, 0x94, 0x9d, 0x2b, 0x09, 0x07,
-	0x22, 0xef, 0x0b, 0x14, 0xe9, 0x3a, 0x13, 0x2a, 0x66, 0xd9, 0xc8, 0x63, 0xe5, 0x46, 0x9e, 0x92,
-	0xdc, 0x8b, 0xe5, 0xdc, 0x53, 0x00, 0x12, 0x88, 0x83, 0x7c, 0x47, 0x8d, 0x36, 0xd8, 0x03, 0x21,
-	0xc5, 0x39, 0xdd, 0x06, 0xf1, 0x4a, 0xe7, 0x52, 0x7a, 0xfb, 0x75, 0xad, 0xd5, 0x89, 0xdc, 0x0a,
-	0x65, 0xb5, 0x0a, 0x83, 0xdf, 0x18, 0xfa, 0x7e, 0xbd, 0xf4, 0x9c, 0xd2, 0xc8, 0x34, 0xe5, 0xc8,
-	0xbc, 0xa7, 0x45, 0x73, 0x48, 0xd1, 0x6c, 0xa4, 0x68, 0x94, 0x16, 0x05, 0xae, 0x63, 0xc5, 0xa0,
-	0x70, 0x96, 0x67, 0xb9, 0x92, 0xa8, 0x79, 0x5a, 0x8c, 0x1a, 0x65, 0xfb, 0xf9, 0xbb, 0x51, 0x32,
-	0x8d, 0x68, 0x9f, 0x9b, 0x74, 0x31, 0x93, 0xad, 0xe6, 0x56, 0xa1, 0x9a, 0xf3, 0x17, 0x89, 0x4a,
-	0xc9, 0x8b, 0x44, 0xb5, 0xf8, 0x22, 0xd1, 0xde, 0xd2, 0xfa, 0x79, 0x4c, 0xfd, 0xbc, 0x22, 0xd7,
-	0x00, 0x85, 0x23, 0x99, 0x7a, 0xaf, 0x1b, 0xaf, 0x5e, 0xb5, 0xb7, 0x25, 0xdd, 0xc0, 0x47, 0x72,
-	0x37, 0xa0, 0x81, 0x93, 0x09, 0x8f, 0xc2, 0xd0, 0x97, 0x86, 0x87, 0x21, 0xc2, 0xe3, 0xd6, 0x60,
-	0x10, 0xf2, 0xf0, 0x20, 0xbf, 0x4b, 0xc2, 0xe3, 0x99, 0x1c, 0x1e, 0x05, 0xe5, 0xaa, 0xe9, 0x24,
-	0x37, 0xd5, 0x91, 0x83, 0xd9, 0xea, 0xf7, 0x77, 0xa9, 0xcd, 0x24, 0x5d, 0xf8, 0x3a, 0x79, 0x2d,
-	0x96, 0xe0, 0xf0, 0x65, 0x3a, 0xc0, 0x59, 0xd2, 0x00, 0xa7, 0x6f, 0x67, 0x3f, 0x2e, 0x4e, 0x27,
-	0x39, 0x18, 0x99, 0x4f, 0x8f, 0x7a, 0xd0, 0xfd, 0x67, 0x48, 0x4b, 0x50, 0x9d, 0xa8, 0x67, 0x26,
-	0x25, 0xaa, 0xef, 0x0d, 0xcd, 0x8c, 0x7d, 0xfe, 0x57, 0x77, 0x53, 0x7a, 0x75, 0x2f, 0x41, 0xf7,
-	0x89, 0x8c, 0x4e, 0x69, 0x5a, 0x9e, 0xe8, 0xd4, 0x53, 0x7e, 0x1e, 0x5c, 0x89, 0xb9, 0x4f, 0x33,
-	0x13, 0x87, 0x4a, 0x99, 0x30, 0xe7, 0x6b, 0x5e, 0x0e, 0x0a, 0xe6, 0xee, 0x68, 0xcd, 0x9d, 0x1a,
-	0x45, 0x7b, 0x5a, 0xf7, 0xee, 0x92, 0xde, 0x31, 0x1a, 0x07, 0x7e, 0x84, 0x89, 0x89, 0x07, 0xf7,
-	0xa9, 0x89, 0xba, 0x6b, 0x3e, 0xb8, 0x4f, 0x2a, 0xfa, 0x9d, 0x30, 0x0c, 0x42, 0x3a, 0x43, 0x37,
-	0x5c, 0xb6, 0x10, 0x7f, 0x42, 0x59, 0x34, 0xaf, 0xd8, 0xc2, 0xf9, 0xc1, 0x50, 0xbd, 0x6b, 0xbc,
-	0xc2, 0x0c, 0xd0, 0x7f, 0x4c, 0x3f, 0x63, 0xfe, 0xda, 0xe9, 0x97, 0x44, 0x7b, 0xb8, 0x83, 0xe2,
-	0x1b, 0x4b, 0xe1, 0x5c, 0xf5, 0xf5, 0xe0, 0x73, 0x66, 0x67, 0x59, 0xaa, 0x48, 0x92, 0xa2, 0xd4,
-	0xca, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x06, 0x33, 0x5c, 0xeb, 0xd5, 0x1b, 0x00, 0x00,
+	proto.RegisterExtension(E_SetDataNodeLeavingCommand_Command)
+	proto.RegisterType((*SetDataNodeLeavingCommand)(nil), "meta.SetDataNodeLeavingCommand")
+	proto.RegisterExtension(E_AddShardOwnerCommand_Command)
+	proto.RegisterType((*AddShardOwnerCommand)(nil), "meta.AddShardOwnerCommand")
+	proto.RegisterExtension(E_ReplaceDataNodeCommand_Command)
+	proto.RegisterType((*ReplaceDataNodeCommand)(nil), "meta.ReplaceDataNodeCommand")
+}
+
+func init() { proto.RegisterFile("internal/meta.proto", fileDescriptor_59b0956366e72083) }
+
+var fileDescriptor_59b0956366e72083 = []byte{
+	// 2095 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x59, 0x4f, 0x6f, 0x1c, 0x49,
+	0x15, 0x57, 0xf5, 0xf4, 0x8c, 0xa7, 0x9f, 0xe3, 0xb1, 0x53, 0x76, 0x9c, 0x8e, 0xe3, 0x38, 0xb3,
+	0xad, 0x68, 0x19, 0xad, 0x56, 0x01, 0x0d, 0x88, 0x0b, 0x7f, 0xbd, 0x9e, 0x64, 0x33, 0x64, 0x9d,
+	0x84, 0xb6, 0xf7, 0x8a, 0xd4, 0x71, 0x57, 0xec, 0x01, 0x4f, 0xf7, 0xd0, 0xdd, 0xe3, 0xc4, 0x2c,
+	0x06, 0x03, 0x41, 0xe2, 0x82, 0x84, 0x16, 0x21, 0x84, 0xb8, 0x71, 0x80, 0x23, 0x42, 0x9c, 0xb9,
+	0x73, 0xe1, 0x80, 0xc4, 0xd7, 0x80, 0x6f, 0x80, 0xb4, 0xaa, 0xaa, 0xae, 0xae, 0xea, 0xee, 0xaa,
+	0xb6, 0xbd, 0xc9, 0x6d, 0xde, 0x9f, 0x7a, 0xef, 0xf7, 0x5e, 0xbf, 0x7a, 0xf5, 0xaa, 0x06, 0x56,
+	0x27, 0x51, 0x46, 0x92, 0x28, 0x38, 0xfe, 0xe2, 0x94, 0x64, 0xc1, 0xfd, 0x59, 0x12, 0x67, 0x31,
+	0xb6, 0xe9, 0x6f, 0xef, 0x57, 0x2d, 0xb0, 0x47, 0x41, 0x16, 0x60, 0x0c, 0xf6, 0x3e, 0x49, 0xa6,
+	0x2e, 0xea, 0x5b, 0x03, 0xdb, 0x67, 0xbf, 0xf1, 0x1a, 0xb4, 0xc7, 0x51, 0x48, 0x5e, 0xb9, 0x16,
+	0x63, 0x72, 0x02, 0x6f, 0x82, 0xb3, 0x73, 0x3c, 0x4f, 0x33, 0x92, 0x8c, 0x47, 0x6e, 0x8b, 0x49,
+	0x24, 0x03, 0xdf, 0x83, 0xf6, 0x93, 0x38, 0x24, 0xa9, 0x6b, 0xf7, 0x5b, 0x83, 0xc5, 0x61, 0xef,
+	0x3e, 0x73, 0x49, 0x59, 0xe3, 0xe8, 0x45, 0xec, 0x73, 0x21, 0xfe, 0x12, 0x38, 0xd4, 0xeb, 0xf3,
+	0x20, 0x25, 0xa9, 0xdb, 0x66, 0x9a, 0x98, 0x6b, 0x0a, 0x36, 0xd3, 0x96, 0x4a, 0xd4, 0xee, 0xc7,
+	0x29, 0x49, 0x52, 0xb7, 0xa3, 0xda, 0xa5, 0x2c, 0x6e, 0x97, 0x09, 0x29, 0xb6, 0xdd, 0xe0, 0x15,
+	0xf3, 0x36, 0x72, 0x17, 0x38, 0xb6, 0x82, 0x81, 0xfb, 0xb0, 0xb8, 0x1b, 0xbc, 0xf2, 0xc9, 0xe1,
+	0x24, 0x8e, 0xc6, 0x23, 0xb7, 0xcb, 0xe4, 0x2a, 0x0b, 0x6f, 0x01, 0xec, 0x06, 0xaf, 0xf6, 0x8e,
+	0x82, 0x24, 0x1c, 0x8f, 0x5c, 0x87, 0x29, 0x28, 0x1c, 0xfc, 0x3e, 0xc7, 0xcd, 0x23, 0x04, 0x6d,
+	0x84, 0x52, 0x81, 0x6a, 0xef, 0x12, 0xa1, 0xbd, 0xa8, 0xd7, 0x2e, 0x14, 0xbc, 0x4f, 0x6d, 0x6e,
+	0x7c, 0x44, 0x8e, 0xdf, 0xe2, 0xf7, 0x28, 0x65, 0xc4, 0xbe, 0x20, 0x23, 0xed, 0x8b, 0x32, 0xd2,
+	0xa9, 0x65, 0xc4, 0x83, 0x6b, 0x0c, 0xfe, 0xce, 0x51, 0x10, 0x1d, 0x92, 0xd0, 0x5d, 0xe8, 0xa3,
+	0x41, 0xd7, 0x2f, 0xf1, 0xca, 0x59, 0xeb, 0x5e, 0x29, 0x6b, 0xce, 0x05, 0x59, 0xa3, 0xfe, 0xd9,
+	0xa7, 0x17, 0xfe, 0x81, 0xfb, 0x57, 0x79, 0xb2, 0x76, 0x16, 0x9b, 0x6a, 0xe7, 0x1e, 0x2c, 0x89,
+	0x72, 0x7b, 0x12, 0x4c, 0x49, 0xea, 0x5e, 0xeb, 0xb7, 0x06, 0x8e, 0x5f, 0x66, 0x96, 0x2b, 0x77,
+	0xe9, 0x32, 0x95, 0xfb, 0x35, 0xe8, 0x09, 0x82, 0x7d, 0xda, 0xd4, 0xed, 0xb1, 0x65, 0xab, 0xe5,
+	0x65, 0x4c, 0xe6, 0x57, 0x54, 0xbd, 0x7f, 0x22, 0x58, 0x2a, 0xb1, 0x68, 0x61, 0x50, 0x24, 0xac,
+	0x30, 0x1c, 0x9f, 0xfd, 0xc6, 0x03, 0x58, 0xde, 0x9f, 0x4c, 0xc9, 0x7e, 0xfc, 0xd1, 0xe4, 0x24,
+	0x07, 0x6f, 0x31, 0xf0, 0x55, 0x36, 0xfe, 0x2a, 0x2c, 0x4a, 0x56, 0xea, 0xb6, 0x18, 0x92, 0x35,
+	0x8e, 0x44, 0x0a, 0x58, 0x08, 0xaa, 0x22, 0xde, 0x86, 0x15, 0x49, 0xe6, 0x61, 0xf0, 0x1d, 0x7e,
+	0xa3, 0xba, 0x98, 0x07, 0x52, 0x53, 0xf7, 0x62, 0x58, 0xae, 0xf0, 0xb4, 0xb1, 0x6c, 0x82, 0x23,
+	0x8a, 0x8f, 0x47, 0x61, 0xfb, 0x92, 0x81, 0xdf, 0x83, 0x05, 0x4e, 0x08, 0xec, 0x2b, 0xdc, 0x7d,
+	0xae, 0x41, 0x71, 0x0b, 0x05, 0xef, 0x39, 0x74, 0x45, 0xc5, 0xe0, 0x1e, 0x58, 0xe3, 0x51, 0xbe,
+	0x99, 0xac, 0xf1, 0x88, 0x7a, 0x7e, 0x14, 0xa7, 0x19, 0xdb, 0x49, 0x8e, 0xcf, 0x7e, 0x63, 0x17,
+	0x16, 0xf6, 0x77, 0x9e, 0x31, 0x76, 0xab, 0x8f, 0x06, 0x8e, 0x2f, 0x48, 0x2a, 0xf9, 0x88, 0x04,
+	0x27, 0x93, 0xe8, 0xd0, 0xb5, 0x59, 0x7d, 0x09, 0xd2, 0xfb, 0x37, 0x82, 0x6b, 0xea, 0x87, 0xd7,
+	0x86, 0xf4, 0x3e, 0x5c, 0x1f, 0x91, 0x17, 0xc1, 0xfc, 0x38, 0x93, 0x09, 0xc8, 0x3d, 0xd7, 0x05,
+	0x9f, 0xfb, 0x13, 0x7d, 0x08, 0xd7, 0x77, 0xe2, 0x28, 0x9b, 0x44, 0xf3, 0x78, 0x9e, 0x7e, 0x77,
+	0x4e, 0x92, 0x49, 0xd1, 0x85, 0x6f, 0xf1, 0xd5, 0x65, 0xf1, 0x29, 0x33, 0x51, 0x5f, 0xe3, 0xbd,
+	0x46, 0xd0, 0x93, 0x86, 0xf7, 0x66, 0xe4, 0x40, 0x89, 0x0a, 0x15, 0x51, 0x6d, 0x40, 0x77, 0x34,
+	0x4f, 0x82, 0x6c, 0x12, 0x47, 0xae, 0xd5, 0x47, 0x83, 0x96, 0x5f, 0xd0, 0xf8, 0x5d, 0xe8, 0xf1,
+	0xaf, 0x50, 0x68, 0xb4, 0x98, 0x46, 0x85, 0x4b, 0x6d, 0xf8, 0x64, 0x76, 0x3c, 0x39, 0x08, 0x9e,
+	0xb0, 0xcc, 0x2e, 0xf9, 0x05, 0xed, 0xfd, 0xaf, 0x04, 0xc3, 0x98, 0xdc, 0x32, 0x0c, 0xeb, 0x42,
+	0x18, 0xd6, 0x85, 0x30, 0x2c, 0x15, 0x86, 0x5a, 0x71, 0xed, 0x0b, 0x2a, 0x0e, 0x7f, 0x1d, 0x96,
+	0xf6, 0xe6, 0xcf, 0xd3, 0x83, 0x64, 0x32, 0xcb, 0xd8, 0x0a, 0x7e, 0x58, 0xad, 0xf3, 0x15, 0xaa,
+	0x88, 0xad, 0x2b, 0x2b, 0x7b, 0xff, 0x40, 0x00, 0xd2, 0x6a, 0xad, 0x64, 0x37, 0xc1, 0xd9, 0xcb,
+	0x82, 0x84, 0x95, 0x4a, 0x1e, 0xa9, 0x64, 0xd0, 0x12, 0x7d, 0x10, 0x85, 0x4c, 0xc6, 0x63, 0x14,
+	0x24, 0x5d, 0x37, 0x22, 0xc7, 0x24, 0x23, 0xe1, 0x76, 0xc6, 0xa2, 0x6b, 0xf9, 0x92, 0x81, 0xbf,
+	0x00, 0x1d, 0xd6, 0xca, 0x45, 0x74, 0xcb, 0x39, 0x56, 0xd6, 0xde, 0x29, 0xc8, 0x5c, 0x4c, 0x8f,
+	0x8a, 0xfd, 0x64, 0x1e, 0x1d, 0x04, 0xdc, 0x50, 0x87, 0x7d, 0x4f, 0x95, 0xe5, 0x11, 0x70, 0x8a,
+	0x65, 0x35, 0xf4, 0x5b, 0xd0, 0x7d, 0xfa, 0x32, 0x22, 0x49, 0xb1, 0xab, 0x3f, 0xb0, 0x5c, 0xe4,
+	0x17, 0x3c, 0x3c, 0x80, 0x0e, 0xfb, 0x5d, 0xd9, 0xd7, 0xcc, 0x20, 0x13, 0xf8, 0xb9, 0xdc, 0xfb,
+	0x1e, 0xac, 0x54, 0x33, 0xa9, 0x2d, 0x0c, 0x0c, 0xf6, 0x6e, 0x1c, 0x8a, 0x8d, 0xc6, 0x7e, 0xd3,
+	0xd3, 0x62, 0x44, 0xd2, 0x6c, 0x12, 0x05, 0x59, 0xd1, 0x43, 0x1c, 0xbf, 0xc4, 0xf3, 0xee, 0x01,
+	0x48, 0xaf, 0x78, 0x1d, 0x3a, 0xf9, 0xe1, 0xc9, 0x63, 0xc9, 0x29, 0xef, 0x5b, 0xb0, 0xaa, 0xd9,
+	0x4e, 0x5a, 0x20, 0x6b, 0xd0, 0x66, 0x0a, 0x39, 0x12, 0x4e, 0x78, 0x67, 0xd0, 0x15, 0x27, 0x90,
+	0x09, 0xfe, 0xa3, 0x20, 0x3d, 0x2a, 0x3a, 0x54, 0x90, 0x1e, 0x51, 0x4b, 0xdb, 0xe1, 0x74, 0xc2,
+	0xcb, 0xb8, 0xeb, 0x73, 0x02, 0x7f, 0x19, 0xe0, 0x59, 0x32, 0x39, 0x99, 0x1c, 0x93, 0xc3, 0x62,
+	0xc7, 0xaf, 0xca, 0x33, 0xae, 0x90, 0xf9, 0x8a, 0x9a, 0x37, 0x86, 0xa5, 0x92, 0x90, 0xed, 0xa3,
+	0xbc, 0x91, 0xe5, 0x38, 0x0a, 0x9a, 0x96, 0x50, 0xa1, 0xc8, 0x00, 0xb5, 0x7d, 0xc9, 0xf0, 0x3e,
+	0x5d, 0x80, 0x85, 0x9d, 0x78, 0x3a, 0x0d, 0xa2, 0x10, 0xbf, 0x0b, 0x76, 0x76, 0x3a, 0xe3, 0x16,
+	0x7a, 0xe2, 0x64, 0xcc, 0x85, 0xf7, 0xf7, 0x4f, 0x67, 0xc4, 0x67, 0x72, 0xef, 0x3f, 0x1d, 0xb0,
+	0x29, 0x89, 0x6f, 0xc0, 0xf5, 0x9d, 0x84, 0x04, 0x19, 0xa1, 0x79, 0xcd, 0x15, 0x57, 0x10, 0x65,
+	0xf3, 0x1a, 0x55, 0xd9, 0x16, 0xbe, 0x05, 0x37, 0xb8, 0xb6, 0x80, 0x26, 0x44, 0x2d, 0x7c, 0x13,
+	0x56, 0x47, 0x49, 0x3c, 0xab, 0x0a, 0x6c, 0x7c, 0x1b, 0x6e, 0xf2, 0x35, 0xb2, 0x99, 0x08, 0x61,
+	0x9b, 0x1a, 0xa4, 0xab, 0xea, 0xa2, 0x0e, 0xbe, 0x0b, 0xb7, 0xf7, 0x48, 0x56, 0xeb, 0xcf, 0x42,
+	0x61, 0x81, 0x1a, 0xfe, 0x78, 0x16, 0x6a, 0x0d, 0x77, 0x29, 0x1c, 0xee, 0x95, 0xef, 0x68, 0x21,
+	0x70, 0x18, 0x4e, 0x16, 0x59, 0x59, 0x00, 0xb8, 0x0f, 0x9b, 0x7c, 0x45, 0xa5, 0xae, 0x84, 0xc6,
+	0x22, 0xde, 0x82, 0x0d, 0x0a, 0xd6, 0x20, 0xbf, 0x26, 0x73, 0x49, 0xbf, 0xac, 0x60, 0x2f, 0xe1,
+	0x55, 0x58, 0xa6, 0xcb, 0x54, 0x66, 0x8f, 0xea, 0x72, 0xf0, 0x2a, 0x7b, 0x99, 0xa2, 0xdb, 0x23,
+	0x59, 0xf1, 0x6d, 0x85, 0x60, 0x05, 0x63, 0xe8, 0xd1, 0x6c, 0x04, 0x59, 0x20, 0x78, 0xd7, 0xf1,
+	0x26, 0xb8, 0x7b, 0x24, 0x63, 0x45, 0x58, 0x5b, 0x81, 0xa5, 0x07, 0xf5, 0x13, 0xae, 0xe2, 0x3b,
+	0x70, 0x8b, 0x83, 0x54, 0x37, 0xb1, 0x10, 0xdf, 0xa0, 0x49, 0xa5, 0x60, 0x75, 0xc2, 0x75, 0x6a,
+	0xd2, 0x27, 0xd3, 0xf8, 0x84, 0x3c, 0x23, 0x12, 0xf4, 0x4d, 0x59, 0x15, 0x62, 0x2c, 0x14, 0x22,
+	0xb7, 0x5c, 0x30, 0xaa, 0xe8, 0x16, 0x15, 0x71, 0x7c, 0x55, 0xd1, 0x06, 0x15, 0xf1, 0x6f, 0x54,
+	0x35, 0x78, 0x5b, 0x8a, 0xaa, 0xab, 0x36, 0xf1, 0x3a, 0xe0, 0x3d, 0x92, 0x55, 0x97, 0xdc, 0xc1,
+	0x6b, 0xb0, 0xc2, 0x42, 0xa2, 0x4d, 0x45, 0x70, 0xb7, 0x68, 0x1e, 0xf2, 0x84, 0x52, 0xed, 0x7c,
+	0x9e, 0x10, 0xe2, 0xbb, 0xd8, 0x85, 0xb5, 0xed, 0x30, 0x94, 0x8d, 0x48, 0x48, 0xfa, 0x78, 0x03,
+	0xd6, 0xe9, 0xe1, 0x14, 0x1c, 0xd4, 0x20, 0xbc, 0xf3, 0x5e, 0xb7, 0x1b, 0xae, 0x9c, 0x9f, 0x9f,
+	0x9f, 0x5b, 0xde, 0x99, 0x66, 0x5f, 0x15, 0x53, 0x0f, 0x52, 0xa6, 0x1e, 0x0c, 0xb6, 0x1f, 0x44,
+	0x61, 0x7e, 0xa7, 0x60, 0xbf, 0x87, 0xdf, 0x86, 0x85, 0x83, 0x7c, 0xc9, 0x52, 0x69, 0x0b, 0xbb,
+	0xa4, 0x8f, 0x06, 0x8b, 0xc3, 0x9b, 0x39, 0xb3, 0xea, 0xc0, 0x17, 0xcb, 0xbc, 0x4f, 0x34, 0xfb,
+	0xb7, 0x76, 0x26, 0xac, 0x41, 0xfb, 0x61, 0x9c, 0x1c, 0xf0, 0x96, 0xd2, 0xf5, 0x39, 0xd1, 0xe0,
+	0xfc, 0x85, 0xea, 0xbc, 0x66, 0x5e, 0x3a, 0xff, 0x0b, 0x32, 0xb4, 0x09, 0x6d, 0xa3, 0xfd, 0x0a,
+	0x40, 0x69, 0x2c, 0x43, 0xc6, 0x71, 0x4b, 0xd1, 0x1b, 0x8e, 0x8c, 0x28, 0x0f, 0x99, 0x85, 0xdb,
+	0x6a, 0x8a, 0x2a, 0x30, 0x24, 0xd2, 0xa9, 0xb6, 0x69, 0xe9, 0x60, 0x0e, 0x3f, 0x30, 0x3a, 0x3c,
+	0xea, 0x23, 0x39, 0xe3, 0x69, 0xcc, 0x49, 0x77, 0xff, 0x42, 0xc6, 0x5e, 0xd8, 0xd8, 0xff, 0xab,
+	0x29, 0xb2, 0x2e, 0x93, 0x22, 0x3a, 0x92, 0xe4, 0xdd, 0x33, 0x3f, 0xaf, 0x04, 0x39, 0x7c, 0x68,
+	0x8c, 0x65, 0xc2, 0x62, 0xb9, 0xa3, 0x26, 0xaf, 0x06, 0x55, 0xc6, 0xf3, 0x6b, 0x64, 0x68, 0xdf,
+	0x8d, 0xd1, 0x88, 0xec, 0x5a, 0x4a, 0x76, 0xcd, 0x9f, 0xf3, 0xfb, 0xea, 0xe7, 0xd4, 0x3a, 0x93,
+	0x78, 0xfe, 0x80, 0x1a, 0xcf, 0x8c, 0x2b, 0xa3, 0xfa, 0x8e, 0x11, 0xd5, 0x0f, 0x18, 0xaa, 0x77,
+	0xf2, 0x21, 0xc9, 0xec, 0x52, 0x62, 0xfb, 0x3f, 0x32, 0x1e, 0x57, 0x57, 0xc5, 0x45, 0xbf, 0xec,
+	0x13, 0xf2, 0x92, 0xb1, 0xf3, 0x9b, 0x52, 0x4e, 0x96, 0xa6, 0x71, 0xbb, 0x72, 0x29, 0x50, 0xa7,
+	0xec, 0x76, 0x79, 0xd8, 0x57, 0x6b, 0xa5, 0x73, 0xd9, 0x5a, 0x39, 0x56, 0x6b, 0xc5, 0x10, 0x9a,
+	0x8c, 0xff, 0xef, 0x48, 0x7b, 0x22, 0x37, 0xc6, 0xbe, 0x55, 0xab, 0x7b, 0xa7, 0x54, 0xe1, 0x9b,
+	0xe0, 0x50, 0x2a, 0xcd, 0x82, 0xe9, 0x2c, 0x1f, 0xbb, 0x25, 0xa3, 0x61, 0xc7, 0x4e, 0xd5, 0x1d,
+	0xab, 0x01, 0x25, 0x51, 0xff, 0x0d, 0x69, 0xc7, 0x85, 0x37, 0x42, 0xcd, 0xbe, 0x43, 0xfe, 0xe2,
+	0xc3, 0xdf, 0x8b, 0x0a, 0xba, 0x01, 0x73, 0x54, 0xea, 0x32, 0x75, 0x48, 0x25, 0xcc, 0x8d, 0x93,
+	0xcc, 0x95, 0xcb, 0xad, 0x18, 0xa0, 0x5b, 0xca, 0x00, 0x3d, 0x7c, 0x6c, 0x84, 0x1a, 0x33, 0xa8,
+	0x9e, 0x9a, 0x5e, 0x3d, 0x12, 0x89, 0xf9, 0xf7, 0xa8, 0x69, 0xb6, 0xba, 0xf2, 0xc6, 0x1d, 0x1b,
+	0xb1, 0xcd, 0x18, 0xb6, 0xbe, 0x6c, 0x27, 0x17, 0x21, 0xfb, 0x2d, 0xd2, 0x4c, 0x75, 0x6f, 0x76,
+	0x63, 0x68, 0x38, 0x62, 0x7f, 0x58, 0x3f, 0xdf, 0x15, 0xb7, 0x12, 0x15, 0xa9, 0xcd, 0x94, 0xda,
+	0x43, 0xeb, 0x9b, 0x46, 0x47, 0x49, 0x1f, 0xc9, 0xc7, 0xa3, 0x8a, 0x29, 0xe9, 0xe6, 0x4c, 0x33,
+	0xa5, 0x5e, 0x36, 0xf6, 0x86, 0x28, 0x53, 0x35, 0xca, 0x9a, 0x03, 0xe9, 0xfe, 0xaf, 0x48, 0x3b,
+	0x0e, 0xd3, 0x72, 0xa0, 0xfa, 0x91, 0x44, 0x51, 0xd0, 0xa5, 0x52, 0xb1, 0x9a, 0xee, 0x51, 0xad,
+	0xca, 0x3d, 0xaa, 0x61, 0xef, 0x65, 0xea, 0xde, 0xd3, 0x00, 0x92, 0x88, 0xe3, 0xea, 0x98, 0x8e,
+	0xb7, 0xf8, 0x03, 0x3f, 0xc3, 0xb9, 0x38, 0x04, 0xf9, 0xe8, 0xe8, 0x33, 0xfe, 0xf0, 0x1b, 0x46,
+	0xaf, 0x73, 0x75, 0x14, 0x2a, 0x5b, 0x95, 0x0e, 0x7f, 0x87, 0xcc, 0x97, 0x80, 0xc6, 0x3c, 0x15,
+	0x95, 0x69, 0xa9, 0x95, 0xf9, 0xa1, 0x11, 0xcd, 0x09, 0x43, 0xb3, 0x55, 0xa0, 0xd1, 0x7a, 0x94,
+	0xb8, 0x4e, 0x35, 0xb7, 0x8f, 0xcb, 0xbc, 0x02, 0x36, 0x54, 0xcd, 0xcb, 0x7a, 0xd5, 0x68, 0xc7,
+	0xcf, 0xff, 0xa2, 0x86, 0x2b, 0x8e, 0xf1, 0x0d, 0xcb, 0x54, 0x33, 0xe5, 0x6e, 0xde, 0xaa, 0x75,
+	0x73, 0xf1, 0xcc, 0x61, 0x37, 0x3c, 0x73, 0xb4, 0xeb, 0xcf, 0x1c, 0xc3, 0x47, 0xc6, 0x38, 0x4f,
+	0x59, 0x9c, 0x77, 0xd5, 0x1e, 0xa0, 0x09, 0xa4, 0xd4, 0xef, 0x4d, 0x77, 0xb6, 0xb7, 0x1d, 0x6d,
+	0xc3, 0x34, 0xf0, 0x23, 0x75, 0x1a, 0x30, 0xc0, 0x29, 0x95, 0x47, 0xed, 0x26, 0x59, 0x94, 0x07,
+	0x92, 0xe5, 0xb1, 0x1d, 0x86, 0x89, 0x28, 0x0f, 0xfa, 0xbb, 0xa1, 0x3c, 0x3e, 0x51, 0xcb, 0xa3,
+	0x66, 0x5c, 0x77, 0x3b, 0xa9, 0x5c, 0x15, 0x69, 0x62, 0x1e, 0xed, 0xef, 0x3f, 0x63, 0x3e, 0xf3,
+	0xed, 0x22, 0xe8, 0xfc, 0x71, 0x5a, 0x81, 0x23, 0xc8, 0xe2, 0x02, 0xd7, 0x52, 0x2e, 0x70, 0xe6,
+	0x71, 0xf6, 0xc7, 0xf5, 0xdb, 0x49, 0x05, 0x46, 0xe9, 0xe8, 0xd1, 0xdf, 0x9e, 0x3f, 0x1f, 0xd2,
+	0x06, 0x54, 0x67, 0xfa, 0x3b, 0x93, 0x16, 0xd5, 0x1f, 0x91, 0xe1, 0xe2, 0x7e, 0xf5, 0x47, 0x7e,
+	0x4b, 0x79, 0xe4, 0x6f, 0x40, 0xf7, 0x13, 0x15, 0x9d, 0xd6, 0xb5, 0x7a, 0xa3, 0xd3, 0x3f, 0x1d,
+	0x54, 0xc1, 0x35, 0xb8, 0xfb, 0xa9, 0xea, 0x4e, 0x6b, 0x4c, 0xba, 0x8b, 0x0c, 0xcf, 0x11, 0x35,
+	0x77, 0x0f, 0x8c, 0xee, 0xce, 0x51, 0xdd, 0x9f, 0x31, 0xbc, 0x87, 0x74, 0x76, 0x4c, 0x67, 0x71,
+	0x94, 0x12, 0xea, 0xe2, 0xe9, 0x63, 0xe6, 0xa2, 0xeb, 0x5b, 0x4f, 0x1f, 0xd3, 0x8e, 0xfe, 0x20,
+	0x49, 0xe2, 0x84, 0xdd, 0xa1, 0x1d, 0x9f, 0x13, 0xf2, 0x4f, 0xcb, 0x16, 0xdb, 0x57, 0x9c, 0xf0,
+	0xfe, 0x84, 0x74, 0x8f, 0x25, 0x6f, 0x71, 0x07, 0x98, 0x0f, 0xd3, 0x9f, 0xf1, 0x78, 0xdd, 0xe2,
+	0x24, 0x31, 0x26, 0x37, 0xac, 0x3f, 0xdc, 0xd4, 0xf2, 0x6a, 0xee, 0x07, 0x3f, 0xe7, 0x7e, 0xd6,
+	0x95, 0x8e, 0xa4, 0x18, 0x92, 0x5e, 0x7e, 0x83, 0x1a, 0x5e, 0x82, 0x6a, 0x35, 0xad, 0xfc, 0x15,
+	0xc5, 0x0f, 0x4e, 0x41, 0x36, 0xcc, 0x9c, 0xbf, 0x40, 0x6a, 0x47, 0x37, 0xfa, 0x92, 0x90, 0x5e,
+	0x23, 0xfd, 0xeb, 0x53, 0x0d, 0x8d, 0x7c, 0x1d, 0xb7, 0xd4, 0xd7, 0xf1, 0x86, 0xe2, 0x7e, 0xcd,
+	0xb1, 0x6c, 0x70, 0xae, 0xce, 0x89, 0x84, 0xf1, 0x67, 0x64, 0x7a, 0xea, 0xaa, 0x01, 0x51, 0x0b,
+	0xc7, 0x32, 0x17, 0x4e, 0xab, 0xdc, 0x90, 0xcc, 0xa7, 0xc9, 0x2f, 0x39, 0xcc, 0x4d, 0xd1, 0xcd,
+	0x75, 0x20, 0x0a, 0xa0, 0x9f, 0x0d, 0x00, 0xd1, 0x49, 0x19, 0x85, 0x61, 0x21, 0x00, 0x00,
 }
@@ -37,6 +37,9 @@ type raftState struct {
 	addr      string
 	logger    hclog.Logger
 	path      string
+
+	// heartbeats are reset when the leadership changes.
+	heartbeats *DataNodeHeartbeats
 }
 
 func newRaftState(c *ServerConfig, addr string) *raftState {
@@ -134,6 +137,9 @@ func (r *raftState) logLeaderChanges() {
 		case <-r.closing:
 			return
 		case <-r.raft.LeaderCh():
+			if r.heartbeats != nil {
+				r.heartbeats.Reset()
+			}
 			peers, err := r.peers()
 			if err != nil {
 				r.logger.Info("failed to lookup peers", "error", err)
@@ -33,10 +33,21 @@ const (
 	// maxRetries is the maximum number of attemps to make before returning
 	// a failure to the caller
 	maxRetries = 10
+
+	// leaderTimeout is the timeout of a request for the meta-server leader.
+	leaderTimeout = 5 * time.Second
+
+	// dataNodeStatusTTL is the time after which the data node statuses
+	// received from the meta leader are no longer used.
+	dataNodeStatusTTL = 30 * time.Second
 )
 
 var _ MetaClient = &RemoteClient{}
 
+// errDeltaUnavailable is returned when the changes of the data since an index
+// cannot be applied from a delta.
+var errDeltaUnavailable = errors.New("meta data delta unavailable")
+
 type RemoteClient struct {
 	tls    bool
 	logger *zap.Logger
@@ -48,6 +59,13 @@ type RemoteClient struct {
 	closing     chan struct{}
 	cacheData   *Data
 
+	// Data node statuses received from the meta leader.
+	statuses   []DataNodeStatus
+	statusesAt time.Time
+
+	// Index of the meta server the last heartbeat was sent to.
+	heartbeatServer int
+
 	// Authentication cache.
 	authCache map[string]authUser
 }
@@ -130,7 +148,47 @@ func (c *RemoteClient) Ping(checkAllMetaServers bool) error {
 		return err
 	}
 	return fmt.Errorf(string(b))
-} // AcquireLease attempts to acquire the specified lease.
+}
+
+// Leader returns the raft address of the meta-server leader. Each meta-server
+// is asked in turn until one knows the leader, so that a meta-server that is
+// down or partitioned does not hide the leader. It returns an empty string if
+// there is no leader.
+func (c *RemoteClient) Leader() (string, error) {
+	c.mu.RLock()
+	servers := append([]string(nil), c.metaServers...)
+	c.mu.RUnlock()
+
+	var err error
+	for _, server := range servers {
+		var leader string
+		if leader, err = c.leader(server); err == nil && leader != "" {
+			return leader, nil
+		}
+	}
+	return "", err
+}
+
+// leader returns the raft address of the meta-server leader as seen by server.
+func (c *RemoteClient) leader(server string) (string, error) {
+	client := http.Client{Timeout: leaderTimeout}
+	resp, err := client.Get(c.url(server) + "/ping")
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("meta service: %s", bytes.TrimSpace(b))
+	}
+	return string(b), nil
+}
+
+// AcquireLease attempts to acquire the specified lease.
 // A lease is a logical concept that can be used by anything that needs to limit
 // execution to a single node.  E.g., the CQ service on all nodes may ask for
 // the "ContinuousQuery" lease. Only the node that acquires it will run CQs.
@@ -285,6 +343,126 @@ func (c *RemoteClient) DataNodes() ([]NodeInfo, error) {
 	return c.data().DataNodes, nil
 }
 
+// Heartbeat sends the heartbeat of a data node to the meta leader and caches
+// the statuses of the data nodes it returns. The heartbeat is sent to the meta
+// server that accepted the last one, which redirects it to the leader, and
+// then to each other meta server in turn until one accepts it.
+func (c *RemoteClient) Heartbeat(hb *Heartbeat) error {
+	b, err := json.Marshal(hb)
+	if err != nil {
+		return err
+	}
+
+	c.mu.RLock()
+	servers := append([]string(nil), c.metaServers...)
+	first := c.heartbeatServer
+	c.mu.RUnlock()
+
+	for i := range servers {
+		n := (first + i) % len(servers)
+
+		var statuses []DataNodeStatus
+		if statuses, err = c.heartbeat(servers[n], b); err != nil {
+			continue
+		}
+
+		c.mu.Lock()
+		c.heartbeatServer = n
+		c.mu.Unlock()
+		c.setDataNodeStatuses(statuses)
+		return nil
+	}
+	return err
+}
+
+// heartbeat sends an encoded heartbeat to server and returns the statuses of
+// the data nodes.
+func (c *RemoteClient) heartbeat(server string, b []byte) ([]DataNodeStatus, error) {
+	resp, err := http.Post(c.url(server)+"/heartbeat", "application/json", bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return readDataNodeStatuses(resp)
+}
+
+// DataNodeStatuses returns the statuses of the data nodes. The statuses
+// received with the last heartbeat are returned if they are recent, otherwise
+// they are requested from the meta leader.
+func (c *RemoteClient) DataNodeStatuses() ([]DataNodeStatus, error) {
+	c.mu.RLock()
+	statuses := c.statuses
+	fresh := time.Since(c.statusesAt) < dataNodeStatusTTL
+	server := c.metaServers[0]
+	c.mu.RUnlock()
+
+	if statuses != nil && fresh {
+		return append([]DataNodeStatus(nil), statuses...), nil
+	}
+
+	resp, err := http.Get(c.url(server) + "/data-node-status")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	statuses, err = readDataNodeStatuses(resp)
+	if err != nil {
+		return nil, err
+	}
+	c.setDataNodeStatuses(statuses)
+	return append([]DataNodeStatus(nil), statuses...), nil
+}
+
+// DataNodeDown returns true if the data node was down according to the last
+// statuses received from the meta leader. A node is never reported down when
+// the statuses are too old, as the meta leader may be unreachable from this
+// node only.
+func (c *RemoteClient) DataNodeDown(id uint64) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if time.Since(c.statusesAt) >= dataNodeStatusTTL {
+		return false
+	}
+	for _, s := range c.statuses {
+		if s.ID == id {
+			return s.State == NodeStateDown
+		}
+	}
+	return false
+}
+
+func (c *RemoteClient) setDataNodeStatuses(statuses []DataNodeStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.statuses = statuses
+	c.statusesAt = time.Now()
+}
+
+// readDataNodeStatuses reads the data node statuses of a meta service response.
+func readDataNodeStatuses(resp *http.Response) ([]DataNodeStatus, error) {
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusServiceUnavailable:
+		return nil, ErrServiceUnavailable
+	default:
+		return nil, fmt.Errorf("meta service: %s", bytes.TrimSpace(b))
+	}
+
+	statuses := []DataNodeStatus{}
+	if err := json.Unmarshal(b, &statuses); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
 // CreateDataNode will create a new data node in the metastore
 func (c *RemoteClient) CreateDataNode(httpAddr, tcpAddr string) (*NodeInfo, error) {
 	cmd := &internal.CreateDataNodeCommand{
@@ -339,6 +517,40 @@ func (c *RemoteClient) DeleteDataNode(id uint64) error {
 	return c.retryUntilExec(internal.Command_DeleteDataNodeCommand, internal.E_DeleteDataNodeCommand_Command, cmd)
 }
 
+// ReplaceDataNode binds a data node to new addresses, keeping its ID and the
+// shards it owns.
+func (c *RemoteClient) ReplaceDataNode(id uint64, httpAddr, tcpAddr string) (*NodeInfo, error) {
+	cmd := &internal.ReplaceDataNodeCommand{
+		ID:       proto.Uint64(id),
+		HTTPAddr: proto.String(httpAddr),
+		TCPAddr:  proto.String(tcpAddr),
+	}
+
+	if err := c.retryUntilExec(internal.Command_ReplaceDataNodeCommand, internal.E_ReplaceDataNodeCommand_Command, cmd); err != nil {
+		return nil, err
+	}
+
+	n, err := c.DataNode(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.nodeID = n.ID
+
+	return n, nil
+}
+
+// SetDataNodeLeaving marks a data node as leaving the cluster, or not. The
+// leaving node moves its shards to the other nodes and removes itself.
+func (c *RemoteClient) SetDataNodeLeaving(id uint64, leaving bool) error {
+	cmd := &internal.SetDataNodeLeavingCommand{
+		ID:      proto.Uint64(id),
+		Leaving: proto.Bool(leaving),
+	}
+
+	return c.retryUntilExec(internal.Command_SetDataNodeLeavingCommand, internal.E_SetDataNodeLeavingCommand_Command, cmd)
+}
+
 // MetaNodes returns the meta nodes' info.
 func (c *RemoteClient) MetaNodes() ([]NodeInfo, error) {
 	return c.data().MetaNodes, nil
@@ -809,6 +1021,16 @@ func (c *RemoteClient) DropShard(id uint64) error {
 	return c.retryUntilExec(internal.Command_DropShardCommand, internal.E_DropShardCommand_Command, cmd)
 }
 
+// AddShardOwner adds a data node to the owners of a shard.
+func (c *RemoteClient) AddShardOwner(id, nodeID uint64) error {
+	cmd := &internal.AddShardOwnerCommand{
+		ID:     proto.Uint64(id),
+		NodeID: proto.Uint64(nodeID),
+	}
+
+	return c.retryUntilExec(internal.Command_AddShardOwnerCommand, internal.E_AddShardOwnerCommand_Command, cmd)
+}
+
 func (c *RemoteClient) TruncateRegions(t time.Time) error {
 
 	return nil
@@ -1217,6 +1439,59 @@ func (c *RemoteClient) getSnapshot(server string, index uint64) (*Data, error) {
 	return data, nil
 }
 
+// getDelta long polls the changes of the data since index and applies them to
+// the cached data, which must be at index.
+func (c *RemoteClient) getDelta(server string, index uint64) (*Data, error) {
+	resp, err := http.Get(c.url(server) + fmt.Sprintf("/delta?index=%d", index))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusGone, http.StatusNotFound:
+		// The changes are no longer known, or the meta server does not
+		// send deltas.
+		return nil, errDeltaUnavailable
+	default:
+		return nil, fmt.Errorf("meta server returned non-200: %s", resp.Status)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	var pb internal.DataDelta
+	if err := proto.Unmarshal(b, &pb); err != nil {
+		return nil, err
+	}
+
+	current := c.data()
+	if current.Index != index {
+		return nil, errDeltaUnavailable
+	}
+	data, err := current.applyDelta(&pb)
+	if err != nil {
+		c.logger.Info("failure applying delta", zap.String("server", server), zap.Error(err))
+		return nil, errDeltaUnavailable
+	}
+	return data, nil
+}
+
+// getUpdate long polls the data changed since index. It applies the changes
+// to the cached data if the meta server can send them and otherwise gets a
+// snapshot.
+func (c *RemoteClient) getUpdate(server string, index uint64) (*Data, error) {
+	if index > 0 {
+		data, err := c.getDelta(server, index)
+		if err != errDeltaUnavailable {
+			return data, err
+		}
+	}
+	return c.getSnapshot(server, index)
+}
+
 func (c *RemoteClient) retryUntilSnapshot(idx uint64) *Data {
 	currentServer := 0
 	for {
@@ -1238,7 +1513,7 @@ func (c *RemoteClient) retryUntilSnapshot(idx uint64) *Data {
 		server := c.metaServers[currentServer]
 		c.mu.RUnlock()
 
-		data, err := c.getSnapshot(server, idx)
+		data, err := c.getUpdate(server, idx)
 
 		if err == nil {
 			return data
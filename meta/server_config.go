@@ -32,6 +32,14 @@ const (
 
 	// DefaultLeaseDuration is the default duration for leases.
 	DefaultLeaseDuration = 60 * time.Second
+
+	// DefaultDataNodeSuspectTimeout is the default time without heartbeats
+	// after which a data node is suspect.
+	DefaultDataNodeSuspectTimeout = 10 * time.Second
+
+	// DefaultDataNodeDownTimeout is the default time without heartbeats after
+	// which a data node is down.
+	DefaultDataNodeDownTimeout = 30 * time.Second
 )
 
 type ServerConfig struct {
@@ -55,6 +63,9 @@ type ServerConfig struct {
 	ClusterTracing     bool          `toml:"cluster-tracing"`
 	LeaseDuration      toml.Duration `toml:"lease-duration"`
 
+	DataNodeSuspectTimeout toml.Duration `toml:"data-node-suspect-timeout"`
+	DataNodeDownTimeout    toml.Duration `toml:"data-node-down-timeout"`
+
 	TLS *tls.Config `toml:"-"`
 }
 
@@ -68,6 +79,9 @@ func NewServerConfig() *ServerConfig {
 		LeaderLeaseTimeout: toml.Duration(DefaultLeaderLeaseTimeout),
 		CommitTimeout:      toml.Duration(DefaultCommitTimeout),
 		LeaseDuration:      toml.Duration(DefaultLeaseDuration),
+
+		DataNodeSuspectTimeout: toml.Duration(DefaultDataNodeSuspectTimeout),
+		DataNodeDownTimeout:    toml.Duration(DefaultDataNodeDownTimeout),
 	}
 
 	return sc
@@ -106,5 +120,8 @@ func (c ServerConfig) Diagnostics() (*diagnostics.Diagnostics, error) {
 		"leader-lease-timeout": c.LeaderLeaseTimeout,
 		"commit-timeout":       c.CommitTimeout,
 		"cluster-tracing":      c.ClusterTracing,
+
+		"data-node-suspect-timeout": c.DataNodeSuspectTimeout,
+		"data-node-down-timeout":    c.DataNodeDownTimeout,
 	}), nil
 }
@@ -32,6 +32,10 @@ const (
 	raftListenerStartupTimeout = time.Second
 )
 
+// maxDataChanges is the number of changes to the data kept to send deltas to
+// the clients. Clients at an older index receive a snapshot.
+const maxDataChanges = 1024
+
 type store struct {
 	mu      sync.RWMutex
 	closing chan struct{}
@@ -48,6 +52,15 @@ type store struct {
 	httpAddr string
 
 	node *cnosdb.Node
+
+	// changes are the changes of the commands applied after changesIndex,
+	// in order.
+	changes      []dataChange
+	changesIndex uint64
+
+	// heartbeats are the heartbeats received from the data nodes while
+	// the store is the leader.
+	heartbeats *DataNodeHeartbeats
 }
 
 // newStore will create a new metastore with the passed in config
@@ -63,6 +76,8 @@ func newStore(c *Config, httpAddr, raftAddr string) *store {
 		httpAddr:    httpAddr,
 		raftAddr:    raftAddr,
 	}
+	s.changesIndex = s.data.Index
+	s.heartbeats = NewDataNodeHeartbeats(time.Duration(c.HTTPD.DataNodeSuspectTimeout), time.Duration(c.HTTPD.DataNodeDownTimeout))
 	if c.HTTPD.LoggingEnabled {
 		s.logger = log.New(os.Stderr, "[metastore] ", log.LstdFlags)
 	} else {
@@ -179,6 +194,7 @@ func (s *store) openRaft(raftln net.Listener) error {
 	defer s.mu.Unlock()
 	rs := newRaftState(s.config.HTTPD, s.raftAddr)
 	rs.path = s.path
+	rs.heartbeats = s.heartbeats
 
 	if err := rs.open(s, raftln); err != nil {
 		return err
@@ -208,6 +224,42 @@ func (s *store) snapshot() (*Data, error) {
 	return s.data.Clone(), nil
 }
 
+// delta returns the change of the data since index. It returns false if the
+// changes since index are no longer known.
+func (s *store) delta(index uint64) (*internal.DataDelta, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if index < s.changesIndex || index > s.data.Index {
+		return nil, false
+	}
+
+	var c dataChange
+	for i := len(s.changes) - 1; i >= 0 && s.changes[i].index > index; i-- {
+		c.merge(&s.changes[i])
+	}
+	if c.all {
+		return nil, false
+	}
+	return s.data.marshalDelta(&c), true
+}
+
+// recordChange records the change of a command. The store must be locked.
+func (s *store) recordChange(c dataChange) {
+	s.changes = append(s.changes, c)
+	if len(s.changes) > maxDataChanges {
+		s.changesIndex = s.changes[0].index
+		s.changes = append(s.changes[:0], s.changes[1:]...)
+	}
+}
+
+// resetChanges forgets the recorded changes, when the data is replaced. The
+// store must be locked.
+func (s *store) resetChanges() {
+	s.changes = nil
+	s.changesIndex = s.data.Index
+}
+
 // afterIndex returns a channel that will be closed to signal
 // the caller when an updated snapshot is available.
 func (s *store) afterIndex(index uint64) <-chan struct{} {
@@ -290,6 +342,22 @@ func (s *store) leaderHTTP() string {
 	return ""
 }
 
+// heartbeat records the heartbeat of a data node and returns the statuses of
+// all data nodes.
+func (s *store) heartbeat(hb *Heartbeat) []DataNodeStatus {
+	s.heartbeats.Record(hb)
+	return s.dataNodeStatuses()
+}
+
+// dataNodeStatuses returns the statuses of the data nodes.
+func (s *store) dataNodeStatuses() []DataNodeStatus {
+	s.mu.RLock()
+	nodes := make([]NodeInfo, len(s.data.DataNodes))
+	copy(nodes, s.data.DataNodes)
+	s.mu.RUnlock()
+	return s.heartbeats.Statuses(nodes)
+}
+
 // metaServersHTTP will return the HTTP bind addresses of all meta-servers in the cluster
 func (s *store) metaServersHTTP() []string {
 	s.mu.RLock()
@@ -340,9 +408,39 @@ func (s *store) apply(b []byte) error {
 	if s.raftState == nil {
 		return fmt.Errorf("store not open")
 	}
+	if err := s.checkCommand(b); err != nil {
+		return err
+	}
 	return s.raftState.apply(b)
 }
 
+// checkCommand rejects the commands that cannot be applied given the state of
+// the data nodes known to the leader only. A data node can only be replaced
+// once it stopped sending heartbeats, so that two hosts never share its ID.
+func (s *store) checkCommand(b []byte) error {
+	if !s.isLeader() {
+		// The command is redirected to the leader.
+		return nil
+	}
+
+	var cmd internal.Command
+	if err := proto.Unmarshal(b, &cmd); err != nil {
+		return err
+	}
+	if cmd.GetType() != internal.Command_ReplaceDataNodeCommand {
+		return nil
+	}
+
+	ext, _ := proto.GetExtension(&cmd, internal.E_ReplaceDataNodeCommand_Command)
+	id := ext.(*internal.ReplaceDataNodeCommand).GetID()
+	for _, status := range s.dataNodeStatuses() {
+		if status.ID == id && status.State != NodeStateDown {
+			return ErrNodeNotDown
+		}
+	}
+	return nil
+}
+
 // joinCluster
 func (s *store) joinCluster(peers []string) (*NodeInfo, error) {
 	if len(peers) > 0 {
@@ -57,6 +57,8 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applyCreateRegionCommand(&cmd)
 		case internal.Command_DeleteRegionCommand:
 			return fsm.applyDeleteRegionCommand(&cmd)
+		case internal.Command_DropShardCommand:
+			return fsm.applyDropShardCommand(&cmd)
 		case internal.Command_CreateContinuousQueryCommand:
 			return fsm.applyCreateContinuousQueryCommand(&cmd)
 		case internal.Command_DropContinuousQueryCommand:
@@ -89,6 +91,12 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 			return fsm.applyCreateDataNodeCommand(&cmd)
 		case internal.Command_DeleteDataNodeCommand:
 			return fsm.applyDeleteDataNodeCommand(&cmd)
+		case internal.Command_SetDataNodeLeavingCommand:
+			return fsm.applySetDataNodeLeavingCommand(&cmd)
+		case internal.Command_AddShardOwnerCommand:
+			return fsm.applyAddShardOwnerCommand(&cmd)
+		case internal.Command_ReplaceDataNodeCommand:
+			return fsm.applyReplaceDataNodeCommand(&cmd)
 		default:
 			panic(fmt.Errorf("cannot apply command: %x", l.Data))
 		}
@@ -98,6 +106,9 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 	fsm.data.Term = l.Term
 	fsm.data.Index = l.Index
 
+	// Record the change to send deltas to the clients.
+	s.recordChange(commandChange(&cmd, fsm.data, l.Index))
+
 	// signal that the data changed
 	close(s.dataChanged)
 	s.dataChanged = make(chan struct{})
@@ -105,6 +116,166 @@ func (fsm *storeFSM) Apply(l *raft.Log) interface{} {
 	return err
 }
 
+// dataChange describes the parts of the data changed by commands.
+type dataChange struct {
+	index     uint64
+	all       bool
+	nodes     bool
+	users     bool
+	databases map[string]struct{}
+
+	// timeToLives holds the names of the changed time to lives by database,
+	// for the commands that change a single time to live.
+	timeToLives map[string]map[string]struct{}
+
+	// regions holds the IDs of the changed regions by database and time to
+	// live, for the commands that change a single region.
+	regions map[string]map[string]map[uint64]struct{}
+}
+
+// merge adds the parts changed by other to c.
+func (c *dataChange) merge(other *dataChange) {
+	c.all = c.all || other.all
+	c.nodes = c.nodes || other.nodes
+	c.users = c.users || other.users
+	for name := range other.databases {
+		c.addDatabase(name)
+	}
+	for database, ttls := range other.timeToLives {
+		for name := range ttls {
+			c.addTimeToLive(database, name)
+		}
+	}
+	for database, ttls := range other.regions {
+		for name, ids := range ttls {
+			for id := range ids {
+				c.addRegion(database, name, id)
+			}
+		}
+	}
+}
+
+func (c *dataChange) addDatabase(name string) {
+	if c.databases == nil {
+		c.databases = make(map[string]struct{})
+	}
+	c.databases[name] = struct{}{}
+}
+
+func (c *dataChange) addTimeToLive(database, name string) {
+	if c.timeToLives == nil {
+		c.timeToLives = make(map[string]map[string]struct{})
+	}
+	if c.timeToLives[database] == nil {
+		c.timeToLives[database] = make(map[string]struct{})
+	}
+	c.timeToLives[database][name] = struct{}{}
+}
+
+func (c *dataChange) addRegion(database, ttl string, id uint64) {
+	if c.regions == nil {
+		c.regions = make(map[string]map[string]map[uint64]struct{})
+	}
+	if c.regions[database] == nil {
+		c.regions[database] = make(map[string]map[uint64]struct{})
+	}
+	if c.regions[database][ttl] == nil {
+		c.regions[database][ttl] = make(map[uint64]struct{})
+	}
+	c.regions[database][ttl][id] = struct{}{}
+}
+
+// databaseCommands are the commands changing the database they name.
+var databaseCommands = map[internal.Command_Type]*proto.ExtensionDesc{
+	internal.Command_CreateTimeToLiveCommand:      internal.E_CreateTimeToLiveCommand_Command,
+	internal.Command_DropTimeToLiveCommand:        internal.E_DropTimeToLiveCommand_Command,
+	internal.Command_SetDefaultTimeToLiveCommand:  internal.E_SetDefaultTimeToLiveCommand_Command,
+	internal.Command_UpdateTimeToLiveCommand:      internal.E_UpdateTimeToLiveCommand_Command,
+	internal.Command_CreateContinuousQueryCommand: internal.E_CreateContinuousQueryCommand_Command,
+	internal.Command_DropContinuousQueryCommand:   internal.E_DropContinuousQueryCommand_Command,
+}
+
+// timeToLiveCommands are the commands changing only the time to live they
+// name.
+var timeToLiveCommands = map[internal.Command_Type]*proto.ExtensionDesc{
+	internal.Command_CreateSubscriptionCommand: internal.E_CreateSubscriptionCommand_Command,
+	internal.Command_DropSubscriptionCommand:   internal.E_DropSubscriptionCommand_Command,
+}
+
+// commandChange returns the parts of the data changed by a command applied
+// at index, data being the data once the command is applied.
+func commandChange(cmd *internal.Command, data *Data, index uint64) dataChange {
+	c := dataChange{index: index}
+
+	switch cmd.GetType() {
+	case internal.Command_CreateRegionCommand:
+		// Regions are created frequently, so only the region holding the
+		// timestamp is sent, whether it was created or already existed.
+		ext, _ := proto.GetExtension(cmd, internal.E_CreateRegionCommand_Command)
+		v := ext.(*internal.CreateRegionCommand)
+		if rgi, err := data.RegionByTimestamp(v.GetDatabase(), v.GetTimeToLive(), time.Unix(0, v.GetTimestamp())); err == nil && rgi != nil {
+			c.addRegion(v.GetDatabase(), v.GetTimeToLive(), rgi.ID)
+		}
+	case internal.Command_DeleteRegionCommand:
+		ext, _ := proto.GetExtension(cmd, internal.E_DeleteRegionCommand_Command)
+		v := ext.(*internal.DeleteRegionCommand)
+		c.addRegion(v.GetDatabase(), v.GetTimeToLive(), v.GetRegionID())
+	case internal.Command_RemovePeerCommand,
+		internal.Command_UpdateNodeCommand,
+		internal.Command_DeleteNodeCommand:
+		// The data does not change.
+	case internal.Command_CreateMetaNodeCommand,
+		internal.Command_SetMetaNodeCommand,
+		internal.Command_DeleteMetaNodeCommand,
+		internal.Command_CreateDataNodeCommand,
+		internal.Command_SetDataNodeLeavingCommand,
+		internal.Command_ReplaceDataNodeCommand:
+		c.nodes = true
+	case internal.Command_CreateUserCommand,
+		internal.Command_DropUserCommand,
+		internal.Command_UpdateUserCommand,
+		internal.Command_SetPrivilegeCommand,
+		internal.Command_SetAdminPrivilegeCommand:
+		c.users = true
+	case internal.Command_CreateDatabaseCommand:
+		ext, _ := proto.GetExtension(cmd, internal.E_CreateDatabaseCommand_Command)
+		c.addDatabase(ext.(*internal.CreateDatabaseCommand).GetName())
+	case internal.Command_DropDatabaseCommand:
+		// Dropping a database also removes the privileges on it.
+		ext, _ := proto.GetExtension(cmd, internal.E_DropDatabaseCommand_Command)
+		c.addDatabase(ext.(*internal.DropDatabaseCommand).GetName())
+		c.users = true
+	default:
+		if desc, ok := timeToLiveCommands[cmd.GetType()]; ok {
+			ext, _ := proto.GetExtension(cmd, desc)
+			v := ext.(interface {
+				GetDatabase() string
+				GetTimeToLive() string
+			})
+			c.addTimeToLive(v.GetDatabase(), v.GetTimeToLive())
+			break
+		}
+
+		desc, ok := databaseCommands[cmd.GetType()]
+		if !ok {
+			// The command may change any part of the data, such as
+			// SetDataCommand or DeleteDataNodeCommand which reassigns
+			// the shards of the node, or AddShardOwnerCommand which
+			// does not name the database of the shard.
+			c.all = true
+			break
+		}
+		ext, _ := proto.GetExtension(cmd, desc)
+		v, ok := ext.(interface{ GetDatabase() string })
+		if !ok {
+			c.all = true
+			break
+		}
+		c.addDatabase(v.GetDatabase())
+	}
+	return c
+}
+
 func (fsm *storeFSM) applyRemovePeerCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_RemovePeerCommand_Command)
 	v := ext.(*internal.RemovePeerCommand)
@@ -361,6 +532,18 @@ func (fsm *storeFSM) applyDeleteRegionCommand(cmd *internal.Command) interface{}
 	return nil
 }
 
+func (fsm *storeFSM) applyDropShardCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_DropShardCommand_Command)
+	v := ext.(*internal.DropShardCommand)
+
+	// Copy data and update.
+	other := fsm.data.Clone()
+	other.DropShard(v.GetID())
+	fsm.data = other
+
+	return nil
+}
+
 func (fsm *storeFSM) applyCreateContinuousQueryCommand(cmd *internal.Command) interface{} {
 	ext, _ := proto.GetExtension(cmd, internal.E_CreateContinuousQueryCommand_Command)
 	v := ext.(*internal.CreateContinuousQueryCommand)
@@ -579,6 +762,42 @@ func (fsm *storeFSM) applyDeleteDataNodeCommand(cmd *internal.Command) interface
 	return nil
 }
 
+func (fsm *storeFSM) applySetDataNodeLeavingCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_SetDataNodeLeavingCommand_Command)
+	v := ext.(*internal.SetDataNodeLeavingCommand)
+
+	other := fsm.data.Clone()
+	if err := other.SetDataNodeLeaving(v.GetID(), v.GetLeaving()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyAddShardOwnerCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_AddShardOwnerCommand_Command)
+	v := ext.(*internal.AddShardOwnerCommand)
+
+	other := fsm.data.Clone()
+	if err := other.AddShardOwner(v.GetID(), v.GetNodeID()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
+func (fsm *storeFSM) applyReplaceDataNodeCommand(cmd *internal.Command) interface{} {
+	ext, _ := proto.GetExtension(cmd, internal.E_ReplaceDataNodeCommand_Command)
+	v := ext.(*internal.ReplaceDataNodeCommand)
+
+	other := fsm.data.Clone()
+	if err := other.ReplaceDataNode(v.GetID(), v.GetHTTPAddr(), v.GetTCPAddr()); err != nil {
+		return err
+	}
+	fsm.data = other
+	return nil
+}
+
 func (fsm *storeFSM) Snapshot() (raft.FSMSnapshot, error) {
 	s := (*store)(fsm)
 	s.mu.Lock()
@@ -604,6 +823,7 @@ func (fsm *storeFSM) Restore(r io.ReadCloser) error {
 	// NOTE: No lock because Hashicorp Raft doesn't call Restore concurrently
 	// with any other function.
 	fsm.data = data
+	(*store)(fsm).resetChanges()
 
 	return nil
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 const (
@@ -14,6 +15,32 @@ type Node struct {
 	path  string
 	ID    uint64
 	Peers []string
+
+	// Rehydrate is set when the node replaced a failed node and has not
+	// copied all of its shards from their other owners yet.
+	Rehydrate bool `json:",omitempty"`
+
+	// RehydrateShards holds the IDs of the shards still to copy while
+	// Rehydrate is set. They are all the shards of the node when the copy
+	// starts.
+	RehydrateShards []uint64 `json:",omitempty"`
+
+	// Decommission is set when the node is being decommissioned, with the
+	// progress of the copy of its shards to their new owners.
+	Decommission *Decommission `json:",omitempty"`
+}
+
+// Decommission is the progress of the decommission of a node, kept across
+// the attempts to decommission it.
+type Decommission struct {
+	// Start is the time the first attempt started. The data written to the
+	// moved shards since then is copied again once the other nodes know
+	// about their new owners.
+	Start time.Time
+
+	// Moved maps the IDs of the shards copied to new owners to the IDs of
+	// these owners.
+	Moved map[uint64][]uint64 `json:",omitempty"`
 }
 
 // LoadNode will load the node information from disk if present
@@ -0,0 +1,98 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+)
+
+// checkpoint is the position up to which an import has been committed.
+type checkpoint struct {
+	Path       string `json:"path"`
+	Offset     int64  `json:"offset"`
+	Database   string `json:"database"`
+	TimeToLive string `json:"ttl"`
+}
+
+// loadCheckpoint reads the checkpoint stored in path.
+func loadCheckpoint(path string) (*checkpoint, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c checkpoint
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint file %s: %s", path, err)
+	}
+	return &c, nil
+}
+
+// save atomically replaces the checkpoint stored in path.
+func (c *checkpoint) save(path string) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0666); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// checkpointer records the offset below which every batch has been written.
+//
+// Batches are written concurrently and may complete out of order, so the
+// checkpoint only advances over a contiguous run of completed batches.
+type checkpointer struct {
+	mu   sync.Mutex
+	path string
+	cp   checkpoint
+	done map[int64]*batch // completed batches waiting for earlier ones, by start offset
+}
+
+func newCheckpointer(path string, cp checkpoint) *checkpointer {
+	return &checkpointer{
+		path: path,
+		cp:   cp,
+		done: make(map[int64]*batch),
+	}
+}
+
+// commit marks b as written and saves the checkpoint if it advanced.
+func (c *checkpointer) commit(b *batch) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.done[b.start] = b
+
+	advanced := false
+	for {
+		next, ok := c.done[c.cp.Offset]
+		if !ok {
+			break
+		}
+		delete(c.done, next.start)
+
+		c.cp.Offset = next.end
+		c.cp.Database = next.database
+		c.cp.TimeToLive = next.timeToLive
+		advanced = true
+	}
+
+	if !advanced || c.path == "" {
+		return nil
+	}
+	return c.cp.save(c.path)
+}
+
+// offset returns the committed offset.
+func (c *checkpointer) offset() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cp.Offset
+}
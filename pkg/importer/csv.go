@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cnosdatabase/db/models"
+)
+
+// Types of the CSV field columns.
+const (
+	csvTypeFloat    = "float"
+	csvTypeInteger  = "int"
+	csvTypeUnsigned = "uint"
+	csvTypeBoolean  = "bool"
+	csvTypeString   = "string"
+)
+
+// CSVConfig maps the columns of a CSV file to points.
+type CSVConfig struct {
+	Metric       string   // metric of every row, unless MetricColumn is set
+	MetricColumn string   // column holding the metric of a row
+	TimeColumn   string   // column holding the time of a row, RFC3339 or in Precision
+	TagColumns   []string // columns written as tags
+	FieldColumns []string // columns written as fields, as name[:type]; all the others by default
+	Delimiter    string
+}
+
+// csvField is a column written as a field.
+type csvField struct {
+	index int
+	name  string
+	typ   string // empty when the type is inferred from the value
+}
+
+// csvConverter converts the rows of a CSV file to line protocol.
+type csvConverter struct {
+	metric    string
+	precision string
+	delimiter rune
+
+	metricIndex int
+	timeIndex   int
+	tags        []int
+	columns     []string
+	fields      []csvField
+}
+
+// newCSVConverter returns a converter for the rows following header.
+func newCSVConverter(c CSVConfig, precision, header string) (*csvConverter, error) {
+	conv := &csvConverter{
+		metric:      c.Metric,
+		precision:   precision,
+		delimiter:   ',',
+		metricIndex: -1,
+		timeIndex:   -1,
+	}
+	if c.Delimiter != "" {
+		r, n := utf8.DecodeRuneInString(c.Delimiter)
+		if n != len(c.Delimiter) {
+			return nil, fmt.Errorf("invalid CSV delimiter %q", c.Delimiter)
+		}
+		conv.delimiter = r
+	}
+
+	columns, err := conv.parse(header)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV header: %s", err)
+	}
+	conv.columns = columns
+
+	index := make(map[string]int, len(columns))
+	for i, name := range columns {
+		index[name] = i
+	}
+	lookup := func(name string) (int, error) {
+		i, ok := index[name]
+		if !ok {
+			return -1, fmt.Errorf("column %q not found in CSV header", name)
+		}
+		return i, nil
+	}
+
+	used := make(map[int]bool)
+	if c.MetricColumn != "" {
+		if conv.metricIndex, err = lookup(c.MetricColumn); err != nil {
+			return nil, err
+		}
+		used[conv.metricIndex] = true
+	} else if c.Metric == "" {
+		return nil, errors.New("metric or metric column required to import CSV")
+	}
+
+	timeColumn := c.TimeColumn
+	if timeColumn == "" {
+		timeColumn = "time"
+	}
+	if i, ok := index[timeColumn]; ok {
+		conv.timeIndex = i
+		used[i] = true
+	} else if c.TimeColumn != "" {
+		return nil, fmt.Errorf("column %q not found in CSV header", c.TimeColumn)
+	}
+
+	for _, name := range c.TagColumns {
+		i, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		conv.tags = append(conv.tags, i)
+		used[i] = true
+	}
+
+	if len(c.FieldColumns) == 0 {
+		for i, name := range columns {
+			if !used[i] {
+				conv.fields = append(conv.fields, csvField{index: i, name: name})
+			}
+		}
+	}
+	for _, spec := range c.FieldColumns {
+		name, typ := spec, ""
+		if i := strings.LastIndexByte(spec, ':'); i >= 0 {
+			name, typ = spec[:i], spec[i+1:]
+			switch typ {
+			case csvTypeFloat, csvTypeInteger, csvTypeUnsigned, csvTypeBoolean, csvTypeString:
+			default:
+				return nil, fmt.Errorf("invalid type %q for CSV column %q", typ, name)
+			}
+		}
+
+		i, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		conv.fields = append(conv.fields, csvField{index: i, name: name, typ: typ})
+	}
+	if len(conv.fields) == 0 {
+		return nil, errors.New("no field column in CSV header")
+	}
+	return conv, nil
+}
+
+// parse splits a CSV line in its values.
+func (c *csvConverter) parse(line string) ([]string, error) {
+	r := csv.NewReader(strings.NewReader(line))
+	r.Comma = c.delimiter
+	r.FieldsPerRecord = -1
+	return r.Read()
+}
+
+// convert returns the line protocol of a CSV row.
+func (c *csvConverter) convert(line string) (string, error) {
+	values, err := c.parse(line)
+	if err != nil {
+		return "", err
+	}
+	if len(values) != len(c.columns) {
+		return "", fmt.Errorf("expected %d columns, got %d", len(c.columns), len(values))
+	}
+
+	name := c.metric
+	if c.metricIndex >= 0 {
+		name = values[c.metricIndex]
+	}
+	if name == "" {
+		return "", errors.New("empty metric")
+	}
+
+	var t time.Time
+	if c.timeIndex >= 0 && values[c.timeIndex] != "" {
+		if t, err = c.parseTime(values[c.timeIndex]); err != nil {
+			return "", err
+		}
+	}
+
+	tags := make(map[string]string, len(c.tags))
+	for _, i := range c.tags {
+		if values[i] != "" {
+			tags[c.columns[i]] = values[i]
+		}
+	}
+
+	fields := make(models.Fields, len(c.fields))
+	for _, f := range c.fields {
+		s := values[f.index]
+		if s == "" {
+			continue
+		}
+		v, err := parseCSVValue(s, f.typ)
+		if err != nil {
+			return "", fmt.Errorf("column %q: %s", f.name, err)
+		}
+		fields[f.name] = v
+	}
+	if len(fields) == 0 {
+		return "", errors.New("no field value")
+	}
+
+	pt, err := models.NewPoint(name, models.NewTags(tags), fields, t)
+	if err != nil {
+		return "", err
+	}
+	return pt.PrecisionString(c.precision), nil
+}
+
+// parseTime parses a time given as RFC3339 or as an integer in the precision.
+func (c *csvConverter) parseTime(s string) (time.Time, error) {
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, n*models.GetPrecisionMultiplier(c.precision)).UTC(), nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q", s)
+	}
+	return t, nil
+}
+
+// parseCSVValue parses a field value of type typ, or infers its type.
+func parseCSVValue(s, typ string) (interface{}, error) {
+	switch typ {
+	case csvTypeFloat:
+		return strconv.ParseFloat(s, 64)
+	case csvTypeInteger:
+		return strconv.ParseInt(s, 10, 64)
+	case csvTypeUnsigned:
+		return strconv.ParseUint(s, 10, 64)
+	case csvTypeBoolean:
+		return strconv.ParseBool(s)
+	case csvTypeString:
+		return s, nil
+	}
+
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	if v, err := strconv.ParseBool(s); err == nil {
+		return v, nil
+	}
+	return s, nil
+}
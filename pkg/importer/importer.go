@@ -3,6 +3,8 @@ package importer
 import (
 	"bufio"
 	"compress/gzip"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -12,12 +14,19 @@ import (
 	"os"
 	"path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cnosdatabase/cnosdb/client"
 )
 
-const batchSize = 5000
+const (
+	batchSize = 5000
+
+	// Input formats.
+	FormatLineProtocol = "lp"
+	FormatCSV          = "csv"
+)
 
 type Config struct {
 	URL        url.URL
@@ -29,6 +38,17 @@ type Config struct {
 	ClientConfig     *client.HTTPConfig
 	Precision        string
 	WriteConsistency string
+
+	Format      string // lp or csv.
+	Database    string // database written to until a CONTEXT-DATABASE line.
+	TimeToLive  string // time-to-live written to until a CONTEXT-TTL line.
+	Concurrency int    // number of batches written concurrently.
+
+	Checkpoint string // file recording the committed offset, Path + ".checkpoint" when resuming.
+	Resume     bool   // whether to continue from the committed offset.
+	Rejects    string // file collecting the lines rejected by the server.
+
+	CSV CSVConfig
 }
 
 func NewConfig() *Config {
@@ -37,21 +57,44 @@ func NewConfig() *Config {
 	}
 }
 
+// batch is a set of lines written in a single request.
+type batch struct {
+	start, end int64 // offsets of the input covered by the batch
+
+	database   string
+	timeToLive string
+	lines      []string
+}
+
 type Importer struct {
 	config *Config
 
 	client                client.Client
+	httpClient            *http.Client
 	database              string
 	timeToLive            string
 	batch                 []string
-	totalInserts          int
-	failedInserts         int
 	totalCommands         int
 	throttlePointsWritten int
 	startTime             time.Time
 	lastWrite             time.Time
 	throttle              *time.Ticker
 
+	// offset is the offset of the input read so far, batchStart the one
+	// of the first line of the batch being accumulated.
+	offset     int64
+	batchStart int64
+	csv        *csvConverter
+
+	batches      chan *batch
+	checkpointer *checkpointer
+	rejects      *rejectsWriter
+
+	mu            sync.Mutex
+	totalInserts  int
+	failedInserts int
+	err           error // first error which stopped the import
+
 	stderrLogger *log.Logger
 	stdoutLogger *log.Logger
 }
@@ -81,6 +124,46 @@ func (i *Importer) Import() error {
 	if i.config.Path == "" {
 		return fmt.Errorf("file argument required")
 	}
+	switch i.config.Format {
+	case "":
+		i.config.Format = FormatLineProtocol
+	case FormatLineProtocol, FormatCSV:
+	default:
+		return fmt.Errorf("invalid format %q", i.config.Format)
+	}
+	if i.config.Concurrency < 1 {
+		i.config.Concurrency = 1
+	}
+	// Progress is only recorded when the import may be resumed.
+	if i.config.Checkpoint == "" && i.config.Resume {
+		i.config.Checkpoint = i.config.Path + ".checkpoint"
+	}
+	i.database, i.timeToLive = i.config.Database, i.config.TimeToLive
+
+	// Load the offset to continue from.
+	var cp checkpoint
+	if i.config.Resume && i.config.Checkpoint != "" {
+		c, err := loadCheckpoint(i.config.Checkpoint)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		} else if c != nil {
+			if c.Path != i.config.Path {
+				return fmt.Errorf("checkpoint %s was recorded for %s", i.config.Checkpoint, c.Path)
+			}
+			cp = *c
+			i.stdoutLogger.Printf("Resuming import of %s at offset %d\n", i.config.Path, cp.Offset)
+		}
+	}
+	cp.Path = i.config.Path
+
+	if i.config.Rejects != "" {
+		rw, err := openRejects(i.config.Rejects, i.config.Resume)
+		if err != nil {
+			return err
+		}
+		defer rw.Close()
+		i.rejects = rw
+	}
 
 	defer func() {
 		if i.totalInserts > 0 {
@@ -116,9 +199,24 @@ func (i *Importer) Import() error {
 	// Get our reader
 	scanner := bufio.NewReader(r)
 
-	// Process the DDL
-	if err := i.processDDL(scanner); err != nil {
-		return fmt.Errorf("reading standard input: %s", err)
+	// The header of a CSV file is needed even when resuming.
+	if i.config.Format == FormatCSV {
+		if err := i.processCSVHeader(scanner); err != nil {
+			return err
+		}
+	}
+
+	if cp.Offset > 0 {
+		// Skip what has already been imported, with its DDL.
+		if err := i.skip(f, scanner, cp.Offset); err != nil {
+			return fmt.Errorf("resuming at offset %d: %s", cp.Offset, err)
+		}
+		i.database, i.timeToLive = cp.Database, cp.TimeToLive
+	} else if i.config.Format == FormatLineProtocol {
+		// Process the DDL
+		if err := i.processDDL(scanner); err != nil {
+			return fmt.Errorf("reading standard input: %s", err)
+		}
 	}
 
 	// Set up our throttle channel.  Since there is effectively no other activity at this point
@@ -129,11 +227,51 @@ func (i *Importer) Import() error {
 	// Prime the last write
 	i.lastWrite = time.Now()
 
+	// Start the writers.
+	tr := &http.Transport{
+		Proxy:               i.config.ClientConfig.Proxy,
+		TLSClientConfig:     i.config.ClientConfig.TLSConfig,
+		MaxIdleConnsPerHost: i.config.Concurrency,
+	}
+	i.httpClient = &http.Client{Timeout: i.config.ClientConfig.Timeout, Transport: tr}
+	cp.Offset, cp.Database, cp.TimeToLive = i.offset, i.database, i.timeToLive
+	i.checkpointer = newCheckpointer(i.config.Checkpoint, cp)
+	i.batchStart = i.offset
+	i.batches = make(chan *batch, i.config.Concurrency)
+
+	var wg sync.WaitGroup
+	for n := 0; n < i.config.Concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range i.batches {
+				i.writeBatch(b)
+			}
+		}()
+	}
+
 	// Process the DML
-	if err := i.processDML(scanner); err != nil {
+	err = i.processDML(scanner)
+	close(i.batches)
+	wg.Wait()
+	if err != nil {
 		return fmt.Errorf("reading standard input: %s", err)
 	}
 
+	if i.err != nil {
+		if i.config.Checkpoint == "" {
+			return fmt.Errorf("%s; the data before offset %d was imported", i.err, i.checkpointer.offset())
+		}
+		return fmt.Errorf("%s; run the import again with --resume to continue at offset %d", i.err, i.checkpointer.offset())
+	}
+
+	// Everything has been imported, the checkpoint is not needed anymore.
+	if i.config.Checkpoint != "" {
+		if err := os.Remove(i.config.Checkpoint); err != nil && !os.IsNotExist(err) {
+			i.stderrLogger.Printf("error removing checkpoint: %s\n", err)
+		}
+	}
+
 	// If there were any failed inserts then return an error so that a non-zero
 	// exit code can be returned.
 	if i.failedInserts > 0 {
@@ -148,9 +286,58 @@ func (i *Importer) Import() error {
 	return nil
 }
 
+// skip moves the input to offset, seeking when it is not compressed.
+func (i *Importer) skip(f *os.File, scanner *bufio.Reader, offset int64) error {
+	if offset < i.offset {
+		return fmt.Errorf("offset is before the start of the data")
+	}
+
+	if !i.config.Compressed {
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		scanner.Reset(f)
+	} else if _, err := io.CopyN(ioutil.Discard, scanner, offset-i.offset); err != nil {
+		return err
+	}
+	i.offset = offset
+	return nil
+}
+
+// readLine returns the next line of the input and advances the offset.
+func (i *Importer) readLine(scanner *bufio.Reader) (string, error) {
+	line, err := scanner.ReadString(byte('\n'))
+	i.offset += int64(len(line))
+	if err == io.EOF && line != "" {
+		return line, nil
+	}
+	return line, err
+}
+
+func (i *Importer) processCSVHeader(scanner *bufio.Reader) error {
+	for {
+		line, err := i.readLine(scanner)
+		if err == io.EOF {
+			return fmt.Errorf("CSV header not found")
+		} else if err != nil {
+			return err
+		}
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		conv, err := newCSVConverter(i.config.CSV, i.config.Precision, strings.TrimRight(line, "\r\n"))
+		if err != nil {
+			return err
+		}
+		i.csv = conv
+		return nil
+	}
+}
+
 func (i *Importer) processDDL(scanner *bufio.Reader) error {
 	for {
-		line, err := scanner.ReadString(byte('\n'))
+		line, err := i.readLine(scanner)
 		if err != nil && err != io.EOF {
 			return err
 		} else if err == io.EOF {
@@ -173,21 +360,23 @@ func (i *Importer) processDDL(scanner *bufio.Reader) error {
 
 func (i *Importer) processDML(scanner *bufio.Reader) error {
 	i.startTime = time.Now()
-	for {
-		line, err := scanner.ReadString(byte('\n'))
+	for !i.stopped() {
+		// The offset before the line is where the current batch ends.
+		offset := i.offset
+		line, err := i.readLine(scanner)
 		if err != nil && err != io.EOF {
 			return err
 		} else if err == io.EOF {
 			// Call batchWrite one last time to flush anything out in the batch
-			i.batchWrite()
+			i.batchWrite(offset)
 			return nil
 		}
 		if strings.HasPrefix(line, "# CONTEXT-DATABASE:") {
-			i.batchWrite()
+			i.batchWrite(offset)
 			i.database = strings.TrimSpace(strings.Split(line, ":")[1])
 		}
 		if strings.HasPrefix(line, "# CONTEXT-TTL:") {
-			i.batchWrite()
+			i.batchWrite(offset)
 			i.timeToLive = strings.TrimSpace(strings.Split(line, ":")[1])
 		}
 		if strings.HasPrefix(line, "#") {
@@ -197,8 +386,19 @@ func (i *Importer) processDML(scanner *bufio.Reader) error {
 		if strings.TrimSpace(line) == "" {
 			continue
 		}
+		line = strings.TrimRight(line, "\r\n")
+
+		if i.csv != nil {
+			converted, err := i.csv.convert(line)
+			if err != nil {
+				i.reject(i.database, i.timeToLive, fmt.Errorf("invalid CSV row: %s", err), []string{line}, 1)
+				continue
+			}
+			line = converted
+		}
 		i.batchAccumulator(line)
 	}
+	return nil
 }
 
 func (i *Importer) execute(command string) {
@@ -220,11 +420,12 @@ func (i *Importer) queryExecutor(command string) {
 func (i *Importer) batchAccumulator(line string) {
 	i.batch = append(i.batch, line)
 	if len(i.batch) == batchSize {
-		i.batchWrite()
+		i.batchWrite(i.offset)
 	}
 }
 
-func (i *Importer) batchWrite() {
+// batchWrite hands the accumulated batch, which ends at offset end, to the writers.
+func (i *Importer) batchWrite(end int64) {
 	// Exit early if there are no points in the batch.
 	if len(i.batch) == 0 {
 		return
@@ -251,34 +452,122 @@ func (i *Importer) batchWrite() {
 
 		// Decrement the batch size back out as it is going to get called again
 		i.throttlePointsWritten -= len(i.batch)
-		i.batchWrite()
+		i.batchWrite(end)
 		return
 	}
 
-	_, e := i.WriteLineProtocol(strings.Join(i.batch, "\n"), i.database, i.timeToLive, i.config.Precision, i.config.WriteConsistency)
-	if e != nil {
-		i.stderrLogger.Println("error writing batch: ", e)
-		i.stderrLogger.Println(strings.Join(i.batch, "\n"))
-		i.failedInserts += len(i.batch)
-	} else {
-		i.totalInserts += len(i.batch)
+	i.batches <- &batch{
+		start:      i.batchStart,
+		end:        end,
+		database:   i.database,
+		timeToLive: i.timeToLive,
+		lines:      i.batch,
 	}
 	i.throttlePointsWritten = 0
 	i.lastWrite = time.Now()
 
-	// Clear the batch and record the number of processed points.
-	i.batch = i.batch[:0]
+	// Start a new batch, the written one is owned by the writers.
+	i.batch = make([]string, 0, batchSize)
+	i.batchStart = end
+}
+
+// writeBatch writes a batch and commits it unless it could not be written at all.
+func (i *Importer) writeBatch(b *batch) {
+	failed, err := i.writeLines(b.database, b.timeToLive, b.lines)
+	if err != nil {
+		// The batch may be retried by resuming the import.
+		i.stop(fmt.Errorf("error writing batch: %s", err))
+		return
+	}
+	inserted := len(b.lines) - failed
+
+	if err := i.checkpointer.commit(b); err != nil {
+		i.stop(fmt.Errorf("error saving checkpoint: %s", err))
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	before := i.totalInserts + i.failedInserts
+	i.totalInserts += inserted
+
 	// Give some status feedback every 100000 lines processed
 	processed := i.totalInserts + i.failedInserts
-	if processed%100000 == 0 {
+	if processed/100000 != before/100000 {
 		since := time.Since(i.startTime)
 		pps := float64(processed) / since.Seconds()
 		i.stdoutLogger.Printf("Processed %d lines.  Time elapsed: %s.  Points per second (PPS): %d", processed, since.String(), int64(pps))
 	}
 }
 
+// writeLines writes lines and rejects the ones the server refused, which
+// would be refused again. It returns the number of points rejected, or an
+// error if the lines could not be written at all.
+//
+// When the server drops some of the points without saying which, the halves
+// of lines are written again until the dropped lines are found. Writing the
+// points the server accepted again does not change them.
+func (i *Importer) writeLines(database, timeToLive string, lines []string) (int, error) {
+	resp, err := i.WriteLineProtocol(strings.Join(lines, "\n"), database, timeToLive, i.config.Precision, i.config.WriteConsistency)
+	if err == nil {
+		return 0, nil
+	} else if resp == nil || resp.StatusCode/100 != 4 {
+		return 0, err
+	}
+
+	rejected, failed := rejectedLines(lines, err.Error())
+	if failed == 0 {
+		return 0, nil
+	}
+	if len(rejected) < len(lines) || failed == len(lines) || len(lines) == 1 {
+		i.reject(database, timeToLive, err, rejected, failed)
+		return failed, nil
+	}
+
+	mid := len(lines) / 2
+	n, err := i.writeLines(database, timeToLive, lines[:mid])
+	if err != nil {
+		return n, err
+	}
+	m, err := i.writeLines(database, timeToLive, lines[mid:])
+	return n + m, err
+}
+
+// reject records failed points and writes the rejected lines to the rejects file.
+func (i *Importer) reject(database, timeToLive string, err error, lines []string, failed int) {
+	i.mu.Lock()
+	i.failedInserts += failed
+	i.mu.Unlock()
+
+	if i.rejects == nil {
+		i.stderrLogger.Println("error writing batch: ", err)
+		i.stderrLogger.Println(strings.Join(lines, "\n"))
+		return
+	}
+	if err := i.rejects.write(database, timeToLive, err, lines); err != nil {
+		i.stop(fmt.Errorf("error writing rejects: %s", err))
+	}
+}
+
+// stop records the error which stops the import.
+func (i *Importer) stop(err error) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.err == nil {
+		i.stderrLogger.Println(err)
+		i.err = err
+	}
+}
+
+// stopped returns true if the import has been stopped by an error.
+func (i *Importer) stopped() bool {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.err != nil
+}
+
 type Response struct {
-	Err error
+	StatusCode int
+	Err        error
 }
 
 // WriteLineProtocol takes a string with line returns to delimit each write
@@ -306,11 +595,14 @@ func (i *Importer) WriteLineProtocol(data, database, timeToLive, precision, writ
 	params.Set("consistency", writeConsistency)
 	req.URL.RawQuery = params.Encode()
 
-	tr := &http.Transport{
-		Proxy:           i.config.ClientConfig.Proxy,
-		TLSClientConfig: i.config.ClientConfig.TLSConfig,
+	httpClient := i.httpClient
+	if httpClient == nil {
+		tr := &http.Transport{
+			Proxy:           i.config.ClientConfig.Proxy,
+			TLSClientConfig: i.config.ClientConfig.TLSConfig,
+		}
+		httpClient = &http.Client{Timeout: i.config.ClientConfig.Timeout, Transport: tr}
 	}
-	httpClient := &http.Client{Timeout: i.config.ClientConfig.Timeout, Transport: tr}
 	resp, err := httpClient.Do(req)
 	if err != nil {
 		return nil, err
@@ -324,7 +616,15 @@ func (i *Importer) WriteLineProtocol(data, database, timeToLive, precision, writ
 	}
 
 	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
-		err := fmt.Errorf(string(body))
+		// Errors are returned as {"error": "..."}.
+		var e struct {
+			Err string `json:"error"`
+		}
+		if json.Unmarshal(body, &e) != nil || e.Err == "" {
+			e.Err = string(body)
+		}
+		err := errors.New(e.Err)
+		response.StatusCode = resp.StatusCode
 		response.Err = err
 		return &response, err
 	}
@@ -0,0 +1,115 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// rejectedLineRegex matches a line the server failed to parse.
+	rejectedLineRegex = regexp.MustCompile(`unable to parse '(.*)': `)
+
+	// droppedRegex matches the number of points dropped by a partial write.
+	droppedRegex = regexp.MustCompile(`dropped=(\d+)`)
+)
+
+// rejectedLines returns the lines of a batch reported by the server in the
+// error of a write, and the number of points the server did not write.
+//
+// When the server does not say which lines were rejected, all the lines of the
+// batch are returned.
+func rejectedLines(lines []string, msg string) ([]string, int) {
+	var rejected []string
+	for _, m := range rejectedLineRegex.FindAllStringSubmatch(msg, -1) {
+		rejected = append(rejected, m[1])
+	}
+	if len(rejected) > 0 {
+		return rejected, len(rejected)
+	}
+
+	if m := droppedRegex.FindStringSubmatch(msg); m != nil {
+		if n, err := strconv.Atoi(m[1]); err == nil && n < len(lines) {
+			return lines, n
+		}
+	}
+	return lines, len(lines)
+}
+
+// rejectsWriter writes rejected lines to a file that can be imported again
+// once they have been fixed.
+type rejectsWriter struct {
+	mu sync.Mutex
+	f  *os.File
+	w  *bufio.Writer
+
+	header     bool
+	database   string
+	timeToLive string
+}
+
+// openRejects opens the rejects file in path, truncating it unless appending.
+func openRejects(path string, append bool) (*rejectsWriter, error) {
+	flag := os.O_CREATE | os.O_WRONLY
+	if append {
+		flag |= os.O_APPEND
+	} else {
+		flag |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(path, flag, 0666)
+	if err != nil {
+		return nil, err
+	}
+	return &rejectsWriter{f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// write writes lines rejected with err, preceded by the context they were
+// written in.
+func (r *rejectsWriter) write(database, timeToLive string, err error, lines []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.header {
+		fmt.Fprintln(r.w, "# DML")
+		r.header = true
+	}
+	if database != r.database {
+		fmt.Fprintf(r.w, "# CONTEXT-DATABASE: %s\n", database)
+		r.database = database
+	}
+	if timeToLive != r.timeToLive {
+		fmt.Fprintf(r.w, "# CONTEXT-TTL: %s\n", timeToLive)
+		r.timeToLive = timeToLive
+	}
+
+	// Only the first line of the error is kept, the others repeat the lines.
+	msg := err.Error()
+	if i := strings.IndexByte(msg, '\n'); i >= 0 {
+		msg = msg[:i]
+	}
+	fmt.Fprintf(r.w, "# error: %s\n", msg)
+
+	for _, line := range lines {
+		if _, err := fmt.Fprintln(r.w, line); err != nil {
+			return err
+		}
+	}
+	return r.w.Flush()
+}
+
+// Close flushes and closes the rejects file.
+func (r *rejectsWriter) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.w.Flush(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
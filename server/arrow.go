@@ -0,0 +1,231 @@
+package server
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/cnosdatabase/db/models"
+)
+
+// contentTypeArrowStream is the media type of the Apache Arrow IPC streaming format.
+const contentTypeArrowStream = "application/vnd.apache.arrow.stream"
+
+// arrowFormatter writes every series, and the error of every statement that
+// failed, as an Apache Arrow IPC stream of its own as soon as it is written:
+// a schema followed by a record batch. The schema of a series has a
+// "statement_id", a "name" and a "tags" column followed by the columns of the
+// series, and the schema of an error has a "statement_id" and an "error"
+// column. Readers decode the streams of a response one after the other.
+type arrowFormatter struct {
+	// written is set once a stream was written.
+	written bool
+}
+
+func (f *arrowFormatter) WriteResponse(w io.Writer, resp Response) error {
+	if resp.Err != nil {
+		return f.writeError(w, 0, resp.Err)
+	}
+
+	for _, result := range resp.Results {
+		if result.Err != nil {
+			if err := f.writeError(w, result.StatementID, result.Err); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, row := range result.Series {
+			if err := f.writeSeries(w, result.StatementID, row); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Finish writes a stream without record batch if nothing was written, so a
+// response always holds at least one stream.
+func (f *arrowFormatter) Finish(w io.Writer) error {
+	if f.written {
+		return nil
+	}
+	return f.writeStream(w, arrowSeriesSchema(nil, nil), 0, nil)
+}
+
+// writeSeries writes the stream of a series.
+func (f *arrowFormatter) writeSeries(w io.Writer, statementID int, row *models.Row) error {
+	var tags string
+	if len(row.Tags) > 0 {
+		tags = string(models.NewTags(row.Tags).HashKey()[1:])
+	}
+
+	schema := arrowSeriesSchema(row.Columns, row.Values)
+	return f.writeStream(w, schema, len(row.Values), func(b *array.RecordBuilder) {
+		for range row.Values {
+			b.Field(0).(*array.Int64Builder).Append(int64(statementID))
+			b.Field(1).(*array.StringBuilder).Append(row.Name)
+			b.Field(2).(*array.StringBuilder).Append(tags)
+		}
+		for j := range row.Columns {
+			for _, values := range row.Values {
+				var v interface{}
+				if j < len(values) {
+					v = values[j]
+				}
+				appendArrowValue(b.Field(3+j), v)
+			}
+		}
+	})
+}
+
+// writeError writes the stream of the error of a statement.
+func (f *arrowFormatter) writeError(w io.Writer, statementID int, err error) error {
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "statement_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "error", Type: arrow.BinaryTypes.String},
+	}, nil)
+	return f.writeStream(w, schema, 1, func(b *array.RecordBuilder) {
+		b.Field(0).(*array.Int64Builder).Append(int64(statementID))
+		b.Field(1).(*array.StringBuilder).Append(err.Error())
+	})
+}
+
+// writeStream writes a stream of schema holding a record batch of n rows
+// appended by build, or no record batch if n is 0.
+func (f *arrowFormatter) writeStream(w io.Writer, schema *arrow.Schema, n int, build func(b *array.RecordBuilder)) error {
+	f.written = true
+
+	sw := ipc.NewWriter(w, ipc.WithSchema(schema), ipc.WithAllocator(memory.DefaultAllocator))
+	if n > 0 {
+		b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+		defer b.Release()
+		build(b)
+
+		rec := b.NewRecord()
+		defer rec.Release()
+		if err := sw.Write(rec); err != nil {
+			return err
+		}
+	}
+	return sw.Close()
+}
+
+// arrowSeriesSchema returns the schema of a series with columns and values.
+func arrowSeriesSchema(columns []string, values [][]interface{}) *arrow.Schema {
+	fields := []arrow.Field{
+		{Name: "statement_id", Type: arrow.PrimitiveTypes.Int64},
+		{Name: "name", Type: arrow.BinaryTypes.String},
+		{Name: "tags", Type: arrow.BinaryTypes.String},
+	}
+	for j, name := range columns {
+		fields = append(fields, arrow.Field{Name: name, Type: arrowTypeOf(values, j), Nullable: true})
+	}
+	return arrow.NewSchema(fields, nil)
+}
+
+// arrowTypeOf returns the type of the jth column of values, taken from its
+// non-nil values: a column holding integers and floats is a float column, a
+// column holding other mixed types or only nils is a string column.
+func arrowTypeOf(values [][]interface{}, j int) arrow.DataType {
+	var typ arrow.DataType
+	for _, row := range values {
+		if j >= len(row) || row[j] == nil {
+			continue
+		}
+
+		var t arrow.DataType
+		switch row[j].(type) {
+		case float64:
+			t = arrow.PrimitiveTypes.Float64
+		case int64:
+			t = arrow.PrimitiveTypes.Int64
+		case uint64:
+			t = arrow.PrimitiveTypes.Uint64
+		case bool:
+			t = arrow.FixedWidthTypes.Boolean
+		case time.Time:
+			t = arrow.FixedWidthTypes.Timestamp_ns
+		default:
+			return arrow.BinaryTypes.String
+		}
+
+		if typ == nil || typ == t {
+			typ = t
+		} else if arrowNumeric(typ) && arrowNumeric(t) {
+			typ = arrow.PrimitiveTypes.Float64
+		} else {
+			return arrow.BinaryTypes.String
+		}
+	}
+	if typ == nil {
+		return arrow.BinaryTypes.String
+	}
+	return typ
+}
+
+// arrowNumeric returns true if t is the type of a numeric column.
+func arrowNumeric(t arrow.DataType) bool {
+	switch t.ID() {
+	case arrow.FLOAT64, arrow.INT64, arrow.UINT64:
+		return true
+	}
+	return false
+}
+
+// appendArrowValue appends v to the builder of a column. Integers are appended
+// to float columns as floats and other values to string columns as strings.
+// Values that are nil or do not match the column are appended as nulls.
+func appendArrowValue(b array.Builder, v interface{}) {
+	switch b := b.(type) {
+	case *array.Float64Builder:
+		switch v := v.(type) {
+		case float64:
+			b.Append(v)
+		case int64:
+			b.Append(float64(v))
+		case uint64:
+			b.Append(float64(v))
+		default:
+			b.AppendNull()
+		}
+	case *array.Int64Builder:
+		if v, ok := v.(int64); ok {
+			b.Append(v)
+		} else {
+			b.AppendNull()
+		}
+	case *array.Uint64Builder:
+		if v, ok := v.(uint64); ok {
+			b.Append(v)
+		} else {
+			b.AppendNull()
+		}
+	case *array.BooleanBuilder:
+		if v, ok := v.(bool); ok {
+			b.Append(v)
+		} else {
+			b.AppendNull()
+		}
+	case *array.TimestampBuilder:
+		if v, ok := v.(time.Time); ok {
+			b.Append(arrow.Timestamp(v.UnixNano()))
+		} else {
+			b.AppendNull()
+		}
+	case *array.StringBuilder:
+		switch v := v.(type) {
+		case nil:
+			b.AppendNull()
+		case string:
+			b.Append(v)
+		case time.Time:
+			b.Append(v.UTC().Format(time.RFC3339Nano))
+		default:
+			b.Append(fmt.Sprint(v))
+		}
+	}
+}
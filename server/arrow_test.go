@@ -0,0 +1,201 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/query"
+)
+
+// Ensure every series and every error of a response is written as a stream
+// of its own as soon as it is written, and that the Arrow reader decodes the
+// streams one after the other.
+func TestArrowFormatter_RoundTrip(t *testing.T) {
+	t0 := time.Unix(0, 1000).UTC()
+	t1 := time.Unix(0, 2000).UTC()
+
+	f := &arrowFormatter{}
+	var buf bytes.Buffer
+	for i, resp := range []Response{
+		{Results: []*query.Result{{
+			StatementID: 0,
+			Series: models.Rows{{
+				Name:    "cpu",
+				Tags:    map[string]string{"host": "a"},
+				Columns: []string{"time", "value"},
+				Values:  [][]interface{}{{t0, 1.5}, {t1, nil}},
+			}},
+		}}},
+		// The second chunk has another column and mixes integers with floats.
+		{Results: []*query.Result{{
+			StatementID: 1,
+			Series: models.Rows{{
+				Name:    "mem",
+				Columns: []string{"time", "value", "used"},
+				Values:  [][]interface{}{{t0, int64(2), true}, {t1, 2.5, nil}},
+			}},
+		}}},
+		{Results: []*query.Result{{
+			StatementID: 2,
+			Err:         errors.New("database not found: db1"),
+		}}},
+	} {
+		n := buf.Len()
+		if err := f.WriteResponse(&buf, resp); err != nil {
+			t.Fatal(err)
+		} else if buf.Len() == n {
+			t.Fatalf("response %d not written before Finish", i)
+		}
+	}
+	n := buf.Len()
+	if err := f.Finish(&buf); err != nil {
+		t.Fatal(err)
+	} else if buf.Len() != n {
+		t.Fatal("unexpected stream written by Finish")
+	}
+
+	// cpu
+	rec := readArrowStream(t, &buf, []string{
+		"statement_id:int64",
+		"name:utf8",
+		"tags:utf8",
+		"time:timestamp",
+		"value:float64",
+	})
+	defer rec.Release()
+	if rec.NumRows() != 2 {
+		t.Fatalf("unexpected number of cpu rows: %d", rec.NumRows())
+	}
+	if v := rec.Column(1).(*array.String).Value(0); v != "cpu" {
+		t.Fatalf("unexpected name: %s", v)
+	}
+	if v := rec.Column(2).(*array.String).Value(0); v != "host=a" {
+		t.Fatalf("unexpected tags: %s", v)
+	}
+	ts := rec.Column(3).(*array.Timestamp)
+	if ts.Value(0) != arrow.Timestamp(1000) || ts.Value(1) != arrow.Timestamp(2000) {
+		t.Fatalf("unexpected times: %v", ts.TimestampValues())
+	}
+	value := rec.Column(4).(*array.Float64)
+	if value.Value(0) != 1.5 || !value.IsNull(1) {
+		t.Fatalf("unexpected values: %v", value)
+	}
+
+	// mem
+	rec = readArrowStream(t, &buf, []string{
+		"statement_id:int64",
+		"name:utf8",
+		"tags:utf8",
+		"time:timestamp",
+		"value:float64",
+		"used:bool",
+	})
+	defer rec.Release()
+	if v := rec.Column(0).(*array.Int64).Value(0); v != 1 {
+		t.Fatalf("unexpected statement id: %d", v)
+	}
+	if v := rec.Column(2).(*array.String).Value(0); v != "" {
+		t.Fatalf("unexpected tags: %s", v)
+	}
+	if v := rec.Column(4).(*array.Float64).Float64Values(); v[0] != 2 || v[1] != 2.5 {
+		t.Fatalf("unexpected values: %v", v)
+	}
+	if used := rec.Column(5).(*array.Boolean); !used.Value(0) || !used.IsNull(1) {
+		t.Fatalf("unexpected used: %v", used)
+	}
+
+	// error
+	rec = readArrowStream(t, &buf, []string{
+		"statement_id:int64",
+		"error:utf8",
+	})
+	defer rec.Release()
+	if v := rec.Column(0).(*array.Int64).Value(0); v != 2 {
+		t.Fatalf("unexpected statement id: %d", v)
+	}
+	if v := rec.Column(1).(*array.String).Value(0); v != "database not found: db1" {
+		t.Fatalf("unexpected error: %s", v)
+	}
+
+	if buf.Len() != 0 {
+		t.Fatalf("unexpected %d bytes after the last stream", buf.Len())
+	}
+}
+
+// readArrowStream reads a stream of a single record batch of the given schema
+// from r and returns the record.
+func readArrowStream(t *testing.T, r io.Reader, schema []string) array.Record {
+	t.Helper()
+
+	sr, err := ipc.NewReader(r)
+	if err != nil {
+		t.Fatalf("read schema: %v", err)
+	}
+	defer sr.Release()
+
+	var names []string
+	for _, field := range sr.Schema().Fields() {
+		names = append(names, field.Name+":"+field.Type.Name())
+	}
+	if !equalStrings(names, schema) {
+		t.Fatalf("unexpected schema:\ngot=%v\nexp=%v", names, schema)
+	}
+
+	if !sr.Next() {
+		t.Fatalf("read record: %v", sr.Err())
+	}
+	rec := sr.Record()
+	rec.Retain()
+	if sr.Next() {
+		t.Fatal("unexpected second record")
+	} else if err := sr.Err(); err != nil {
+		t.Fatalf("read end of stream: %v", err)
+	}
+	return rec
+}
+
+// Ensure a response without series is a valid empty stream.
+func TestArrowFormatter_Empty(t *testing.T) {
+	f := &arrowFormatter{}
+	var buf bytes.Buffer
+	if err := f.WriteResponse(&buf, Response{Results: []*query.Result{{StatementID: 0}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Finish(&buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := ipc.NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Release()
+	if n := len(r.Schema().Fields()); n != 3 {
+		t.Fatalf("unexpected number of fields: %d", n)
+	}
+	if r.Next() {
+		t.Fatal("expected no records")
+	}
+	if err := r.Err(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
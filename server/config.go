@@ -18,6 +18,7 @@ import (
 	"github.com/cnosdatabase/cnosdb/server/continuous_querier"
 	"github.com/cnosdatabase/cnosdb/server/coordinator"
 	"github.com/cnosdatabase/cnosdb/server/hh"
+	"github.com/cnosdatabase/cnosdb/server/otlp"
 	"github.com/cnosdatabase/cnosdb/server/region"
 	"github.com/cnosdatabase/cnosdb/server/subscriber"
 	"github.com/cnosdatabase/cnosdb/server/ttl"
@@ -49,6 +50,7 @@ type Config struct {
 	ContinuousQuery continuous_querier.Config
 	HintedHandoff   hh.Config
 	TLS             tlsconfig.Config
+	Tracing         otlp.Config
 }
 
 // NewConfig returns an instance of Config with reasonable defaults.
@@ -66,6 +68,7 @@ func NewConfig() *Config {
 
 	c.ContinuousQuery = continuous_querier.NewConfig()
 	c.TimeToLive = ttl.NewConfig()
+	c.Tracing = otlp.NewConfig()
 
 	return c
 }
@@ -159,6 +162,10 @@ func (c *Config) Validate() error {
 		return err
 	}
 
+	if err := c.Tracing.Validate(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
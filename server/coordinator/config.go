@@ -20,6 +20,10 @@ const (
 	// DefaultShardMapperTimeout is the default timeout set on shard mappers.
 	DefaultShardMapperTimeout = 5 * time.Second
 
+	// DefaultShardCopyTimeout is the default timeout for a data node to copy
+	// a shard from another data node.
+	DefaultShardCopyTimeout = time.Hour
+
 	// DefaultMaxRemoteWriteConnections is the maximum number of open connections
 	// that will be available for remote writes to another host.
 	DefaultMaxRemoteWriteConnections = 3
@@ -35,6 +39,23 @@ const (
 	// DefaultMaxSelectSeriesN is the maximum number of series a SELECT can run.
 	// A value of zero will make the maximum series count unlimited.
 	DefaultMaxSelectSeriesN = 0
+
+	// DefaultMaxSelectBlocksN is the maximum number of blocks a SELECT is
+	// estimated to read. A value of zero will make the maximum block count unlimited.
+	DefaultMaxSelectBlocksN = 0
+
+	// DefaultMaxSelectBytes is the maximum number of bytes a SELECT is estimated
+	// to read. A value of zero will make the maximum size unlimited.
+	DefaultMaxSelectBytes = 0
+
+	// DefaultMaxSelectSeriesScannedN is the maximum number of series a SELECT is
+	// estimated to scan. A value of zero will make the maximum series count unlimited.
+	DefaultMaxSelectSeriesScannedN = 0
+
+	// DefaultMaxConcurrentExpensiveQueries is the maximum number of running
+	// queries that exceed a cost limit. A value of zero will reject these
+	// queries instead of queueing them.
+	DefaultMaxConcurrentExpensiveQueries = 0
 )
 
 // Config represents the configuration for the coordinator service.
@@ -44,6 +65,7 @@ type Config struct {
 	ShardWriterTimeout        toml.Duration `toml:"shard-writer-timeout"`
 	MaxRemoteWriteConnections int           `toml:"max-remote-write-connections"`
 	ShardMapperTimeout        toml.Duration `toml:"shard-mapper-timeout"`
+	ShardCopyTimeout          toml.Duration `toml:"shard-copy-timeout"`
 
 	MaxConcurrentQueries int           `toml:"max-concurrent-queries"`
 	QueryTimeout         toml.Duration `toml:"query-timeout"`
@@ -51,6 +73,11 @@ type Config struct {
 	MaxSelectPointN      int           `toml:"max-select-point"`
 	MaxSelectSeriesN     int           `toml:"max-select-series"`
 	MaxSelectBucketsN    int           `toml:"max-select-buckets"`
+
+	MaxSelectBlocksN              int       `toml:"max-select-blocks"`
+	MaxSelectBytes                toml.Size `toml:"max-select-bytes"`
+	MaxSelectSeriesScannedN       int       `toml:"max-select-series-scanned"`
+	MaxConcurrentExpensiveQueries int       `toml:"max-concurrent-expensive-queries"`
 }
 
 // NewConfig returns an instance of Config with defaults.
@@ -59,24 +86,35 @@ func NewConfig() Config {
 		WriteTimeout:              toml.Duration(DefaultWriteTimeout),
 		ShardWriterTimeout:        toml.Duration(DefaultShardWriterTimeout),
 		ShardMapperTimeout:        toml.Duration(DefaultShardMapperTimeout),
+		ShardCopyTimeout:          toml.Duration(DefaultShardCopyTimeout),
 		MaxRemoteWriteConnections: DefaultMaxRemoteWriteConnections,
 
 		QueryTimeout:         toml.Duration(query.DefaultQueryTimeout),
 		MaxConcurrentQueries: DefaultMaxConcurrentQueries,
 		MaxSelectPointN:      DefaultMaxSelectPointN,
 		MaxSelectSeriesN:     DefaultMaxSelectSeriesN,
+
+		MaxSelectBlocksN:              DefaultMaxSelectBlocksN,
+		MaxSelectBytes:                DefaultMaxSelectBytes,
+		MaxSelectSeriesScannedN:       DefaultMaxSelectSeriesScannedN,
+		MaxConcurrentExpensiveQueries: DefaultMaxConcurrentExpensiveQueries,
 	}
 }
 
 // Diagnostics returns a diagnostics representation of a subset of the Config.
 func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return diagnostics.RowFromMap(map[string]interface{}{
-		"write-timeout":          c.WriteTimeout,
-		"max-concurrent-queries": c.MaxConcurrentQueries,
-		"query-timeout":          c.QueryTimeout,
-		"log-queries-after":      c.LogQueriesAfter,
-		"max-select-point":       c.MaxSelectPointN,
-		"max-select-series":      c.MaxSelectSeriesN,
-		"max-select-buckets":     c.MaxSelectBucketsN,
+		"write-timeout":                    c.WriteTimeout,
+		"shard-copy-timeout":               c.ShardCopyTimeout,
+		"max-concurrent-queries":           c.MaxConcurrentQueries,
+		"query-timeout":                    c.QueryTimeout,
+		"log-queries-after":                c.LogQueriesAfter,
+		"max-select-point":                 c.MaxSelectPointN,
+		"max-select-series":                c.MaxSelectSeriesN,
+		"max-select-buckets":               c.MaxSelectBucketsN,
+		"max-select-blocks":                c.MaxSelectBlocksN,
+		"max-select-bytes":                 c.MaxSelectBytes,
+		"max-select-series-scanned":        c.MaxSelectSeriesScannedN,
+		"max-concurrent-expensive-queries": c.MaxConcurrentExpensiveQueries,
 	}), nil
 }
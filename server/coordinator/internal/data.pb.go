@@ -226,6 +226,9 @@ type CreateIteratorRequest struct {
 	Database             []byte   `protobuf:"bytes,3,req,name=Database" json:"Database,omitempty"`
 	TimeToLive           []byte   `protobuf:"bytes,4,req,name=TimeToLive" json:"TimeToLive,omitempty"`
 	MetricName           []byte   `protobuf:"bytes,5,req,name=MetricName" json:"MetricName,omitempty"`
+	MetricRegex          *string  `protobuf:"bytes,6,opt,name=MetricRegex" json:"MetricRegex,omitempty"`
+	SystemIterator       *string  `protobuf:"bytes,7,opt,name=SystemIterator" json:"SystemIterator,omitempty"`
+	SpanContext          []byte   `protobuf:"bytes,8,opt,name=SpanContext" json:"SpanContext,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -290,6 +293,27 @@ func (m *CreateIteratorRequest) GetMetricName() []byte {
 	return nil
 }
 
+func (m *CreateIteratorRequest) GetMetricRegex() string {
+	if m != nil && m.MetricRegex != nil {
+		return *m.MetricRegex
+	}
+	return ""
+}
+
+func (m *CreateIteratorRequest) GetSystemIterator() string {
+	if m != nil && m.SystemIterator != nil {
+		return *m.SystemIterator
+	}
+	return ""
+}
+
+func (m *CreateIteratorRequest) GetSpanContext() []byte {
+	if m != nil {
+		return m.SpanContext
+	}
+	return nil
+}
+
 type CreateIteratorResponse struct {
 	Err                  *string  `protobuf:"bytes,1,opt,name=Err" json:"Err,omitempty"`
 	DataType             *int32   `protobuf:"varint,2,opt,name=DataType" json:"DataType,omitempty"`
@@ -452,6 +476,676 @@ func (m *FieldDimensionsResponse) GetErr() string {
 	return ""
 }
 
+type MetricNamesRequest struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	Condition            *string  `protobuf:"bytes,2,opt,name=Condition" json:"Condition,omitempty"`
+	User                 *string  `protobuf:"bytes,3,opt,name=User" json:"User,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MetricNamesRequest) Reset()         { *m = MetricNamesRequest{} }
+func (m *MetricNamesRequest) String() string { return proto.CompactTextString(m) }
+func (*MetricNamesRequest) ProtoMessage()    {}
+func (*MetricNamesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{8}
+}
+func (m *MetricNamesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricNamesRequest.Unmarshal(m, b)
+}
+func (m *MetricNamesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricNamesRequest.Marshal(b, m, deterministic)
+}
+func (m *MetricNamesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricNamesRequest.Merge(m, src)
+}
+func (m *MetricNamesRequest) XXX_Size() int {
+	return xxx_messageInfo_MetricNamesRequest.Size(m)
+}
+func (m *MetricNamesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricNamesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricNamesRequest proto.InternalMessageInfo
+
+func (m *MetricNamesRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+func (m *MetricNamesRequest) GetCondition() string {
+	if m != nil && m.Condition != nil {
+		return *m.Condition
+	}
+	return ""
+}
+
+func (m *MetricNamesRequest) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+type MetricNamesResponse struct {
+	Names                [][]byte `protobuf:"bytes,1,rep,name=Names" json:"Names,omitempty"`
+	Err                  *string  `protobuf:"bytes,2,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MetricNamesResponse) Reset()         { *m = MetricNamesResponse{} }
+func (m *MetricNamesResponse) String() string { return proto.CompactTextString(m) }
+func (*MetricNamesResponse) ProtoMessage()    {}
+func (*MetricNamesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{9}
+}
+func (m *MetricNamesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MetricNamesResponse.Unmarshal(m, b)
+}
+func (m *MetricNamesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MetricNamesResponse.Marshal(b, m, deterministic)
+}
+func (m *MetricNamesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MetricNamesResponse.Merge(m, src)
+}
+func (m *MetricNamesResponse) XXX_Size() int {
+	return xxx_messageInfo_MetricNamesResponse.Size(m)
+}
+func (m *MetricNamesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_MetricNamesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MetricNamesResponse proto.InternalMessageInfo
+
+func (m *MetricNamesResponse) GetNames() [][]byte {
+	if m != nil {
+		return m.Names
+	}
+	return nil
+}
+
+func (m *MetricNamesResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type TagKeysRequest struct {
+	ShardIDs             []uint64 `protobuf:"varint,1,rep,name=ShardIDs" json:"ShardIDs,omitempty"`
+	Condition            *string  `protobuf:"bytes,2,opt,name=Condition" json:"Condition,omitempty"`
+	User                 *string  `protobuf:"bytes,3,opt,name=User" json:"User,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TagKeysRequest) Reset()         { *m = TagKeysRequest{} }
+func (m *TagKeysRequest) String() string { return proto.CompactTextString(m) }
+func (*TagKeysRequest) ProtoMessage()    {}
+func (*TagKeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{10}
+}
+func (m *TagKeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TagKeysRequest.Unmarshal(m, b)
+}
+func (m *TagKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TagKeysRequest.Marshal(b, m, deterministic)
+}
+func (m *TagKeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TagKeysRequest.Merge(m, src)
+}
+func (m *TagKeysRequest) XXX_Size() int {
+	return xxx_messageInfo_TagKeysRequest.Size(m)
+}
+func (m *TagKeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TagKeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TagKeysRequest proto.InternalMessageInfo
+
+func (m *TagKeysRequest) GetShardIDs() []uint64 {
+	if m != nil {
+		return m.ShardIDs
+	}
+	return nil
+}
+
+func (m *TagKeysRequest) GetCondition() string {
+	if m != nil && m.Condition != nil {
+		return *m.Condition
+	}
+	return ""
+}
+
+func (m *TagKeysRequest) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+type TagKeysResponse struct {
+	TagKeys              []byte   `protobuf:"bytes,1,opt,name=TagKeys" json:"TagKeys,omitempty"`
+	Err                  *string  `protobuf:"bytes,2,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TagKeysResponse) Reset()         { *m = TagKeysResponse{} }
+func (m *TagKeysResponse) String() string { return proto.CompactTextString(m) }
+func (*TagKeysResponse) ProtoMessage()    {}
+func (*TagKeysResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{11}
+}
+func (m *TagKeysResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TagKeysResponse.Unmarshal(m, b)
+}
+func (m *TagKeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TagKeysResponse.Marshal(b, m, deterministic)
+}
+func (m *TagKeysResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TagKeysResponse.Merge(m, src)
+}
+func (m *TagKeysResponse) XXX_Size() int {
+	return xxx_messageInfo_TagKeysResponse.Size(m)
+}
+func (m *TagKeysResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TagKeysResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TagKeysResponse proto.InternalMessageInfo
+
+func (m *TagKeysResponse) GetTagKeys() []byte {
+	if m != nil {
+		return m.TagKeys
+	}
+	return nil
+}
+
+func (m *TagKeysResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type TagValuesRequest struct {
+	ShardIDs             []uint64 `protobuf:"varint,1,rep,name=ShardIDs" json:"ShardIDs,omitempty"`
+	Condition            *string  `protobuf:"bytes,2,opt,name=Condition" json:"Condition,omitempty"`
+	User                 *string  `protobuf:"bytes,3,opt,name=User" json:"User,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TagValuesRequest) Reset()         { *m = TagValuesRequest{} }
+func (m *TagValuesRequest) String() string { return proto.CompactTextString(m) }
+func (*TagValuesRequest) ProtoMessage()    {}
+func (*TagValuesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{12}
+}
+func (m *TagValuesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TagValuesRequest.Unmarshal(m, b)
+}
+func (m *TagValuesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TagValuesRequest.Marshal(b, m, deterministic)
+}
+func (m *TagValuesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TagValuesRequest.Merge(m, src)
+}
+func (m *TagValuesRequest) XXX_Size() int {
+	return xxx_messageInfo_TagValuesRequest.Size(m)
+}
+func (m *TagValuesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TagValuesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TagValuesRequest proto.InternalMessageInfo
+
+func (m *TagValuesRequest) GetShardIDs() []uint64 {
+	if m != nil {
+		return m.ShardIDs
+	}
+	return nil
+}
+
+func (m *TagValuesRequest) GetCondition() string {
+	if m != nil && m.Condition != nil {
+		return *m.Condition
+	}
+	return ""
+}
+
+func (m *TagValuesRequest) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+type TagValuesResponse struct {
+	TagValues            []byte   `protobuf:"bytes,1,opt,name=TagValues" json:"TagValues,omitempty"`
+	Err                  *string  `protobuf:"bytes,2,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TagValuesResponse) Reset()         { *m = TagValuesResponse{} }
+func (m *TagValuesResponse) String() string { return proto.CompactTextString(m) }
+func (*TagValuesResponse) ProtoMessage()    {}
+func (*TagValuesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{13}
+}
+func (m *TagValuesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TagValuesResponse.Unmarshal(m, b)
+}
+func (m *TagValuesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TagValuesResponse.Marshal(b, m, deterministic)
+}
+func (m *TagValuesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TagValuesResponse.Merge(m, src)
+}
+func (m *TagValuesResponse) XXX_Size() int {
+	return xxx_messageInfo_TagValuesResponse.Size(m)
+}
+func (m *TagValuesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TagValuesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TagValuesResponse proto.InternalMessageInfo
+
+func (m *TagValuesResponse) GetTagValues() []byte {
+	if m != nil {
+		return m.TagValues
+	}
+	return nil
+}
+
+func (m *TagValuesResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type SeriesKeysRequest struct {
+	ShardIDs             []uint64 `protobuf:"varint,1,rep,name=ShardIDs" json:"ShardIDs,omitempty"`
+	Condition            *string  `protobuf:"bytes,2,opt,name=Condition" json:"Condition,omitempty"`
+	User                 *string  `protobuf:"bytes,3,opt,name=User" json:"User,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SeriesKeysRequest) Reset()         { *m = SeriesKeysRequest{} }
+func (m *SeriesKeysRequest) String() string { return proto.CompactTextString(m) }
+func (*SeriesKeysRequest) ProtoMessage()    {}
+func (*SeriesKeysRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{14}
+}
+func (m *SeriesKeysRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SeriesKeysRequest.Unmarshal(m, b)
+}
+func (m *SeriesKeysRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SeriesKeysRequest.Marshal(b, m, deterministic)
+}
+func (m *SeriesKeysRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeriesKeysRequest.Merge(m, src)
+}
+func (m *SeriesKeysRequest) XXX_Size() int {
+	return xxx_messageInfo_SeriesKeysRequest.Size(m)
+}
+func (m *SeriesKeysRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeriesKeysRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeriesKeysRequest proto.InternalMessageInfo
+
+func (m *SeriesKeysRequest) GetShardIDs() []uint64 {
+	if m != nil {
+		return m.ShardIDs
+	}
+	return nil
+}
+
+func (m *SeriesKeysRequest) GetCondition() string {
+	if m != nil && m.Condition != nil {
+		return *m.Condition
+	}
+	return ""
+}
+
+func (m *SeriesKeysRequest) GetUser() string {
+	if m != nil && m.User != nil {
+		return *m.User
+	}
+	return ""
+}
+
+type SeriesKeysResponse struct {
+	Keys                 []string `protobuf:"bytes,1,rep,name=Keys" json:"Keys,omitempty"`
+	Err                  *string  `protobuf:"bytes,2,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SeriesKeysResponse) Reset()         { *m = SeriesKeysResponse{} }
+func (m *SeriesKeysResponse) String() string { return proto.CompactTextString(m) }
+func (*SeriesKeysResponse) ProtoMessage()    {}
+func (*SeriesKeysResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{15}
+}
+func (m *SeriesKeysResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SeriesKeysResponse.Unmarshal(m, b)
+}
+func (m *SeriesKeysResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SeriesKeysResponse.Marshal(b, m, deterministic)
+}
+func (m *SeriesKeysResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SeriesKeysResponse.Merge(m, src)
+}
+func (m *SeriesKeysResponse) XXX_Size() int {
+	return xxx_messageInfo_SeriesKeysResponse.Size(m)
+}
+func (m *SeriesKeysResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SeriesKeysResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SeriesKeysResponse proto.InternalMessageInfo
+
+func (m *SeriesKeysResponse) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
+func (m *SeriesKeysResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type SketchesRequest struct {
+	Database             *string  `protobuf:"bytes,1,req,name=Database" json:"Database,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SketchesRequest) Reset()         { *m = SketchesRequest{} }
+func (m *SketchesRequest) String() string { return proto.CompactTextString(m) }
+func (*SketchesRequest) ProtoMessage()    {}
+func (*SketchesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{16}
+}
+func (m *SketchesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SketchesRequest.Unmarshal(m, b)
+}
+func (m *SketchesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SketchesRequest.Marshal(b, m, deterministic)
+}
+func (m *SketchesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SketchesRequest.Merge(m, src)
+}
+func (m *SketchesRequest) XXX_Size() int {
+	return xxx_messageInfo_SketchesRequest.Size(m)
+}
+func (m *SketchesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SketchesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SketchesRequest proto.InternalMessageInfo
+
+func (m *SketchesRequest) GetDatabase() string {
+	if m != nil && m.Database != nil {
+		return *m.Database
+	}
+	return ""
+}
+
+type SketchesResponse struct {
+	Sketch               []byte   `protobuf:"bytes,1,opt,name=Sketch" json:"Sketch,omitempty"`
+	TSSketch             []byte   `protobuf:"bytes,2,opt,name=TSSketch" json:"TSSketch,omitempty"`
+	Err                  *string  `protobuf:"bytes,3,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SketchesResponse) Reset()         { *m = SketchesResponse{} }
+func (m *SketchesResponse) String() string { return proto.CompactTextString(m) }
+func (*SketchesResponse) ProtoMessage()    {}
+func (*SketchesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{17}
+}
+func (m *SketchesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SketchesResponse.Unmarshal(m, b)
+}
+func (m *SketchesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SketchesResponse.Marshal(b, m, deterministic)
+}
+func (m *SketchesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SketchesResponse.Merge(m, src)
+}
+func (m *SketchesResponse) XXX_Size() int {
+	return xxx_messageInfo_SketchesResponse.Size(m)
+}
+func (m *SketchesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SketchesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SketchesResponse proto.InternalMessageInfo
+
+func (m *SketchesResponse) GetSketch() []byte {
+	if m != nil {
+		return m.Sketch
+	}
+	return nil
+}
+
+func (m *SketchesResponse) GetTSSketch() []byte {
+	if m != nil {
+		return m.TSSketch
+	}
+	return nil
+}
+
+func (m *SketchesResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type IteratorCostResponse struct {
+	NumShards            *int64   `protobuf:"varint,1,opt,name=NumShards" json:"NumShards,omitempty"`
+	NumSeries            *int64   `protobuf:"varint,2,opt,name=NumSeries" json:"NumSeries,omitempty"`
+	CachedValues         *int64   `protobuf:"varint,3,opt,name=CachedValues" json:"CachedValues,omitempty"`
+	NumFiles             *int64   `protobuf:"varint,4,opt,name=NumFiles" json:"NumFiles,omitempty"`
+	BlocksRead           *int64   `protobuf:"varint,5,opt,name=BlocksRead" json:"BlocksRead,omitempty"`
+	BlockSize            *int64   `protobuf:"varint,6,opt,name=BlockSize" json:"BlockSize,omitempty"`
+	Err                  *string  `protobuf:"bytes,7,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IteratorCostResponse) Reset()         { *m = IteratorCostResponse{} }
+func (m *IteratorCostResponse) String() string { return proto.CompactTextString(m) }
+func (*IteratorCostResponse) ProtoMessage()    {}
+func (*IteratorCostResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{18}
+}
+func (m *IteratorCostResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_IteratorCostResponse.Unmarshal(m, b)
+}
+func (m *IteratorCostResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_IteratorCostResponse.Marshal(b, m, deterministic)
+}
+func (m *IteratorCostResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IteratorCostResponse.Merge(m, src)
+}
+func (m *IteratorCostResponse) XXX_Size() int {
+	return xxx_messageInfo_IteratorCostResponse.Size(m)
+}
+func (m *IteratorCostResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_IteratorCostResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IteratorCostResponse proto.InternalMessageInfo
+
+func (m *IteratorCostResponse) GetNumShards() int64 {
+	if m != nil && m.NumShards != nil {
+		return *m.NumShards
+	}
+	return 0
+}
+
+func (m *IteratorCostResponse) GetNumSeries() int64 {
+	if m != nil && m.NumSeries != nil {
+		return *m.NumSeries
+	}
+	return 0
+}
+
+func (m *IteratorCostResponse) GetCachedValues() int64 {
+	if m != nil && m.CachedValues != nil {
+		return *m.CachedValues
+	}
+	return 0
+}
+
+func (m *IteratorCostResponse) GetNumFiles() int64 {
+	if m != nil && m.NumFiles != nil {
+		return *m.NumFiles
+	}
+	return 0
+}
+
+func (m *IteratorCostResponse) GetBlocksRead() int64 {
+	if m != nil && m.BlocksRead != nil {
+		return *m.BlocksRead
+	}
+	return 0
+}
+
+func (m *IteratorCostResponse) GetBlockSize() int64 {
+	if m != nil && m.BlockSize != nil {
+		return *m.BlockSize
+	}
+	return 0
+}
+
+func (m *IteratorCostResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
+type CopyShardRequest struct {
+	ShardID              *uint64  `protobuf:"varint,1,req,name=ShardID" json:"ShardID,omitempty"`
+	SourceNodeID         *uint64  `protobuf:"varint,2,req,name=SourceNodeID" json:"SourceNodeID,omitempty"`
+	Since                *int64   `protobuf:"varint,3,opt,name=Since" json:"Since,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CopyShardRequest) Reset()         { *m = CopyShardRequest{} }
+func (m *CopyShardRequest) String() string { return proto.CompactTextString(m) }
+func (*CopyShardRequest) ProtoMessage()    {}
+func (*CopyShardRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{19}
+}
+func (m *CopyShardRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CopyShardRequest.Unmarshal(m, b)
+}
+func (m *CopyShardRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CopyShardRequest.Marshal(b, m, deterministic)
+}
+func (m *CopyShardRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CopyShardRequest.Merge(m, src)
+}
+func (m *CopyShardRequest) XXX_Size() int {
+	return xxx_messageInfo_CopyShardRequest.Size(m)
+}
+func (m *CopyShardRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CopyShardRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CopyShardRequest proto.InternalMessageInfo
+
+func (m *CopyShardRequest) GetShardID() uint64 {
+	if m != nil && m.ShardID != nil {
+		return *m.ShardID
+	}
+	return 0
+}
+
+func (m *CopyShardRequest) GetSourceNodeID() uint64 {
+	if m != nil && m.SourceNodeID != nil {
+		return *m.SourceNodeID
+	}
+	return 0
+}
+
+func (m *CopyShardRequest) GetSince() int64 {
+	if m != nil && m.Since != nil {
+		return *m.Since
+	}
+	return 0
+}
+
+type CopyShardResponse struct {
+	Err                  *string  `protobuf:"bytes,1,opt,name=Err" json:"Err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CopyShardResponse) Reset()         { *m = CopyShardResponse{} }
+func (m *CopyShardResponse) String() string { return proto.CompactTextString(m) }
+func (*CopyShardResponse) ProtoMessage()    {}
+func (*CopyShardResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_7438786364df21e1, []int{20}
+}
+func (m *CopyShardResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CopyShardResponse.Unmarshal(m, b)
+}
+func (m *CopyShardResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CopyShardResponse.Marshal(b, m, deterministic)
+}
+func (m *CopyShardResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CopyShardResponse.Merge(m, src)
+}
+func (m *CopyShardResponse) XXX_Size() int {
+	return xxx_messageInfo_CopyShardResponse.Size(m)
+}
+func (m *CopyShardResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CopyShardResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CopyShardResponse proto.InternalMessageInfo
+
+func (m *CopyShardResponse) GetErr() string {
+	if m != nil && m.Err != nil {
+		return *m.Err
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterType((*WriteShardRequest)(nil), "internal.WriteShardRequest")
 	proto.RegisterType((*WriteShardResponse)(nil), "internal.WriteShardResponse")
@@ -461,35 +1155,70 @@ func init() {
 	proto.RegisterType((*CreateIteratorResponse)(nil), "internal.CreateIteratorResponse")
 	proto.RegisterType((*FieldDimensionsRequest)(nil), "internal.FieldDimensionsRequest")
 	proto.RegisterType((*FieldDimensionsResponse)(nil), "internal.FieldDimensionsResponse")
+	proto.RegisterType((*MetricNamesRequest)(nil), "internal.MetricNamesRequest")
+	proto.RegisterType((*MetricNamesResponse)(nil), "internal.MetricNamesResponse")
+	proto.RegisterType((*TagKeysRequest)(nil), "internal.TagKeysRequest")
+	proto.RegisterType((*TagKeysResponse)(nil), "internal.TagKeysResponse")
+	proto.RegisterType((*TagValuesRequest)(nil), "internal.TagValuesRequest")
+	proto.RegisterType((*TagValuesResponse)(nil), "internal.TagValuesResponse")
+	proto.RegisterType((*SeriesKeysRequest)(nil), "internal.SeriesKeysRequest")
+	proto.RegisterType((*SeriesKeysResponse)(nil), "internal.SeriesKeysResponse")
+	proto.RegisterType((*SketchesRequest)(nil), "internal.SketchesRequest")
+	proto.RegisterType((*SketchesResponse)(nil), "internal.SketchesResponse")
+	proto.RegisterType((*IteratorCostResponse)(nil), "internal.IteratorCostResponse")
+	proto.RegisterType((*CopyShardRequest)(nil), "internal.CopyShardRequest")
+	proto.RegisterType((*CopyShardResponse)(nil), "internal.CopyShardResponse")
 }
 
 func init() { proto.RegisterFile("internal/data.proto", fileDescriptor_7438786364df21e1) }
 
 var fileDescriptor_7438786364df21e1 = []byte{
-	// 398 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x92, 0x5f, 0x8b, 0xd3, 0x40,
-	0x14, 0xc5, 0xc9, 0xbf, 0xda, 0x5c, 0xfa, 0xb0, 0x8e, 0x98, 0x1d, 0x16, 0x91, 0x90, 0xa7, 0x3c,
-	0xe9, 0x77, 0x70, 0xbb, 0xe2, 0xc2, 0x6e, 0x94, 0x69, 0xc1, 0xe7, 0xb1, 0xb9, 0xe8, 0xc0, 0x26,
-	0x13, 0x67, 0xee, 0x4a, 0x7d, 0xf4, 0xa3, 0xf8, 0x4d, 0x25, 0xd3, 0x99, 0x34, 0xb6, 0x08, 0xb2,
-	0x6f, 0xf3, 0x3b, 0x37, 0x5c, 0xce, 0x3d, 0x39, 0xf0, 0x42, 0xf5, 0x84, 0xa6, 0x97, 0x0f, 0x6f,
-	0x5b, 0x49, 0xf2, 0xcd, 0x60, 0x34, 0x69, 0xb6, 0x0c, 0x62, 0xf5, 0x2b, 0x82, 0xe7, 0x9f, 0x8d,
-	0x22, 0xdc, 0x7c, 0x93, 0xa6, 0x15, 0xf8, 0xfd, 0x11, 0x2d, 0x31, 0x0e, 0xcf, 0x1c, 0xdf, 0xae,
-	0x79, 0x54, 0xc6, 0x75, 0x2a, 0x02, 0xb2, 0x02, 0x16, 0x9f, 0xb4, 0xea, 0xc9, 0xf2, 0xb8, 0x4c,
-	0xea, 0x95, 0xf0, 0xc4, 0xae, 0x60, 0xb9, 0x96, 0x24, 0xbf, 0x48, 0x8b, 0x3c, 0x29, 0xa3, 0x3a,
-	0x17, 0x13, 0xb3, 0xd7, 0x00, 0x5b, 0xd5, 0xe1, 0x56, 0xdf, 0xa9, 0x1f, 0xc8, 0x53, 0x37, 0x9d,
-	0x29, 0xd5, 0x3b, 0x60, 0x73, 0x0b, 0x76, 0xd0, 0xbd, 0x45, 0xc6, 0x20, 0xbd, 0xd6, 0x2d, 0x3a,
-	0x03, 0x99, 0x70, 0xef, 0xd1, 0xd7, 0x3d, 0x5a, 0x2b, 0xbf, 0x22, 0x8f, 0xdd, 0x9a, 0x80, 0xd5,
-	0x06, 0x2e, 0x6f, 0xf6, 0xb8, 0x7b, 0x24, 0xdc, 0x90, 0x24, 0xec, 0xb0, 0xa7, 0x70, 0xcc, 0x2b,
-	0xc8, 0x27, 0xcd, 0x6d, 0xcb, 0xc5, 0x51, 0xf8, 0xcb, 0x78, 0xec, 0x86, 0x13, 0x57, 0x1f, 0x80,
-	0x9f, 0x2f, 0x7d, 0x92, 0xbd, 0xdf, 0x11, 0xbc, 0xbc, 0x36, 0x28, 0x09, 0x6f, 0x09, 0x8d, 0x24,
-	0x6d, 0x82, 0xbb, 0x2b, 0x58, 0xfa, 0x6c, 0x2d, 0x8f, 0xca, 0xa4, 0x4e, 0xc5, 0xc4, 0xec, 0x02,
-	0x92, 0x8f, 0x03, 0x39, 0x5b, 0x2b, 0x31, 0x3e, 0x4f, 0x62, 0x1e, 0xe5, 0x7f, 0xc7, 0x3c, 0x4e,
-	0x67, 0xca, 0x38, 0xbf, 0x47, 0x32, 0x6a, 0xd7, 0xc8, 0x0e, 0x79, 0x76, 0x98, 0x1f, 0x95, 0x6a,
-	0x0f, 0xc5, 0xa9, 0x45, 0x7f, 0xeb, 0x05, 0x24, 0x37, 0xc6, 0xf0, 0xc8, 0xdd, 0x34, 0x3e, 0x83,
-	0x8f, 0xed, 0xcf, 0xe1, 0x70, 0x6a, 0x26, 0x26, 0x76, 0xe5, 0x41, 0xa3, 0xd0, 0x36, 0xae, 0x09,
-	0x99, 0x08, 0x38, 0x95, 0xa7, 0x71, 0x25, 0xc8, 0x7c, 0x79, 0x9a, 0xea, 0x0e, 0x8a, 0xf7, 0x0a,
-	0x1f, 0xda, 0xb5, 0xea, 0xb0, 0xb7, 0x4a, 0xf7, 0xf6, 0x7f, 0xd2, 0x29, 0x60, 0x71, 0x70, 0xef,
-	0x03, 0xf2, 0x54, 0xed, 0xe0, 0xf2, 0x6c, 0x9b, 0x3f, 0xa4, 0x80, 0x85, 0x1b, 0x59, 0xf7, 0xdb,
-	0x56, 0xc2, 0xd3, 0x18, 0xcd, 0xf1, 0x6b, 0xd7, 0xec, 0x5c, 0xcc, 0x94, 0x10, 0x40, 0x32, 0x05,
-	0xf0, 0x27, 0x00, 0x00, 0xff, 0xff, 0xdd, 0x72, 0x8e, 0x71, 0x57, 0x03, 0x00, 0x00,
+	// 749 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xcb, 0x6e, 0xdb, 0x38,
+	0x14, 0x85, 0x24, 0x3f, 0xef, 0x08, 0x89, 0xad, 0x64, 0x1c, 0x22, 0x08, 0x06, 0x86, 0x80, 0x19,
+	0x78, 0x33, 0x33, 0xfb, 0x02, 0xdd, 0x44, 0x4e, 0xd0, 0xa0, 0x89, 0x5b, 0x50, 0xee, 0x63, 0x55,
+	0x94, 0x91, 0x6e, 0x1d, 0x21, 0xb6, 0xe8, 0x8a, 0x74, 0x61, 0x77, 0xd7, 0x0f, 0xe8, 0x4f, 0xf6,
+	0x4b, 0x0a, 0x52, 0xd4, 0xc3, 0x79, 0x00, 0x46, 0x80, 0xec, 0x74, 0xce, 0x25, 0x2e, 0xcf, 0x3d,
+	0x3c, 0xa4, 0xe0, 0x20, 0x49, 0x25, 0x66, 0x29, 0x9b, 0xff, 0x1f, 0x33, 0xc9, 0xfe, 0x5b, 0x66,
+	0x5c, 0x72, 0xaf, 0x53, 0x90, 0xfe, 0x0f, 0x0b, 0xfa, 0x1f, 0xb2, 0x44, 0x62, 0x78, 0xc3, 0xb2,
+	0x98, 0xe2, 0xd7, 0x15, 0x0a, 0xe9, 0x11, 0x68, 0x6b, 0x7c, 0x31, 0x26, 0xd6, 0xd0, 0x1e, 0x35,
+	0x68, 0x01, 0xbd, 0x01, 0xb4, 0xde, 0xf2, 0x24, 0x95, 0x82, 0xd8, 0x43, 0x67, 0xe4, 0x52, 0x83,
+	0xbc, 0x63, 0xe8, 0x8c, 0x99, 0x64, 0xd7, 0x4c, 0x20, 0x71, 0x86, 0xd6, 0xa8, 0x4b, 0x4b, 0xec,
+	0xfd, 0x05, 0x30, 0x4d, 0x16, 0x38, 0xe5, 0x97, 0xc9, 0x37, 0x24, 0x0d, 0x5d, 0xad, 0x31, 0xfe,
+	0x29, 0x78, 0x75, 0x09, 0x62, 0xc9, 0x53, 0x81, 0x9e, 0x07, 0x8d, 0x80, 0xc7, 0xa8, 0x05, 0x34,
+	0xa9, 0xfe, 0x56, 0xba, 0xae, 0x50, 0x08, 0x36, 0x43, 0x62, 0xeb, 0x36, 0x05, 0xf4, 0x43, 0x38,
+	0x3a, 0x5b, 0x63, 0xb4, 0x92, 0x18, 0x4a, 0x26, 0x71, 0x81, 0xa9, 0x2c, 0x86, 0x39, 0x81, 0x6e,
+	0xc9, 0xe9, 0x6e, 0x5d, 0x5a, 0x11, 0x5b, 0xc2, 0x6d, 0x5d, 0x2c, 0xb1, 0xff, 0x0a, 0xc8, 0xfd,
+	0xa6, 0x4f, 0x92, 0xf7, 0xd3, 0x86, 0x3f, 0x83, 0x0c, 0x99, 0xc4, 0x0b, 0x89, 0x19, 0x93, 0x3c,
+	0x2b, 0xd4, 0x1d, 0x43, 0xc7, 0x78, 0x2b, 0x88, 0x35, 0x74, 0x46, 0x0d, 0x5a, 0x62, 0xaf, 0x07,
+	0xce, 0x9b, 0xa5, 0xd4, 0xb2, 0x5c, 0xaa, 0x3e, 0xef, 0xd8, 0xac, 0xe8, 0xc7, 0x6d, 0x56, 0xd5,
+	0x1a, 0xa3, 0xea, 0x57, 0x28, 0xb3, 0x24, 0x9a, 0xb0, 0x05, 0x92, 0x66, 0x5e, 0xaf, 0x18, 0x6f,
+	0x08, 0x7f, 0xe4, 0x88, 0xe2, 0x0c, 0xd7, 0xa4, 0xa5, 0x27, 0xa8, 0x53, 0xde, 0x3f, 0xb0, 0x17,
+	0x6e, 0x84, 0xc4, 0x45, 0x31, 0x04, 0x69, 0xeb, 0x45, 0x77, 0x58, 0xd5, 0x29, 0x5c, 0xb2, 0x34,
+	0xe0, 0xa9, 0xc4, 0xb5, 0x24, 0x9d, 0xa1, 0x35, 0x72, 0x69, 0x9d, 0xf2, 0xd7, 0x30, 0xb8, 0x6b,
+	0x87, 0xf1, 0xb5, 0x07, 0xce, 0x59, 0x96, 0x11, 0x4b, 0x37, 0x56, 0x9f, 0xc5, 0xcc, 0xd3, 0xcd,
+	0x32, 0xb7, 0xb5, 0x49, 0x4b, 0xac, 0x83, 0x8a, 0x59, 0x82, 0x62, 0xa2, 0x53, 0xd7, 0xa4, 0x05,
+	0x2c, 0x83, 0x3a, 0xd1, 0x81, 0x6b, 0x9a, 0xa0, 0x4e, 0xfc, 0x4b, 0x18, 0x9c, 0x27, 0x38, 0x8f,
+	0xc7, 0xc9, 0x02, 0x53, 0x91, 0xf0, 0x54, 0xec, 0x72, 0x12, 0x03, 0x68, 0xe5, 0x46, 0x98, 0xc3,
+	0x30, 0xc8, 0x8f, 0xe0, 0xe8, 0x5e, 0x37, 0x33, 0xc8, 0x00, 0x5a, 0xba, 0x24, 0x74, 0x44, 0x5c,
+	0x6a, 0x90, 0x3a, 0x86, 0x6a, 0xb5, 0xbe, 0x45, 0x5d, 0x5a, 0x63, 0x0a, 0x03, 0x9c, 0xd2, 0x00,
+	0xff, 0x1a, 0xbc, 0xea, 0x98, 0xea, 0x72, 0xcb, 0x28, 0x58, 0xdb, 0xc1, 0x55, 0x91, 0x0f, 0x78,
+	0x1a, 0x27, 0x32, 0xe1, 0xa9, 0x89, 0x62, 0x45, 0xa8, 0xe8, 0xbe, 0x13, 0x58, 0x6c, 0xa1, 0xbf,
+	0xfd, 0x97, 0x70, 0xb0, 0xb5, 0x87, 0x19, 0xe2, 0x10, 0x9a, 0x9a, 0xd0, 0x86, 0xb8, 0x34, 0x07,
+	0x85, 0x44, 0xbb, 0x92, 0xf8, 0x09, 0xf6, 0xa6, 0x6c, 0xf6, 0x1a, 0x37, 0x3b, 0xb9, 0xf9, 0x14,
+	0x79, 0xfb, 0x65, 0x7f, 0x23, 0x8d, 0x40, 0xdb, 0x50, 0x3a, 0x2c, 0x2e, 0x2d, 0xe0, 0x03, 0xf2,
+	0x3e, 0x43, 0x6f, 0xca, 0x66, 0xef, 0xd9, 0x7c, 0x85, 0xcf, 0x24, 0x30, 0x80, 0x7e, 0x6d, 0x07,
+	0x23, 0xf1, 0x04, 0xba, 0x25, 0x69, 0x44, 0x56, 0xc4, 0x03, 0x32, 0x19, 0xf4, 0xf3, 0xf8, 0x3e,
+	0x9f, 0x91, 0x2f, 0xc0, 0xab, 0x6f, 0x51, 0x3d, 0x66, 0xc6, 0x48, 0x95, 0xc6, 0xc6, 0x23, 0x2e,
+	0xfe, 0x0b, 0xfb, 0xe1, 0x2d, 0xca, 0xe8, 0x66, 0xa7, 0x10, 0xfa, 0x1f, 0xa1, 0x57, 0x2d, 0xaf,
+	0x2e, 0x45, 0xce, 0x19, 0x3b, 0x0c, 0x52, 0x7d, 0xa6, 0xa1, 0xa9, 0xd8, 0xba, 0x52, 0xe2, 0x07,
+	0x2e, 0xc4, 0x2f, 0x0b, 0x0e, 0x8b, 0x87, 0x23, 0xe0, 0x42, 0xd6, 0x0d, 0x9f, 0xac, 0x16, 0xda,
+	0x9e, 0xdc, 0x70, 0x87, 0x56, 0x44, 0x51, 0xd5, 0xe3, 0x13, 0xbb, 0xaa, 0x6a, 0xc2, 0xf3, 0xc1,
+	0x0d, 0x58, 0x74, 0x83, 0xb1, 0x39, 0x2f, 0x47, 0x2f, 0xd8, 0xe2, 0x94, 0xcc, 0xc9, 0x6a, 0x71,
+	0x9e, 0xcc, 0x51, 0xe8, 0x67, 0xc5, 0xa1, 0x25, 0x56, 0xf7, 0xfa, 0x74, 0xce, 0xa3, 0x5b, 0x41,
+	0x91, 0xc5, 0xa4, 0xa9, 0xab, 0x35, 0x46, 0xed, 0xae, 0x51, 0x98, 0x7c, 0x47, 0xfd, 0xb8, 0x3a,
+	0xb4, 0x22, 0x8a, 0x21, 0xdb, 0xd5, 0x90, 0x5f, 0xa0, 0x17, 0xf0, 0xe5, 0x66, 0xc7, 0xff, 0xb2,
+	0x0f, 0x6e, 0xc8, 0x57, 0x59, 0x84, 0x13, 0x1e, 0xe3, 0xc5, 0x58, 0x3f, 0x53, 0x0d, 0xba, 0xc5,
+	0xa9, 0xcb, 0x1c, 0x26, 0x69, 0x84, 0x66, 0xb4, 0x1c, 0xf8, 0x7f, 0x43, 0xbf, 0xb6, 0xcf, 0x63,
+	0xaf, 0xf0, 0xef, 0x01, 0x00, 0x42, 0x49, 0x18, 0x9c, 0x48, 0x08, 0x00, 0x00,
 }
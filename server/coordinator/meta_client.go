@@ -19,6 +19,8 @@ type MetaClient interface {
 	Databases() []meta.DatabaseInfo
 	DataNode(id uint64) (*meta.NodeInfo, error)
 	DataNodes() ([]meta.NodeInfo, error)
+	DataNodeStatuses() ([]meta.DataNodeStatus, error)
+	DataNodeDown(id uint64) bool
 	DeleteDataNode(id uint64) error
 	MetaNodes() ([]meta.NodeInfo, error)
 	DeleteMetaNode(id uint64) error
@@ -5,6 +5,8 @@ import (
 	"log"
 	"net"
 	"os"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,6 +18,8 @@ import (
 const (
 	metaExecutorWriteTimeout        = 5 * time.Second
 	metaExecutorMaxWriteConnections = 10
+	metaExecutorMaxRetries          = 3
+	metaExecutorRetryInterval       = time.Second
 )
 
 // MetaExecutor executes meta queries on all data nodes.
@@ -24,6 +28,8 @@ type MetaExecutor struct {
 	timeout        time.Duration
 	pool           *clientPool
 	maxConnections int
+	maxRetries     int
+	retryInterval  time.Duration
 	Logger         *log.Logger
 	Node           *cnosdb.Node
 
@@ -43,6 +49,8 @@ func NewMetaExecutor() *MetaExecutor {
 		timeout:        metaExecutorWriteTimeout,
 		pool:           newClientPool(),
 		maxConnections: metaExecutorMaxWriteConnections,
+		maxRetries:     metaExecutorMaxRetries,
+		retryInterval:  metaExecutorRetryInterval,
 		Logger:         log.New(os.Stderr, "[meta-executor] ", log.LstdFlags),
 	}
 	m.nodeExecutor = m
@@ -61,7 +69,29 @@ func (e remoteNodeError) Error() string {
 	return fmt.Sprintf("partial success, node %d may be down (%s)", e.id, e.err)
 }
 
+// remoteNodeErrors is returned when a statement could not be executed on
+// some of the remote nodes. It reports which nodes succeeded and which failed.
+type remoteNodeErrors struct {
+	succeeded []uint64
+	errs      []remoteNodeError
+}
+
+func (e remoteNodeErrors) Error() string {
+	if len(e.errs) == 1 && len(e.succeeded) == 0 {
+		return e.errs[0].Error()
+	}
+
+	msgs := make([]string, 0, len(e.errs))
+	for _, err := range e.errs {
+		msgs = append(msgs, fmt.Sprintf("node %d (%s)", err.id, err.err))
+	}
+	return fmt.Sprintf("partial success, succeeded on nodes %v, failed on %s",
+		e.succeeded, strings.Join(msgs, ", "))
+}
+
 // ExecuteStatement executes a single CnosQL statement on all nodes in the cluster concurrently.
+// Nodes that fail are retried up to maxRetries times before an error listing
+// the failed nodes is returned.
 func (m *MetaExecutor) ExecuteStatement(stmt cnosql.Statement, database string) error {
 	// Get a list of all nodes the query needs to be executed on.
 	nodes, err := m.MetaClient.DataNodes()
@@ -71,32 +101,79 @@ func (m *MetaExecutor) ExecuteStatement(stmt cnosql.Statement, database string)
 		return nil
 	}
 
-	// Start a goroutine to execute the statement on each of the remote nodes.
-	var wg sync.WaitGroup
-	errs := make(chan error, len(nodes)-1)
+	pending := make([]meta.NodeInfo, 0, len(nodes))
 	for _, node := range nodes {
 		if m.Node.ID == node.ID {
 			continue // Don't execute statement on ourselves.
 		}
+		pending = append(pending, node)
+	}
+
+	var succeeded []uint64
+	for i := 0; ; i++ {
+		ok, errs := m.executeOnNodes(stmt, database, pending)
+		succeeded = append(succeeded, ok...)
+		if len(errs) == 0 {
+			return nil
+		}
+
+		if i >= m.maxRetries {
+			sort.Slice(succeeded, func(i, j int) bool { return succeeded[i] < succeeded[j] })
+			sort.Slice(errs, func(i, j int) bool { return errs[i].id < errs[j].id })
+			return remoteNodeErrors{succeeded: succeeded, errs: errs}
+		}
+
+		// Only retry the nodes that failed.
+		failed := make(map[uint64]struct{}, len(errs))
+		for _, e := range errs {
+			failed[e.id] = struct{}{}
+			m.Logger.Printf("failed to execute %q on node %d, retrying: %s", stmt, e.id, e.err)
+		}
+
+		retry := pending[:0]
+		for _, node := range pending {
+			if _, ok := failed[node.ID]; ok {
+				retry = append(retry, node)
+			}
+		}
+		pending = retry
+
+		time.Sleep(m.retryInterval)
+	}
+}
 
+// executeOnNodes executes a statement on each of the given nodes concurrently
+// and returns the IDs of the nodes that succeeded along with the errors of
+// those that did not.
+func (m *MetaExecutor) executeOnNodes(stmt cnosql.Statement, database string, nodes []meta.NodeInfo) ([]uint64, []remoteNodeError) {
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded []uint64
+		errs      []remoteNodeError
+	)
+
+	// Start a goroutine to execute the statement on each of the remote nodes.
+	for _, node := range nodes {
 		wg.Add(1)
 		go func(node meta.NodeInfo) {
 			defer wg.Done()
-			if err := m.nodeExecutor.executeOnNode(stmt, database, &node); err != nil {
-				errs <- remoteNodeError{id: node.ID, err: err}
+			err := m.nodeExecutor.executeOnNode(stmt, database, &node)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, remoteNodeError{id: node.ID, err: err})
+				return
 			}
+			succeeded = append(succeeded, node.ID)
 		}(node)
 	}
 
-	// Wait on n-1 nodes to execute the statement and respond.
+	// Wait on all nodes to execute the statement and respond.
 	wg.Wait()
 
-	select {
-	case err = <-errs:
-		return err
-	default:
-		return nil
-	}
+	return succeeded, errs
 }
 
 // executeOnNode executes a single CnosQL statement on a single node.
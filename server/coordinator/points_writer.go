@@ -1,9 +1,11 @@
 package coordinator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -11,6 +13,8 @@ import (
 	"github.com/cnosdatabase/cnosdb"
 	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/tracing"
+	"github.com/cnosdatabase/db/pkg/tracing/fields"
 	"github.com/cnosdatabase/db/tsdb"
 	"go.uber.org/zap"
 )
@@ -42,6 +46,10 @@ var (
 	// ErrWriteFailed is returned when no writes succeeded.
 	ErrWriteFailed = errors.New("write failed")
 
+	// ErrNodeDown is returned when a write is not sent to a data node because
+	// the node is down.
+	ErrNodeDown = errors.New("node is down")
+
 	// ErrInvalidConsistencyLevel is returned when parsing the string version
 	// of a consistency level.
 	ErrInvalidConsistencyLevel = errors.New("invalid consistency level")
@@ -60,6 +68,7 @@ type PointsWriter struct {
 		Database(name string) (di *meta.DatabaseInfo)
 		TimeToLive(database, ttl string) (*meta.TimeToLiveInfo, error)
 		CreateRegion(database, ttl string, timestamp time.Time) (*meta.RegionInfo, error)
+		DataNodeDown(id uint64) bool
 	}
 
 	TSDBStore interface {
@@ -345,9 +354,19 @@ func (w *PointsWriter) WritePoints(database, timeToLive string, consistencyLevel
 	return w.WritePointsPrivileged(database, timeToLive, consistencyLevel, points)
 }
 
+// WritePointsWithContext is like WritePoints. The writes to the shards are
+// traced when ctx holds a span.
+func (w *PointsWriter) WritePointsWithContext(ctx context.Context, database, timeToLive string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error {
+	return w.writePoints(ctx, database, timeToLive, consistencyLevel, points)
+}
+
 // WritePointsPrivileged writes the data to the underlying storage,
 // consitencyLevel is only used for clustered scenarios
 func (w *PointsWriter) WritePointsPrivileged(database, timeToLive string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
+	return w.writePoints(context.Background(), database, timeToLive, consistencyLevel, points)
+}
+
+func (w *PointsWriter) writePoints(ctx context.Context, database, timeToLive string, consistencyLevel models.ConsistencyLevel, points []models.Point) error {
 	atomic.AddInt64(&w.stats.WriteReq, 1)
 	atomic.AddInt64(&w.stats.PointWriteReq, int64(len(points)))
 
@@ -368,7 +387,7 @@ func (w *PointsWriter) WritePointsPrivileged(database, timeToLive string, consis
 	ch := make(chan error, len(shardMappings.Points))
 	for shardID, points := range shardMappings.Points {
 		go func(shard *meta.ShardInfo, database, timeToLive string, points []models.Point) {
-			err := w.writeToShard(shard, database, timeToLive, consistencyLevel, points)
+			err := w.writeToShard(ctx, shard, database, timeToLive, consistencyLevel, points)
 			if err == tsdb.ErrShardDeletion {
 				err = tsdb.PartialWriteError{Reason: fmt.Sprintf("shard %d is pending deletion", shard.ID), Dropped: len(points)}
 			}
@@ -424,7 +443,15 @@ func (w *PointsWriter) WritePointsPrivileged(database, timeToLive string, consis
 
 // writeToShard writes points to a shard and ensures a write consistency level has been met.  If the write
 // partially succeeds, ErrPartialWrite is returned.
-func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, timeToLive string, consistency models.ConsistencyLevel, points []models.Point) error {
+func (w *PointsWriter) writeToShard(ctx context.Context, shard *meta.ShardInfo, database, timeToLive string, consistency models.ConsistencyLevel, points []models.Point) (err error) {
+	span := tracing.SpanFromContext(ctx)
+	if span != nil {
+		span = span.StartSpan("write_to_shard")
+		span.SetLabels("shard_id", strconv.FormatUint(shard.ID, 10))
+		span.MergeFields(fields.Int64("points", int64(len(points))))
+		defer func() { finishWriteSpan(span, err) }()
+	}
+
 	// The required number of writes to achieve the requested consistency level
 	required := len(shard.Owners)
 	switch consistency {
@@ -445,6 +472,7 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, timeToLive
 		go func(shardID uint64, owner meta.ShardOwner, points []models.Point) {
 			if owner.NodeID == 0 || w.Node.ID == owner.NodeID {
 				atomic.AddInt64(&w.stats.PointWriteReqLocal, int64(len(points)))
+				local := startWriteSpan(span, "write_local", owner.NodeID)
 				err := w.TSDBStore.WriteToShard(shardID, points)
 				// If we've written to shard that should exist on the current node, but the store has
 				// not actually created this shard, tell it to create it and retry the write
@@ -456,14 +484,26 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, timeToLive
 					}
 					err = w.TSDBStore.WriteToShard(shardID, points)
 				}
+				finishWriteSpan(local, err)
 				ch <- &AsyncWriteResult{owner, err}
 			} else {
 				atomic.AddInt64(&w.stats.PointWriteReqRemote, int64(len(points)))
-				err := w.ShardWriter.WriteShard(shardID, owner.NodeID, points)
+				var err error
+				if w.MetaClient.DataNodeDown(owner.NodeID) {
+					// Don't wait for a node known to be down, queue the write
+					// right away.
+					err = ErrNodeDown
+				} else {
+					remote := startWriteSpan(span, "shard_writer", owner.NodeID)
+					err = w.ShardWriter.WriteShard(shardID, owner.NodeID, points)
+					finishWriteSpan(remote, err)
+				}
 				if err != nil && tsdb.IsRetryable(err) {
 					// The remote write failed so queue it via hinted handoff
 					atomic.AddInt64(&w.stats.WritePointReqHH, int64(len(points)))
+					hh := startWriteSpan(span, "hinted_handoff", owner.NodeID)
 					hherr := w.HintedHandoff.WriteShard(shardID, owner.NodeID, points)
+					finishWriteSpan(hh, hherr)
 					if hherr != nil {
 						ch <- &AsyncWriteResult{owner, hherr}
 						return
@@ -527,3 +567,25 @@ func (w *PointsWriter) writeToShard(shard *meta.ShardInfo, database, timeToLive
 
 	return ErrWriteFailed
 }
+
+// startWriteSpan starts a span for a write of a shard to the owner on node
+// nodeID. It returns nil if the write is not traced.
+func startWriteSpan(parent *tracing.Span, name string, nodeID uint64) *tracing.Span {
+	if parent == nil {
+		return nil
+	}
+	span := parent.StartSpan(name)
+	span.SetLabels("node_id", strconv.FormatUint(nodeID, 10))
+	return span
+}
+
+// finishWriteSpan records the error of a write, if any, and finishes the span.
+func finishWriteSpan(span *tracing.Span, err error) {
+	if span == nil {
+		return
+	}
+	if err != nil {
+		span.MergeFields(fields.String("error", err.Error()))
+	}
+	span.Finish()
+}
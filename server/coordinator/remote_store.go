@@ -0,0 +1,142 @@
+package coordinator
+
+import (
+	"encoding"
+	"time"
+
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/pkg/estimator"
+	"github.com/cnosdatabase/db/tsdb"
+)
+
+// remoteNodeStore retrieves metadata from the shards held by a remote data node.
+type remoteNodeStore struct {
+	dialer *NodeDialer
+	nodeID uint64
+}
+
+// newRemoteNodeStore returns a new instance of remoteNodeStore for a remote node.
+func newRemoteNodeStore(dialer *NodeDialer, nodeID uint64) *remoteNodeStore {
+	return &remoteNodeStore{
+		dialer: dialer,
+		nodeID: nodeID,
+	}
+}
+
+// MetricNames returns the metric names of database on the remote node that
+// user is authorized to read.
+func (s *remoteNodeStore) MetricNames(user, database string, cond cnosql.Expr) ([][]byte, error) {
+	var resp MetricNamesResponse
+	if err := s.request(metricNamesRequestMessage, &MetricNamesRequest{
+		Database:  database,
+		Condition: cond,
+		User:      user,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Names, resp.Err
+}
+
+// TagKeys returns the tag keys of shardIDs on the remote node that user is
+// authorized to read.
+func (s *remoteNodeStore) TagKeys(user string, shardIDs []uint64, cond cnosql.Expr) ([]tsdb.TagKeys, error) {
+	var resp TagKeysResponse
+	if err := s.request(tagKeysRequestMessage, &TagKeysRequest{
+		ShardIDs:  shardIDs,
+		Condition: cond,
+		User:      user,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.TagKeys, resp.Err
+}
+
+// TagValues returns the tag values of shardIDs on the remote node that user
+// is authorized to read.
+func (s *remoteNodeStore) TagValues(user string, shardIDs []uint64, cond cnosql.Expr) ([]tsdb.TagValues, error) {
+	var resp TagValuesResponse
+	if err := s.request(tagValuesRequestMessage, &TagValuesRequest{
+		ShardIDs:  shardIDs,
+		Condition: cond,
+		User:      user,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.TagValues, resp.Err
+}
+
+// SeriesKeys returns the keys of the series of shardIDs on the remote node
+// that user is authorized to read.
+func (s *remoteNodeStore) SeriesKeys(user string, shardIDs []uint64, cond cnosql.Expr) ([]string, error) {
+	var resp SeriesKeysResponse
+	if err := s.request(seriesKeysRequestMessage, &SeriesKeysRequest{
+		ShardIDs:  shardIDs,
+		Condition: cond,
+		User:      user,
+	}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Keys, resp.Err
+}
+
+// SeriesSketches returns the series sketches of database on the remote node.
+func (s *remoteNodeStore) SeriesSketches(database string) (estimator.Sketch, estimator.Sketch, error) {
+	var resp SketchesResponse
+	if err := s.request(seriesSketchesRequestMessage, &SketchesRequest{Database: database}, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Sketch, resp.TSSketch, resp.Err
+}
+
+// MetricsSketches returns the metric sketches of database on the remote node.
+func (s *remoteNodeStore) MetricsSketches(database string) (estimator.Sketch, estimator.Sketch, error) {
+	var resp SketchesResponse
+	if err := s.request(metricSketchesRequestMessage, &SketchesRequest{Database: database}, &resp); err != nil {
+		return nil, nil, err
+	}
+	return resp.Sketch, resp.TSSketch, resp.Err
+}
+
+// CopyShard makes the remote node copy a shard from the data node sourceID.
+func (s *remoteNodeStore) CopyShard(shardID, sourceID uint64, since time.Time) error {
+	var resp CopyShardResponse
+	if err := s.request(copyShardRequestMessage, &CopyShardRequest{
+		ShardID:      shardID,
+		SourceNodeID: sourceID,
+		Since:        since,
+	}, &resp); err != nil {
+		return err
+	}
+	return resp.Err
+}
+
+// request sends req to the remote node and decodes the reply into resp.
+func (s *remoteNodeStore) request(typ byte, req encoding.BinaryMarshaler, resp encoding.BinaryUnmarshaler) error {
+	conn, err := s.dialer.DialNode(s.nodeID)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// Write request.
+	if err := EncodeTLV(conn, typ, req); err != nil {
+		return err
+	}
+
+	// Read the response.
+	_, err = DecodeTLV(conn, resp)
+	return err
+}
+
+// ShardCopier copies shards between data nodes.
+type ShardCopier struct {
+	// NodeDialer dials the nodes copying the shards. Its timeout bounds the
+	// time to copy a shard.
+	NodeDialer *NodeDialer
+}
+
+// CopyShard makes the data node nodeID copy a shard from the data node
+// sourceID. If since is not zero, only the data written since then is copied.
+func (c *ShardCopier) CopyShard(nodeID, shardID, sourceID uint64, since time.Time) error {
+	return newRemoteNodeStore(c.NodeDialer, nodeID).CopyShard(shardID, sourceID, since)
+}
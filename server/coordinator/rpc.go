@@ -4,12 +4,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"github.com/cnosdatabase/cnosdb/server/coordinator/internal"
 	"github.com/cnosdatabase/cnosql"
 	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/estimator"
+	"github.com/cnosdatabase/db/pkg/estimator/hll"
+	"github.com/cnosdatabase/db/pkg/tracing"
 	"github.com/cnosdatabase/db/query"
+	"github.com/cnosdatabase/db/tsdb"
 	"github.com/gogo/protobuf/proto"
 )
 
@@ -209,6 +214,9 @@ type CreateIteratorRequest struct {
 	ShardIDs []uint64
 	Metric   cnosql.Metric
 	Opt      query.IteratorOptions
+
+	// SpanContext continues the trace of the query on the remote node.
+	SpanContext *tracing.SpanContext
 }
 
 // MarshalBinary encodes r to a binary format.
@@ -217,13 +225,27 @@ func (r *CreateIteratorRequest) MarshalBinary() ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
-	return proto.Marshal(&internal.CreateIteratorRequest{
+	pb := internal.CreateIteratorRequest{
 		ShardIDs:   r.ShardIDs,
 		Database:   []byte(r.Metric.Database),
 		TimeToLive: []byte(r.Metric.TimeToLive),
 		MetricName: []byte(r.Metric.Name),
 		Opt:        buf,
-	})
+	}
+	if r.Metric.Regex != nil {
+		pb.MetricRegex = proto.String(r.Metric.Regex.Val.String())
+	}
+	if r.Metric.SystemIterator != "" {
+		pb.SystemIterator = proto.String(r.Metric.SystemIterator)
+	}
+	if r.SpanContext != nil {
+		buf, err := r.SpanContext.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pb.SpanContext = buf
+	}
+	return proto.Marshal(&pb)
 }
 
 // UnmarshalBinary decodes data into r.
@@ -237,9 +259,23 @@ func (r *CreateIteratorRequest) UnmarshalBinary(data []byte) error {
 	r.Metric.Database = string(pb.GetDatabase()[:])
 	r.Metric.TimeToLive = string(pb.GetTimeToLive()[:])
 	r.Metric.Name = string(pb.GetMetricName()[:])
+	r.Metric.SystemIterator = pb.GetSystemIterator()
+	if pb.MetricRegex != nil {
+		re, err := regexp.Compile(pb.GetMetricRegex())
+		if err != nil {
+			return err
+		}
+		r.Metric.Regex = &cnosql.RegexLiteral{Val: re}
+	}
 	if err := r.Opt.UnmarshalBinary(pb.GetOpt()); err != nil {
 		return err
 	}
+	if buf := pb.GetSpanContext(); len(buf) > 0 {
+		r.SpanContext = &tracing.SpanContext{}
+		if err := r.SpanContext.UnmarshalBinary(buf); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -360,3 +396,496 @@ func (r *FieldDimensionsResponse) UnmarshalBinary(data []byte) error {
 	}
 	return nil
 }
+
+// MetricNamesRequest represents a request to retrieve the metric names of a database.
+type MetricNamesRequest struct {
+	Database  string
+	Condition cnosql.Expr
+
+	// User is the user the request is made for, if authenticated.
+	User string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *MetricNamesRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.MetricNamesRequest{
+		Database:  proto.String(r.Database),
+		Condition: marshalCondition(r.Condition),
+		User:      proto.String(r.User),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *MetricNamesRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.MetricNamesRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.Database = pb.GetDatabase()
+	r.User = pb.GetUser()
+	cond, err := unmarshalCondition(pb.GetCondition())
+	if err != nil {
+		return err
+	}
+	r.Condition = cond
+
+	return nil
+}
+
+// MetricNamesResponse represents a response to a MetricNamesRequest.
+type MetricNamesResponse struct {
+	Names [][]byte
+	Err   error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *MetricNamesResponse) MarshalBinary() ([]byte, error) {
+	pb := internal.MetricNamesResponse{Names: r.Names}
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *MetricNamesResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.MetricNamesResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.Names = pb.GetNames()
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// TagKeysRequest represents a request to retrieve the tag keys of a set of shards.
+type TagKeysRequest struct {
+	ShardIDs  []uint64
+	Condition cnosql.Expr
+
+	// User is the user the request is made for, if authenticated.
+	User string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *TagKeysRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.TagKeysRequest{
+		ShardIDs:  r.ShardIDs,
+		Condition: marshalCondition(r.Condition),
+		User:      proto.String(r.User),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *TagKeysRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.TagKeysRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.ShardIDs = pb.GetShardIDs()
+	r.User = pb.GetUser()
+	cond, err := unmarshalCondition(pb.GetCondition())
+	if err != nil {
+		return err
+	}
+	r.Condition = cond
+
+	return nil
+}
+
+// TagKeysResponse represents a response to a TagKeysRequest.
+type TagKeysResponse struct {
+	TagKeys []tsdb.TagKeys
+	Err     error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *TagKeysResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.TagKeysResponse
+
+	buf, err := json.Marshal(r.TagKeys)
+	if err != nil {
+		return nil, err
+	}
+	pb.TagKeys = buf
+
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *TagKeysResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.TagKeysResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(pb.GetTagKeys(), &r.TagKeys); err != nil {
+		return err
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// TagValuesRequest represents a request to retrieve the tag values of a set of shards.
+type TagValuesRequest struct {
+	ShardIDs  []uint64
+	Condition cnosql.Expr
+
+	// User is the user the request is made for, if authenticated.
+	User string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *TagValuesRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.TagValuesRequest{
+		ShardIDs:  r.ShardIDs,
+		Condition: marshalCondition(r.Condition),
+		User:      proto.String(r.User),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *TagValuesRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.TagValuesRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.ShardIDs = pb.GetShardIDs()
+	r.User = pb.GetUser()
+	cond, err := unmarshalCondition(pb.GetCondition())
+	if err != nil {
+		return err
+	}
+	r.Condition = cond
+
+	return nil
+}
+
+// TagValuesResponse represents a response to a TagValuesRequest.
+type TagValuesResponse struct {
+	TagValues []tsdb.TagValues
+	Err       error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *TagValuesResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.TagValuesResponse
+
+	buf, err := json.Marshal(r.TagValues)
+	if err != nil {
+		return nil, err
+	}
+	pb.TagValues = buf
+
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *TagValuesResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.TagValuesResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(pb.GetTagValues(), &r.TagValues); err != nil {
+		return err
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// SeriesKeysRequest represents a request to retrieve the series keys of a set of shards.
+type SeriesKeysRequest struct {
+	ShardIDs  []uint64
+	Condition cnosql.Expr
+
+	// User is the user the request is made for, if authenticated.
+	User string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *SeriesKeysRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.SeriesKeysRequest{
+		ShardIDs:  r.ShardIDs,
+		Condition: marshalCondition(r.Condition),
+		User:      proto.String(r.User),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *SeriesKeysRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.SeriesKeysRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.ShardIDs = pb.GetShardIDs()
+	r.User = pb.GetUser()
+	cond, err := unmarshalCondition(pb.GetCondition())
+	if err != nil {
+		return err
+	}
+	r.Condition = cond
+
+	return nil
+}
+
+// SeriesKeysResponse represents a response to a SeriesKeysRequest.
+type SeriesKeysResponse struct {
+	Keys []string
+	Err  error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *SeriesKeysResponse) MarshalBinary() ([]byte, error) {
+	pb := internal.SeriesKeysResponse{Keys: r.Keys}
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *SeriesKeysResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.SeriesKeysResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.Keys = pb.GetKeys()
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// SketchesRequest represents a request to retrieve the series or metric
+// cardinality sketches of a database.
+type SketchesRequest struct {
+	Database string
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *SketchesRequest) MarshalBinary() ([]byte, error) {
+	return proto.Marshal(&internal.SketchesRequest{
+		Database: proto.String(r.Database),
+	})
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *SketchesRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.SketchesRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.Database = pb.GetDatabase()
+	return nil
+}
+
+// SketchesResponse represents a response to a SketchesRequest. Sketch estimates
+// the number of items and TSSketch the number of tombstoned items.
+type SketchesResponse struct {
+	Sketch   estimator.Sketch
+	TSSketch estimator.Sketch
+	Err      error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *SketchesResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.SketchesResponse
+
+	if r.Sketch != nil {
+		buf, err := r.Sketch.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pb.Sketch = buf
+	}
+
+	if r.TSSketch != nil {
+		buf, err := r.TSSketch.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		pb.TSSketch = buf
+	}
+
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *SketchesResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.SketchesResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+		return nil
+	}
+
+	r.Sketch, r.TSSketch = hll.NewDefaultPlus(), hll.NewDefaultPlus()
+	if buf := pb.GetSketch(); len(buf) > 0 {
+		if err := r.Sketch.UnmarshalBinary(buf); err != nil {
+			return err
+		}
+	}
+	if buf := pb.GetTSSketch(); len(buf) > 0 {
+		if err := r.TSSketch.UnmarshalBinary(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IteratorCostRequest represents a request for the cost of creating a remote
+// iterator. It is encoded like a CreateIteratorRequest.
+type IteratorCostRequest struct {
+	CreateIteratorRequest
+}
+
+// IteratorCostResponse represents a response to an IteratorCostRequest.
+type IteratorCostResponse struct {
+	Cost query.IteratorCost
+	Err  error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *IteratorCostResponse) MarshalBinary() ([]byte, error) {
+	pb := internal.IteratorCostResponse{
+		NumShards:    proto.Int64(r.Cost.NumShards),
+		NumSeries:    proto.Int64(r.Cost.NumSeries),
+		CachedValues: proto.Int64(r.Cost.CachedValues),
+		NumFiles:     proto.Int64(r.Cost.NumFiles),
+		BlocksRead:   proto.Int64(r.Cost.BlocksRead),
+		BlockSize:    proto.Int64(r.Cost.BlockSize),
+	}
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *IteratorCostResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.IteratorCostResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+		return nil
+	}
+
+	r.Cost = query.IteratorCost{
+		NumShards:    pb.GetNumShards(),
+		NumSeries:    pb.GetNumSeries(),
+		CachedValues: pb.GetCachedValues(),
+		NumFiles:     pb.GetNumFiles(),
+		BlocksRead:   pb.GetBlocksRead(),
+		BlockSize:    pb.GetBlockSize(),
+	}
+	return nil
+}
+
+// CopyShardRequest represents a request to copy a shard from another data
+// node. If Since is not zero, only the data written since then is copied.
+type CopyShardRequest struct {
+	ShardID      uint64
+	SourceNodeID uint64
+	Since        time.Time
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *CopyShardRequest) MarshalBinary() ([]byte, error) {
+	pb := internal.CopyShardRequest{
+		ShardID:      proto.Uint64(r.ShardID),
+		SourceNodeID: proto.Uint64(r.SourceNodeID),
+	}
+	if !r.Since.IsZero() {
+		pb.Since = proto.Int64(r.Since.UnixNano())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *CopyShardRequest) UnmarshalBinary(data []byte) error {
+	var pb internal.CopyShardRequest
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	r.ShardID = pb.GetShardID()
+	r.SourceNodeID = pb.GetSourceNodeID()
+	if pb.Since != nil {
+		r.Since = time.Unix(0, pb.GetSince()).UTC()
+	}
+	return nil
+}
+
+// CopyShardResponse represents a response to a CopyShardRequest.
+type CopyShardResponse struct {
+	Err error
+}
+
+// MarshalBinary encodes r to a binary format.
+func (r *CopyShardResponse) MarshalBinary() ([]byte, error) {
+	var pb internal.CopyShardResponse
+	if r.Err != nil {
+		pb.Err = proto.String(r.Err.Error())
+	}
+	return proto.Marshal(&pb)
+}
+
+// UnmarshalBinary decodes data into r.
+func (r *CopyShardResponse) UnmarshalBinary(data []byte) error {
+	var pb internal.CopyShardResponse
+	if err := proto.Unmarshal(data, &pb); err != nil {
+		return err
+	}
+
+	if pb.Err != nil {
+		r.Err = errors.New(pb.GetErr())
+	}
+	return nil
+}
+
+// marshalCondition encodes a condition expression so it can be sent to another node.
+func marshalCondition(cond cnosql.Expr) *string {
+	if cond == nil {
+		return nil
+	}
+	return proto.String(cond.String())
+}
+
+// unmarshalCondition decodes a condition expression encoded by marshalCondition.
+func unmarshalCondition(s string) (cnosql.Expr, error) {
+	if s == "" {
+		return nil, nil
+	}
+	return cnosql.ParseExpr(s)
+}
@@ -11,10 +11,14 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/cnosdatabase/cnosdb/meta"
+	"github.com/cnosdatabase/cnosdb/server/snapshotter"
 	"github.com/cnosdatabase/cnosql"
 	"github.com/cnosdatabase/common"
+	"github.com/cnosdatabase/db/pkg/tracing"
+	"github.com/cnosdatabase/db/pkg/tracing/fields"
 	"github.com/cnosdatabase/db/query"
 	"github.com/cnosdatabase/db/tsdb"
 	"go.uber.org/zap"
@@ -38,8 +42,16 @@ const (
 	fieldDimensionsReq  = "fieldDimensionsReq"
 	fieldDimensionsResp = "fieldDimensionsResp"
 
+	metricNamesReq = "metricNamesReq"
+	tagKeysReq     = "tagKeysReq"
+	tagValuesReq   = "tagValuesReq"
 	seriesKeysReq  = "seriesKeysReq"
-	seriesKeysResp = "seriesKeysResp"
+	sketchesReq    = "sketchesReq"
+
+	iteratorCostReq = "iteratorCostReq"
+
+	copyShardReq  = "copyShardReq"
+	copyShardFail = "copyShardFail"
 )
 
 // Service processes data received over raw TCP connections.
@@ -52,7 +64,9 @@ type Service struct {
 	Listener net.Listener
 
 	MetaClient interface {
+		DataNode(id uint64) (*meta.NodeInfo, error)
 		ShardOwner(shardID uint64) (string, string, *meta.RegionInfo)
+		User(name string) (meta.User, error)
 	}
 
 	TSDBStore TSDBStore
@@ -190,6 +204,34 @@ func (s *Service) handleConn(conn net.Conn) {
 			s.statMap.Add(fieldDimensionsReq, 1)
 			s.processFieldDimensionsRequest(conn)
 			return
+		case metricNamesRequestMessage:
+			s.statMap.Add(metricNamesReq, 1)
+			s.processMetricNamesRequest(conn)
+			return
+		case tagKeysRequestMessage:
+			s.statMap.Add(tagKeysReq, 1)
+			s.processTagKeysRequest(conn)
+			return
+		case tagValuesRequestMessage:
+			s.statMap.Add(tagValuesReq, 1)
+			s.processTagValuesRequest(conn)
+			return
+		case seriesKeysRequestMessage:
+			s.statMap.Add(seriesKeysReq, 1)
+			s.processSeriesKeysRequest(conn)
+			return
+		case seriesSketchesRequestMessage, metricSketchesRequestMessage:
+			s.statMap.Add(sketchesReq, 1)
+			s.processSketchesRequest(conn, typ)
+			return
+		case iteratorCostRequestMessage:
+			s.statMap.Add(iteratorCostReq, 1)
+			s.processIteratorCostRequest(conn)
+			return
+		case copyShardRequestMessage:
+			s.statMap.Add(copyShardReq, 1)
+			s.processCopyShardRequest(conn)
+			return
 		default:
 			s.Logger.Info("coordinator service message type not found:", zap.Uint8("Type", uint8(typ)))
 		}
@@ -220,6 +262,10 @@ func (s *Service) executeStatement(stmt cnosql.Statement, database string) error
 		return s.TSDBStore.DeleteMetric(database, t.Name)
 	case *cnosql.DropSeriesStatement:
 		return s.TSDBStore.DeleteSeries(database, t.Sources, t.Condition)
+	case *cnosql.DeleteSeriesStatement:
+		return s.TSDBStore.DeleteSeries(database, t.Sources, t.Condition)
+	case *cnosql.DropShardStatement:
+		return s.TSDBStore.DeleteShard(t.ID)
 	case *cnosql.DropTimeToLiveStatement:
 		return s.TSDBStore.DeleteTimeToLive(database, t.Name)
 	default:
@@ -297,29 +343,70 @@ func (s *Service) writeShardResponse(w io.Writer, e error) {
 func (s *Service) processCreateIteratorRequest(conn net.Conn) {
 	defer conn.Close()
 
-	var itr query.Iterator
+	var (
+		itr   query.Iterator
+		trace *tracing.Trace
+		span  *tracing.Span
+		start = time.Now()
+	)
 	if err := func() error {
 		// Parse request.
 		var req CreateIteratorRequest
 		if err := DecodeLV(conn, &req); err != nil {
 			return err
 		}
+
+		// Continue the trace of the query if the request is traced.
+		ctx := context.Background()
+		if req.SpanContext != nil {
+			trace, span = tracing.NewTraceFromSpan("create_iterator_remote", *req.SpanContext)
+			ctx = tracing.NewContextWithTrace(ctx, trace)
+			ctx = tracing.NewContextWithSpan(ctx, span)
+		}
+
 		sg := s.TSDBStore.Region(req.ShardIDs)
-		ic, err := sg.CreateIterator(context.Background(), &req.Metric, req.Opt)
+		if req.Metric.Regex == nil {
+			ic, err := sg.CreateIterator(ctx, &req.Metric, req.Opt)
+			if err != nil {
+				return err
+			}
+			itr = ic
+			return nil
+		}
+
+		// Create an iterator for each metric matching the regex.
+		metrics := sg.MetricsByRegex(req.Metric.Regex.Val)
+		inputs := make([]query.Iterator, 0, len(metrics))
+		for _, metric := range metrics {
+			m := req.Metric.Clone()
+			m.Name = metric
+			input, err := sg.CreateIterator(ctx, m, req.Opt)
+			if err != nil {
+				query.Iterators(inputs).Close()
+				return err
+			}
+			inputs = append(inputs, input)
+		}
+
+		ic, err := query.Iterators(inputs).Merge(req.Opt)
 		if err != nil {
+			query.Iterators(inputs).Close()
 			return err
 		}
 		itr = ic
 		return nil
 	}(); err != nil {
-		itr.Close()
+		if itr != nil {
+			itr.Close()
+		}
 		//s.Logger.Printf("error reading CreateIterator request: %s", err)
 		EncodeTLV(conn, createIteratorResponseMessage, &CreateIteratorResponse{Err: err})
 		return
 	}
 
-	// Exit if no iterator was produced.
+	// Respond with an empty iterator if none was produced.
 	if itr == nil {
+		EncodeTLV(conn, createIteratorResponseMessage, &CreateIteratorResponse{typ: cnosql.Unknown})
 		return
 	}
 
@@ -349,10 +436,129 @@ func (s *Service) processCreateIteratorRequest(conn net.Conn) {
 	}
 
 	// Stream iterator to connection.
-	if err := query.NewIteratorEncoder(conn).EncodeIterator(itr); err != nil {
+	planningTime := time.Since(start)
+	enc := query.NewIteratorEncoder(conn)
+	if err := enc.EncodeIterator(itr); err != nil {
+		itr.Close()
 		s.Logger.Info("error encoding CreateIterator iterator", zap.Error(err))
 		return
 	}
+	itr.Close()
+
+	// Send the spans of the remote node at the end of the stream. The
+	// iterators finish their spans when they are closed.
+	if trace != nil {
+		totalTime := time.Since(start)
+		span.MergeFields(
+			fields.Duration("total_time", totalTime),
+			fields.Duration("planning_time", planningTime),
+			fields.Duration("execution_time", totalTime-planningTime),
+		)
+		span.Finish()
+		if err := enc.EncodeTrace(trace); err != nil {
+			s.Logger.Info("error encoding CreateIterator trace", zap.Error(err))
+			return
+		}
+	}
+}
+
+// processIteratorCostRequest returns the cost of creating an iterator on the
+// local shards.
+func (s *Service) processIteratorCostRequest(conn net.Conn) {
+	defer conn.Close()
+
+	var cost query.IteratorCost
+	if err := func() error {
+		// Parse request.
+		var req IteratorCostRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+
+		sg := s.TSDBStore.Region(req.ShardIDs)
+		if req.Metric.Regex == nil {
+			var err error
+			cost, err = sg.IteratorCost(req.Metric.Name, req.Opt)
+			return err
+		}
+
+		for _, metric := range sg.MetricsByRegex(req.Metric.Regex.Val) {
+			c, err := sg.IteratorCost(metric, req.Opt)
+			if err != nil {
+				return err
+			}
+			cost = cost.Combine(c)
+		}
+		return nil
+	}(); err != nil {
+		s.Logger.Info("error reading IteratorCost request", zap.Error(err))
+		EncodeTLV(conn, iteratorCostResponseMessage, &IteratorCostResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, iteratorCostResponseMessage, &IteratorCostResponse{Cost: cost}); err != nil {
+		s.Logger.Info("error writing IteratorCost response", zap.Error(err))
+		return
+	}
+}
+
+// processCopyShardRequest copies a shard from another data node into the
+// local store.
+func (s *Service) processCopyShardRequest(conn net.Conn) {
+	defer conn.Close()
+
+	if err := func() error {
+		// Parse request.
+		var req CopyShardRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+		return s.CopyShard(req.ShardID, req.SourceNodeID, req.Since)
+	}(); err != nil {
+		s.statMap.Add(copyShardFail, 1)
+		s.Logger.Info("error copying shard", zap.Error(err))
+		EncodeTLV(conn, copyShardResponseMessage, &CopyShardResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, copyShardResponseMessage, &CopyShardResponse{}); err != nil {
+		s.Logger.Info("error writing CopyShard response", zap.Error(err))
+		return
+	}
+}
+
+// CopyShard copies a shard from the data node sourceID into the local store,
+// creating the shard if needed. The copied files are added to the shard, so
+// copying a shard again only duplicates data until it is compacted. If since
+// is not zero, only the files changed since then are copied.
+func (s *Service) CopyShard(shardID, sourceID uint64, since time.Time) error {
+	database, ttl, _ := s.MetaClient.ShardOwner(shardID)
+	if database == "" {
+		return fmt.Errorf("shard %d not found", shardID)
+	}
+
+	source, err := s.MetaClient.DataNode(sourceID)
+	if err != nil {
+		return fmt.Errorf("source node %d: %s", sourceID, err)
+	}
+
+	if err := s.TSDBStore.CreateShard(database, ttl, shardID, true); err != nil {
+		return err
+	}
+
+	r, err := snapshotter.NewClient(source.TCPHost).ShardBackup(shardID, since)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	s.Logger.Info("Copying shard",
+		zap.Uint64("shard_id", shardID),
+		zap.Uint64("source_node_id", sourceID),
+		zap.Time("since", since))
+	return s.TSDBStore.ImportShard(shardID, r)
 }
 
 func (s *Service) processFieldDimensionsRequest(conn net.Conn) {
@@ -398,6 +604,197 @@ func (s *Service) processFieldDimensionsRequest(conn net.Conn) {
 	}
 }
 
+func (s *Service) processMetricNamesRequest(conn net.Conn) {
+	defer conn.Close()
+
+	var names [][]byte
+	if err := func() error {
+		// Parse request.
+		var req MetricNamesRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+
+		auth, err := s.authorizer(req.User)
+		if err != nil {
+			return err
+		}
+		n, err := s.TSDBStore.MetricNames(auth, req.Database, req.Condition)
+		if err != nil {
+			return err
+		}
+		names = n
+		return nil
+	}(); err != nil {
+		s.Logger.Info("error reading MetricNames request", zap.Error(err))
+		EncodeTLV(conn, metricNamesResponseMessage, &MetricNamesResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, metricNamesResponseMessage, &MetricNamesResponse{
+		Names: names,
+	}); err != nil {
+		s.Logger.Info("error writing MetricNames response", zap.Error(err))
+		return
+	}
+}
+
+func (s *Service) processTagKeysRequest(conn net.Conn) {
+	defer conn.Close()
+
+	var tagKeys []tsdb.TagKeys
+	if err := func() error {
+		// Parse request.
+		var req TagKeysRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+
+		auth, err := s.authorizer(req.User)
+		if err != nil {
+			return err
+		}
+		keys, err := s.TSDBStore.TagKeys(auth, req.ShardIDs, req.Condition)
+		if err != nil {
+			return err
+		}
+		tagKeys = keys
+		return nil
+	}(); err != nil {
+		s.Logger.Info("error reading TagKeys request", zap.Error(err))
+		EncodeTLV(conn, tagKeysResponseMessage, &TagKeysResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, tagKeysResponseMessage, &TagKeysResponse{
+		TagKeys: tagKeys,
+	}); err != nil {
+		s.Logger.Info("error writing TagKeys response", zap.Error(err))
+		return
+	}
+}
+
+func (s *Service) processTagValuesRequest(conn net.Conn) {
+	defer conn.Close()
+
+	var tagValues []tsdb.TagValues
+	if err := func() error {
+		// Parse request.
+		var req TagValuesRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+
+		auth, err := s.authorizer(req.User)
+		if err != nil {
+			return err
+		}
+		values, err := s.TSDBStore.TagValues(auth, req.ShardIDs, req.Condition)
+		if err != nil {
+			return err
+		}
+		tagValues = values
+		return nil
+	}(); err != nil {
+		s.Logger.Info("error reading TagValues request", zap.Error(err))
+		EncodeTLV(conn, tagValuesResponseMessage, &TagValuesResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, tagValuesResponseMessage, &TagValuesResponse{
+		TagValues: tagValues,
+	}); err != nil {
+		s.Logger.Info("error writing TagValues response", zap.Error(err))
+		return
+	}
+}
+
+func (s *Service) processSeriesKeysRequest(conn net.Conn) {
+	defer conn.Close()
+
+	var seriesKeys []string
+	if err := func() error {
+		// Parse request.
+		var req SeriesKeysRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+
+		auth, err := s.authorizer(req.User)
+		if err != nil {
+			return err
+		}
+		keys, err := s.TSDBStore.SeriesKeys(auth, req.ShardIDs, req.Condition)
+		if err != nil {
+			return err
+		}
+		seriesKeys = keys
+		return nil
+	}(); err != nil {
+		s.Logger.Info("error reading SeriesKeys request", zap.Error(err))
+		EncodeTLV(conn, seriesKeysResponseMessage, &SeriesKeysResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, seriesKeysResponseMessage, &SeriesKeysResponse{
+		Keys: seriesKeys,
+	}); err != nil {
+		s.Logger.Info("error writing SeriesKeys response", zap.Error(err))
+		return
+	}
+}
+
+// authorizer returns the fine grained authorizer of the user a remote request
+// is made for. Requests made without authentication are not restricted.
+func (s *Service) authorizer(user string) (query.FineAuthorizer, error) {
+	if user == "" {
+		return query.OpenAuthorizer, nil
+	}
+	return s.MetaClient.User(user)
+}
+
+// processSketchesRequest returns the series or metric sketches of a database,
+// depending on the message type.
+func (s *Service) processSketchesRequest(conn net.Conn, typ byte) {
+	defer conn.Close()
+
+	respType := seriesSketchesResponseMessage
+	if typ == metricSketchesRequestMessage {
+		respType = metricSketchesResponseMessage
+	}
+
+	var resp SketchesResponse
+	if err := func() error {
+		// Parse request.
+		var req SketchesRequest
+		if err := DecodeLV(conn, &req); err != nil {
+			return err
+		}
+
+		var err error
+		if typ == metricSketchesRequestMessage {
+			resp.Sketch, resp.TSSketch, err = s.TSDBStore.MetricsSketches(req.Database)
+		} else {
+			resp.Sketch, resp.TSSketch, err = s.TSDBStore.SeriesSketches(req.Database)
+		}
+		return err
+	}(); err != nil {
+		s.Logger.Info("error reading Sketches request", zap.Error(err))
+		EncodeTLV(conn, respType, &SketchesResponse{Err: err})
+		return
+	}
+
+	// Encode success response.
+	if err := EncodeTLV(conn, respType, &resp); err != nil {
+		s.Logger.Info("error writing Sketches response", zap.Error(err))
+		return
+	}
+}
+
 // ReadTLV reads a type-length-value record from r.
 func ReadTLV(r io.Reader) (byte, []byte, error) {
 	typ, err := ReadType(r)
@@ -2,13 +2,20 @@ package coordinator
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"net"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/cnosdatabase/cnosdb"
 	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/pkg/network"
 	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/pkg/tracing"
+	"github.com/cnosdatabase/db/pkg/tracing/fields"
 	"github.com/cnosdatabase/db/query"
 	"github.com/cnosdatabase/db/tsdb"
 )
@@ -80,6 +87,10 @@ func (e *LocalShardMapper) mapShards(a *LocalShardMapping, sources cnosql.Source
 			if err := e.mapShards(a, s.Statement.Sources, tmin, tmax); err != nil {
 				return err
 			}
+		case *cnosql.Join:
+			if err := e.mapShards(a, cnosql.Sources{s.Left, s.Right}, tmin, tmax); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -250,6 +261,397 @@ func (a *LocalShardMapping) Close() error {
 	return nil
 }
 
+// ClusterShardMapper implements a ShardMapper for local and remote shards.
+// Each shard is read from a single owner, preferring the local copy.
+type ClusterShardMapper struct {
+	MetaClient interface {
+		RegionsByTimeRange(database, ttl string, min, max time.Time) (a []meta.RegionInfo, err error)
+		DataNodeDown(id uint64) bool
+	}
+
+	TSDBStore interface {
+		Region(ids []uint64) tsdb.Region
+	}
+
+	// Local data node.
+	Node *cnosdb.Node
+
+	// Dialer for the data nodes owning remote shards.
+	NodeDialer *NodeDialer
+
+	// Read shards from a remote owner even when a local copy exists.
+	ForceRemoteMapping bool
+
+	// Local shards being copied from their other owners, which are read
+	// from a remote owner until they are complete.
+	Rehydrating RehydratingShards
+}
+
+// RehydratingShards reports the local shards being copied from their other
+// owners.
+type RehydratingShards interface {
+	Rehydrating(id uint64) bool
+}
+
+// rehydrating returns a function reporting whether a local shard is being
+// copied from its other owners.
+func rehydrating(r RehydratingShards) func(id uint64) bool {
+	if r == nil {
+		return func(id uint64) bool { return false }
+	}
+	return r.Rehydrating
+}
+
+// MapShards maps the sources to the appropriate shards into an IteratorCreator.
+func (e *ClusterShardMapper) MapShards(sources cnosql.Sources, t cnosql.TimeRange, opt query.SelectOptions) (query.Region, error) {
+	a := &ClusterShardMapping{
+		ShardMap: make(map[Source]*clusterRegion),
+	}
+
+	tmin := time.Unix(0, t.MinTimeNano())
+	tmax := time.Unix(0, t.MaxTimeNano())
+	if err := e.mapShards(a, sources, tmin, tmax); err != nil {
+		return nil, err
+	}
+	a.MinTime, a.MaxTime = tmin, tmax
+	return a, nil
+}
+
+func (e *ClusterShardMapper) mapShards(a *ClusterShardMapping, sources cnosql.Sources, tmin, tmax time.Time) error {
+	for _, s := range sources {
+		switch s := s.(type) {
+		case *cnosql.Metric:
+			source := Source{
+				Database:   s.Database,
+				TimeToLive: s.TimeToLive,
+			}
+			// Retrieve the list of shards for this database. This list of
+			// shards is always the same regardless of which metric we are
+			// using.
+			if _, ok := a.ShardMap[source]; !ok {
+				groups, err := e.MetaClient.RegionsByTimeRange(s.Database, s.TimeToLive, tmin, tmax)
+				if err != nil {
+					return err
+				}
+
+				if len(groups) == 0 {
+					a.ShardMap[source] = nil
+					continue
+				}
+
+				var localID uint64
+				if e.Node != nil {
+					localID = e.Node.ID
+				}
+
+				owners, err := shardIDsByOwner(groups, localID, e.NodeDialer != nil, e.ForceRemoteMapping, e.MetaClient.DataNodeDown, rehydrating(e.Rehydrating))
+				if err != nil {
+					return err
+				}
+
+				rg := &clusterRegion{}
+				for nodeID, shardIDs := range owners {
+					if nodeID == localID {
+						rg.local = e.TSDBStore.Region(shardIDs)
+						continue
+					}
+					rg.remotes = append(rg.remotes, &cachedRemoteIteratorCreator{
+						remoteIteratorCreator: newRemoteIteratorCreator(e.NodeDialer, nodeID, shardIDs),
+					})
+				}
+				a.ShardMap[source] = rg
+			}
+		case *cnosql.SubQuery:
+			if err := e.mapShards(a, s.Statement.Sources, tmin, tmax); err != nil {
+				return err
+			}
+		case *cnosql.Join:
+			if err := e.mapShards(a, cnosql.Sources{s.Left, s.Right}, tmin, tmax); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// shardIDsByOwner assigns every shard in regions to a single owner and returns
+// the shard IDs to read, keyed by node ID. Local copies are preferred unless
+// forceRemote is set or rehydrating returns true for the shard, and owners for
+// which down returns true are skipped unless no other owner is left. If remote
+// is false every shard is read locally. A shard being rehydrated without
+// another owner up is never read from its incomplete local copy.
+func shardIDsByOwner(regions []meta.RegionInfo, localID uint64, remote, forceRemote bool, down, rehydrating func(id uint64) bool) (map[uint64][]uint64, error) {
+	m := make(map[uint64][]uint64)
+	for _, rg := range regions {
+		for _, si := range rg.Shards {
+			nodeID := localID
+			if remote && len(si.Owners) > 0 {
+				if !si.OwnedBy(localID) {
+					nodeID = remoteOwner(&si, localID, down)
+				} else if forceRemote || rehydrating(si.ID) {
+					for _, owner := range si.Owners {
+						if owner.NodeID != localID && owner.NodeID != 0 && !down(owner.NodeID) {
+							nodeID = owner.NodeID
+							break
+						}
+					}
+					if nodeID == localID && rehydrating(si.ID) {
+						return nil, fmt.Errorf("shard %d is being copied from its other owners and none of them is up", si.ID)
+					}
+				}
+				if nodeID == 0 {
+					nodeID = localID
+				}
+			}
+			m[nodeID] = append(m[nodeID], si.ID)
+		}
+	}
+	return m, nil
+}
+
+// remoteOwner returns the owner to read a shard not owned by the local node
+// from. The owners are spread by shard ID and the owners that are down are
+// skipped.
+func remoteOwner(si *meta.ShardInfo, localID uint64, down func(id uint64) bool) uint64 {
+	n := uint64(len(si.Owners))
+	first := si.ID % n
+	for i := uint64(0); i < n; i++ {
+		if id := si.Owners[(first+i)%n].NodeID; !down(id) {
+			return id
+		}
+	}
+	return si.Owners[first].NodeID
+}
+
+// clusterRegion holds the local and remote shards of a source.
+type clusterRegion struct {
+	local   tsdb.Region
+	remotes []*cachedRemoteIteratorCreator
+}
+
+// cachedRemoteIteratorCreator caches the fields and dimensions of the remote
+// shards so that mapping the type of each field does not require a round trip.
+type cachedRemoteIteratorCreator struct {
+	*remoteIteratorCreator
+
+	mu    sync.Mutex
+	cache map[string]fieldDimensions
+}
+
+type fieldDimensions struct {
+	fields     map[string]cnosql.DataType
+	dimensions map[string]struct{}
+	err        error
+}
+
+// FieldDimensions returns the unique fields and dimensions of the remote shards.
+func (ic *cachedRemoteIteratorCreator) FieldDimensions(m *cnosql.Metric) (map[string]cnosql.DataType, map[string]struct{}, error) {
+	key := m.String()
+
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if fd, ok := ic.cache[key]; ok {
+		return fd.fields, fd.dimensions, fd.err
+	}
+
+	fields, dimensions, err := ic.remoteIteratorCreator.FieldDimensions(m)
+	if ic.cache == nil {
+		ic.cache = make(map[string]fieldDimensions)
+	}
+	ic.cache[key] = fieldDimensions{fields: fields, dimensions: dimensions, err: err}
+	return fields, dimensions, err
+}
+
+// MapType returns the data type of field in the remote shards.
+func (ic *cachedRemoteIteratorCreator) MapType(m *cnosql.Metric, field string) cnosql.DataType {
+	switch field {
+	case "_name", "_tagKey", "_tagValue", "_seriesKey":
+		return cnosql.String
+	}
+
+	// Process system metrics.
+	switch m.SystemIterator {
+	case "_fieldKeys":
+		if field == "fieldKey" || field == "fieldType" {
+			return cnosql.String
+		}
+		return cnosql.Unknown
+	case "_series":
+		if field == "key" {
+			return cnosql.String
+		}
+		return cnosql.Unknown
+	case "_tagKeys":
+		if field == "tagKey" {
+			return cnosql.String
+		}
+		return cnosql.Unknown
+	}
+
+	fields, dimensions, err := ic.FieldDimensions(m)
+	if err != nil {
+		return cnosql.Unknown
+	}
+	if typ, ok := fields[field]; ok {
+		return typ
+	} else if _, ok := dimensions[field]; ok {
+		return cnosql.Tag
+	}
+	return cnosql.Unknown
+}
+
+// ClusterShardMapping maps data sources to local and remote shards.
+type ClusterShardMapping struct {
+	ShardMap map[Source]*clusterRegion
+
+	// MinTime is the minimum time that this shard mapper will allow.
+	// Any attempt to use a time before this one will automatically result in using
+	// this time instead.
+	MinTime time.Time
+
+	// MaxTime is the maximum time that this shard mapper will allow.
+	// Any attempt to use a time after this one will automatically result in using
+	// this time instead.
+	MaxTime time.Time
+}
+
+// local returns a LocalShardMapping of the local shards.
+func (a *ClusterShardMapping) local() *LocalShardMapping {
+	m := &LocalShardMapping{
+		ShardMap: make(map[Source]tsdb.Region, len(a.ShardMap)),
+		MinTime:  a.MinTime,
+		MaxTime:  a.MaxTime,
+	}
+	for source, rg := range a.ShardMap {
+		if rg != nil && rg.local != nil {
+			m.ShardMap[source] = rg.local
+		}
+	}
+	return m
+}
+
+// remotes returns the remote iterator creators of the source of m.
+func (a *ClusterShardMapping) remotes(m *cnosql.Metric) []*cachedRemoteIteratorCreator {
+	rg := a.ShardMap[Source{Database: m.Database, TimeToLive: m.TimeToLive}]
+	if rg == nil {
+		return nil
+	}
+	return rg.remotes
+}
+
+func (a *ClusterShardMapping) FieldDimensions(m *cnosql.Metric) (fields map[string]cnosql.DataType, dimensions map[string]struct{}, err error) {
+	fields, dimensions, err = a.local().FieldDimensions(m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remotes := a.remotes(m)
+	if len(remotes) == 0 {
+		return fields, dimensions, nil
+	}
+
+	if fields == nil {
+		fields = make(map[string]cnosql.DataType)
+		dimensions = make(map[string]struct{})
+	}
+	for _, ic := range remotes {
+		f, d, err := ic.FieldDimensions(m)
+		if err != nil {
+			return nil, nil, err
+		}
+		for k, typ := range f {
+			if fields[k].LessThan(typ) {
+				fields[k] = typ
+			}
+		}
+		for k := range d {
+			dimensions[k] = struct{}{}
+		}
+	}
+	return fields, dimensions, nil
+}
+
+func (a *ClusterShardMapping) MapType(m *cnosql.Metric, field string) cnosql.DataType {
+	typ := a.local().MapType(m, field)
+	for _, ic := range a.remotes(m) {
+		if t := ic.MapType(m, field); typ.LessThan(t) {
+			typ = t
+		}
+	}
+	return typ
+}
+
+func (a *ClusterShardMapping) CreateIterator(ctx context.Context, m *cnosql.Metric, opt query.IteratorOptions) (query.Iterator, error) {
+	// Override the time constraints if they don't match each other.
+	if !a.MinTime.IsZero() && opt.StartTime < a.MinTime.UnixNano() {
+		opt.StartTime = a.MinTime.UnixNano()
+	}
+	if !a.MaxTime.IsZero() && opt.EndTime > a.MaxTime.UnixNano() {
+		opt.EndTime = a.MaxTime.UnixNano()
+	}
+
+	itr, err := a.local().CreateIterator(ctx, m, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	remotes := a.remotes(m)
+	if len(remotes) == 0 {
+		return itr, nil
+	}
+
+	inputs := make([]query.Iterator, 0, len(remotes)+1)
+	if itr != nil {
+		inputs = append(inputs, itr)
+	}
+	for _, ic := range remotes {
+		input, err := ic.CreateIterator(ctx, m, opt)
+		if err != nil {
+			query.Iterators(inputs).Close()
+			return nil, err
+		} else if input == nil {
+			continue
+		}
+		inputs = append(inputs, input)
+	}
+
+	if len(inputs) == 0 {
+		return nil, nil
+	}
+	return query.Iterators(inputs).Merge(opt)
+}
+
+// IteratorCost returns the cost of reading the local and the remote shards.
+func (a *ClusterShardMapping) IteratorCost(m *cnosql.Metric, opt query.IteratorOptions) (query.IteratorCost, error) {
+	// Override the time constraints if they don't match each other.
+	if !a.MinTime.IsZero() && opt.StartTime < a.MinTime.UnixNano() {
+		opt.StartTime = a.MinTime.UnixNano()
+	}
+	if !a.MaxTime.IsZero() && opt.EndTime > a.MaxTime.UnixNano() {
+		opt.EndTime = a.MaxTime.UnixNano()
+	}
+
+	cost, err := a.local().IteratorCost(m, opt)
+	if err != nil {
+		return query.IteratorCost{}, err
+	}
+
+	for _, ic := range a.remotes(m) {
+		c, err := ic.IteratorCost(m, opt)
+		if err != nil {
+			return query.IteratorCost{}, err
+		}
+		cost = cost.Combine(c)
+	}
+	return cost, nil
+}
+
+// Close clears out the list of mapped shards.
+func (a *ClusterShardMapping) Close() error {
+	a.ShardMap = nil
+	return nil
+}
+
 // remoteIteratorCreator creates iterators for remote shards.
 type remoteIteratorCreator struct {
 	dialer   *NodeDialer
@@ -273,13 +675,27 @@ func (ic *remoteIteratorCreator) CreateIterator(ctx context.Context, m *cnosql.M
 		return nil, err
 	}
 
+	req := &CreateIteratorRequest{
+		ShardIDs: ic.shardIDs,
+		Metric:   *m,
+		Opt:      opt,
+	}
+
+	// Continue the trace on the remote node. Its spans are sent back at the
+	// end of the iterator stream and are children of the span of this
+	// connection.
+	if span := tracing.SpanFromContext(ctx); span != nil {
+		span = span.StartSpan("remote_iterator")
+		span.SetLabels("node_id", strconv.FormatUint(ic.nodeID, 10), "shard_ids", formatShardIDs(ic.shardIDs), "metric", m.String())
+		sc := span.Context()
+		req.SpanContext = &sc
+		conn = newTracedConn(conn, span)
+	}
+
 	var resp CreateIteratorResponse
 	if err := func() error {
 		// Write request.
-		if err := EncodeTLV(conn, createIteratorRequestMessage, &CreateIteratorRequest{
-			ShardIDs: ic.shardIDs,
-			Opt:      opt,
-		}); err != nil {
+		if err := EncodeTLV(conn, createIteratorRequestMessage, req); err != nil {
 			return err
 		}
 
@@ -287,7 +703,7 @@ func (ic *remoteIteratorCreator) CreateIterator(ctx context.Context, m *cnosql.M
 		if _, err := DecodeTLV(conn, &resp); err != nil {
 			return err
 		} else if resp.Err != nil {
-			return err
+			return resp.Err
 		}
 
 		return nil
@@ -296,9 +712,99 @@ func (ic *remoteIteratorCreator) CreateIterator(ctx context.Context, m *cnosql.M
 		return nil, err
 	}
 
+	// The remote node did not produce an iterator.
+	if resp.typ == cnosql.Unknown {
+		conn.Close()
+		return nil, nil
+	}
+
+	// The iterator is streamed for as long as the query runs, so the dial
+	// timeout no longer applies.
+	conn.SetDeadline(time.Time{})
+
 	return query.NewReaderIterator(ctx, conn, resp.typ, resp.stats), nil
 }
 
+// IteratorCost returns the cost of creating an iterator on the remote shards.
+func (ic *remoteIteratorCreator) IteratorCost(m *cnosql.Metric, opt query.IteratorOptions) (query.IteratorCost, error) {
+	conn, err := ic.dialer.DialNode(ic.nodeID)
+	if err != nil {
+		return query.IteratorCost{}, err
+	}
+	defer conn.Close()
+
+	// Write request.
+	if err := EncodeTLV(conn, iteratorCostRequestMessage, &IteratorCostRequest{
+		CreateIteratorRequest: CreateIteratorRequest{
+			ShardIDs: ic.shardIDs,
+			Metric:   *m,
+			Opt:      opt,
+		},
+	}); err != nil {
+		return query.IteratorCost{}, err
+	}
+
+	// Read the response.
+	var resp IteratorCostResponse
+	if _, err := DecodeTLV(conn, &resp); err != nil {
+		return query.IteratorCost{}, err
+	}
+	return resp.Cost, resp.Err
+}
+
+// tracedConn records the time spent on a connection to a remote node and the
+// number of bytes transferred over it in a span. The span is finished when the
+// connection is closed.
+type tracedConn struct {
+	net.Conn
+	span *tracing.Span
+
+	networkTime  time.Duration
+	bytesRead    int64
+	bytesWritten int64
+}
+
+func newTracedConn(conn net.Conn, span *tracing.Span) *tracedConn {
+	return &tracedConn{Conn: conn, span: span}
+}
+
+// Read reads from the connection. The time spent waiting on the remote node
+// for points is included in the network time.
+func (c *tracedConn) Read(b []byte) (int, error) {
+	start := time.Now()
+	n, err := c.Conn.Read(b)
+	c.networkTime += time.Since(start)
+	c.bytesRead += int64(n)
+	return n, err
+}
+
+func (c *tracedConn) Write(b []byte) (int, error) {
+	start := time.Now()
+	n, err := c.Conn.Write(b)
+	c.networkTime += time.Since(start)
+	c.bytesWritten += int64(n)
+	return n, err
+}
+
+func (c *tracedConn) Close() error {
+	c.span.MergeFields(
+		fields.Duration("network_time", c.networkTime),
+		fields.Int64("bytes_read", c.bytesRead),
+		fields.Int64("bytes_written", c.bytesWritten),
+	)
+	c.span.Finish()
+	return c.Conn.Close()
+}
+
+// formatShardIDs returns the shard ids as a comma separated list.
+func formatShardIDs(ids []uint64) string {
+	a := make([]string, len(ids))
+	for i, id := range ids {
+		a[i] = strconv.FormatUint(id, 10)
+	}
+	return strings.Join(a, ",")
+}
+
 // FieldDimensions returns the unique fields and dimensions across a list of sources.
 func (ic *remoteIteratorCreator) FieldDimensions(km *cnosql.Metric) (fields map[string]cnosql.DataType, dimensions map[string]struct{}, err error) {
 	conn, err := ic.dialer.DialNode(ic.nodeID)
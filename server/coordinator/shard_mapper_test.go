@@ -0,0 +1,464 @@
+package coordinator
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cnosdatabase/cnosdb"
+	"github.com/cnosdatabase/cnosdb/meta"
+	"github.com/cnosdatabase/cnosdb/pkg/network"
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/estimator"
+	"github.com/cnosdatabase/db/pkg/estimator/hll"
+	"github.com/cnosdatabase/db/query"
+	"github.com/cnosdatabase/db/tsdb"
+	"github.com/soheilhy/cmux"
+)
+
+// Ensure the shards being rehydrated are read from another owner that is up,
+// and never from their incomplete local copy.
+func TestShardIDsByOwner_Rehydrating(t *testing.T) {
+	regions := []meta.RegionInfo{{
+		ID: 1,
+		Shards: []meta.ShardInfo{
+			{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}, {NodeID: 2}}},
+			{ID: 2, Owners: []meta.ShardOwner{{NodeID: 1}, {NodeID: 3}}},
+			{ID: 3, Owners: []meta.ShardOwner{{NodeID: 2}}},
+		},
+	}}
+	down := func(id uint64) bool { return id == 3 }
+
+	owners, err := shardIDsByOwner(regions, 1, true, false, down, func(id uint64) bool { return id == 1 })
+	if err != nil {
+		t.Fatal(err)
+	} else if exp := map[uint64][]uint64{1: {2}, 2: {1, 3}}; !reflect.DeepEqual(owners, exp) {
+		t.Fatalf("unexpected owners: got=%v exp=%v", owners, exp)
+	}
+
+	_, err = shardIDsByOwner(regions, 1, true, false, down, func(id uint64) bool { return id == 2 })
+	if err == nil || err.Error() != "shard 2 is being copied from its other owners and none of them is up" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure stddev() and spread() are merged from the partial aggregates of the
+// local shards and of the shards read over the coordinator service.
+func TestClusterShardMapper_StddevSpread(t *testing.T) {
+	c := NewTestCluster(t)
+	defer c.Close()
+
+	for _, tt := range []struct {
+		s    string
+		rows []string
+	}{
+		{
+			s:    `SELECT stddev(value), spread(value) FROM cpu WHERE time >= 0s AND time < 60s`,
+			rows: []string{" 0 [2.3380903889000244 7]"},
+		},
+		{
+			s: `SELECT stddev(value), spread(value) FROM cpu WHERE time >= 0s AND time < 60s GROUP BY host`,
+			rows: []string{
+				"host=a 0 [1.1547005383792515 2]",
+				"host=b 0 [2.6457513110645907 5]",
+			},
+		},
+	} {
+		if rows := c.Select(t, tt.s); !reflect.DeepEqual(rows, tt.rows) {
+			t.Fatalf("%s: unexpected rows: got=%q exp=%q", tt.s, rows, tt.rows)
+		}
+	}
+	if n := c.Service.statMap.Get(createIteratorReq); n == nil || n.String() == "0" {
+		t.Fatal("remote shard not read")
+	}
+}
+
+// Ensure stddev() of a string field fails on the shards.
+func TestClusterShardMapper_StddevString(t *testing.T) {
+	c := NewTestCluster(t)
+	defer c.Close()
+
+	stmt := cnosql.MustParseStatement(`SELECT stddev(state) FROM cpu WHERE time >= 0s AND time < 60s`).(*cnosql.SelectStatement)
+	_, err := query.Select(context.Background(), stmt, c.ShardMapper, query.SelectOptions{})
+	if err == nil || !strings.Contains(err.Error(), "unsupported stddev iterator type") {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// TestCluster is a data node with a ClusterShardMapper reading shard 1 locally
+// and shard 2 from a coordinator service standing for the other data node.
+type TestCluster struct {
+	Listener    net.Listener
+	Service     *Service
+	MetaClient  *testMetaClient
+	ShardMapper *ClusterShardMapper
+}
+
+// NewTestCluster returns a TestCluster holding the points of cpu, host a
+// and b, in both of its shards, and a point of mem, host c, in shard 2.
+func NewTestCluster(t *testing.T) *TestCluster {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := cmux.New(ln)
+
+	mc := &testMetaClient{
+		tcpHosts: map[uint64]string{2: ln.Addr().String()},
+		regions: []meta.RegionInfo{{
+			ID:        1,
+			StartTime: time.Unix(0, 0),
+			EndTime:   time.Unix(3600, 0),
+			Shards: []meta.ShardInfo{
+				{ID: 1, Owners: []meta.ShardOwner{{NodeID: 1}}},
+				{ID: 2, Owners: []meta.ShardOwner{{NodeID: 2}}},
+			},
+		}},
+	}
+
+	s := NewService(Config{})
+	s.Listener = network.ListenString(mux, MuxHeader)
+	s.TSDBStore = &testStore{shards: map[uint64][]testPoint{
+		2: {
+			{name: "cpu", tags: map[string]string{"host": "a"}, time: 20, value: 4},
+			{name: "cpu", tags: map[string]string{"host": "b"}, time: 30, value: 5},
+			{name: "cpu", tags: map[string]string{"host": "b"}, time: 40, value: 9},
+			{name: "mem", tags: map[string]string{"host": "c"}, time: 40, value: 1},
+		},
+	}}
+	s.Open()
+	go mux.Serve()
+
+	return &TestCluster{
+		Listener:   ln,
+		Service:    s,
+		MetaClient: mc,
+		ShardMapper: &ClusterShardMapper{
+			MetaClient: mc,
+			TSDBStore: &testStore{shards: map[uint64][]testPoint{
+				1: {
+					{name: "cpu", tags: map[string]string{"host": "a"}, time: 0, value: 2},
+					{name: "cpu", tags: map[string]string{"host": "a"}, time: 10, value: 4},
+					{name: "cpu", tags: map[string]string{"host": "b"}, time: 10, value: 4},
+				},
+			}},
+			Node:       &cnosdb.Node{ID: 1},
+			NodeDialer: &NodeDialer{MetaClient: mc, Timeout: 5 * time.Second},
+		},
+	}
+}
+
+// StatementExecutor returns a StatementExecutor of the local data node.
+func (c *TestCluster) StatementExecutor() *StatementExecutor {
+	return &StatementExecutor{
+		MetaClient:  c.MetaClient,
+		TSDBStore:   c.ShardMapper.TSDBStore.(*testStore),
+		ShardMapper: c.ShardMapper,
+		Node:        c.ShardMapper.Node,
+		NodeDialer:  c.ShardMapper.NodeDialer,
+	}
+}
+
+// Close stops the coordinator service.
+func (c *TestCluster) Close() error {
+	c.Service.Close()
+	return c.Listener.Close()
+}
+
+// Select executes s and returns its rows as "tags time values" strings, with
+// the time in seconds.
+func (c *TestCluster) Select(t *testing.T, s string) []string {
+	t.Helper()
+
+	stmt := cnosql.MustParseStatement(s).(*cnosql.SelectStatement)
+	cur, err := query.Select(context.Background(), stmt, c.ShardMapper, query.SelectOptions{})
+	if err != nil {
+		t.Fatalf("select %s: %s", s, err)
+	}
+	defer cur.Close()
+
+	var rows []string
+	var row query.Row
+	for cur.Scan(&row) {
+		var tags []string
+		for _, k := range row.Series.Tags.Keys() {
+			tags = append(tags, k+"="+row.Series.Tags.Value(k))
+		}
+		// The first value is the time.
+		rows = append(rows, fmt.Sprintf("%s %d %v", strings.Join(tags, ","), row.Time/int64(time.Second), row.Values[1:]))
+	}
+	if err := cur.Err(); err != nil {
+		t.Fatalf("select %s: %s", s, err)
+	}
+	return rows
+}
+
+// testMetaClient is a MetaClient for the nodes and regions of a TestCluster.
+type testMetaClient struct {
+	MetaClient
+	tcpHosts map[uint64]string
+	regions  []meta.RegionInfo
+}
+
+func (c *testMetaClient) DataNode(id uint64) (*meta.NodeInfo, error) {
+	host, ok := c.tcpHosts[id]
+	if !ok {
+		return nil, meta.ErrNodeNotFound
+	}
+	return &meta.NodeInfo{ID: id, TCPHost: host}, nil
+}
+
+func (c *testMetaClient) DataNodes() ([]meta.NodeInfo, error) {
+	nodes := []meta.NodeInfo{{ID: 1}}
+	for id, host := range c.tcpHosts {
+		nodes = append(nodes, meta.NodeInfo{ID: id, TCPHost: host})
+	}
+	return nodes, nil
+}
+
+func (c *testMetaClient) DataNodeDown(id uint64) bool { return false }
+
+func (c *testMetaClient) Database(name string) *meta.DatabaseInfo {
+	return &meta.DatabaseInfo{
+		Name:        name,
+		TimeToLives: []meta.TimeToLiveInfo{{Name: "autogen", Regions: c.regions}},
+	}
+}
+
+func (c *testMetaClient) RegionsByTimeRange(database, ttl string, min, max time.Time) ([]meta.RegionInfo, error) {
+	return c.regions, nil
+}
+
+// testPoint is a point of a float field named value and of a string field
+// named state, at a time in seconds.
+type testPoint struct {
+	name  string
+	tags  map[string]string
+	time  int64
+	value float64
+}
+
+// key returns the series key of p.
+func (p testPoint) key() string {
+	return string(models.MakeKey([]byte(p.name), models.NewTags(p.tags)))
+}
+
+// testStore is a TSDBStore holding the points of its shards.
+type testStore struct {
+	TSDBStore
+	shards map[uint64][]testPoint
+}
+
+func (s *testStore) Region(ids []uint64) tsdb.Region {
+	var r testRegion
+	for _, id := range ids {
+		if points, ok := s.shards[id]; ok {
+			r = append(r, points)
+		}
+	}
+	return r
+}
+
+// SeriesKeys returns the keys of the series of shardIDs matching cond.
+func (s *testStore) SeriesKeys(auth query.FineAuthorizer, shardIDs []uint64, cond cnosql.Expr) ([]string, error) {
+	m := make(map[string]struct{})
+	for _, id := range shardIDs {
+		for _, p := range s.shards[id] {
+			values := map[string]interface{}{"_name": p.name}
+			for k, v := range p.tags {
+				values[k] = v
+			}
+			if cond == nil || cnosql.EvalBool(cond, values) {
+				m[p.key()] = struct{}{}
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// SeriesSketches returns a sketch of the series of every shard and an empty
+// sketch of the deleted series.
+func (s *testStore) SeriesSketches(database string) (estimator.Sketch, estimator.Sketch, error) {
+	ss := hll.NewDefaultPlus()
+	for _, points := range s.shards {
+		for _, p := range points {
+			ss.Add([]byte(p.key()))
+		}
+	}
+	return ss, hll.NewDefaultPlus(), nil
+}
+
+// testRegion reads the points of its shards like the shards of the engine:
+// the points of a field are read by series and a call is computed for each
+// series before the series of all shards are merged.
+type testRegion [][]testPoint
+
+func (r testRegion) MetricsByRegex(re *regexp.Regexp) []string {
+	m := make(map[string]struct{})
+	for _, points := range r {
+		for _, p := range points {
+			if re.MatchString(p.name) {
+				m[p.name] = struct{}{}
+			}
+		}
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r testRegion) FieldKeysByMetric(name []byte) []string { return []string{"state", "value"} }
+
+func (r testRegion) FieldDimensions(metrics []string) (map[string]cnosql.DataType, map[string]struct{}, error) {
+	fields := make(map[string]cnosql.DataType)
+	dimensions := make(map[string]struct{})
+	for _, points := range r {
+		for _, p := range points {
+			for _, name := range metrics {
+				if p.name != name {
+					continue
+				}
+				fields["state"] = cnosql.String
+				fields["value"] = cnosql.Float
+				for k := range p.tags {
+					dimensions[k] = struct{}{}
+				}
+			}
+		}
+	}
+	return fields, dimensions, nil
+}
+
+func (r testRegion) MapType(metric, field string) cnosql.DataType {
+	fields, dimensions, _ := r.FieldDimensions([]string{metric})
+	if typ, ok := fields[field]; ok {
+		return typ
+	} else if _, ok := dimensions[field]; ok {
+		return cnosql.Tag
+	}
+	return cnosql.Unknown
+}
+
+func (r testRegion) CreateIterator(ctx context.Context, m *cnosql.Metric, opt query.IteratorOptions) (query.Iterator, error) {
+	call, isCall := opt.Expr.(*cnosql.Call)
+	ref := opt.Expr
+	if isCall {
+		ref = call.Args[0]
+	}
+
+	var inputs []query.Iterator
+	for _, series := range r.series(m.Name, opt) {
+		var input query.Iterator
+		if ref.(*cnosql.VarRef).Val == "value" {
+			itr := &floatIterator{}
+			for _, p := range series {
+				itr.points = append(itr.points, query.FloatPoint{Name: p.name, Tags: query.NewTags(p.tags), Time: p.time * int64(time.Second), Value: p.value})
+			}
+			input = itr
+		} else {
+			itr := &stringIterator{}
+			for _, p := range series {
+				itr.points = append(itr.points, query.StringPoint{Name: p.name, Tags: query.NewTags(p.tags), Time: p.time * int64(time.Second), Value: "ok"})
+			}
+			input = itr
+		}
+
+		if isCall {
+			itr, err := query.NewCallIterator(input, opt)
+			if err != nil {
+				query.Iterators(inputs).Close()
+				return nil, err
+			}
+			input = itr
+		}
+		inputs = append(inputs, input)
+	}
+	return query.Iterators(inputs).Merge(opt)
+}
+
+// series returns the points of the named metric in the time range of opt,
+// grouped by series and ordered by time.
+func (r testRegion) series(name string, opt query.IteratorOptions) [][]testPoint {
+	var series [][]testPoint
+	for _, points := range r {
+		bySeries := make(map[string][]testPoint)
+		var keys []string
+		for _, p := range points {
+			t := p.time * int64(time.Second)
+			if p.name != name || t < opt.StartTime || t > opt.EndTime {
+				continue
+			}
+			key := query.NewTags(p.tags).ID()
+			if _, ok := bySeries[key]; !ok {
+				keys = append(keys, key)
+			}
+			bySeries[key] = append(bySeries[key], p)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			series = append(series, bySeries[key])
+		}
+	}
+	return series
+}
+
+func (r testRegion) IteratorCost(metric string, opt query.IteratorOptions) (query.IteratorCost, error) {
+	cost := query.IteratorCost{NumShards: int64(len(r))}
+	for _, series := range r.series(metric, opt) {
+		cost.NumSeries++
+		cost.BlocksRead += int64(len(series))
+	}
+	return cost, nil
+}
+
+func (r testRegion) ExpandSources(sources cnosql.Sources) (cnosql.Sources, error) {
+	return sources, nil
+}
+
+type floatIterator struct {
+	points []query.FloatPoint
+}
+
+func (itr *floatIterator) Next() (*query.FloatPoint, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+func (itr *floatIterator) Stats() query.IteratorStats { return query.IteratorStats{} }
+func (itr *floatIterator) Close() error               { return nil }
+
+type stringIterator struct {
+	points []query.StringPoint
+}
+
+func (itr *stringIterator) Next() (*query.StringPoint, error) {
+	if len(itr.points) == 0 {
+		return nil, nil
+	}
+	p := itr.points[0]
+	itr.points = itr.points[1:]
+	return &p, nil
+}
+
+func (itr *stringIterator) Stats() query.IteratorStats { return query.IteratorStats{} }
+func (itr *stringIterator) Close() error               { return nil }
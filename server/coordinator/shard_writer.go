@@ -22,6 +22,30 @@ const (
 
 	fieldDimensionsRequestMessage
 	fieldDimensionsResponseMessage
+
+	metricNamesRequestMessage
+	metricNamesResponseMessage
+
+	tagKeysRequestMessage
+	tagKeysResponseMessage
+
+	tagValuesRequestMessage
+	tagValuesResponseMessage
+
+	seriesSketchesRequestMessage
+	seriesSketchesResponseMessage
+
+	metricSketchesRequestMessage
+	metricSketchesResponseMessage
+
+	iteratorCostRequestMessage
+	iteratorCostResponseMessage
+
+	copyShardRequestMessage
+	copyShardResponseMessage
+
+	seriesKeysRequestMessage
+	seriesKeysResponseMessage
 )
 
 // ShardWriter writes a set of points to a shard.
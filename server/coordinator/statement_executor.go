@@ -9,13 +9,17 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/cnosdatabase/cnosdb"
 	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/monitor"
+	"github.com/cnosdatabase/cnosdb/server/hh"
 	"github.com/cnosdatabase/cnosql"
 	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/estimator"
+	"github.com/cnosdatabase/db/pkg/estimator/hll"
 	"github.com/cnosdatabase/db/pkg/tracing"
 	"github.com/cnosdatabase/db/pkg/tracing/fields"
 	"github.com/cnosdatabase/db/query"
@@ -26,6 +30,13 @@ import (
 // when a database has not been provided.
 var ErrDatabaseNameRequired = errors.New("database name required")
 
+// ErrQueryCostLimitExceeded is an error when the estimated cost of a SELECT
+// exceeds one of the configured cost limits.
+func ErrQueryCostLimitExceeded(limit string, n, max int64, cost query.IteratorCost) error {
+	return fmt.Errorf("%s limit exceeded: (%d/%d), estimated cost: shards=%d series=%d files=%d blocks=%d bytes=%d cached-values=%d",
+		limit, n, max, cost.NumShards, cost.NumSeries, cost.NumFiles, cost.BlocksRead, cost.BlockSize, cost.CachedValues)
+}
+
 type pointsWriter interface {
 	WritePointsInto(*IntoWriteRequest) error
 }
@@ -40,12 +51,31 @@ type StatementExecutor struct {
 	// TSDB storage for local node.
 	TSDBStore TSDBStore
 
+	// Executes statements on the other data nodes in the cluster.
+	MetaExecutor interface {
+		ExecuteStatement(stmt cnosql.Statement, database string) error
+	}
+
 	// ShardMapper for mapping shards when executing a SELECT statement.
 	ShardMapper query.ShardMapper
 
+	// Local data node, and the dialer used to reach the other data nodes when
+	// metadata statements are executed across the cluster.
+	Node       *cnosdb.Node
+	NodeDialer *NodeDialer
+
+	// Local shards being copied from their other owners, which are queried
+	// on a remote owner until they are complete.
+	Rehydrating RehydratingShards
+
 	// Holds monitoring data for SHOW STATS and SHOW DIAGNOSTICS.
 	Monitor *monitor.Monitor
 
+	// Queues of the local node for SHOW HINTED HANDOFF.
+	HintedHandoff interface {
+		NodeStatuses() []hh.NodeStatus
+	}
+
 	// Used for rewriting points back into system for SELECT INTO statements.
 	PointsWriter interface {
 		WritePointsInto(*IntoWriteRequest) error
@@ -55,6 +85,17 @@ type StatementExecutor struct {
 	MaxSelectPointN   int
 	MaxSelectSeriesN  int
 	MaxSelectBucketsN int
+
+	// Select statement cost limits. A SELECT whose estimated cost exceeds one
+	// of the limits is rejected, or queued when expensive queries are allowed
+	// to run concurrently.
+	MaxSelectBlocksN              int
+	MaxSelectBytes                int64
+	MaxSelectSeriesScannedN       int
+	MaxConcurrentExpensiveQueries int
+
+	expensiveOnce sync.Once
+	expensive     chan struct{}
 }
 
 // ExecuteStatement executes the given statement with the given execution context.
@@ -174,16 +215,22 @@ func (e *StatementExecutor) ExecuteStatement(ctx *query.ExecutionContext, stmt c
 		rows, err = e.executeShowDiagnosticsStatement(stmt)
 	case *cnosql.ShowGrantsForUserStatement:
 		rows, err = e.executeShowGrantsForUserStatement(stmt)
+	case *cnosql.ShowHintedHandoffStatement:
+		rows, err = e.executeShowHintedHandoffStatement(stmt)
 	case *cnosql.ShowMetricsStatement:
 		return e.executeShowMetricsStatement(ctx, stmt)
 	case *cnosql.ShowMetricCardinalityStatement:
 		rows, err = e.executeShowMetricCardinalityStatement(ctx, stmt)
 	case *cnosql.ShowTimeToLivesStatement:
 		rows, err = e.executeShowTimeToLivesStatement(stmt)
+	case *cnosql.ShowSeriesStatement:
+		return e.executeShowSeries(ctx, stmt)
 	case *cnosql.ShowSeriesCardinalityStatement:
 		rows, err = e.executeShowSeriesCardinalityStatement(ctx, stmt)
 	case *cnosql.ShowShardsStatement:
 		rows, err = e.executeShowShardsStatement(stmt)
+	case *cnosql.ShowDataNodesStatement:
+		rows, err = e.executeShowDataNodesStatement(stmt)
 	case *cnosql.ShowRegionsStatement:
 		rows, err = e.executeShowRegionsStatement(stmt)
 	case *cnosql.ShowStatsStatement:
@@ -325,7 +372,12 @@ func (e *StatementExecutor) executeDeleteSeriesStatement(stmt *cnosql.DeleteSeri
 	stmt.Condition = cnosql.Reduce(stmt.Condition, &cnosql.NowValuer{Now: time.Now().UTC()})
 
 	// Locally delete the series.
-	return e.TSDBStore.DeleteSeries(database, stmt.Sources, stmt.Condition)
+	if err := e.TSDBStore.DeleteSeries(database, stmt.Sources, stmt.Condition); err != nil {
+		return err
+	}
+
+	// Delete the series on the other data nodes.
+	return e.executeOnRemoteNodes(stmt, database)
 }
 
 func (e *StatementExecutor) executeDropContinuousQueryStatement(q *cnosql.DropContinuousQueryStatement) error {
@@ -355,7 +407,12 @@ func (e *StatementExecutor) executeDropMetricStatement(stmt *cnosql.DropMetricSt
 	}
 
 	// Locally drop the metric
-	return e.TSDBStore.DeleteMetric(database, stmt.Name)
+	if err := e.TSDBStore.DeleteMetric(database, stmt.Name); err != nil {
+		return err
+	}
+
+	// Drop the metric on the other data nodes.
+	return e.executeOnRemoteNodes(stmt, database)
 }
 
 func (e *StatementExecutor) executeDropSeriesStatement(stmt *cnosql.DropSeriesStatement, database string) error {
@@ -369,7 +426,12 @@ func (e *StatementExecutor) executeDropSeriesStatement(stmt *cnosql.DropSeriesSt
 	}
 
 	// Locally drop the series.
-	return e.TSDBStore.DeleteSeries(database, stmt.Sources, stmt.Condition)
+	if err := e.TSDBStore.DeleteSeries(database, stmt.Sources, stmt.Condition); err != nil {
+		return err
+	}
+
+	// Drop the series on the other data nodes.
+	return e.executeOnRemoteNodes(stmt, database)
 }
 
 func (e *StatementExecutor) executeDropShardStatement(stmt *cnosql.DropShardStatement) error {
@@ -378,10 +440,25 @@ func (e *StatementExecutor) executeDropShardStatement(stmt *cnosql.DropShardStat
 		return err
 	}
 
+	// Delete the shard on the other owners. The shard is only removed from
+	// the Meta Store once every owner has deleted it, so a failed DROP SHARD
+	// can safely be retried.
+	if err := e.executeOnRemoteNodes(stmt, ""); err != nil {
+		return err
+	}
+
 	// Remove the shard reference from the Meta Store.
 	return e.MetaClient.DropShard(stmt.ID)
 }
 
+// executeOnRemoteNodes executes stmt on every other data node in the cluster.
+func (e *StatementExecutor) executeOnRemoteNodes(stmt cnosql.Statement, database string) error {
+	if e.MetaExecutor == nil {
+		return nil
+	}
+	return e.MetaExecutor.ExecuteStatement(stmt, database)
+}
+
 func (e *StatementExecutor) executeDropTimeToLiveStatement(stmt *cnosql.DropTimeToLiveStatement) error {
 	dbi := e.MetaClient.Database(stmt.Database)
 	if dbi == nil {
@@ -634,14 +711,101 @@ func (e *StatementExecutor) createIterators(ctx context.Context, stmt *cnosql.Se
 		Authorizer:  opt.Authorizer,
 	}
 
+	// Prepare the query so its cost can be checked before it is executed.
+	p, err := query.Prepare(stmt, e.ShardMapper, sopt)
+	if err != nil {
+		return nil, err
+	}
+	// Must be deferred so it runs after Select.
+	defer p.Close()
+
+	release, err := e.admit(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create a set of iterators from a selection.
-	cur, err := query.Select(ctx, stmt, e.ShardMapper, sopt)
+	cur, err := p.Select(ctx)
 	if err != nil {
+		if release != nil {
+			release()
+		}
 		return nil, err
 	}
+	if release != nil {
+		cur = &admittedCursor{Cursor: cur, release: release}
+	}
 	return cur, nil
 }
 
+// hasCostLimits returns true if any of the select statement cost limits is set.
+func (e *StatementExecutor) hasCostLimits() bool {
+	return e.MaxSelectBlocksN > 0 || e.MaxSelectBytes > 0 || e.MaxSelectSeriesScannedN > 0
+}
+
+// checkCost returns an error if the cost exceeds one of the select statement
+// cost limits.
+func (e *StatementExecutor) checkCost(cost query.IteratorCost) error {
+	if e.MaxSelectBlocksN > 0 && cost.BlocksRead > int64(e.MaxSelectBlocksN) {
+		return ErrQueryCostLimitExceeded("max-select-blocks", cost.BlocksRead, int64(e.MaxSelectBlocksN), cost)
+	}
+	if e.MaxSelectBytes > 0 && cost.BlockSize > e.MaxSelectBytes {
+		return ErrQueryCostLimitExceeded("max-select-bytes", cost.BlockSize, e.MaxSelectBytes, cost)
+	}
+	if e.MaxSelectSeriesScannedN > 0 && cost.NumSeries > int64(e.MaxSelectSeriesScannedN) {
+		return ErrQueryCostLimitExceeded("max-select-series-scanned", cost.NumSeries, int64(e.MaxSelectSeriesScannedN), cost)
+	}
+	return nil
+}
+
+// admit estimates the cost of the prepared statement and checks it against
+// the select statement cost limits. A statement over the limits is rejected
+// unless expensive queries may run concurrently, in which case it waits for
+// one of the slots. The returned function releases the slot and is nil if
+// no slot was taken.
+func (e *StatementExecutor) admit(ctx context.Context, p query.PreparedStatement) (func(), error) {
+	if !e.hasCostLimits() {
+		return nil, nil
+	}
+
+	cost, err := p.Cost()
+	if err != nil {
+		return nil, err
+	}
+	err = e.checkCost(cost)
+	if err == nil {
+		return nil, nil
+	} else if e.MaxConcurrentExpensiveQueries <= 0 {
+		return nil, err
+	}
+
+	e.expensiveOnce.Do(func() {
+		e.expensive = make(chan struct{}, e.MaxConcurrentExpensiveQueries)
+	})
+	select {
+	case e.expensive <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-e.expensive })
+	}, nil
+}
+
+// admittedCursor releases the slot of an expensive query when it is closed.
+type admittedCursor struct {
+	query.Cursor
+	release func()
+}
+
+func (c *admittedCursor) Close() error {
+	err := c.Cursor.Close()
+	c.release()
+	return err
+}
+
 func (e *StatementExecutor) executeShowContinuousQueriesStatement(stmt *cnosql.ShowContinuousQueriesStatement) (models.Rows, error) {
 	dis := e.MetaClient.Databases()
 
@@ -711,12 +875,33 @@ func (e *StatementExecutor) executeShowGrantsForUserStatement(q *cnosql.ShowGran
 	return []*models.Row{row}, nil
 }
 
+func (e *StatementExecutor) executeShowHintedHandoffStatement(stmt *cnosql.ShowHintedHandoffStatement) (models.Rows, error) {
+	row := &models.Row{Columns: []string{"node", "bytes", "oldest_entry_age", "send_rate", "last_error"}}
+	if e.HintedHandoff == nil {
+		return []*models.Row{row}, nil
+	}
+
+	now := time.Now()
+	for _, st := range e.HintedHandoff.NodeStatuses() {
+		var age string
+		if !st.OldestEntry.IsZero() {
+			age = now.Sub(st.OldestEntry).Truncate(time.Second).String()
+		}
+		var lastErr string
+		if st.LastError != "" {
+			lastErr = fmt.Sprintf("%s: %s", st.LastErrorAt.Format(time.RFC3339), st.LastError)
+		}
+		row.Values = append(row.Values, []interface{}{st.NodeID, st.Bytes, age, st.SendRate, lastErr})
+	}
+	return []*models.Row{row}, nil
+}
+
 func (e *StatementExecutor) executeShowMetricsStatement(ctx *query.ExecutionContext, q *cnosql.ShowMetricsStatement) error {
 	if q.Database == "" {
 		return ErrDatabaseNameRequired
 	}
 
-	names, err := e.TSDBStore.MetricNames(ctx.Authorizer, q.Database, q.Condition)
+	names, err := e.metricNames(ctx.Authorizer, q.Database, q.Condition)
 	if err != nil || len(names) == 0 {
 		return ctx.Send(&query.Result{
 			Err: err,
@@ -760,7 +945,8 @@ func (e *StatementExecutor) executeShowMetricCardinalityStatement(ctx *query.Exe
 		return nil, ErrDatabaseNameRequired
 	}
 
-	n, err := e.TSDBStore.MetricsCardinality(stmt.Database)
+	n, err := e.cardinality(stmt.Database, e.TSDBStore.MetricsCardinality, e.TSDBStore.MetricsSketches,
+		(*remoteNodeStore).MetricsSketches)
 	if err != nil {
 		return nil, err
 	}
@@ -826,12 +1012,45 @@ func (e *StatementExecutor) executeShowShardsStatement(stmt *cnosql.ShowShardsSt
 	return rows, nil
 }
 
+func (e *StatementExecutor) executeShowDataNodesStatement(stmt *cnosql.ShowDataNodesStatement) (models.Rows, error) {
+	nodes, err := e.MetaClient.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses, err := e.MetaClient.DataNodeStatuses()
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[uint64]meta.DataNodeStatus, len(statuses))
+	for _, s := range statuses {
+		byID[s.ID] = s
+	}
+
+	row := &models.Row{Columns: []string{"id", "http_addr", "tcp_addr", "status", "last_seen", "version", "disk_bytes"}}
+	for _, n := range nodes {
+		s, ok := byID[n.ID]
+		if !ok {
+			row.Values = append(row.Values, []interface{}{n.ID, n.Host, n.TCPHost, "unknown", nil, nil, nil})
+			continue
+		}
+
+		var lastSeen interface{}
+		if !s.LastSeen.IsZero() {
+			lastSeen = s.LastSeen.UTC().Format(time.RFC3339)
+		}
+		row.Values = append(row.Values, []interface{}{n.ID, n.Host, n.TCPHost, s.State, lastSeen, s.Version, s.DiskBytes})
+	}
+	return []*models.Row{row}, nil
+}
+
 func (e *StatementExecutor) executeShowSeriesCardinalityStatement(ctx *query.ExecutionContext, stmt *cnosql.ShowSeriesCardinalityStatement) (models.Rows, error) {
 	if stmt.Database == "" {
 		return nil, ErrDatabaseNameRequired
 	}
 
-	n, err := e.TSDBStore.SeriesCardinality(stmt.Database)
+	n, err := e.cardinality(stmt.Database, e.TSDBStore.SeriesCardinality, e.TSDBStore.SeriesSketches,
+		(*remoteNodeStore).SeriesSketches)
 	if err != nil {
 		return nil, err
 	}
@@ -956,14 +1175,7 @@ func (e *StatementExecutor) executeShowTagKeys(ctx *query.ExecutionContext, q *c
 		allGroups = append(allGroups, sgis...)
 	}
 
-	var shardIDs []uint64
-	for _, sgi := range allGroups {
-		for _, si := range sgi.Shards {
-			shardIDs = append(shardIDs, si.ID)
-		}
-	}
-
-	tagKeys, err := e.TSDBStore.TagKeys(ctx.Authorizer, shardIDs, cond)
+	tagKeys, err := e.tagKeys(ctx.Authorizer, allGroups, cond)
 	if err != nil {
 		return ctx.Send(&query.Result{
 			Err: err,
@@ -1013,6 +1225,82 @@ func (e *StatementExecutor) executeShowTagKeys(ctx *query.ExecutionContext, q *c
 	return nil
 }
 
+// executeShowSeries returns the series keys of every data node. Only SHOW
+// SERIES statements without a time condition are executed here, see
+// RewriteStatement.
+func (e *StatementExecutor) executeShowSeries(ctx *query.ExecutionContext, q *cnosql.ShowSeriesStatement) error {
+	database := q.Database
+	for _, src := range q.Sources {
+		if m, ok := src.(*cnosql.Metric); ok && m.Database != "" {
+			database = m.Database
+		}
+	}
+	if database == "" {
+		return ErrDatabaseNameRequired
+	}
+
+	di := e.MetaClient.Database(database)
+	if di == nil {
+		return fmt.Errorf("database not found: %s", database)
+	}
+
+	// The statement reads the _series system iterator once rewritten, with
+	// the metric names of its sources added to its condition.
+	stmt, err := query.RewriteStatement(q)
+	if err != nil {
+		return err
+	}
+	valuer := &cnosql.NowValuer{Now: time.Now()}
+	cond, timeRange, err := cnosql.ConditionExpr(stmt.(*cnosql.SelectStatement).Condition, valuer)
+	if err != nil {
+		return err
+	}
+
+	// Get all shards for all time-to-lives.
+	var allGroups []meta.RegionInfo
+	for _, ttli := range di.TimeToLives {
+		sgis, err := e.MetaClient.RegionsByTimeRange(database, ttli.Name, timeRange.MinTime(), timeRange.MaxTime())
+		if err != nil {
+			return err
+		}
+		allGroups = append(allGroups, sgis...)
+	}
+
+	keys, err := e.seriesKeys(ctx.Authorizer, allGroups, cond)
+	if err != nil {
+		return ctx.Send(&query.Result{
+			Err: err,
+		})
+	}
+
+	if q.Offset > 0 {
+		if q.Offset >= len(keys) {
+			keys = nil
+		} else {
+			keys = keys[q.Offset:]
+		}
+	}
+	if q.Limit > 0 && q.Limit < len(keys) {
+		keys = keys[:q.Limit]
+	}
+
+	// Ensure at least one result is emitted.
+	if len(keys) == 0 {
+		return ctx.Send(&query.Result{})
+	}
+
+	row := &models.Row{
+		Columns: []string{"key"},
+		Values:  make([][]interface{}, len(keys)),
+	}
+	for i, key := range keys {
+		row.Values[i] = []interface{}{key}
+	}
+	return ctx.Send(&query.Result{
+		Series: []*models.Row{row},
+	})
+}
+
 func (e *StatementExecutor) executeShowTagValues(ctx *query.ExecutionContext, q *cnosql.ShowTagValuesStatement) error {
 	if q.Database == "" {
 		return ErrDatabaseNameRequired
@@ -1043,14 +1331,7 @@ func (e *StatementExecutor) executeShowTagValues(ctx *query.ExecutionContext, q
 		allGroups = append(allGroups, sgis...)
 	}
 
-	var shardIDs []uint64
-	for _, sgi := range allGroups {
-		for _, si := range sgi.Shards {
-			shardIDs = append(shardIDs, si.ID)
-		}
-	}
-
-	tagValues, err := e.TSDBStore.TagValues(ctx.Authorizer, shardIDs, cond)
+	tagValues, err := e.tagValues(ctx.Authorizer, allGroups, cond)
 	if err != nil {
 		return ctx.Send(&query.Result{Err: err})
 	}
@@ -1101,6 +1382,445 @@ func (e *StatementExecutor) executeShowTagValues(ctx *query.ExecutionContext, q
 	return nil
 }
 
+// localNodeID returns the ID of the local data node.
+func (e *StatementExecutor) localNodeID() uint64 {
+	if e.Node == nil {
+		return 0
+	}
+	return e.Node.ID
+}
+
+// remoteNodeIDs returns the IDs of the other data nodes in the cluster.
+func (e *StatementExecutor) remoteNodeIDs() ([]uint64, error) {
+	if e.Node == nil || e.NodeDialer == nil {
+		return nil, nil
+	}
+
+	nodes, err := e.MetaClient.DataNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]uint64, 0, len(nodes))
+	for _, n := range nodes {
+		if n.ID != e.Node.ID {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids, nil
+}
+
+// shardIDsByNode assigns every shard in regions to a single owner and returns
+// the shard IDs to query, keyed by node ID. Local copies are preferred unless
+// they are being copied from their other owners, and owners that are down are
+// skipped.
+func (e *StatementExecutor) shardIDsByNode(regions []meta.RegionInfo) (map[uint64][]uint64, error) {
+	return shardIDsByOwner(regions, e.localNodeID(), e.Node != nil && e.NodeDialer != nil, false, e.MetaClient.DataNodeDown, rehydrating(e.Rehydrating))
+}
+
+// queryShards calls fn concurrently for the shards of regions, grouped by the
+// owner to query. When a remote owner fails, its shards are queried from
+// their other owners. The error of a shard without other owner is returned.
+func (e *StatementExecutor) queryShards(regions []meta.RegionInfo, fn func(nodeID uint64, ids []uint64) error) error {
+	owners := make(map[uint64][]meta.ShardOwner)
+	for _, rg := range regions {
+		for _, si := range rg.Shards {
+			owners[si.ID] = si.Owners
+		}
+	}
+
+	plan, err := e.shardIDsByNode(regions)
+	if err != nil {
+		return err
+	}
+
+	localID := e.localNodeID()
+	isRehydrating := rehydrating(e.Rehydrating)
+	tried := make(map[uint64]map[uint64]bool)
+	for len(plan) > 0 {
+		var (
+			wg     sync.WaitGroup
+			mu     sync.Mutex
+			failed = make(map[uint64]error)
+		)
+		for nodeID, ids := range plan {
+			wg.Add(1)
+			go func(nodeID uint64, ids []uint64) {
+				defer wg.Done()
+				if err := fn(nodeID, ids); err != nil {
+					mu.Lock()
+					failed[nodeID] = err
+					mu.Unlock()
+				}
+			}(nodeID, ids)
+		}
+		wg.Wait()
+
+		next := make(map[uint64][]uint64)
+		for nodeID, err := range failed {
+			if nodeID == localID {
+				return err
+			}
+			for _, id := range plan[nodeID] {
+				if tried[id] == nil {
+					// The incomplete local copy of a shard being
+					// rehydrated is not a fallback.
+					tried[id] = map[uint64]bool{localID: isRehydrating(id)}
+				}
+				tried[id][nodeID] = true
+
+				other := e.otherOwner(owners[id], tried[id])
+				if other == 0 {
+					return err
+				}
+				next[other] = append(next[other], id)
+			}
+		}
+		plan = next
+	}
+	return nil
+}
+
+// otherOwner returns an owner not in tried, preferring the owners that are
+// not down, or 0 if there is none.
+func (e *StatementExecutor) otherOwner(owners []meta.ShardOwner, tried map[uint64]bool) uint64 {
+	var down uint64
+	for _, o := range owners {
+		if o.NodeID == 0 || tried[o.NodeID] {
+			continue
+		}
+		if !e.MetaClient.DataNodeDown(o.NodeID) {
+			return o.NodeID
+		} else if down == 0 {
+			down = o.NodeID
+		}
+	}
+	return down
+}
+
+// userName returns the name of the user authorized by auth, sent with remote
+// requests to authorize them, or an empty name if auth is not a user.
+func userName(auth query.FineAuthorizer) string {
+	if u, ok := auth.(meta.User); ok {
+		return u.ID()
+	}
+	return ""
+}
+
+// metricNames returns the metric names of database across all data nodes.
+func (e *StatementExecutor) metricNames(auth query.FineAuthorizer, database string, cond cnosql.Expr) ([][]byte, error) {
+	names, err := e.TSDBStore.MetricNames(auth, database, cond)
+	if err != nil {
+		return nil, err
+	}
+
+	nodeIDs, err := e.remoteNodeIDs()
+	if err != nil || len(nodeIDs) == 0 {
+		return names, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	sets := [][][]byte{names}
+	for _, nodeID := range nodeIDs {
+		wg.Add(1)
+		go func(nodeID uint64) {
+			defer wg.Done()
+			a, err := newRemoteNodeStore(e.NodeDialer, nodeID).MetricNames(userName(auth), database, cond)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, remoteNodeError{id: nodeID, err: err})
+				return
+			}
+			sets = append(sets, a)
+		}(nodeID)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return mergeMetricNames(sets), nil
+}
+
+// tagKeys returns the tag keys of the shards of regions, each queried from
+// one of its owners.
+func (e *StatementExecutor) tagKeys(auth query.FineAuthorizer, regions []meta.RegionInfo, cond cnosql.Expr) ([]tsdb.TagKeys, error) {
+	localID := e.localNodeID()
+
+	var (
+		mu   sync.Mutex
+		sets [][]tsdb.TagKeys
+	)
+	if err := e.queryShards(regions, func(nodeID uint64, ids []uint64) error {
+		var a []tsdb.TagKeys
+		var err error
+		if nodeID == localID {
+			a, err = e.TSDBStore.TagKeys(auth, ids, cond)
+		} else if a, err = newRemoteNodeStore(e.NodeDialer, nodeID).TagKeys(userName(auth), ids, cond); err != nil {
+			err = remoteNodeError{id: nodeID, err: err}
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		sets = append(sets, a)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(sets) == 0 {
+		return e.TSDBStore.TagKeys(auth, nil, cond)
+	} else if len(sets) == 1 {
+		return sets[0], nil
+	}
+	return mergeTagKeys(sets), nil
+}
+
+// tagValues returns the tag values of the shards of regions, each queried
+// from one of its owners.
+func (e *StatementExecutor) tagValues(auth query.FineAuthorizer, regions []meta.RegionInfo, cond cnosql.Expr) ([]tsdb.TagValues, error) {
+	localID := e.localNodeID()
+
+	var (
+		mu   sync.Mutex
+		sets [][]tsdb.TagValues
+	)
+	if err := e.queryShards(regions, func(nodeID uint64, ids []uint64) error {
+		var a []tsdb.TagValues
+		var err error
+		if nodeID == localID {
+			a, err = e.TSDBStore.TagValues(auth, ids, cond)
+		} else if a, err = newRemoteNodeStore(e.NodeDialer, nodeID).TagValues(userName(auth), ids, cond); err != nil {
+			err = remoteNodeError{id: nodeID, err: err}
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		sets = append(sets, a)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(sets) == 0 {
+		return e.TSDBStore.TagValues(auth, nil, cond)
+	} else if len(sets) == 1 {
+		return sets[0], nil
+	}
+	return mergeTagValues(sets), nil
+}
+
+// seriesKeys returns the series keys of the shards of regions, each queried
+// from one of its owners.
+func (e *StatementExecutor) seriesKeys(auth query.FineAuthorizer, regions []meta.RegionInfo, cond cnosql.Expr) ([]string, error) {
+	localID := e.localNodeID()
+
+	var (
+		mu   sync.Mutex
+		sets [][]string
+	)
+	if err := e.queryShards(regions, func(nodeID uint64, ids []uint64) error {
+		var a []string
+		var err error
+		if nodeID == localID {
+			a, err = e.TSDBStore.SeriesKeys(auth, ids, cond)
+		} else if a, err = newRemoteNodeStore(e.NodeDialer, nodeID).SeriesKeys(userName(auth), ids, cond); err != nil {
+			err = remoteNodeError{id: nodeID, err: err}
+		}
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		sets = append(sets, a)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+
+	if len(sets) == 0 {
+		return nil, nil
+	} else if len(sets) == 1 {
+		return sets[0], nil
+	}
+	return mergeSeriesKeys(sets), nil
+}
+
+// cardinality returns the cardinality of database. On a single node the local
+// count is returned. Otherwise the sketches of every data node are merged
+// into an estimate, so that series replicated on several nodes are only
+// counted once.
+func (e *StatementExecutor) cardinality(
+	database string,
+	local func(database string) (int64, error),
+	localSketches func(database string) (estimator.Sketch, estimator.Sketch, error),
+	remoteSketches func(s *remoteNodeStore, database string) (estimator.Sketch, estimator.Sketch, error),
+) (int64, error) {
+	nodeIDs, err := e.remoteNodeIDs()
+	if err != nil {
+		return 0, err
+	} else if len(nodeIDs) == 0 {
+		return local(database)
+	}
+
+	ss, ts := hll.NewDefaultPlus(), hll.NewDefaultPlus()
+	s, t, err := localSketches(database)
+	if err != nil {
+		return 0, err
+	}
+	if err := ss.Merge(s); err != nil {
+		return 0, err
+	} else if err := ts.Merge(t); err != nil {
+		return 0, err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for _, nodeID := range nodeIDs {
+		wg.Add(1)
+		go func(nodeID uint64) {
+			defer wg.Done()
+			s, t, err := remoteSketches(newRemoteNodeStore(e.NodeDialer, nodeID), database)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				if err = ss.Merge(s); err == nil {
+					err = ts.Merge(t)
+				}
+			}
+			if err != nil {
+				errs = append(errs, remoteNodeError{id: nodeID, err: err})
+			}
+		}(nodeID)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return 0, errs[0]
+	}
+
+	n, tn := ss.Count(), ts.Count()
+	if tn > n {
+		return 0, nil
+	}
+	return int64(n - tn), nil
+}
+
+// mergeMetricNames returns the sorted union of sets.
+func mergeMetricNames(sets [][][]byte) [][]byte {
+	m := make(map[string]struct{})
+	for _, names := range sets {
+		for _, name := range names {
+			m[string(name)] = struct{}{}
+		}
+	}
+
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	a := make([][]byte, len(names))
+	for i, name := range names {
+		a[i] = []byte(name)
+	}
+	return a
+}
+
+// mergeTagKeys returns the union of the tag keys in sets, sorted by metric and key.
+func mergeTagKeys(sets [][]tsdb.TagKeys) []tsdb.TagKeys {
+	m := make(map[string]map[string]struct{})
+	for _, set := range sets {
+		for _, tk := range set {
+			keys, ok := m[tk.Metric]
+			if !ok {
+				keys = make(map[string]struct{}, len(tk.Keys))
+				m[tk.Metric] = keys
+			}
+			for _, k := range tk.Keys {
+				keys[k] = struct{}{}
+			}
+		}
+	}
+
+	a := make([]tsdb.TagKeys, 0, len(m))
+	for name, keys := range m {
+		tk := tsdb.TagKeys{Metric: name, Keys: make([]string, 0, len(keys))}
+		for k := range keys {
+			tk.Keys = append(tk.Keys, k)
+		}
+		sort.Strings(tk.Keys)
+		a = append(a, tk)
+	}
+	sort.Sort(tsdb.TagKeysSlice(a))
+	return a
+}
+
+// mergeTagValues returns the union of the tag values in sets, sorted by metric,
+// key and value.
+func mergeTagValues(sets [][]tsdb.TagValues) []tsdb.TagValues {
+	m := make(map[string]map[tsdb.KeyValue]struct{})
+	for _, set := range sets {
+		for _, tv := range set {
+			values, ok := m[tv.Metric]
+			if !ok {
+				values = make(map[tsdb.KeyValue]struct{}, len(tv.Values))
+				m[tv.Metric] = values
+			}
+			for _, kv := range tv.Values {
+				values[kv] = struct{}{}
+			}
+		}
+	}
+
+	a := make([]tsdb.TagValues, 0, len(m))
+	for name, values := range m {
+		tv := tsdb.TagValues{Metric: name, Values: make([]tsdb.KeyValue, 0, len(values))}
+		for kv := range values {
+			tv.Values = append(tv.Values, kv)
+		}
+		sort.Sort(tsdb.KeyValues(tv.Values))
+		a = append(a, tv)
+	}
+	sort.Sort(tsdb.TagValuesSlice(a))
+	return a
+}
+
+// mergeSeriesKeys returns the sorted union of sets.
+func mergeSeriesKeys(sets [][]string) []string {
+	m := make(map[string]struct{})
+	for _, keys := range sets {
+		for _, key := range keys {
+			m[key] = struct{}{}
+		}
+	}
+
+	a := make([]string, 0, len(m))
+	for key := range m {
+		a = append(a, key)
+	}
+	sort.Strings(a)
+	return a
+}
+
 func (e *StatementExecutor) executeShowUsersStatement(q *cnosql.ShowUsersStatement) (models.Rows, error) {
 	row := &models.Row{Columns: []string{"user", "admin"}}
 	for _, ui := range e.MetaClient.Users() {
@@ -1266,6 +1986,16 @@ func convertRowToPoints(metricName string, row *models.Row) ([]models.Point, err
 	return points, nil
 }
 
+// RewriteStatement rewrites stmt with query.RewriteStatement, except for the
+// SHOW SERIES statements without a time condition. These are answered from
+// the index of every data node by executeShowSeries.
+func (e *StatementExecutor) RewriteStatement(stmt cnosql.Statement) (cnosql.Statement, error) {
+	if q, ok := stmt.(*cnosql.ShowSeriesStatement); ok && !cnosql.HasTimeExpr(q.Condition) {
+		return q, nil
+	}
+	return query.RewriteStatement(stmt)
+}
+
 // NormalizeStatement adds a default database and time-to-live to the metrics in statement.
 // Parameter defaultTimeToLive can be "".
 func (e *StatementExecutor) NormalizeStatement(stmt cnosql.Statement, defaultDatabase, defaultTimeToLive string) (err error) {
@@ -1294,6 +2024,10 @@ func (e *StatementExecutor) NormalizeStatement(stmt cnosql.Statement, defaultDat
 			if node.Database == "" {
 				node.Database = defaultDatabase
 			}
+		case *cnosql.ShowSeriesStatement:
+			if node.Database == "" {
+				node.Database = defaultDatabase
+			}
 		case *cnosql.ShowSeriesCardinalityStatement:
 			if node.Database == "" {
 				node.Database = defaultDatabase
@@ -1360,6 +2094,7 @@ type TSDBStore interface {
 	WriteToShard(shardID uint64, points []models.Point) error
 
 	RestoreShard(id uint64, r io.Reader) error
+	ImportShard(id uint64, r io.Reader) error
 	BackupShard(id uint64, since time.Time, w io.Writer) error
 
 	DeleteDatabase(name string) error
@@ -1371,9 +2106,12 @@ type TSDBStore interface {
 	MetricNames(auth query.FineAuthorizer, database string, cond cnosql.Expr) ([][]byte, error)
 	TagKeys(auth query.FineAuthorizer, shardIDs []uint64, cond cnosql.Expr) ([]tsdb.TagKeys, error)
 	TagValues(auth query.FineAuthorizer, shardIDs []uint64, cond cnosql.Expr) ([]tsdb.TagValues, error)
+	SeriesKeys(auth query.FineAuthorizer, shardIDs []uint64, cond cnosql.Expr) ([]string, error)
 
 	SeriesCardinality(database string) (int64, error)
 	MetricsCardinality(database string) (int64, error)
+	SeriesSketches(database string) (estimator.Sketch, estimator.Sketch, error)
+	MetricsSketches(database string) (estimator.Sketch, estimator.Sketch, error)
 
 	Region(ids []uint64) tsdb.Region
 }
@@ -0,0 +1,148 @@
+package coordinator
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/query"
+	"github.com/cnosdatabase/db/tsdb"
+)
+
+// Ensure EXPLAIN ANALYZE renders the span of the iterator read from a remote
+// node, with the spans of the remote node beneath it.
+func TestStatementExecutor_ExplainAnalyze_Remote(t *testing.T) {
+	c := NewTestCluster(t)
+	defer c.Close()
+
+	e := &StatementExecutor{ShardMapper: c.ShardMapper}
+	stmt := cnosql.MustParseStatement(`EXPLAIN ANALYZE SELECT stddev(value) FROM cpu WHERE time >= 0s AND time < 60s`).(*cnosql.ExplainStatement)
+	rows, err := e.executeExplainAnalyzeStatement(&query.ExecutionContext{Context: context.Background()}, stmt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for _, v := range rows[0].Values {
+		lines = append(lines, strings.TrimSpace(strings.TrimLeft(v[0].(string), "│├└─ ")))
+	}
+	tree := strings.Join(lines, "\n")
+	for _, exp := range []string{
+		"remote_iterator",
+		"node_id: 2",
+		"shard_ids: 2",
+		"bytes_read: ",
+		"network_time: ",
+		"create_iterator_remote",
+	} {
+		if !strings.Contains(tree, exp) {
+			t.Fatalf("%q not rendered:\n%s", exp, tree)
+		}
+	}
+}
+
+// Ensure a SELECT over the cost limits is rejected with its estimated cost,
+// which includes the shards read from a remote node.
+func TestStatementExecutor_CostLimitExceeded(t *testing.T) {
+	c := NewTestCluster(t)
+	defer c.Close()
+
+	e := &StatementExecutor{ShardMapper: c.ShardMapper, MaxSelectSeriesScannedN: 3}
+	stmt := cnosql.MustParseStatement(`SELECT count(value) FROM cpu WHERE time >= 0s AND time < 60s`).(*cnosql.SelectStatement)
+	_, err := e.createIterators(context.Background(), stmt, query.ExecutionOptions{})
+	if exp := "max-select-series-scanned limit exceeded: (4/3), estimated cost: shards=2 series=4 files=0 blocks=6 bytes=0 cached-values=0"; err == nil || err.Error() != exp {
+		t.Fatalf("unexpected error: got=%v exp=%s", err, exp)
+	}
+	if n := c.Service.statMap.Get(iteratorCostReq); n == nil || n.String() == "0" {
+		t.Fatal("remote cost not requested")
+	}
+
+	e.MaxSelectSeriesScannedN = 4
+	cur, err := e.createIterators(context.Background(), stmt, query.ExecutionOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cur.Close()
+}
+
+// Ensure SHOW SERIES merges the series keys of the local and of the remote
+// shards before applying its limit and offset.
+func TestStatementExecutor_ShowSeries(t *testing.T) {
+	c := NewTestCluster(t)
+	defer c.Close()
+
+	e := c.StatementExecutor()
+	for _, tt := range []struct {
+		s    string
+		keys []interface{}
+	}{
+		{s: `SHOW SERIES ON db0`, keys: []interface{}{"cpu,host=a", "cpu,host=b", "mem,host=c"}},
+		{s: `SHOW SERIES ON db0 FROM cpu`, keys: []interface{}{"cpu,host=a", "cpu,host=b"}},
+		{s: `SHOW SERIES ON db0 WHERE host = 'c'`, keys: []interface{}{"mem,host=c"}},
+		{s: `SHOW SERIES ON db0 LIMIT 1 OFFSET 1`, keys: []interface{}{"cpu,host=b"}},
+	} {
+		stmt, err := e.RewriteStatement(cnosql.MustParseStatement(tt.s))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ctx := &query.ExecutionContext{Context: context.Background(), Results: make(chan *query.Result, 1)}
+		if err := e.ExecuteStatement(ctx, stmt); err != nil {
+			t.Fatalf("%s: %s", tt.s, err)
+		}
+		result := <-ctx.Results
+		if result.Err != nil {
+			t.Fatalf("%s: %s", tt.s, result.Err)
+		}
+
+		var keys []interface{}
+		for _, row := range result.Series {
+			for _, v := range row.Values {
+				keys = append(keys, v[0])
+			}
+		}
+		if !reflect.DeepEqual(keys, tt.keys) {
+			t.Fatalf("%s: unexpected keys: got=%v exp=%v", tt.s, keys, tt.keys)
+		}
+	}
+	if n := c.Service.statMap.Get(seriesKeysReq); n == nil || n.String() == "0" {
+		t.Fatal("remote series keys not requested")
+	}
+}
+
+// Ensure SHOW SERIES CARDINALITY merges the sketches of every data node, so
+// the series held by several nodes are only counted once.
+func TestStatementExecutor_ShowSeriesCardinality(t *testing.T) {
+	c := NewTestCluster(t)
+	defer c.Close()
+
+	e := c.StatementExecutor()
+	stmt := cnosql.MustParseStatement(`SHOW SERIES CARDINALITY ON db0`).(*cnosql.ShowSeriesCardinalityStatement)
+	rows, err := e.executeShowSeriesCardinalityStatement(&query.ExecutionContext{Context: context.Background()}, stmt)
+	if err != nil {
+		t.Fatal(err)
+	} else if n := rows[0].Values[0][0]; n != int64(3) {
+		t.Fatalf("unexpected cardinality: got=%v exp=3", n)
+	}
+	if n := c.Service.statMap.Get(sketchesReq); n == nil || n.String() == "0" {
+		t.Fatal("remote sketches not requested")
+	}
+}
+
+// Ensure the tag keys gathered from several nodes are merged by metric and
+// sorted.
+func TestMergeTagKeys(t *testing.T) {
+	keys := mergeTagKeys([][]tsdb.TagKeys{
+		{{Metric: "cpu", Keys: []string{"host", "region"}}, {Metric: "mem", Keys: []string{"host"}}},
+		{{Metric: "cpu", Keys: []string{"az", "host"}}, {Metric: "disk", Keys: []string{"path"}}},
+	})
+	if exp := []tsdb.TagKeys{
+		{Metric: "cpu", Keys: []string{"az", "host", "region"}},
+		{Metric: "disk", Keys: []string{"path"}},
+		{Metric: "mem", Keys: []string{"host"}},
+	}; !reflect.DeepEqual(keys, exp) {
+		t.Fatalf("unexpected tag keys: got=%v exp=%v", keys, exp)
+	}
+}
@@ -0,0 +1,377 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cnosdatabase/cnosdb"
+	"github.com/cnosdatabase/cnosdb/meta"
+	"github.com/cnosdatabase/cnosdb/server/coordinator"
+	"go.uber.org/zap"
+)
+
+const (
+	// decommissionInterval is the interval at which the node checks whether
+	// it is leaving the cluster and whether its hinted handoff queues are
+	// drained.
+	decommissionInterval = 10 * time.Second
+
+	// decommissionSettleTime is the time given to the other nodes to learn
+	// the new owners of the shards before the data written meanwhile is
+	// copied.
+	decommissionSettleTime = 10 * time.Second
+)
+
+// errDecommissionCanceled is returned when the node is no longer leaving the
+// cluster or the server is closing.
+var errDecommissionCanceled = errors.New("decommission canceled")
+
+// decommissionState holds the progress of the decommission of the node.
+type decommissionState struct {
+	mu       sync.Mutex
+	progress *meta.DecommissionProgress
+}
+
+// shardMove is a shard of the node with the nodes it is copied to.
+type shardMove struct {
+	shardID uint64
+	nodeIDs []uint64
+}
+
+// watchDecommission decommissions the node when it is marked as leaving the
+// cluster, until the server is closed.
+func (s *Server) watchDecommission() {
+	ticker := time.NewTicker(decommissionInterval)
+	defer ticker.Stop()
+
+	for {
+		if s.Node.ID != 0 && s.leaving() {
+			if err := s.decommission(); err == errDecommissionCanceled {
+				s.setDecommissionProgress(nil)
+			} else if err != nil {
+				s.logger.Warn("failed to decommission node, retrying", zap.Error(err))
+				s.updateDecommissionProgress(func(p *meta.DecommissionProgress) {
+					p.Error = err.Error()
+				})
+			}
+		} else {
+			s.setDecommissionProgress(nil)
+			s.resetDecommission()
+		}
+
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// leaving returns whether the node is marked as leaving the cluster.
+func (s *Server) leaving() bool {
+	n, err := s.metaClient.DataNode(s.Node.ID)
+	return err == nil && n != nil && n.Leaving
+}
+
+// decommission copies the shards of the node to the other nodes, waits for
+// the hinted handoff queued by the node and for the node on every other node
+// to drain and removes the node from the cluster.
+// It can be run again after a failure or a restart: the shards already having
+// enough owners are not copied again, but the data written to them since the
+// first attempt started is.
+func (s *Server) decommission() error {
+	if s.Node.Decommission == nil {
+		s.Node.Decommission = &cnosdb.Decommission{Start: time.Now().UTC()}
+		if err := s.Node.Save(); err != nil {
+			return fmt.Errorf("save node: %s", err)
+		}
+	}
+	d := s.Node.Decommission
+
+	moves, err := s.shardMoves()
+	if err != nil {
+		return err
+	}
+
+	s.logger.Info("Decommissioning node", zap.Int("shards", len(moves)))
+	s.setDecommissionProgress(&meta.DecommissionProgress{
+		Phase:       meta.DecommissionCopyingShards,
+		ShardsTotal: len(moves),
+	})
+
+	copier := &coordinator.ShardCopier{
+		NodeDialer: &coordinator.NodeDialer{
+			MetaClient: s.metaClient,
+			Timeout:    time.Duration(s.Config.Coordinator.ShardCopyTimeout),
+		},
+	}
+
+	// Copy each shard before adding the new owner, so that the shard is
+	// never owned by a node without its data.
+	for i, m := range moves {
+		for _, id := range m.nodeIDs {
+			if !s.leaving() || s.isClosing() {
+				return errDecommissionCanceled
+			}
+			if err := copier.CopyShard(id, m.shardID, s.Node.ID, time.Time{}); err != nil {
+				return fmt.Errorf("copy shard %d to node %d: %s", m.shardID, id, err)
+			}
+			if err := s.metaClient.AddShardOwner(m.shardID, id); err != nil {
+				return fmt.Errorf("add node %d to owners of shard %d: %s", id, m.shardID, err)
+			}
+
+			if d.Moved == nil {
+				d.Moved = make(map[uint64][]uint64)
+			}
+			d.Moved[m.shardID] = append(d.Moved[m.shardID], id)
+			if err := s.Node.Save(); err != nil {
+				return fmt.Errorf("save node: %s", err)
+			}
+		}
+		s.updateDecommissionProgress(func(p *meta.DecommissionProgress) {
+			p.ShardsCopied = i + 1
+		})
+	}
+
+	// Copy the data written to the shards moved by any attempt before the
+	// other nodes learned about the new owners.
+	if len(d.Moved) > 0 {
+		if err := s.sleep(decommissionSettleTime); err != nil {
+			return err
+		}
+
+		shardIDs := make([]uint64, 0, len(d.Moved))
+		for id := range d.Moved {
+			shardIDs = append(shardIDs, id)
+		}
+		sort.Slice(shardIDs, func(i, j int) bool { return shardIDs[i] < shardIDs[j] })
+
+		for _, shardID := range shardIDs {
+			sh := s.shardInfo(shardID)
+			for _, id := range d.Moved[shardID] {
+				if sh == nil || !sh.OwnedBy(id) {
+					// The shard was dropped or moved away meanwhile.
+					continue
+				}
+				if err := copier.CopyShard(id, shardID, s.Node.ID, d.Start); err != nil {
+					return fmt.Errorf("copy shard %d to node %d: %s", shardID, id, err)
+				}
+			}
+		}
+	}
+
+	// Wait for the hinted handoff queued by the node and for the node, so
+	// that no write is left for a node that is no longer in the cluster.
+	s.updateDecommissionProgress(func(p *meta.DecommissionProgress) {
+		p.Phase = meta.DecommissionDrainingHH
+		p.Error = ""
+	})
+	since := time.Now()
+	for {
+		statuses, err := s.metaClient.DataNodeStatuses()
+		if err != nil {
+			return fmt.Errorf("data node statuses: %s", err)
+		}
+		nodeIDs := hintedHandoffNodes(s.Node.ID, statuses, since)
+		if s.hintedHandoff != nil && !s.hintedHandoff.Empty() {
+			nodeIDs = append([]uint64{s.Node.ID}, nodeIDs...)
+		}
+		s.updateDecommissionProgress(func(p *meta.DecommissionProgress) {
+			p.HintedHandoffNodes = nodeIDs
+		})
+		if len(nodeIDs) == 0 {
+			break
+		}
+
+		if err := s.sleep(decommissionInterval); err != nil {
+			return err
+		}
+		if !s.leaving() {
+			return errDecommissionCanceled
+		}
+	}
+
+	s.updateDecommissionProgress(func(p *meta.DecommissionProgress) {
+		p.Phase = meta.DecommissionRemoving
+	})
+	if err := s.metaClient.DeleteDataNode(s.Node.ID); err != nil {
+		return fmt.Errorf("remove node: %s", err)
+	}
+	s.resetDecommission()
+
+	s.logger.Info("Node decommissioned, it can be stopped")
+	return nil
+}
+
+// hintedHandoffNodes returns the other nodes that may still hold hinted
+// handoff for the node nodeID according to statuses: the nodes that reported
+// queued data for it and the nodes that are up but did not report their queues
+// since the given time. The queues of the nodes that are down are only known
+// from their last heartbeat.
+func hintedHandoffNodes(nodeID uint64, statuses []meta.DataNodeStatus, since time.Time) []uint64 {
+	var ids []uint64
+	for _, st := range statuses {
+		if st.ID == nodeID {
+			continue
+		}
+		if st.HintedHandoff[nodeID] > 0 || (st.State != meta.NodeStateDown && st.LastSeen.Before(since)) {
+			ids = append(ids, st.ID)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// shardInfo returns the shard with the given ID, or nil if it does not exist.
+func (s *Server) shardInfo(id uint64) *meta.ShardInfo {
+	_, _, rg := s.metaClient.ShardOwner(id)
+	if rg == nil {
+		return nil
+	}
+	for i := range rg.Shards {
+		if rg.Shards[i].ID == id {
+			return &rg.Shards[i]
+		}
+	}
+	return nil
+}
+
+// resetDecommission forgets the progress of a previous decommission of the
+// node.
+func (s *Server) resetDecommission() {
+	if s.Node.Decommission == nil {
+		return
+	}
+	s.Node.Decommission = nil
+	if err := s.Node.Save(); err != nil {
+		s.logger.Error("error save node", zap.Error(err))
+	}
+}
+
+// shardMoves returns the shards of the node with the nodes they must be
+// copied to, so that they keep their replication factor once the node has
+// left. The new owners are the nodes not leaving the cluster owning the
+// fewest shards.
+func (s *Server) shardMoves() ([]shardMove, error) {
+	data := s.metaClient.Data()
+
+	load := make(map[uint64]int)
+	for _, n := range data.DataNodes {
+		if n.ID != s.Node.ID && !n.Leaving {
+			load[n.ID] = 0
+		}
+	}
+	if len(load) == 0 {
+		return nil, errors.New("no data node to move the shards to")
+	}
+
+	var shards []meta.ShardInfo
+	replicaN := make(map[uint64]int)
+	for _, db := range data.Databases {
+		for _, ttl := range db.TimeToLives {
+			for _, rg := range ttl.Regions {
+				if rg.Deleted() {
+					continue
+				}
+				for _, sh := range rg.Shards {
+					for _, o := range sh.Owners {
+						if _, ok := load[o.NodeID]; ok {
+							load[o.NodeID]++
+						}
+					}
+					if sh.OwnedBy(s.Node.ID) {
+						shards = append(shards, sh)
+						replicaN[sh.ID] = ttl.ReplicaN
+					}
+				}
+			}
+		}
+	}
+
+	var moves []shardMove
+	for _, sh := range shards {
+		// Require at least one replica but no more replicas than nodes.
+		want := replicaN[sh.ID]
+		if want < 1 {
+			want = 1
+		} else if want > len(load) {
+			want = len(load)
+		}
+
+		var candidates []uint64
+		for id := range load {
+			if sh.OwnedBy(id) {
+				want--
+			} else {
+				candidates = append(candidates, id)
+			}
+		}
+		if want <= 0 {
+			continue
+		}
+
+		sort.Slice(candidates, func(i, j int) bool {
+			if load[candidates[i]] != load[candidates[j]] {
+				return load[candidates[i]] < load[candidates[j]]
+			}
+			return candidates[i] < candidates[j]
+		})
+		m := shardMove{shardID: sh.ID, nodeIDs: candidates[:want]}
+		for _, id := range m.nodeIDs {
+			load[id]++
+		}
+		moves = append(moves, m)
+	}
+	return moves, nil
+}
+
+// sleep waits for d, returning errDecommissionCanceled if the server is
+// closed meanwhile.
+func (s *Server) sleep(d time.Duration) error {
+	select {
+	case <-s.closing:
+		return errDecommissionCanceled
+	case <-time.After(d):
+		return nil
+	}
+}
+
+// isClosing returns whether the server is closing.
+func (s *Server) isClosing() bool {
+	select {
+	case <-s.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// decommissionProgress returns a copy of the decommission progress of the
+// node, or nil if it is not leaving the cluster.
+func (s *Server) decommissionProgress() *meta.DecommissionProgress {
+	s.decommissionState.mu.Lock()
+	defer s.decommissionState.mu.Unlock()
+	if s.decommissionState.progress == nil {
+		return nil
+	}
+	p := *s.decommissionState.progress
+	return &p
+}
+
+func (s *Server) setDecommissionProgress(p *meta.DecommissionProgress) {
+	s.decommissionState.mu.Lock()
+	defer s.decommissionState.mu.Unlock()
+	s.decommissionState.progress = p
+}
+
+func (s *Server) updateDecommissionProgress(fn func(p *meta.DecommissionProgress)) {
+	s.decommissionState.mu.Lock()
+	defer s.decommissionState.mu.Unlock()
+	if s.decommissionState.progress == nil {
+		s.decommissionState.progress = &meta.DecommissionProgress{}
+	}
+	fn(s.decommissionState.progress)
+}
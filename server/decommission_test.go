@@ -0,0 +1,37 @@
+package server
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cnosdatabase/cnosdb/meta"
+)
+
+// Ensure a leaving node waits for the nodes holding hinted handoff for it and
+// for the nodes that are up but have not reported their queues yet.
+func TestHintedHandoffNodes(t *testing.T) {
+	since := time.Now()
+	before, after := since.Add(-time.Second), since.Add(time.Second)
+
+	statuses := []meta.DataNodeStatus{
+		{ID: 1, State: meta.NodeStateUp, LastSeen: after, HintedHandoff: map[uint64]int64{3: 100}},
+		{ID: 2, State: meta.NodeStateUp, LastSeen: after},
+		{ID: 3, State: meta.NodeStateUp, LastSeen: after, HintedHandoff: map[uint64]int64{2: 100}},
+		{ID: 4, State: meta.NodeStateSuspect, LastSeen: before},
+		{ID: 5, State: meta.NodeStateDown, LastSeen: before},
+		{ID: 6, State: meta.NodeStateDown, LastSeen: before, HintedHandoff: map[uint64]int64{1: 100}},
+	}
+	if ids, exp := hintedHandoffNodes(1, statuses, since), []uint64{4, 6}; !reflect.DeepEqual(ids, exp) {
+		t.Fatalf("unexpected nodes: got=%v exp=%v", ids, exp)
+	}
+	if ids, exp := hintedHandoffNodes(3, statuses, since), []uint64{1, 4}; !reflect.DeepEqual(ids, exp) {
+		t.Fatalf("unexpected nodes: got=%v exp=%v", ids, exp)
+	}
+
+	statuses[3].LastSeen = after
+	statuses[5].HintedHandoff = nil
+	if ids := hintedHandoffNodes(1, statuses, since); len(ids) != 0 {
+		t.Fatalf("unexpected nodes: %v", ids)
+	}
+}
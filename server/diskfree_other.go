@@ -0,0 +1,8 @@
+// +build !linux,!darwin,!freebsd,!dragonfly
+
+package server
+
+// diskFree is not supported on this platform.
+func diskFree(path string) (uint64, error) {
+	return 0, errDiskFreeUnsupported
+}
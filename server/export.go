@@ -0,0 +1,312 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdatabase/cnosdb/meta"
+	"github.com/cnosdatabase/cnosql"
+	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/query"
+	"go.uber.org/zap"
+)
+
+// Formats supported by the export endpoint.
+const (
+	exportFormatLineProtocol = "lp"
+	exportFormatCSV          = "csv"
+)
+
+// serveExport streams the data of a database, from every shard in the
+// requested time range, as line protocol or CSV.
+//
+// The data is read through the query engine, so the user must be allowed to
+// read the database and shards owned by other data nodes are included.
+func (h *Handler) serveExport(w http.ResponseWriter, r *http.Request, user meta.User) {
+	atomic.AddInt64(&h.stats.QueryRequests, 1)
+	defer func(start time.Time) {
+		atomic.AddInt64(&h.stats.QueryRequestDuration, time.Since(start).Nanoseconds())
+	}(time.Now())
+	h.requestTracker.Add(r, user)
+
+	q := r.URL.Query()
+	db, ttl := q.Get("db"), q.Get("ttl")
+	if db == "" {
+		writeError(w, "database is required")
+		return
+	}
+
+	format := q.Get("format")
+	switch format {
+	case "":
+		format = exportFormatLineProtocol
+	case exportFormatLineProtocol, exportFormatCSV:
+	default:
+		writeError(w, fmt.Sprintf("invalid format %q (use lp or csv)", format))
+		return
+	}
+
+	start, err := parseExportTime(q.Get("start"))
+	if err != nil {
+		writeError(w, fmt.Sprintf("invalid start: %s", err))
+		return
+	}
+	end, err := parseExportTime(q.Get("end"))
+	if err != nil {
+		writeError(w, fmt.Sprintf("invalid end: %s", err))
+		return
+	}
+
+	di := h.metaClient.Database(db)
+	if di == nil {
+		writeErrorWithCode(w, fmt.Sprintf("database not found: %q", db), http.StatusNotFound)
+		return
+	}
+	if ttl == "" {
+		ttl = di.DefaultTimeToLive
+	}
+	if di.TimeToLive(ttl) == nil {
+		writeErrorWithCode(w, fmt.Sprintf("time-to-live not found: %q", ttl), http.StatusNotFound)
+		return
+	}
+
+	// Check that the user can read every metric of the database.
+	var cond string
+	if !start.IsZero() {
+		cond = fmt.Sprintf(" WHERE time >= '%s'", start.UTC().Format(time.RFC3339Nano))
+	}
+	if !end.IsZero() {
+		if cond == "" {
+			cond = " WHERE"
+		} else {
+			cond += " AND"
+		}
+		cond += fmt.Sprintf(" time < '%s'", end.UTC().Format(time.RFC3339Nano))
+	}
+	all, err := cnosql.ParseQuery(fmt.Sprintf("SELECT * FROM %s./.*/%s GROUP BY *", cnosql.QuoteIdent(db, ttl), cond))
+	if err != nil {
+		writeError(w, err.Error())
+		return
+	}
+
+	opts := query.ExecutionOptions{
+		Database:         db,
+		TimeToLive:       ttl,
+		ChunkSize:        DefaultChunkSize,
+		ReadOnly:         true,
+		Authorizer:       query.OpenAuthorizer,
+		CoarseAuthorizer: query.OpenCoarseAuthorizer,
+	}
+	if h.config.AuthEnabled {
+		if opts.Authorizer, err = h.QueryAuthorizer.AuthorizeQuery(user, all, db); err != nil {
+			writeErrorWithCode(w, "error authorizing export: "+err.Error(), http.StatusForbidden)
+			return
+		}
+		opts.CoarseAuthorizer = &userQueryAuthorizer{
+			auth: h.QueryAuthorizer,
+			user: user,
+		}
+	}
+
+	closing := make(chan struct{})
+	defer close(closing)
+
+	// Each metric is selected by its own statement so that field types are
+	// not widened across metrics.
+	names, err := h.exportMetricNames(db, opts, closing)
+	if err != nil {
+		writeErrorWithCode(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	stmts := make([]string, len(names))
+	for i, name := range names {
+		stmts[i] = fmt.Sprintf("SELECT * FROM %s%s GROUP BY *", cnosql.QuoteIdent(db, ttl, name), cond)
+	}
+
+	ext := "lp"
+	if format == exportFormatCSV {
+		w.Header().Set(headerContentType, "text/csv")
+		ext = "csv"
+	} else {
+		w.Header().Set(headerContentType, "text/plain; charset=utf-8")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", db+"."+ext))
+	// An error once the export has begun is reported in the trailer, as well
+	// as at the end of the body.
+	w.Header().Set("Trailer", headerErrorMsg)
+	writeHeader(w, http.StatusOK)
+
+	if len(stmts) == 0 {
+		return
+	}
+
+	stmt, err := cnosql.ParseQuery(strings.Join(stmts, "; "))
+	if err != nil {
+		h.logger.Info("Error parsing export query", zap.Error(err))
+		return
+	}
+
+	bw := &bytesCountWriter{w: w}
+	var ew exportWriter
+	if format == exportFormatCSV {
+		ew = &csvExportWriter{w: bw, f: &csvFormatter{statementID: -1}}
+	} else {
+		ew = &lineProtocolExportWriter{w: bufio.NewWriter(bw)}
+	}
+
+	for result := range h.QueryExecutor.ExecuteQuery(stmt, opts, closing) {
+		if result.Err != nil {
+			h.logger.Info("Error while exporting",
+				zap.String("db", db),
+				zap.Error(result.Err))
+			w.Header().Set(headerErrorMsg, result.Err.Error())
+			if err := ew.WriteError(result.Err); err != nil {
+				h.logger.Info("Error writing export", zap.Error(err))
+			}
+			break
+		}
+
+		if err := ew.WriteResult(result); err != nil {
+			h.logger.Info("Error writing export", zap.Error(err))
+			break
+		}
+	}
+
+	if err := ew.Flush(); err != nil {
+		h.logger.Info("Error writing export", zap.Error(err))
+	}
+	atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(bw.n))
+}
+
+// exportMetricNames returns the names of the metrics in db.
+func (h *Handler) exportMetricNames(db string, opts query.ExecutionOptions, closing chan struct{}) ([]string, error) {
+	q, err := cnosql.ParseQuery("SHOW METRICS ON " + cnosql.QuoteIdent(db))
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for result := range h.QueryExecutor.ExecuteQuery(q, opts, closing) {
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		for _, row := range result.Series {
+			for _, values := range row.Values {
+				if name, ok := values[0].(string); ok {
+					names = append(names, name)
+				}
+			}
+		}
+	}
+	return names, nil
+}
+
+// parseExportTime parses a time given as RFC3339 or as nanoseconds since the epoch.
+func parseExportTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(0, n).UTC(), nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+// exportWriter writes query results in an export format.
+type exportWriter interface {
+	WriteResult(result *query.Result) error
+
+	// WriteError ends the export with err, so that a failed export cannot
+	// be mistaken for a complete one.
+	WriteError(err error) error
+
+	Flush() error
+}
+
+// lineProtocolExportWriter writes query results as line protocol.
+type lineProtocolExportWriter struct {
+	w *bufio.Writer
+}
+
+func (ew *lineProtocolExportWriter) WriteResult(result *query.Result) error {
+	for _, row := range result.Series {
+		// Series without a tag value are returned with an empty value by GROUP BY *.
+		tags := make(map[string]string, len(row.Tags))
+		for k, v := range row.Tags {
+			if v != "" {
+				tags[k] = v
+			}
+		}
+
+		for _, values := range row.Values {
+			ts, ok := values[0].(time.Time)
+			if !ok {
+				continue
+			}
+
+			fields := make(models.Fields, len(values)-1)
+			for i := 1; i < len(values) && i < len(row.Columns); i++ {
+				if values[i] != nil {
+					fields[row.Columns[i]] = values[i]
+				}
+			}
+			if len(fields) == 0 {
+				continue
+			}
+
+			pt, err := models.NewPoint(row.Name, models.NewTags(tags), fields, ts)
+			if err != nil {
+				return err
+			}
+			if _, err := io.WriteString(ew.w, pt.String()); err != nil {
+				return err
+			}
+			if err := ew.w.WriteByte('\n'); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteError writes err as a comment line.
+func (ew *lineProtocolExportWriter) WriteError(err error) error {
+	_, e := fmt.Fprintf(ew.w, "# error: %s\n", strings.Replace(err.Error(), "\n", " ", -1))
+	return e
+}
+
+func (ew *lineProtocolExportWriter) Flush() error {
+	return ew.w.Flush()
+}
+
+// csvExportWriter writes query results in the CSV format of the query endpoint.
+type csvExportWriter struct {
+	w io.Writer
+	f *csvFormatter
+}
+
+func (ew *csvExportWriter) WriteResult(result *query.Result) error {
+	return ew.f.WriteResponse(ew.w, Response{Results: []*query.Result{result}})
+}
+
+// WriteError writes err as the error table of the query endpoint, after a
+// blank line if results were written.
+func (ew *csvExportWriter) WriteError(err error) error {
+	if ew.f.statementID >= 0 {
+		if _, err := io.WriteString(ew.w, "\n"); err != nil {
+			return err
+		}
+	}
+	return ew.f.WriteResponse(ew.w, Response{Err: err})
+}
+
+func (ew *csvExportWriter) Flush() error {
+	return nil
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/cnosdatabase/cnosdb/meta"
+)
+
+// serveHintedHandoffPurge deletes the hinted handoff data queued for a node.
+func (h *Handler) serveHintedHandoffPurge(w http.ResponseWriter, r *http.Request, user meta.User) {
+	h.serveHintedHandoff(w, r, user, h.HintedHandoff.Purge)
+}
+
+// serveHintedHandoffReplay sends the hinted handoff data queued for a node
+// immediately.
+func (h *Handler) serveHintedHandoffReplay(w http.ResponseWriter, r *http.Request, user meta.User) {
+	h.serveHintedHandoff(w, r, user, h.HintedHandoff.Replay)
+}
+
+// serveHintedHandoff applies fn to the queue of the node given by the "node"
+// parameter. It requires an admin user when authentication is enabled.
+func (h *Handler) serveHintedHandoff(w http.ResponseWriter, r *http.Request, user meta.User, fn func(nodeID uint64) error) {
+	if h.config.AuthEnabled {
+		if user == nil || !user.AuthorizeUnrestricted() {
+			writeErrorWithCode(w, "admin user is required to manage hinted handoff", http.StatusForbidden)
+			return
+		}
+	}
+
+	nodeID, err := strconv.ParseUint(r.URL.Query().Get("node"), 10, 64)
+	if err != nil {
+		writeError(w, fmt.Sprintf("invalid node: %q", r.URL.Query().Get("node")))
+		return
+	}
+
+	if err := fn(nodeID); err != nil {
+		writeError(w, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,165 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Health check statuses.
+const (
+	healthPass = "pass"
+	healthFail = "fail"
+)
+
+// errDiskFreeUnsupported is returned when the free disk space cannot be
+// determined on the platform.
+var errDiskFreeUnsupported = errors.New("disk free space is not supported on this platform")
+
+// healthCheck is the result of a check of the state of the node.
+type healthCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	// liveness is true if the node cannot serve at all when the check fails.
+	liveness bool
+}
+
+// healthResponse is the body of the /health and /ready responses.
+type healthResponse struct {
+	Status string        `json:"status"`
+	Checks []healthCheck `json:"checks"`
+}
+
+func passed(name, msg string) healthCheck {
+	return healthCheck{Name: name, Status: healthPass, Message: msg}
+}
+
+func failed(name, msg string) healthCheck {
+	return healthCheck{Name: name, Status: healthFail, Message: msg}
+}
+
+// serveHealth reports whether the node is alive. It fails only when the node
+// cannot serve at all, the other checks are reported for information.
+func (h *Handler) serveHealth(w http.ResponseWriter, r *http.Request) {
+	h.writeHealth(w, h.healthChecks(), func(c healthCheck) bool { return c.liveness })
+}
+
+// serveReady reports whether the node is ready to serve queries and writes.
+// It fails when any check fails.
+func (h *Handler) serveReady(w http.ResponseWriter, r *http.Request) {
+	h.writeHealth(w, h.healthChecks(), func(c healthCheck) bool { return true })
+}
+
+// writeHealth writes the checks with status 503 if a check for which
+// required returns true failed.
+func (h *Handler) writeHealth(w http.ResponseWriter, checks []healthCheck, required func(c healthCheck) bool) {
+	resp := healthResponse{Status: healthPass, Checks: checks}
+	for _, c := range checks {
+		if c.Status == healthFail && required(c) {
+			resp.Status = healthFail
+			break
+		}
+	}
+
+	w.Header().Set(headerContentType, contentTypeJSON)
+	if resp.Status == healthPass {
+		writeHeader(w, http.StatusOK)
+	} else {
+		writeHeader(w, http.StatusServiceUnavailable)
+	}
+	b, _ := json.Marshal(resp)
+	_, _ = w.Write(b)
+}
+
+// healthChecks checks the state of the node.
+func (h *Handler) healthChecks() []healthCheck {
+	var checks []healthCheck
+	if h.TSDBStore != nil {
+		checks = append(checks, h.checkTSDBStore())
+	}
+	if h.metaClient != nil {
+		checks = append(checks, h.checkMeta()...)
+	}
+	if h.HintedHandoff != nil {
+		checks = append(checks, h.checkHintedHandoff())
+	}
+	if len(h.DiskPaths) > 0 {
+		checks = append(checks, h.checkDiskFree())
+	}
+	return checks
+}
+
+// checkTSDBStore checks that the store is open.
+func (h *Handler) checkTSDBStore() healthCheck {
+	c := passed("tsdb", "")
+	if !h.TSDBStore.IsOpen() {
+		c = failed("tsdb", "store is closed")
+	}
+	c.liveness = true
+	return c
+}
+
+// checkMeta checks that the meta service is reachable and has a leader.
+func (h *Handler) checkMeta() []healthCheck {
+	mc, ok := h.metaClient.(interface {
+		Leader() (string, error)
+	})
+	if !ok {
+		// The meta store is local.
+		if err := h.metaClient.Ping(false); err != nil {
+			return []healthCheck{failed("meta", err.Error())}
+		}
+		return []healthCheck{passed("meta", "")}
+	}
+
+	leader, err := mc.Leader()
+	if err != nil {
+		return []healthCheck{
+			failed("meta", err.Error()),
+			failed("meta_leader", "meta service is unreachable"),
+		}
+	} else if leader == "" {
+		return []healthCheck{
+			passed("meta", ""),
+			failed("meta_leader", "meta service has no leader"),
+		}
+	}
+	return []healthCheck{
+		passed("meta", ""),
+		passed("meta_leader", leader),
+	}
+}
+
+// checkHintedHandoff reports the size of the hinted handoff queues. A backlog
+// does not make the node unready, as the node still serves its own shards and
+// taking it out of rotation would only grow the backlog of the other nodes.
+func (h *Handler) checkHintedHandoff() healthCheck {
+	return passed("hinted_handoff", fmt.Sprintf("%d bytes queued", h.HintedHandoff.DiskUsage()))
+}
+
+// checkDiskFree checks that the file systems of the data directories have
+// the configured free space.
+func (h *Handler) checkDiskFree() healthCheck {
+	var minPath string
+	var minFree uint64
+	for _, path := range h.DiskPaths {
+		free, err := diskFree(path)
+		if err == errDiskFreeUnsupported {
+			return passed("disk", err.Error())
+		} else if err != nil {
+			return failed("disk", fmt.Sprintf("%s: %s", path, err))
+		}
+		if minPath == "" || free < minFree {
+			minPath, minFree = path, free
+		}
+	}
+
+	msg := fmt.Sprintf("%s: %d bytes free", minPath, minFree)
+	if min := uint64(h.config.ReadyMinDiskFree); minFree < min {
+		return failed("disk", fmt.Sprintf("%s, limit is %d", msg, min))
+	}
+	return passed("disk", msg)
+}
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"time"
+
+	"github.com/cnosdatabase/cnosdb/meta"
+	"go.uber.org/zap"
+)
+
+// sendHeartbeats periodically sends the heartbeat of the node to the meta
+// leader until the server is closed.
+func (s *Server) sendHeartbeats() {
+	interval := time.Duration(s.Config.Meta.HeartbeatInterval)
+	if interval <= 0 {
+		interval = meta.DefaultHeartbeatInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var failing bool
+	for {
+		// A new node has no ID until it has joined the cluster.
+		if s.Node.ID != 0 {
+			err := s.metaClient.Heartbeat(s.heartbeat())
+			if err != nil && !failing {
+				s.logger.Warn("failed to send heartbeat to meta service", zap.Error(err))
+			} else if err == nil && failing {
+				s.logger.Info("sending heartbeats to meta service again")
+			}
+			failing = err != nil
+		}
+
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// heartbeat returns the heartbeat of the node.
+func (s *Server) heartbeat() *meta.Heartbeat {
+	hb := &meta.Heartbeat{
+		NodeID:  s.Node.ID,
+		Version: s.Version,
+
+		Decommission: s.decommissionProgress(),
+	}
+	if size, err := s.tsdbStore.DiskSize(); err == nil {
+		hb.DiskBytes = size
+	}
+	if s.hintedHandoff != nil {
+		if pending := s.hintedHandoff.Pending(); len(pending) > 0 {
+			hb.HintedHandoff = pending
+		}
+	}
+	return hb
+}
@@ -0,0 +1,156 @@
+package hh
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"time"
+
+	"github.com/cnosdatabase/db/models"
+	"github.com/golang/snappy"
+)
+
+// ErrBlockCorrupt is returned when the checksum of a block does not match its
+// content, after a torn write or a bit flip.
+var ErrBlockCorrupt = errors.New("hinted handoff block is corrupt")
+
+const (
+	// blockVersion2 is the first byte of the blocks written by this version.
+	// Older blocks start with the 8-byte shard ID of their write, whose first
+	// byte is 0 as shard IDs never reach 1<<57.
+	blockVersion2 = 2
+
+	// blockHeaderSize is the size of the header of a v2 block: the version,
+	// the CRC32C of the rest of the block, the compression and the time the
+	// block was queued.
+	blockHeaderSize = 1 + 4 + 1 + 8
+
+	// maxBlockSize is the size of the uncompressed writes above which
+	// concurrent writes are not batched in the same block.
+	maxBlockSize = 1 << 20
+)
+
+// Compressions of the payload of a v2 block.
+const (
+	compressionNone   = 0
+	compressionSnappy = 1
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// shardWrite is a write of points to a shard, as line protocol.
+type shardWrite struct {
+	shardID uint64
+	data    []byte
+}
+
+func newShardWrite(shardID uint64, points []models.Point) shardWrite {
+	var b []byte
+	for _, p := range points {
+		b = append(b, []byte(p.String())...)
+		b = append(b, '\n')
+	}
+	return shardWrite{shardID: shardID, data: b}
+}
+
+// Points parses the points of the write.
+func (w shardWrite) Points() ([]models.Point, error) {
+	return models.ParsePoints(w.data)
+}
+
+// marshalBlock encodes writes as a v2 block. The payload holds the shard ID,
+// the length and the points of each write and is compressed with snappy.
+//
+// ┌───────┬───────┬─────────────┬───────────┬──────────────────────────┐
+// │version│CRC32C │ compression │ queued at │ payload                  │
+// │1 byte │4 bytes│ 1 byte      │ 8 bytes   │ (shard ID, len, points)* │
+// └───────┴───────┴─────────────┴───────────┴──────────────────────────┘
+func marshalBlock(queuedAt time.Time, writes []shardWrite) []byte {
+	var payload []byte
+	for _, w := range writes {
+		var hdr [12]byte
+		binary.BigEndian.PutUint64(hdr[:8], w.shardID)
+		binary.BigEndian.PutUint32(hdr[8:], uint32(len(w.data)))
+		payload = append(payload, hdr[:]...)
+		payload = append(payload, w.data...)
+	}
+
+	b := make([]byte, blockHeaderSize, blockHeaderSize+snappy.MaxEncodedLen(len(payload)))
+	b[0] = blockVersion2
+	b[5] = compressionSnappy
+	binary.BigEndian.PutUint64(b[6:14], uint64(queuedAt.UnixNano()))
+	b = append(b, snappy.Encode(nil, payload)...)
+	binary.BigEndian.PutUint32(b[1:5], crc32.Checksum(b[5:], castagnoli))
+	return b
+}
+
+// unmarshalBlock returns the time a block was queued and its writes. Blocks
+// written by older versions hold a single write and no time.
+func unmarshalBlock(b []byte) (time.Time, []shardWrite, error) {
+	if len(b) == 0 || b[0] != blockVersion2 {
+		shardID, data, err := unmarshalLegacyBlock(b)
+		if err != nil {
+			return time.Time{}, nil, err
+		}
+		return time.Time{}, []shardWrite{{shardID: shardID, data: data}}, nil
+	}
+
+	queuedAt, err := blockTime(b)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if crc32.Checksum(b[5:], castagnoli) != binary.BigEndian.Uint32(b[1:5]) {
+		return time.Time{}, nil, ErrBlockCorrupt
+	}
+
+	var payload []byte
+	switch b[5] {
+	case compressionNone:
+		payload = b[blockHeaderSize:]
+	case compressionSnappy:
+		if payload, err = snappy.Decode(nil, b[blockHeaderSize:]); err != nil {
+			return time.Time{}, nil, fmt.Errorf("decompress block: %s", err)
+		}
+	default:
+		return time.Time{}, nil, fmt.Errorf("unknown block compression: %d", b[5])
+	}
+
+	var writes []shardWrite
+	for len(payload) > 0 {
+		if len(payload) < 12 {
+			return time.Time{}, nil, fmt.Errorf("write header too short: len = %d", len(payload))
+		}
+		shardID := binary.BigEndian.Uint64(payload[:8])
+		n := int(binary.BigEndian.Uint32(payload[8:12]))
+		payload = payload[12:]
+		if len(payload) < n {
+			return time.Time{}, nil, fmt.Errorf("write too short: len = %d, exp %d", len(payload), n)
+		}
+		writes = append(writes, shardWrite{shardID: shardID, data: payload[:n]})
+		payload = payload[n:]
+	}
+	return queuedAt, writes, nil
+}
+
+// blockTime returns the time a block was queued without decoding its writes.
+// The time is zero for the blocks queued by older versions without it.
+func blockTime(b []byte) (time.Time, error) {
+	if len(b) == 0 || b[0] != blockVersion2 {
+		_, _, err := unmarshalLegacyBlock(b)
+		return time.Time{}, err
+	}
+	if len(b) < blockHeaderSize {
+		return time.Time{}, fmt.Errorf("too short: len = %d", len(b))
+	}
+	return time.Unix(0, int64(binary.BigEndian.Uint64(b[6:14]))).UTC(), nil
+}
+
+// unmarshalLegacyBlock returns the shard ID and the points of a block written
+// by an older version.
+func unmarshalLegacyBlock(b []byte) (uint64, []byte, error) {
+	if len(b) < 8 {
+		return 0, nil, fmt.Errorf("too short: len = %d", len(b))
+	}
+	return binary.BigEndian.Uint64(b[:8]), b[8:], nil
+}
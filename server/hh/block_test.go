@@ -0,0 +1,144 @@
+package hh
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Ensure the blocks of a segment written by an older version are read as
+// single writes without queue time, before the blocks appended since.
+func TestQueue_LegacySegment(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// A segment holds the blocks, each prefixed by its length, followed by
+	// the position of the current block.
+	var segment []byte
+	for _, w := range []shardWrite{
+		{shardID: 1, data: []byte("cpu value=1 10\n")},
+		{shardID: 2, data: []byte("cpu value=2 20\ncpu value=3 30\n")},
+	} {
+		var b [16]byte
+		binary.BigEndian.PutUint64(b[:8], uint64(8+len(w.data)))
+		binary.BigEndian.PutUint64(b[8:], w.shardID)
+		segment = append(segment, b[:]...)
+		segment = append(segment, w.data...)
+	}
+	segment = append(segment, make([]byte, footerSize)...)
+	if err := ioutil.WriteFile(filepath.Join(dir, "1"), segment, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	q, err := newQueue(dir, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	} else if err := q.Open(); err != nil {
+		t.Fatal(err)
+	}
+	defer q.Close()
+
+	queuedAt := time.Unix(0, 40).UTC()
+	if err := q.Append(marshalBlock(queuedAt, []shardWrite{{shardID: 3, data: []byte("cpu value=4 40\n")}})); err != nil {
+		t.Fatal(err)
+	}
+
+	for i, exp := range []struct {
+		queuedAt time.Time
+		shardID  uint64
+		n        int
+	}{
+		{shardID: 1, n: 1},
+		{shardID: 2, n: 2},
+		{queuedAt: queuedAt, shardID: 3, n: 1},
+	} {
+		b, err := q.Current()
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		}
+		if tm, err := blockTime(b); err != nil {
+			t.Fatalf("%d: %s", i, err)
+		} else if !tm.Equal(exp.queuedAt) {
+			t.Fatalf("%d: unexpected block time: got=%v exp=%v", i, tm, exp.queuedAt)
+		}
+
+		tm, writes, err := unmarshalBlock(b)
+		if err != nil {
+			t.Fatalf("%d: %s", i, err)
+		} else if !tm.Equal(exp.queuedAt) {
+			t.Fatalf("%d: unexpected queue time: got=%v exp=%v", i, tm, exp.queuedAt)
+		} else if len(writes) != 1 || writes[0].shardID != exp.shardID {
+			t.Fatalf("%d: unexpected writes: %v", i, writes)
+		}
+		if points, err := writes[0].Points(); err != nil {
+			t.Fatalf("%d: %s", i, err)
+		} else if len(points) != exp.n {
+			t.Fatalf("%d: unexpected points: got=%d exp=%d", i, len(points), exp.n)
+		}
+
+		if err := q.Advance(); err != nil && i < 2 {
+			t.Fatalf("%d: %s", i, err)
+		}
+	}
+	if !q.Empty() {
+		t.Fatal("queue not empty")
+	}
+}
+
+// Ensure a v2 block whose content does not match its checksum is rejected.
+func TestUnmarshalBlock_Corrupt(t *testing.T) {
+	b := marshalBlock(time.Unix(0, 10), []shardWrite{{shardID: 1, data: []byte("cpu value=1 10\n")}})
+	if _, _, err := unmarshalBlock(b); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, i := range []int{1, 5, 6, len(b) - 1} {
+		corrupt := append([]byte(nil), b...)
+		corrupt[i] ^= 0x01
+		if _, _, err := unmarshalBlock(corrupt); err != ErrBlockCorrupt {
+			t.Fatalf("byte %d: unexpected error: got=%v exp=%v", i, err, ErrBlockCorrupt)
+		}
+	}
+
+	if _, _, err := unmarshalBlock(b[:blockHeaderSize-1]); err == nil || err.Error() != "too short: len = 13" {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+// Ensure the writes batched in a v2 block are decoded in order.
+func TestUnmarshalBlock_Batch(t *testing.T) {
+	queuedAt := time.Unix(1, 500).UTC()
+	writes := []shardWrite{
+		{shardID: 1, data: []byte("cpu,host=a value=1 10\n")},
+		{shardID: 2, data: []byte("cpu,host=b value=2 20\ncpu,host=b value=3 30\n")},
+		{shardID: 1, data: []byte("mem,host=a free=4i 40\n")},
+	}
+
+	tm, got, err := unmarshalBlock(marshalBlock(queuedAt, writes))
+	if err != nil {
+		t.Fatal(err)
+	} else if !tm.Equal(queuedAt) {
+		t.Fatalf("unexpected queue time: got=%v exp=%v", tm, queuedAt)
+	} else if !reflect.DeepEqual(got, writes) {
+		t.Fatalf("unexpected writes: got=%q exp=%q", got, writes)
+	}
+
+	var n int
+	for _, w := range got {
+		points, err := w.Points()
+		if err != nil {
+			t.Fatal(err)
+		}
+		n += len(points)
+	}
+	if n != 4 {
+		t.Fatalf("unexpected points: got=%d exp=4", n)
+	}
+}
@@ -1,6 +1,9 @@
 package hh
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type limiter struct {
 	count int64
@@ -59,3 +62,47 @@ func (t *limiter) clamp(value, min, max float64) float64 {
 	}
 	return value
 }
+
+// rateCounter measures the amount used per second over windows of a given
+// duration. The rate of the last complete window is reported.
+type rateCounter struct {
+	mu     sync.Mutex
+	window time.Duration
+	start  time.Time
+	count  int64
+	rate   float64
+}
+
+func newRateCounter(window time.Duration) *rateCounter {
+	return &rateCounter{
+		window: window,
+		start:  time.Now(),
+	}
+}
+
+// Update updates the amount used.
+func (r *rateCounter) Update(count int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.roll(time.Now())
+	r.count += int64(count)
+}
+
+// Rate returns the amount used per second over the last complete window.
+func (r *rateCounter) Rate() float64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.roll(time.Now())
+	return r.rate
+}
+
+// roll starts a new window once the current one is complete.
+func (r *rateCounter) roll(now time.Time) {
+	elapsed := now.Sub(r.start)
+	if elapsed < r.window {
+		return
+	}
+	r.rate = float64(r.count) / elapsed.Seconds()
+	r.start = now
+	r.count = 0
+}
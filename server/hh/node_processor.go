@@ -1,20 +1,31 @@
 package hh
 
 import (
-	"encoding/binary"
-	"expvar"
 	"fmt"
 	"io"
 	"log"
 	"os"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/cnosdatabase/common"
 	"github.com/cnosdatabase/db/models"
 )
 
+// sendRateWindow is the window over which the rate data is sent to the node
+// is measured.
+const sendRateWindow = 10 * time.Second
+
+// NodeStatus is the state of the hinted-handoff queue of a node.
+type NodeStatus struct {
+	NodeID      uint64
+	Bytes       int64     // Size on disk of the queue.
+	OldestEntry time.Time // Time the oldest queued block was queued, zero if unknown.
+	SendRate    float64   // Bytes per second sent to the node.
+	LastError   string    // Last error writing to the node.
+	LastErrorAt time.Time
+}
+
 // NodeProcessor encapsulates a queue of hinted-handoff data for a node, and the
 // transmission of the data to the node.
 type NodeProcessor struct {
@@ -27,24 +38,49 @@ type NodeProcessor struct {
 	nodeID           uint64
 	dir              string
 
-	mu   sync.RWMutex
-	wg   sync.WaitGroup
-	done chan struct{}
+	mu     sync.RWMutex
+	wg     sync.WaitGroup
+	done   chan struct{}
+	replay chan struct{}
+
+	// Writes waiting to be appended to the queue. Concurrent writes are
+	// appended as a single block.
+	pendingMu sync.Mutex
+	pending   []*pendingWrite
+	appendMu  sync.Mutex
+
+	// Number of writes of the head block already sent to the node.
+	headSent int
+
+	errMu     sync.Mutex
+	lastErr   error
+	lastErrAt time.Time
+	sendRate  *rateCounter
 
 	queue  *queue
 	meta   metaClient
 	writer shardWriter
 
-	statMap *expvar.Map
-	Logger  *log.Logger
+	// Writers holding the processor outside of the lock of the service.
+	refs sync.WaitGroup
+
+	stats  *NodeProcessorStatistics
+	Logger *log.Logger
+}
+
+// NodeProcessorStatistics keeps statistics related to a NodeProcessor.
+type NodeProcessorStatistics struct {
+	WriteShardReq       int64
+	WriteShardReqPoints int64
+	WriteNodeReq        int64
+	WriteNodeReqFail    int64
+	WriteNodeReqPoints  int64
+	BlockCorrupt        int64
 }
 
 // NewNodeProcessor returns a new NodeProcessor for the given node, using dir for
 // the hinted-handoff data.
 func NewNodeProcessor(nodeID uint64, dir string, w shardWriter, m metaClient) *NodeProcessor {
-	key := strings.Join([]string{"hh_processor", dir}, ":")
-	tags := map[string]string{"node": fmt.Sprintf("%d", nodeID), "path": dir}
-
 	return &NodeProcessor{
 		PurgeInterval:    DefaultPurgeInterval,
 		RetryInterval:    DefaultRetryInterval,
@@ -55,7 +91,8 @@ func NewNodeProcessor(nodeID uint64, dir string, w shardWriter, m metaClient) *N
 		dir:              dir,
 		writer:           w,
 		meta:             m,
-		statMap:          common.NewStatistics(key, "hh_processor", tags),
+		sendRate:         newRateCounter(sendRateWindow),
+		stats:            &NodeProcessorStatistics{},
 		Logger:           log.New(os.Stderr, "[handoff] ", log.LstdFlags),
 	}
 }
@@ -72,6 +109,7 @@ func (n *NodeProcessor) Open() error {
 		return nil
 	}
 	n.done = make(chan struct{})
+	n.replay = make(chan struct{}, 1)
 
 	// Create the queue directory if it doesn't already exist.
 	if err := os.MkdirAll(n.dir, 0700); err != nil {
@@ -125,6 +163,24 @@ func (n *NodeProcessor) Purge() error {
 	return os.RemoveAll(n.dir)
 }
 
+// Replay makes the NodeProcessor send its hinted-handoff data to the node
+// immediately, instead of waiting for the retry interval to expire.
+func (n *NodeProcessor) Replay() error {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	if n.done == nil {
+		return fmt.Errorf("node processor is closed")
+	}
+
+	select {
+	case n.replay <- struct{}{}:
+	default:
+		// A replay is already pending.
+	}
+	return nil
+}
+
 // WriteShard writes hinted-handoff data for the given shard and node. Since it may manipulate
 // hinted-handoff queues, and be called concurrently, it takes a lock during queue access.
 func (n *NodeProcessor) WriteShard(shardID uint64, points []models.Point) error {
@@ -135,11 +191,57 @@ func (n *NodeProcessor) WriteShard(shardID uint64, points []models.Point) error
 		return fmt.Errorf("node processor is closed")
 	}
 
-	n.statMap.Add(writeShardReq, 1)
-	n.statMap.Add(writeShardReqPoints, int64(len(points)))
+	atomic.AddInt64(&n.stats.WriteShardReq, 1)
+	atomic.AddInt64(&n.stats.WriteShardReqPoints, int64(len(points)))
+
+	w := &pendingWrite{shardWrite: newShardWrite(shardID, points), done: make(chan error, 1)}
+	n.pendingMu.Lock()
+	n.pending = append(n.pending, w)
+	n.pendingMu.Unlock()
+
+	// Append the pending writes, unless a concurrent writer appended them
+	// while this one was waiting.
+	n.appendMu.Lock()
+	n.appendPending()
+	n.appendMu.Unlock()
+
+	return <-w.done
+}
+
+// pendingWrite is a write waiting to be appended to the queue.
+type pendingWrite struct {
+	shardWrite
+	done chan error
+}
+
+// appendPending appends the pending writes to the queue, batching up to
+// maxBlockSize bytes of writes per block. It must be called with appendMu
+// held.
+func (n *NodeProcessor) appendPending() {
+	for {
+		n.pendingMu.Lock()
+		var size, i int
+		for i < len(n.pending) && (i == 0 || size+len(n.pending[i].data) <= maxBlockSize) {
+			size += len(n.pending[i].data)
+			i++
+		}
+		batch := n.pending[:i]
+		n.pending = n.pending[i:]
+		n.pendingMu.Unlock()
+
+		if len(batch) == 0 {
+			return
+		}
 
-	b := marshalWrite(shardID, points)
-	return n.queue.Append(b)
+		writes := make([]shardWrite, len(batch))
+		for i, w := range batch {
+			writes[i] = w.shardWrite
+		}
+		err := n.queue.Append(marshalBlock(time.Now(), writes))
+		for _, w := range batch {
+			w.done <- err
+		}
+	}
 }
 
 // LastModified returns the time the NodeProcessor last receieved hinted-handoff data.
@@ -170,40 +272,47 @@ func (n *NodeProcessor) run() {
 			if err := n.queue.PurgeOlderThan(time.Now().Add(-n.MaxAge)); err != nil {
 				n.Logger.Printf("failed to purge for node %d: %s", n.nodeID, err.Error())
 			}
+			// The head block may have been purged.
+			n.headSent = 0
+			continue
 
 		case <-time.After(currInterval):
-			limiter := NewRateLimiter(n.RetryRateLimit)
-			for {
-				c, err := n.SendWrite()
-				if err != nil {
-					if err == io.EOF {
-						// No more data, return to configured interval
-						currInterval = time.Duration(n.RetryInterval)
-					} else {
-						currInterval = currInterval * 2
-						if currInterval > time.Duration(n.RetryMaxInterval) {
-							currInterval = time.Duration(n.RetryMaxInterval)
-						}
+		case <-n.replay:
+		}
+
+		limiter := NewRateLimiter(n.RetryRateLimit)
+		for {
+			c, err := n.SendWrite()
+			if err != nil {
+				if err == io.EOF {
+					// No more data, return to configured interval
+					currInterval = time.Duration(n.RetryInterval)
+				} else {
+					currInterval = currInterval * 2
+					if currInterval > time.Duration(n.RetryMaxInterval) {
+						currInterval = time.Duration(n.RetryMaxInterval)
 					}
-					break
 				}
+				break
+			}
 
-				// Success! Ensure backoff is cancelled.
-				currInterval = time.Duration(n.RetryInterval)
+			// Success! Ensure backoff is cancelled.
+			currInterval = time.Duration(n.RetryInterval)
 
-				// Update how many bytes we've sent
-				limiter.Update(c)
+			// Update how many bytes we've sent
+			limiter.Update(c)
+			n.sendRate.Update(c)
 
-				// Block to maintain the throughput rate
-				time.Sleep(limiter.Delay())
-			}
+			// Block to maintain the throughput rate
+			time.Sleep(limiter.Delay())
 		}
 	}
 }
 
 // SendWrite attempts to sent the current block of hinted data to the target node. If successful,
 // it returns the number of bytes it sent and advances to the next block. Otherwise returns EOF
-// when there is no more data or the node is inactive.
+// when there is no more data or the node is inactive or down. The writes of a block already
+// sent are not sent again when a later write of the block fails.
 func (n *NodeProcessor) SendWrite() (int, error) {
 	n.mu.RLock()
 	defer n.mu.RUnlock()
@@ -216,35 +325,55 @@ func (n *NodeProcessor) SendWrite() (int, error) {
 		return 0, io.EOF
 	}
 
+	// Wait for a node known to be down to come back up.
+	if n.meta.DataNodeDown(n.nodeID) {
+		return 0, io.EOF
+	}
+
 	// Get the current block from the queue
 	buf, err := n.queue.Current()
 	if err != nil {
 		return 0, err
 	}
 
-	// unmarshal the byte slice back to shard ID and points
-	shardID, points, err := unmarshalWrite(buf)
+	// unmarshal the byte slice back to the writes of shard IDs and points
+	_, writes, err := unmarshalBlock(buf)
 	if err != nil {
-		n.Logger.Printf("unmarshal write failed: %v", err)
-		// Try to skip it.
-		if err := n.queue.Advance(); err != nil {
-			n.Logger.Printf("failed to advance queue for node %d: %s", n.nodeID, err.Error())
-		}
+		n.Logger.Printf("unmarshal block failed for node %d, skipping it: %v", n.nodeID, err)
+		atomic.AddInt64(&n.stats.BlockCorrupt, 1)
+		n.setLastError(err)
+		n.advance()
 		return 0, err
 	}
 
-	if err := n.writer.WriteShard(shardID, n.nodeID, points); err != nil {
-		n.statMap.Add(writeNodeReqFail, 1)
-		return 0, err
+	for ; n.headSent < len(writes); n.headSent++ {
+		w := writes[n.headSent]
+		points, err := w.Points()
+		if err != nil {
+			n.Logger.Printf("unmarshal points failed for node %d, skipping them: %v", n.nodeID, err)
+			atomic.AddInt64(&n.stats.BlockCorrupt, 1)
+			continue
+		}
+
+		if err := n.writer.WriteShard(w.shardID, n.nodeID, points); err != nil {
+			atomic.AddInt64(&n.stats.WriteNodeReqFail, 1)
+			n.setLastError(err)
+			return 0, err
+		}
+		atomic.AddInt64(&n.stats.WriteNodeReq, 1)
+		atomic.AddInt64(&n.stats.WriteNodeReqPoints, int64(len(points)))
 	}
-	n.statMap.Add(writeNodeReq, 1)
-	n.statMap.Add(writeNodeReqPoints, int64(len(points)))
 
+	n.advance()
+	return len(buf), nil
+}
+
+// advance moves to the next block of the queue.
+func (n *NodeProcessor) advance() {
+	n.headSent = 0
 	if err := n.queue.Advance(); err != nil {
 		n.Logger.Printf("failed to advance queue for node %d: %s", n.nodeID, err.Error())
 	}
-
-	return len(buf), nil
 }
 
 // Head returns the head of the processor's queue.
@@ -265,6 +394,48 @@ func (n *NodeProcessor) Tail() string {
 	return qp.tail
 }
 
+// DiskUsage returns the size on disk of the processor's queue.
+func (n *NodeProcessor) DiskUsage() int64 {
+	return n.queue.DiskUsage()
+}
+
+// Empty returns whether all the data in the processor's queue has been sent.
+func (n *NodeProcessor) Empty() bool {
+	return n.queue.Empty()
+}
+
+// Status returns the state of the processor's queue.
+func (n *NodeProcessor) Status() NodeStatus {
+	st := NodeStatus{
+		NodeID:   n.nodeID,
+		Bytes:    n.DiskUsage(),
+		SendRate: n.sendRate.Rate(),
+	}
+	if !n.Empty() {
+		if buf, err := n.queue.Current(); err == nil {
+			if t, err := blockTime(buf); err == nil {
+				st.OldestEntry = t
+			}
+		}
+	}
+
+	n.errMu.Lock()
+	defer n.errMu.Unlock()
+	if n.lastErr != nil {
+		st.LastError = n.lastErr.Error()
+		st.LastErrorAt = n.lastErrAt
+	}
+	return st
+}
+
+// setLastError records the last error writing to the node.
+func (n *NodeProcessor) setLastError(err error) {
+	n.errMu.Lock()
+	defer n.errMu.Unlock()
+	n.lastErr = err
+	n.lastErrAt = time.Now().UTC()
+}
+
 // Active returns whether this node processor is for a currently active node.
 func (n *NodeProcessor) Active() (bool, error) {
 	nio, err := n.meta.DataNode(n.nodeID)
@@ -274,22 +445,3 @@ func (n *NodeProcessor) Active() (bool, error) {
 	}
 	return nio != nil, nil
 }
-
-func marshalWrite(shardID uint64, points []models.Point) []byte {
-	b := make([]byte, 8)
-	binary.BigEndian.PutUint64(b, shardID)
-	for _, p := range points {
-		b = append(b, []byte(p.String())...)
-		b = append(b, '\n')
-	}
-	return b
-}
-
-func unmarshalWrite(b []byte) (uint64, []models.Point, error) {
-	if len(b) < 8 {
-		return 0, nil, fmt.Errorf("too short: len = %d", len(b))
-	}
-	ownerID := binary.BigEndian.Uint64(b[:8])
-	points, err := models.ParsePoints(b[8:])
-	return ownerID, points, err
-}
@@ -233,6 +233,13 @@ func (l *queue) Position() (*queuePos, error) {
 	return qp, nil
 }
 
+// DiskUsage returns the total size on disk used by the queue.
+func (l *queue) DiskUsage() int64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.diskUsage()
+}
+
 // diskUsage returns the total size on disk used by the queue
 func (l *queue) diskUsage() int64 {
 	var size int64
@@ -242,6 +249,19 @@ func (l *queue) diskUsage() int64 {
 	return size
 }
 
+// Empty returns whether all the byte slices in the queue have been read.
+func (l *queue) Empty() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, s := range l.segments {
+		if !s.empty() {
+			return false
+		}
+	}
+	return true
+}
+
 // addSegment creates a new empty segment file
 func (l *queue) addSegment() (*segment, error) {
 	nextID, err := l.nextSegmentID()
@@ -345,6 +365,8 @@ func (l *queue) Append(b []byte) error {
 
 // Current returns the current byte slice at the head of the queue
 func (l *queue) Current() ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	if l.head == nil {
 		return nil, ErrNotOpen
 	}
@@ -607,6 +629,13 @@ func (l *segment) advance() error {
 	return nil
 }
 
+// empty returns whether all the blocks of the segment have been read.
+func (l *segment) empty() bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.pos == l.size-footerSize
+}
+
 func (l *segment) close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -1,19 +1,18 @@
 package hh
 
 import (
-	"expvar"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
-	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cnosdatabase/cnosdb/meta"
-	"github.com/cnosdatabase/common"
 	"github.com/cnosdatabase/common/monitor/diagnostics"
 	"github.com/cnosdatabase/db/models"
 )
@@ -28,6 +27,11 @@ const (
 	writeNodeReq        = "writeNodeReq"
 	writeNodeReqFail    = "writeNodeReqFail"
 	writeNodeReqPoints  = "writeNodeReqPoints"
+	blockCorrupt        = "blockCorrupt"
+
+	statQueueBytes  = "queueBytes"
+	statOldestAgeMs = "oldestEntryAgeMs"
+	statSendRate    = "sendRate"
 )
 
 // Service represents a hinted handoff service.
@@ -38,9 +42,9 @@ type Service struct {
 
 	processors map[uint64]*NodeProcessor
 
-	statMap *expvar.Map
-	Logger  *log.Logger
-	cfg     Config
+	stats  *Statistics
+	Logger *log.Logger
+	cfg    Config
 
 	shardWriter shardWriter
 	MetaClient  metaClient
@@ -51,24 +55,28 @@ type Service struct {
 	}
 }
 
+// Statistics keeps statistics related to the hinted handoff service.
+type Statistics struct {
+	WriteShardReq       int64
+	WriteShardReqPoints int64
+}
+
 type shardWriter interface {
 	WriteShard(shardID, ownerID uint64, points []models.Point) error
 }
 
 type metaClient interface {
 	DataNode(id uint64) (ni *meta.NodeInfo, err error)
+	DataNodeDown(id uint64) bool
 }
 
 // NewService returns a new instance of Service.
 func NewService(c Config, w shardWriter, m metaClient) *Service {
-	key := strings.Join([]string{"hh", c.Dir}, ":")
-	tags := map[string]string{"path": c.Dir}
-
 	return &Service{
 		cfg:         c,
 		closing:     make(chan struct{}),
 		processors:  make(map[uint64]*NodeProcessor),
-		statMap:     common.NewStatistics(key, "hh", tags),
+		stats:       &Statistics{},
 		Logger:      log.New(os.Stderr, "[handoff] ", log.LstdFlags),
 		shardWriter: w,
 		MetaClient:  m,
@@ -130,6 +138,7 @@ func (s *Service) Close() error {
 	defer s.mu.Unlock()
 
 	for _, p := range s.processors {
+		p.refs.Wait()
 		if err := p.Close(); err != nil {
 			return err
 		}
@@ -158,11 +167,15 @@ func (s *Service) WriteShard(shardID, ownerID uint64, points []models.Point) err
 	if !s.cfg.Enabled {
 		return ErrHintedHandoffDisabled
 	}
-	s.statMap.Add(writeShardReq, 1)
-	s.statMap.Add(writeShardReqPoints, int64(len(points)))
+	atomic.AddInt64(&s.stats.WriteShardReq, 1)
+	atomic.AddInt64(&s.stats.WriteShardReqPoints, int64(len(points)))
 
+	// Hold the processor while writing, so that it is not closed meanwhile.
 	s.mu.RLock()
 	processor, ok := s.processors[ownerID]
+	if ok {
+		processor.refs.Add(1)
+	}
 	s.mu.RUnlock()
 	if !ok {
 		if err := func() error {
@@ -178,11 +191,13 @@ func (s *Service) WriteShard(shardID, ownerID uint64, points []models.Point) err
 				}
 				s.processors[ownerID] = processor
 			}
+			processor.refs.Add(1)
 			return nil
 		}(); err != nil {
 			return err
 		}
 	}
+	defer processor.refs.Done()
 
 	if err := processor.WriteShard(shardID, points); err != nil {
 		return err
@@ -191,6 +206,20 @@ func (s *Service) WriteShard(shardID, ownerID uint64, points []models.Point) err
 	return nil
 }
 
+// removeProcessor removes the processor of a node and deletes its data once
+// the writers holding it are done. The service must be locked for writing, so
+// that no writer takes the processor and no new processor is created for the
+// node meanwhile.
+func (s *Service) removeProcessor(nodeID uint64, processor *NodeProcessor) error {
+	delete(s.processors, nodeID)
+	processor.refs.Wait()
+
+	if err := processor.Close(); err != nil {
+		return err
+	}
+	return processor.Purge()
+}
+
 // Diagnostics returns diagnostic information.
 func (s *Service) Diagnostics() (*diagnostics.Diagnostics, error) {
 	s.mu.RLock()
@@ -217,6 +246,131 @@ func (s *Service) Diagnostics() (*diagnostics.Diagnostics, error) {
 	return d, nil
 }
 
+// DiskUsage returns the total size on disk of the queues of all nodes.
+func (s *Service) DiskUsage() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var size int64
+	for _, v := range s.processors {
+		size += v.DiskUsage()
+	}
+	return size
+}
+
+// Empty returns whether all the data queued for the nodes in the cluster has
+// been sent. The queues of the nodes removed from the cluster are ignored.
+func (s *Service) Empty() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for k, v := range s.processors {
+		if ni, err := s.MetaClient.DataNode(k); err != nil || ni == nil {
+			continue
+		}
+		if !v.Empty() {
+			return false
+		}
+	}
+	return true
+}
+
+// Pending returns the size of the queues that are not empty, keyed by node.
+// The queues of the nodes no longer in the cluster are ignored, like in Empty.
+func (s *Service) Pending() map[uint64]int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	pending := make(map[uint64]int64)
+	for k, v := range s.processors {
+		if ni, err := s.MetaClient.DataNode(k); err != nil || ni == nil {
+			continue
+		}
+		if !v.Empty() {
+			pending[k] = v.DiskUsage()
+		}
+	}
+	return pending
+}
+
+// NodeStatuses returns the state of the queues of all nodes, ordered by node.
+func (s *Service) NodeStatuses() []NodeStatus {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	statuses := make([]NodeStatus, 0, len(s.processors))
+	for _, v := range s.processors {
+		statuses = append(statuses, v.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].NodeID < statuses[j].NodeID })
+	return statuses
+}
+
+// Purge deletes all the data queued for a node.
+func (s *Service) Purge(nodeID uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	processor, ok := s.processors[nodeID]
+	if !ok {
+		return fmt.Errorf("no hinted handoff queue for node %d", nodeID)
+	}
+	return s.removeProcessor(nodeID, processor)
+}
+
+// Replay sends the data queued for a node immediately, without waiting for
+// the retry interval to expire.
+func (s *Service) Replay(nodeID uint64) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	processor, ok := s.processors[nodeID]
+	if !ok {
+		return fmt.Errorf("no hinted handoff queue for node %d", nodeID)
+	}
+	return processor.Replay()
+}
+
+// Statistics returns statistics for periodic monitoring.
+func (s *Service) Statistics(tags map[string]string) []models.Statistic {
+	statistics := []models.Statistic{{
+		Name: "hh",
+		Tags: models.StatisticTags{"path": s.cfg.Dir}.Merge(tags),
+		Values: map[string]interface{}{
+			writeShardReq:       atomic.LoadInt64(&s.stats.WriteShardReq),
+			writeShardReqPoints: atomic.LoadInt64(&s.stats.WriteShardReqPoints),
+		},
+	}}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for k, v := range s.processors {
+		st := v.Status()
+		var oldestAge int64
+		if !st.OldestEntry.IsZero() {
+			oldestAge = int64(time.Since(st.OldestEntry) / time.Millisecond)
+		}
+
+		statistics = append(statistics, models.Statistic{
+			Name: "hh_processor",
+			Tags: models.StatisticTags{"node": strconv.FormatUint(k, 10), "path": v.dir}.Merge(tags),
+			Values: map[string]interface{}{
+				statQueueBytes:      st.Bytes,
+				statOldestAgeMs:     oldestAge,
+				statSendRate:        st.SendRate,
+				writeShardReq:       atomic.LoadInt64(&v.stats.WriteShardReq),
+				writeShardReqPoints: atomic.LoadInt64(&v.stats.WriteShardReqPoints),
+				writeNodeReq:        atomic.LoadInt64(&v.stats.WriteNodeReq),
+				writeNodeReqFail:    atomic.LoadInt64(&v.stats.WriteNodeReqFail),
+				writeNodeReqPoints:  atomic.LoadInt64(&v.stats.WriteNodeReqPoints),
+				blockCorrupt:        atomic.LoadInt64(&v.stats.BlockCorrupt),
+			},
+		})
+	}
+	return statistics
+}
+
 // purgeInactiveProcessors will cause the service to remove processors for inactive nodes.
 func (s *Service) purgeInactiveProcessors() {
 	defer s.wg.Done()
@@ -254,15 +408,10 @@ func (s *Service) purgeInactiveProcessors() {
 						continue
 					}
 
-					if err := v.Close(); err != nil {
-						s.Logger.Printf("failed to close node processor %d: %s", k, err.Error())
-						continue
-					}
-					if err := v.Purge(); err != nil {
+					if err := s.removeProcessor(k, v); err != nil {
 						s.Logger.Printf("failed to purge node processor %d: %s", k, err.Error())
 						continue
 					}
-					delete(s.processors, k)
 				}
 			}()
 		}
@@ -27,6 +27,10 @@ const (
 
 	// DefaultEnqueuedWriteTimeout is the maximum time a write request can wait to be processed.
 	DefaultEnqueuedWriteTimeout = 30 * time.Second
+
+	// DefaultReadyMinDiskFree is the default free disk space below which the
+	// node is not ready. Specify 0 for no limit.
+	DefaultReadyMinDiskFree = 1024 * 1024 * 1024
 )
 
 type HTTPConfig struct {
@@ -55,6 +59,7 @@ type HTTPConfig struct {
 	MaxConcurrentWriteLimit int            `toml:"max-concurrent-write-limit"`
 	MaxEnqueuedWriteLimit   int            `toml:"max-enqueued-write-limit"`
 	EnqueuedWriteTimeout    time.Duration  `toml:"enqueued-write-timeout"`
+	ReadyMinDiskFree        toml.Size      `toml:"ready-min-disk-free"`
 	TLS                     *tls.Config    `toml:"-"`
 }
 
@@ -74,6 +79,7 @@ func NewHTTPConfig() HTTPConfig {
 		BindSocket:            DefaultBindSocket,
 		MaxBodySize:           DefaultMaxBodySize,
 		EnqueuedWriteTimeout:  DefaultEnqueuedWriteTimeout,
+		ReadyMinDiskFree:      DefaultReadyMinDiskFree,
 	}
 }
 
@@ -3,6 +3,7 @@ package server
 import (
 	"bytes"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -19,9 +20,12 @@ import (
 	"github.com/cnosdatabase/cnosdb/monitor"
 	"github.com/cnosdatabase/cnosdb/pkg/logger"
 	"github.com/cnosdatabase/cnosdb/pkg/uuid"
+	"github.com/cnosdatabase/cnosdb/server/otlp"
 	"github.com/cnosdatabase/cnosql"
 	"github.com/cnosdatabase/common/monitor/diagnostics"
 	"github.com/cnosdatabase/db/models"
+	"github.com/cnosdatabase/db/pkg/tracing"
+	"github.com/cnosdatabase/db/pkg/tracing/fields"
 	"github.com/cnosdatabase/db/query"
 	"github.com/cnosdatabase/db/tsdb"
 	"github.com/dgrijalva/jwt-go"
@@ -148,9 +152,29 @@ type Handler struct {
 	}
 
 	PointsWriter interface {
-		WritePoints(database, timeToLive string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error
+		WritePointsWithContext(ctx context.Context, database, timeToLive string, consistencyLevel models.ConsistencyLevel, user meta.User, points []models.Point) error
 	}
 
+	// Tracer starts the traces of queries and writes and exports them.
+	Tracer interface {
+		StartTrace(name, traceparent string) (*tracing.Trace, *tracing.Span)
+		Export(t *tracing.Trace)
+	}
+
+	// TSDBStore, HintedHandoff and DiskPaths are checked by /health and /ready.
+	TSDBStore interface {
+		IsOpen() bool
+	}
+
+	// HintedHandoff is also managed by /hh/purge and /hh/replay.
+	HintedHandoff interface {
+		DiskUsage() int64
+		Purge(nodeID uint64) error
+		Replay(nodeID uint64) error
+	}
+
+	DiskPaths []string
+
 	requestTracker *RequestTracker
 	writeThrottler *Throttler
 
@@ -186,6 +210,26 @@ func NewHandler(conf *HTTPConfig) *Handler {
 			"ping", http.MethodGet, "/ping", false, true,
 			h.servePing,
 		},
+		{
+			"metrics", http.MethodGet, "/metrics", true, false,
+			h.serveMetrics,
+		},
+		{
+			"health", http.MethodGet, "/health", false, false,
+			h.serveHealth,
+		},
+		{
+			"ready", http.MethodGet, "/ready", false, false,
+			h.serveReady,
+		},
+		{
+			"hh-purge", http.MethodPost, "/hh/purge", false, true,
+			h.serveHintedHandoffPurge,
+		},
+		{
+			"hh-replay", http.MethodPost, "/hh/replay", false, true,
+			h.serveHintedHandoffReplay,
+		},
 		{
 			"write-options", http.MethodOptions, "/write", false, true,
 			h.serveOptions,
@@ -194,6 +238,10 @@ func NewHandler(conf *HTTPConfig) *Handler {
 			"write", http.MethodPost, "/write", true, true,
 			h.serveWrite,
 		},
+		{
+			"export", http.MethodGet, "/api/v1/export", true, true,
+			h.serveExport,
+		},
 	}...)
 
 	return h
@@ -349,6 +397,13 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		opts.CoarseAuthorizer = query.OpenCoarseAuthorizer
 	}
 
+	// Trace the query if requested by the client or sampled.
+	trace, span := h.startTrace(r, "query")
+	if span != nil {
+		span.SetLabels("db", db, "query", q.String())
+		opts.Trace, opts.Span = trace, span
+	}
+
 	// Make sure if the client disconnects we signal the query to abort
 	var closing chan struct{}
 	if !async {
@@ -382,10 +437,14 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 	// If we are running in async mode, open a goroutine to drain the results
 	// and return with a StatusNoContent.
 	if async {
-		go h.async(q, results)
+		go func() {
+			h.async(q, results)
+			h.finishTrace(trace, span)
+		}()
 		writeHeader(w, http.StatusNoContent)
 		return
 	}
+	defer h.finishTrace(trace, span)
 
 	// if we're not chunking, this will be the in memory buffer for all results before sending to client
 	resp := Response{Results: make([]*query.Result, 0)}
@@ -509,6 +568,32 @@ func (h *Handler) serveQuery(w http.ResponseWriter, r *http.Request, user meta.U
 		n, _ := rw.WriteResponse(resp)
 		atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
 	}
+
+	// Finish formats that write a trailer or must not leave the response
+	// empty, such as Arrow streams.
+	if f, ok := rw.(interface{ Finish() (int, error) }); ok {
+		n, _ := f.Finish()
+		atomic.AddInt64(&h.stats.QueryRequestBytesTransmitted, int64(n))
+	}
+}
+
+// startTrace starts the trace of a request, continuing the trace of the client
+// if the request has a traceparent header. It returns nil if the request is
+// not traced.
+func (h *Handler) startTrace(r *http.Request, name string) (*tracing.Trace, *tracing.Span) {
+	if h.Tracer == nil {
+		return nil, nil
+	}
+	return h.Tracer.StartTrace(name, r.Header.Get(otlp.TraceParentHeader))
+}
+
+// finishTrace finishes the root span of a request and exports its trace.
+func (h *Handler) finishTrace(trace *tracing.Trace, span *tracing.Span) {
+	if span == nil {
+		return
+	}
+	span.Finish()
+	h.Tracer.Export(trace)
 }
 
 // servePing returns a simple response to let the client know the server is running.
@@ -657,8 +742,19 @@ func (h *Handler) serveWrite(w http.ResponseWriter, r *http.Request, user meta.U
 		}
 	}
 
+	// Trace the write if requested by the client or sampled.
+	ctx := r.Context()
+	trace, span := h.startTrace(r, "write")
+	if span != nil {
+		span.SetLabels("db", database, "ttl", timeToLive)
+		span.MergeFields(fields.Int64("points", int64(len(points))))
+		ctx = tracing.NewContextWithTrace(ctx, trace)
+		ctx = tracing.NewContextWithSpan(ctx, span)
+		defer h.finishTrace(trace, span)
+	}
+
 	// Write points.
-	if err := h.PointsWriter.WritePoints(database, timeToLive, consistency, user, points); cnosdb.IsClientError(err) {
+	if err := h.PointsWriter.WritePointsWithContext(ctx, database, timeToLive, consistency, user, points); cnosdb.IsClientError(err) {
 		atomic.AddInt64(&h.stats.PointsWrittenFail, int64(len(points)))
 		writeError(w, err.Error())
 		return
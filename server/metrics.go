@@ -0,0 +1,257 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/cnosdatabase/common/monitor/diagnostics"
+)
+
+// contentTypePrometheus is the content type of the Prometheus text format.
+const contentTypePrometheus = "text/plain; version=0.0.4; charset=utf-8"
+
+// metricsNamespace prefixes the names of the exposed metrics.
+const metricsNamespace = "cnosdb"
+
+// gaugeKeys are the keys of statistic values that are levels rather than
+// cumulative counts. Any other value is exposed as a counter.
+var gaugeKeys = map[string]struct{}{
+	// tsdb
+	"numSeries":  {},
+	"numMetrics": {},
+	"diskBytes":  {},
+
+	// tsm1
+	"numFiles":                {},
+	"oldSegmentsDiskBytes":    {},
+	"currentSegmentDiskBytes": {},
+	"memBytes":                {},
+	"snapshotCount":           {},
+	"cacheAgeMs":              {},
+
+	// hinted handoff
+	"queueBytes":       {},
+	"oldestEntryAgeMs": {},
+	"sendRate":         {},
+
+	// Go runtime
+	"Alloc":        {},
+	"Sys":          {},
+	"HeapAlloc":    {},
+	"HeapSys":      {},
+	"HeapIdle":     {},
+	"HeapInUse":    {},
+	"HeapReleased": {},
+	"HeapObjects":  {},
+	"NumGoroutine": {},
+}
+
+// isGauge returns true if the statistic value with the key is a gauge.
+func isGauge(key string) bool {
+	if _, ok := gaugeKeys[key]; ok {
+		return true
+	}
+	return strings.HasSuffix(key, "Active") || strings.HasSuffix(key, "Queue")
+}
+
+// metricFamily is a metric and its samples.
+type metricFamily struct {
+	name    string
+	typ     string
+	samples map[string]float64 // keyed by the rendered labels
+}
+
+// serveMetrics renders the internal statistics and the Go runtime
+// diagnostics in the Prometheus text exposition format.
+//
+// Every value of a statistic is a metric named after the statistic and the
+// key of the value, with the tags of the statistic as labels.
+func (h *Handler) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.Monitor.Statistics(nil)
+	if err != nil {
+		writeErrorWithCode(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	families := make(map[string]*metricFamily)
+	add := func(name, typ, labels string, v float64) {
+		f := families[name]
+		if f == nil {
+			f = &metricFamily{name: name, typ: typ, samples: make(map[string]float64)}
+			families[name] = f
+		}
+		// Statistics with the same name and tags are exposed once.
+		if _, ok := f.samples[labels]; !ok {
+			f.samples[labels] = v
+		}
+	}
+
+	for _, s := range stats {
+		labels := formatLabels(s.Tags)
+		for key, value := range s.Values {
+			v, ok := metricValue(value)
+			if !ok {
+				continue
+			}
+
+			name := metricName(s.Name, key)
+			if isGauge(key) {
+				add(name, "gauge", labels, v)
+			} else {
+				add(name+"_total", "counter", labels, v)
+			}
+		}
+	}
+
+	// Expose the Go runtime diagnostics, strings as the labels of an info
+	// metric and numbers as gauges.
+	if diags, err := h.Monitor.Diagnostics(); err == nil {
+		if d := diags["runtime"]; d != nil {
+			addRuntimeDiagnostics(d, add)
+		}
+	}
+
+	names := make([]string, 0, len(families))
+	for name := range families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set(headerContentType, contentTypePrometheus)
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		f := families[name]
+		fmt.Fprintf(bw, "# TYPE %s %s\n", f.name, f.typ)
+
+		labels := make([]string, 0, len(f.samples))
+		for l := range f.samples {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			fmt.Fprintf(bw, "%s%s %s\n", f.name, l, strconv.FormatFloat(f.samples[l], 'g', -1, 64))
+		}
+	}
+	bw.Flush()
+}
+
+// addRuntimeDiagnostics adds the metrics of the Go runtime diagnostics.
+func addRuntimeDiagnostics(d *diagnostics.Diagnostics, add func(name, typ, labels string, v float64)) {
+	if len(d.Rows) == 0 {
+		return
+	}
+
+	info := make(map[string]string)
+	for i, column := range d.Columns {
+		if i >= len(d.Rows[0]) {
+			break
+		}
+		switch v := d.Rows[0][i].(type) {
+		case string:
+			info[column] = v
+		default:
+			if f, ok := metricValue(v); ok {
+				add(metricName("runtime", column), "gauge", "", f)
+			}
+		}
+	}
+	if len(info) > 0 {
+		add(metricName("runtime", "info"), "gauge", formatLabels(info), 1)
+	}
+}
+
+// metricValue returns the value of a statistic as a float.
+func metricValue(v interface{}) (float64, bool) {
+	switch v := v.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case float64:
+		return v, true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// metricName returns the name of the metric of a statistic value, such as
+// cnosdb_httpd_write_req for the writeReq value of the httpd statistic.
+func metricName(name, key string) string {
+	return metricsNamespace + "_" + snakeCase(name) + "_" + snakeCase(key)
+}
+
+// snakeCase converts a camel case name to a lowercase metric name component,
+// replacing characters that are not valid in metric names.
+func snakeCase(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i, c := range runes {
+		switch {
+		case unicode.IsUpper(c):
+			// Start a new word at a lowercase to uppercase transition and
+			// at the last capital of an acronym, as in "WALCompaction".
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				b.WriteByte('_')
+			}
+			b.WriteRune(unicode.ToLower(c))
+		case c < unicode.MaxASCII && (unicode.IsLetter(c) || unicode.IsDigit(c)):
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// formatLabels renders tags as sorted Prometheus labels.
+func formatLabels(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(labelName(k))
+		b.WriteString(`="`)
+		b.WriteString(labelValueReplacer.Replace(tags[k]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+// labelName replaces the characters that are not valid in label names.
+func labelName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,105 @@
+package otlp
+
+import (
+	"errors"
+	"net/url"
+	"time"
+
+	"github.com/cnosdatabase/common/monitor/diagnostics"
+	"github.com/cnosdatabase/common/pkg/toml"
+)
+
+const (
+	// DefaultEndpoint is the default URL of the OTLP/HTTP traces endpoint.
+	DefaultEndpoint = "http://localhost:4318/v1/traces"
+
+	// DefaultServiceName is the default service name of the exported spans.
+	DefaultServiceName = "cnosdb"
+
+	// DefaultSampleRatio is the default ratio of the requests that are traced
+	// when they do not continue a trace. It is kept low so that enabling the
+	// exporter does not trace every query and write of a busy server.
+	DefaultSampleRatio = 0.01
+
+	// DefaultBatchSize is the default maximum number of spans sent in a request.
+	DefaultBatchSize = 512
+
+	// DefaultQueueSize is the default number of traces waiting to be exported
+	// before new traces are dropped.
+	DefaultQueueSize = 2048
+
+	// DefaultFlushInterval is the default interval at which spans are sent.
+	DefaultFlushInterval = 5 * time.Second
+
+	// DefaultTimeout is the default timeout of a request to the endpoint.
+	DefaultTimeout = 10 * time.Second
+)
+
+// Config represents the configuration for the trace exporter.
+type Config struct {
+	Enabled       bool          `toml:"enabled"`
+	Endpoint      string        `toml:"endpoint"`
+	ServiceName   string        `toml:"service-name"`
+	SampleRatio   float64       `toml:"sample-ratio"`
+	BatchSize     int           `toml:"batch-size"`
+	QueueSize     int           `toml:"queue-size"`
+	FlushInterval toml.Duration `toml:"flush-interval"`
+	Timeout       toml.Duration `toml:"timeout"`
+}
+
+// NewConfig returns an instance of Config with defaults.
+func NewConfig() Config {
+	return Config{
+		Endpoint:      DefaultEndpoint,
+		ServiceName:   DefaultServiceName,
+		SampleRatio:   DefaultSampleRatio,
+		BatchSize:     DefaultBatchSize,
+		QueueSize:     DefaultQueueSize,
+		FlushInterval: toml.Duration(DefaultFlushInterval),
+		Timeout:       toml.Duration(DefaultTimeout),
+	}
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	if u, err := url.Parse(c.Endpoint); err != nil || u.Host == "" {
+		return errors.New("endpoint must be an absolute URL")
+	}
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return errors.New("sample-ratio must be between 0 and 1")
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("batch-size must be positive")
+	}
+	if c.QueueSize <= 0 {
+		return errors.New("queue-size must be positive")
+	}
+	if c.FlushInterval <= 0 {
+		return errors.New("flush-interval must be positive")
+	}
+	return nil
+}
+
+// Diagnostics returns a diagnostics representation of a subset of the Config.
+func (c Config) Diagnostics() (*diagnostics.Diagnostics, error) {
+	if !c.Enabled {
+		return diagnostics.RowFromMap(map[string]interface{}{
+			"enabled": false,
+		}), nil
+	}
+
+	return diagnostics.RowFromMap(map[string]interface{}{
+		"enabled":        true,
+		"endpoint":       c.Endpoint,
+		"service-name":   c.ServiceName,
+		"sample-ratio":   c.SampleRatio,
+		"batch-size":     c.BatchSize,
+		"queue-size":     c.QueueSize,
+		"flush-interval": c.FlushInterval,
+		"timeout":        c.Timeout,
+	}), nil
+}
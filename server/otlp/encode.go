@@ -0,0 +1,130 @@
+package otlp
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cnosdatabase/db/pkg/tracing"
+)
+
+// The types below are the subset of the OTLP/JSON encoding of an
+// ExportTraceServiceRequest written by the exporter.
+
+type exportTraceServiceRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope  `json:"scope"`
+	Spans []span `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type span struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              int        `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    string   `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+// Span kinds.
+const (
+	spanKindInternal = 1
+	spanKindServer   = 2
+)
+
+func stringValue(s string) anyValue { return anyValue{StringValue: &s} }
+
+// encodeSpan converts a span to OTLP. Labels are exported as string
+// attributes and fields as attributes of their type, with durations in
+// nanoseconds. A span without a parent in its trace, such as the span of a
+// request, is a server span.
+func encodeSpan(raw tracing.RawSpan, entry bool) span {
+	sp := span{
+		TraceID:           formatTraceID(raw.Context),
+		SpanID:            formatSpanID(raw.Context.SpanID),
+		Name:              raw.Name,
+		Kind:              spanKindInternal,
+		StartTimeUnixNano: strconv.FormatInt(raw.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(raw.Start.Add(raw.Duration).UnixNano(), 10),
+	}
+	if raw.ParentSpanID != 0 {
+		sp.ParentSpanID = formatSpanID(raw.ParentSpanID)
+	}
+	if entry {
+		sp.Kind = spanKindServer
+	}
+
+	for _, l := range raw.Labels {
+		sp.Attributes = append(sp.Attributes, keyValue{Key: l.Key, Value: stringValue(l.Value)})
+	}
+	for _, f := range raw.Fields {
+		var v anyValue
+		switch val := f.Value().(type) {
+		case string:
+			v = stringValue(val)
+		case bool:
+			v.BoolValue = &val
+		case int64:
+			v.IntValue = strconv.FormatInt(val, 10)
+		case uint64:
+			if val > math.MaxInt64 {
+				d := float64(val)
+				v.DoubleValue = &d
+			} else {
+				v.IntValue = strconv.FormatUint(val, 10)
+			}
+		case time.Duration:
+			v.IntValue = strconv.FormatInt(int64(val), 10)
+		case float64:
+			v.DoubleValue = &val
+		default:
+			continue
+		}
+		sp.Attributes = append(sp.Attributes, keyValue{Key: f.Key(), Value: v})
+	}
+	return sp
+}
+
+// encodeTrace appends the spans of a trace converted to OTLP to a.
+func encodeTrace(a []span, t *tracing.Trace) []span {
+	spans := t.Spans()
+	ids := make(map[uint64]struct{}, len(spans))
+	for _, s := range spans {
+		ids[s.Context.SpanID] = struct{}{}
+	}
+	for _, s := range spans {
+		_, ok := ids[s.ParentSpanID]
+		a = append(a, encodeSpan(s, !ok))
+	}
+	return a
+}
@@ -0,0 +1,203 @@
+// Package otlp exports the traces of queries and writes to an OpenTelemetry
+// collector with OTLP over HTTP/JSON.
+package otlp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cnosdatabase/db/pkg/tracing"
+	"go.uber.org/zap"
+)
+
+// scopeName is the instrumentation scope of the exported spans.
+const scopeName = "github.com/cnosdatabase/cnosdb"
+
+// Service batches finished traces and sends their spans to the endpoint.
+type Service struct {
+	config Config
+	client *http.Client
+
+	mu     sync.Mutex
+	rand   *rand.Rand
+	traces chan *tracing.Trace
+
+	wg   sync.WaitGroup
+	done chan struct{}
+
+	dropped int64
+
+	logger *zap.Logger
+}
+
+// NewService returns a configured trace exporter.
+func NewService(c Config) *Service {
+	return &Service{
+		config: c,
+		client: &http.Client{Timeout: time.Duration(c.Timeout)},
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+		logger: zap.NewNop(),
+	}
+}
+
+// Open starts exporting traces.
+func (s *Service) Open() error {
+	if !s.config.Enabled || s.done != nil {
+		return nil
+	}
+
+	s.logger.Info("Starting trace exporter", zap.String("endpoint", s.config.Endpoint))
+	s.traces = make(chan *tracing.Trace, s.config.QueueSize)
+	s.done = make(chan struct{})
+
+	s.wg.Add(1)
+	go func() { defer s.wg.Done(); s.run() }()
+	return nil
+}
+
+// Close stops exporting traces after sending the traces already exported.
+func (s *Service) Close() error {
+	if !s.config.Enabled || s.done == nil {
+		return nil
+	}
+
+	s.logger.Info("Closing trace exporter")
+	close(s.done)
+
+	s.wg.Wait()
+	s.done = nil
+	return nil
+}
+
+// WithLogger sets the logger on the service.
+func (s *Service) WithLogger(log *zap.Logger) {
+	s.logger = log.With(zap.String("service", "otlp"))
+}
+
+// StartTrace starts a trace with a root span named name if the request is
+// sampled. traceparent is the value of the traceparent header of the request,
+// if any. A request continuing the trace of a client is sampled when the
+// client samples it, other requests are sampled with the configured ratio.
+// It returns nil if the request is not traced.
+func (s *Service) StartTrace(name, traceparent string) (*tracing.Trace, *tracing.Span) {
+	if !s.config.Enabled {
+		return nil, nil
+	}
+
+	if traceparent != "" {
+		sc, sampled, err := ParseTraceParent(traceparent)
+		if err == nil {
+			if !sampled {
+				return nil, nil
+			}
+			return tracing.NewTraceFromSpan(name, sc)
+		}
+		// An invalid header is ignored and a new trace is started.
+	}
+
+	s.mu.Lock()
+	sampled := s.rand.Float64() < s.config.SampleRatio
+	s.mu.Unlock()
+	if !sampled {
+		return nil, nil
+	}
+	return tracing.NewTrace(name)
+}
+
+// Export queues the spans of a finished trace to be sent. The trace is
+// dropped if the queue is full.
+func (s *Service) Export(t *tracing.Trace) {
+	if !s.config.Enabled || t == nil {
+		return
+	}
+
+	select {
+	case s.traces <- t:
+	default:
+		atomic.AddInt64(&s.dropped, 1)
+	}
+}
+
+func (s *Service) run() {
+	ticker := time.NewTicker(time.Duration(s.config.FlushInterval))
+	defer ticker.Stop()
+
+	var spans []span
+	for {
+		select {
+		case <-s.done:
+			// Send the traces that are already queued.
+			for len(s.traces) > 0 {
+				spans = encodeTrace(spans, <-s.traces)
+			}
+			s.flush(spans)
+			return
+
+		case t := <-s.traces:
+			spans = encodeTrace(spans, t)
+			if len(spans) >= s.config.BatchSize {
+				spans = s.flush(spans)
+			}
+
+		case <-ticker.C:
+			spans = s.flush(spans)
+		}
+	}
+}
+
+// flush sends the spans in batches and returns the emptied slice.
+func (s *Service) flush(spans []span) []span {
+	if n := atomic.SwapInt64(&s.dropped, 0); n > 0 {
+		s.logger.Warn("Dropped traces, the export queue is full", zap.Int64("traces", n))
+	}
+
+	for i := 0; i < len(spans); i += s.config.BatchSize {
+		j := i + s.config.BatchSize
+		if j > len(spans) {
+			j = len(spans)
+		}
+		if err := s.send(spans[i:j]); err != nil {
+			s.logger.Info("Failed to export spans", zap.Int("spans", j-i), zap.Error(err))
+		}
+	}
+	return spans[:0]
+}
+
+// send posts spans to the endpoint.
+func (s *Service) send(spans []span) error {
+	body, err := json.Marshal(exportTraceServiceRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{
+				Attributes: []keyValue{{Key: "service.name", Value: stringValue(s.config.ServiceName)}},
+			},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: scopeName},
+				Spans: spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.client.Post(s.config.Endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	return nil
+}
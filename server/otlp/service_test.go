@@ -0,0 +1,228 @@
+package otlp_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cnosdatabase/cnosdb/server/otlp"
+	"github.com/cnosdatabase/common/pkg/toml"
+)
+
+// Collector is a stand-in for an OpenTelemetry collector receiving OTLP/JSON.
+type Collector struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	requests []exportRequest
+}
+
+// exportRequest is the part of an ExportTraceServiceRequest checked by the tests.
+type exportRequest struct {
+	ResourceSpans []struct {
+		Resource struct {
+			Attributes []struct {
+				Key   string `json:"key"`
+				Value struct {
+					StringValue string `json:"stringValue"`
+				} `json:"value"`
+			} `json:"attributes"`
+		} `json:"resource"`
+		ScopeSpans []struct {
+			Spans []exportSpan `json:"spans"`
+		} `json:"scopeSpans"`
+	} `json:"resourceSpans"`
+}
+
+type exportSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+	Kind         int    `json:"kind"`
+}
+
+// NewCollector returns a running collector answering with status code.
+func NewCollector(t *testing.T, code int) *Collector {
+	c := &Collector{}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("unexpected request: %s %s %s", r.Method, r.URL.Path, r.Header.Get("Content-Type"))
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		var req exportRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("unmarshal request: %s", err)
+		}
+		c.mu.Lock()
+		c.requests = append(c.requests, req)
+		c.mu.Unlock()
+		w.WriteHeader(code)
+	}))
+	return c
+}
+
+// Spans returns the spans received by the collector.
+func (c *Collector) Spans() []exportSpan {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var spans []exportSpan
+	for _, req := range c.requests {
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				spans = append(spans, ss.Spans...)
+			}
+		}
+	}
+	return spans
+}
+
+// NewService returns an open exporter sending to c.
+func NewService(t *testing.T, c *Collector, ratio float64) *otlp.Service {
+	config := otlp.NewConfig()
+	config.Enabled = true
+	config.Endpoint = c.URL + "/v1/traces"
+	config.SampleRatio = ratio
+	config.BatchSize = 2
+	config.FlushInterval = toml.Duration(time.Hour)
+	s := otlp.NewService(config)
+	if err := s.Open(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+// Ensure finished traces are sent to the collector in batches when the
+// exporter is closed.
+func TestService_Export(t *testing.T) {
+	c := NewCollector(t, http.StatusOK)
+	defer c.Close()
+	s := NewService(t, c, 1)
+
+	for i := 0; i < 2; i++ {
+		trace, root := s.StartTrace("query", "")
+		if trace == nil {
+			t.Fatal("expected the request to be sampled")
+		}
+		child := root.StartSpan("select")
+		child.Finish()
+		root.Finish()
+		s.Export(trace)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := len(c.requests); n != 2 {
+		t.Fatalf("unexpected number of requests: %d", n)
+	}
+	attrs := c.requests[0].ResourceSpans[0].Resource.Attributes
+	if len(attrs) != 1 || attrs[0].Key != "service.name" || attrs[0].Value.StringValue != otlp.DefaultServiceName {
+		t.Fatalf("unexpected resource attributes: %+v", attrs)
+	}
+
+	spans := c.Spans()
+	if len(spans) != 4 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	byName := make(map[string]exportSpan)
+	for _, sp := range spans[:2] {
+		byName[sp.Name] = sp
+	}
+	root, child := byName["query"], byName["select"]
+	if root.ParentSpanID != "" || root.Kind != 2 {
+		t.Fatalf("unexpected root span: %+v", root)
+	}
+	if child.TraceID != root.TraceID || child.ParentSpanID != root.SpanID || child.Kind != 1 {
+		t.Fatalf("unexpected child span: %+v", child)
+	}
+}
+
+// Ensure a failure of the collector does not stop the exporter.
+func TestService_Export_CollectorError(t *testing.T) {
+	c := NewCollector(t, http.StatusServiceUnavailable)
+	defer c.Close()
+	s := NewService(t, c, 1)
+
+	trace, root := s.StartTrace("write", "")
+	root.Finish()
+	s.Export(trace)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(c.Spans()); n != 1 {
+		t.Fatalf("unexpected number of spans: %d", n)
+	}
+}
+
+// Ensure requests without a traceparent header are sampled with the ratio.
+func TestService_StartTrace_SampleRatio(t *testing.T) {
+	c := NewCollector(t, http.StatusOK)
+	defer c.Close()
+
+	s := NewService(t, c, 0)
+	defer s.Close()
+	for i := 0; i < 100; i++ {
+		if trace, _ := s.StartTrace("query", ""); trace != nil {
+			t.Fatal("expected the request not to be sampled")
+		}
+	}
+
+	if ratio := otlp.NewConfig().SampleRatio; ratio <= 0 || ratio > 0.1 {
+		t.Fatalf("unexpected default sample ratio: %v", ratio)
+	}
+}
+
+// Ensure the trace of a client sending a traceparent header is continued
+// whatever the sample ratio, and exported with the ids of the client.
+func TestService_StartTrace_TraceParent(t *testing.T) {
+	c := NewCollector(t, http.StatusOK)
+	defer c.Close()
+	s := NewService(t, c, 0)
+
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+
+	// An unsampled client trace is not traced.
+	if trace, _ := s.StartTrace("query", "00-"+traceID+"-"+spanID+"-00"); trace != nil {
+		t.Fatal("expected the request not to be sampled")
+	}
+
+	trace, root := s.StartTrace("query", "00-"+traceID+"-"+spanID+"-01")
+	if trace == nil {
+		t.Fatal("expected the request to be sampled")
+	}
+	child := root.StartSpan("select")
+	child.Finish()
+	root.Finish()
+	s.Export(trace)
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := c.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("unexpected number of spans: %d", len(spans))
+	}
+	for _, sp := range spans {
+		if sp.TraceID != traceID {
+			t.Fatalf("unexpected trace id: %s", sp.TraceID)
+		}
+		switch sp.Name {
+		case "query":
+			if sp.ParentSpanID != spanID || sp.Kind != 2 {
+				t.Fatalf("unexpected root span: %+v", sp)
+			}
+		case "select":
+			if sp.ParentSpanID == spanID || sp.ParentSpanID == "" {
+				t.Fatalf("unexpected child span: %+v", sp)
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package otlp
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/cnosdatabase/db/pkg/tracing"
+)
+
+// TraceParentHeader is the W3C Trace Context header continuing the trace of
+// a client.
+const TraceParentHeader = "traceparent"
+
+// sampledFlag is the trace flag set when the client records the trace.
+const sampledFlag = 0x01
+
+var errInvalidTraceParent = errors.New("invalid traceparent header")
+
+// ParseTraceParent parses the value of a traceparent header. It returns the
+// span context of the client and whether the client samples the trace.
+func ParseTraceParent(s string) (tracing.SpanContext, bool, error) {
+	// version "-" trace-id "-" parent-id "-" trace-flags
+	if len(s) < 55 || s[2] != '-' || s[35] != '-' || s[52] != '-' {
+		return tracing.SpanContext{}, false, errInvalidTraceParent
+	}
+
+	version, err := hex.DecodeString(s[:2])
+	if err != nil || version[0] == 0xff {
+		return tracing.SpanContext{}, false, errInvalidTraceParent
+	}
+	// Later versions may append fields, version 00 must not.
+	if len(s) > 55 && (version[0] == 0 || s[55] != '-') {
+		return tracing.SpanContext{}, false, errInvalidTraceParent
+	}
+
+	high, err := parseID(s[3:19])
+	if err != nil {
+		return tracing.SpanContext{}, false, err
+	}
+	low, err := parseID(s[19:35])
+	if err != nil {
+		return tracing.SpanContext{}, false, err
+	}
+	parent, err := parseID(s[36:52])
+	if err != nil {
+		return tracing.SpanContext{}, false, err
+	}
+	flags, err := hex.DecodeString(s[53:55])
+	if err != nil {
+		return tracing.SpanContext{}, false, errInvalidTraceParent
+	}
+
+	if high == 0 && low == 0 || parent == 0 {
+		return tracing.SpanContext{}, false, errInvalidTraceParent
+	}
+
+	return tracing.SpanContext{
+		TraceIDHigh: high,
+		TraceID:     low,
+		SpanID:      parent,
+	}, flags[0]&sampledFlag != 0, nil
+}
+
+// parseID parses 16 lowercase hex digits.
+func parseID(s string) (uint64, error) {
+	for i := 0; i < len(s); i++ {
+		if c := s[i]; !('0' <= c && c <= '9' || 'a' <= c && c <= 'f') {
+			return 0, errInvalidTraceParent
+		}
+	}
+	v, err := strconv.ParseUint(s, 16, 64)
+	if err != nil {
+		return 0, errInvalidTraceParent
+	}
+	return v, nil
+}
+
+// formatTraceID returns the 32 hex digits of the trace id of sc.
+func formatTraceID(sc tracing.SpanContext) string {
+	return fmt.Sprintf("%016x%016x", sc.TraceIDHigh, sc.TraceID)
+}
+
+// formatSpanID returns the 16 hex digits of a span id.
+func formatSpanID(id uint64) string {
+	return fmt.Sprintf("%016x", id)
+}
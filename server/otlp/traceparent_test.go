@@ -0,0 +1,44 @@
+package otlp_test
+
+import (
+	"testing"
+
+	"github.com/cnosdatabase/cnosdb/server/otlp"
+)
+
+func TestParseTraceParent(t *testing.T) {
+	for _, tt := range []struct {
+		s       string
+		high    uint64
+		low     uint64
+		parent  uint64
+		sampled bool
+		err     bool
+	}{
+		{s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", high: 0x4bf92f3577b34da6, low: 0xa3ce929d0e0e4736, parent: 0x00f067aa0ba902b7, sampled: true},
+		{s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", high: 0x4bf92f3577b34da6, low: 0xa3ce929d0e0e4736, parent: 0x00f067aa0ba902b7},
+		// A later version may append fields.
+		{s: "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", high: 0x4bf92f3577b34da6, low: 0xa3ce929d0e0e4736, parent: 0x00f067aa0ba902b7, sampled: true},
+		{s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", err: true},
+		{s: "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", err: true},
+		{s: "00-00000000000000000000000000000000-00f067aa0ba902b7-01", err: true},
+		{s: "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", err: true},
+		{s: "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", err: true},
+		{s: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", err: true},
+		{s: "", err: true},
+	} {
+		sc, sampled, err := otlp.ParseTraceParent(tt.s)
+		if tt.err {
+			if err == nil {
+				t.Errorf("%q: expected an error", tt.s)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("%q: unexpected error: %s", tt.s, err)
+			continue
+		}
+		if sc.TraceIDHigh != tt.high || sc.TraceID != tt.low || sc.SpanID != tt.parent || sampled != tt.sampled {
+			t.Errorf("%q: unexpected result: %+v sampled=%v", tt.s, sc, sampled)
+		}
+	}
+}
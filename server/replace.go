@@ -0,0 +1,204 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cnosdatabase/cnosdb/meta"
+	"go.uber.org/zap"
+)
+
+// rehydrateRetryInterval is the interval at which the shards that could not
+// be copied from their other owners are retried.
+const rehydrateRetryInterval = time.Minute
+
+// rehydrateState holds the shards of the node still to copy from their other
+// owners.
+type rehydrateState struct {
+	mu      sync.RWMutex
+	pending map[uint64]struct{}
+}
+
+// Rehydrating returns whether the shard is still to copy from its other
+// owners.
+func (r *rehydrateState) Rehydrating(id uint64) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.pending[id]
+	return ok
+}
+
+// shardIDs returns the sorted IDs of the shards still to copy.
+func (r *rehydrateState) shardIDs() []uint64 {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ids := make([]uint64, 0, len(r.pending))
+	for id := range r.pending {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// replaceNode makes the node take the ID of a failed data node. The node then
+// owns the shards of the failed node and copies them from their other owners.
+func (s *Server) replaceNode(conn net.Conn, peers []string, id uint64) {
+	metaClient := meta.NewRemoteClient()
+	metaClient.SetMetaServers(peers)
+	if err := metaClient.Open(); err != nil {
+		s.logger.Error("error open MetaClient", zap.Error(err))
+		return
+	}
+
+	n, err := metaClient.ReplaceDataNode(id, s.HTTPAddr(), s.TCPAddr())
+	metaClient.Close()
+	if err != nil {
+		s.logger.Error("unable to replace data node", zap.Uint64("id", id), zap.Error(err))
+		return
+	}
+
+	s.Node.ID = n.ID
+	s.Node.Rehydrate = true
+	s.Node.Peers = peers
+
+	if err := s.Node.Save(); err != nil {
+		s.logger.Error("error save node", zap.Error(err))
+		return
+	}
+	s.NewNode = false
+
+	if err := json.NewEncoder(conn).Encode(n); err != nil {
+		s.logger.Error("error writing response", zap.Error(err))
+	}
+}
+
+// initRehydrate loads the shards still to copy from their other owners, so
+// that they are read from these owners meanwhile. The first time, these are
+// all the shards owned by the node.
+func (s *Server) initRehydrate() error {
+	if s.Node.RehydrateShards == nil {
+		s.Node.RehydrateShards = s.ownedShards()
+		if err := s.Node.Save(); err != nil {
+			return err
+		}
+	}
+
+	s.rehydrateState.mu.Lock()
+	defer s.rehydrateState.mu.Unlock()
+	s.rehydrateState.pending = make(map[uint64]struct{}, len(s.Node.RehydrateShards))
+	for _, id := range s.Node.RehydrateShards {
+		s.rehydrateState.pending[id] = struct{}{}
+	}
+	return nil
+}
+
+// rehydrated records that a shard was copied from its other owners, so that
+// it is read locally and not copied again after a restart.
+func (s *Server) rehydrated(id uint64) error {
+	s.rehydrateState.mu.Lock()
+	delete(s.rehydrateState.pending, id)
+	s.rehydrateState.mu.Unlock()
+
+	s.Node.RehydrateShards = s.rehydrateState.shardIDs()
+	return s.Node.Save()
+}
+
+// rehydrateShards copies the shards still to copy from their other owners,
+// retrying the failed shards until all are copied or the server is closed.
+// The copied data is added to the data written to the node meanwhile.
+func (s *Server) rehydrateShards() {
+	shardIDs := s.rehydrateState.shardIDs()
+	s.logger.Info("Copying shards from their other owners", zap.Int("shards", len(shardIDs)))
+
+	for {
+		var failed []uint64
+		for _, id := range shardIDs {
+			if err := s.rehydrateShard(id); err != nil {
+				s.logger.Warn("failed to copy shard", zap.Uint64("shard_id", id), zap.Error(err))
+				failed = append(failed, id)
+				continue
+			}
+			if err := s.rehydrated(id); err != nil {
+				s.logger.Error("error save node", zap.Error(err))
+			}
+		}
+		if len(failed) == 0 {
+			break
+		}
+
+		shardIDs = failed
+		select {
+		case <-s.closing:
+			return
+		case <-time.After(rehydrateRetryInterval):
+		}
+	}
+
+	s.Node.Rehydrate = false
+	s.Node.RehydrateShards = nil
+	if err := s.Node.Save(); err != nil {
+		s.logger.Error("error save node", zap.Error(err))
+		return
+	}
+	s.logger.Info("Copied all shards from their other owners")
+}
+
+// ownedShards returns the IDs of the shards of the node in the regions that
+// are not deleted.
+func (s *Server) ownedShards() []uint64 {
+	var ids []uint64
+	for _, db := range s.metaClient.Databases() {
+		for _, ttl := range db.TimeToLives {
+			for _, rg := range ttl.Regions {
+				if rg.Deleted() {
+					continue
+				}
+				for _, sh := range rg.Shards {
+					if sh.OwnedBy(s.Node.ID) {
+						ids = append(ids, sh.ID)
+					}
+				}
+			}
+		}
+	}
+	return ids
+}
+
+// rehydrateShard copies a shard from one of its other owners that is not
+// down. A shard without other owners is skipped, as its data is lost.
+func (s *Server) rehydrateShard(id uint64) error {
+	sh := s.shardInfo(id)
+	if sh == nil {
+		// The shard was dropped meanwhile.
+		return nil
+	}
+
+	var owners []uint64
+	for _, o := range sh.Owners {
+		if o.NodeID != s.Node.ID {
+			owners = append(owners, o.NodeID)
+		}
+	}
+	if len(owners) == 0 {
+		s.logger.Warn("shard has no other owner to copy it from", zap.Uint64("shard_id", id))
+		return nil
+	}
+
+	var lastErr error
+	for _, owner := range owners {
+		if s.metaClient.DataNodeDown(owner) {
+			lastErr = fmt.Errorf("node %d is down", owner)
+			continue
+		}
+		if err := s.coordinatorService.CopyShard(id, owner, time.Time{}); err != nil {
+			lastErr = fmt.Errorf("copy from node %d: %s", owner, err)
+			continue
+		}
+		return nil
+	}
+	return lastErr
+}
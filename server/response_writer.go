@@ -32,6 +32,9 @@ func NewResponseWriter(w http.ResponseWriter, r *http.Request) ResponseWriter {
 	case "application/x-msgpack":
 		w.Header().Add("Content-Type", "application/x-msgpack")
 		rw.formatter = &msgpackFormatter{}
+	case contentTypeArrowStream:
+		w.Header().Add("Content-Type", contentTypeArrowStream)
+		rw.formatter = &arrowFormatter{}
 	case "application/json":
 		fallthrough
 	default:
@@ -67,6 +70,21 @@ func (w *responseWriter) WriteResponse(resp Response) (int, error) {
 	return writer.n, err
 }
 
+// Finish writes any trailer required by the format once all responses
+// have been written.
+func (w *responseWriter) Finish() (int, error) {
+	f, ok := w.formatter.(interface {
+		Finish(w io.Writer) error
+	})
+	if !ok {
+		return 0, nil
+	}
+
+	writer := bytesCountWriter{w: w.ResponseWriter}
+	err := f.Finish(&writer)
+	return writer.n, err
+}
+
 // Flush flushes the ResponseWriter if it has a Flush() method.
 func (w *responseWriter) Flush() {
 	if w, ok := w.ResponseWriter.(http.Flusher); ok {
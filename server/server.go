@@ -20,6 +20,7 @@ import (
 	"github.com/cnosdatabase/cnosdb/pkg/utils"
 	"github.com/cnosdatabase/cnosdb/server/coordinator"
 	"github.com/cnosdatabase/cnosdb/server/hh"
+	"github.com/cnosdatabase/cnosdb/server/otlp"
 	"github.com/cnosdatabase/cnosdb/server/snapshotter"
 	"github.com/cnosdatabase/cnosdb/server/subscriber"
 	"github.com/cnosdatabase/db/models"
@@ -40,6 +41,9 @@ const NodeMuxHeader = "node"
 type Server struct {
 	Config *Config
 
+	// Version is the version of the server reported to the meta service.
+	Version string
+
 	err     chan error
 	closing chan struct{}
 
@@ -61,12 +65,17 @@ type Server struct {
 	queryExecutor *query.Executor
 	pointsWriter  *coordinator.PointsWriter
 	shardWriter   *coordinator.ShardWriter
+	metaExecutor  *coordinator.MetaExecutor
 	hintedHandoff *hh.Service
 	subscriber    *subscriber.Service
+	tracer        *otlp.Service
 
 	coordinatorService *coordinator.Service
 	snapshotterService *snapshotter.Service
 
+	decommissionState decommissionState
+	rehydrateState    rehydrateState
+
 	services []interface {
 		WithLogger(log *zap.Logger)
 		Open() error
@@ -122,8 +131,23 @@ func (s *Server) Open() error {
 		return err
 	}
 
+	if s.Config.Meta.HTTPD != nil && s.Node.Rehydrate {
+		if err := s.initRehydrate(); err != nil {
+			return err
+		}
+	}
+
 	go s.startHTTPServer()
 
+	if s.Config.Meta.HTTPD != nil {
+		go s.sendHeartbeats()
+		go s.watchDecommission()
+
+		if s.Node.Rehydrate {
+			go s.rehydrateShards()
+		}
+	}
+
 	return nil
 }
 
@@ -203,22 +227,49 @@ func (s *Server) initTSDBStore() error {
 	s.subscriber = subscriber.NewService(s.Config.Subscriber)
 	s.subscriber.MetaClient = s.metaClient
 
+	s.tracer = otlp.NewService(s.Config.Tracing)
+	s.tracer.WithLogger(s.logger)
+	s.services = append(s.services, s.tracer)
+
+	s.metaExecutor = coordinator.NewMetaExecutor()
+	s.metaExecutor.MetaClient = s.metaClient
+	s.metaExecutor.Node = s.Node
+
+	nodeDialer := &coordinator.NodeDialer{
+		MetaClient: s.metaClient,
+		Timeout:    time.Duration(s.Config.Coordinator.ShardMapperTimeout),
+	}
+
 	s.queryExecutor = query.NewExecutor()
 	s.queryExecutor.StatementExecutor = &coordinator.StatementExecutor{
-		MetaClient:  s.metaClient,
-		TaskManager: s.queryExecutor.TaskManager,
-		TSDBStore:   s.tsdbStore,
-		ShardMapper: &coordinator.LocalShardMapper{
+		MetaClient:   s.metaClient,
+		TaskManager:  s.queryExecutor.TaskManager,
+		TSDBStore:    s.tsdbStore,
+		MetaExecutor: s.metaExecutor,
+		ShardMapper: &coordinator.ClusterShardMapper{
 			MetaClient: s.metaClient,
 			TSDBStore: coordinator.LocalTSDBStore{
 				Store: s.tsdbStore,
 			},
+			Node:               s.Node,
+			NodeDialer:         nodeDialer,
+			ForceRemoteMapping: s.Config.Coordinator.ForceRemoteShardMapping,
+			Rehydrating:        &s.rehydrateState,
 		},
+		Node:              s.Node,
+		NodeDialer:        nodeDialer,
+		Rehydrating:       &s.rehydrateState,
 		Monitor:           s.monitor,
+		HintedHandoff:     s.hintedHandoff,
 		PointsWriter:      s.pointsWriter,
 		MaxSelectPointN:   s.Config.Coordinator.MaxSelectPointN,
 		MaxSelectSeriesN:  s.Config.Coordinator.MaxSelectSeriesN,
 		MaxSelectBucketsN: s.Config.Coordinator.MaxSelectBucketsN,
+
+		MaxSelectBlocksN:              s.Config.Coordinator.MaxSelectBlocksN,
+		MaxSelectBytes:                int64(s.Config.Coordinator.MaxSelectBytes),
+		MaxSelectSeriesScannedN:       s.Config.Coordinator.MaxSelectSeriesScannedN,
+		MaxConcurrentExpensiveQueries: s.Config.Coordinator.MaxConcurrentExpensiveQueries,
 	}
 	s.queryExecutor.TaskManager.QueryTimeout = time.Duration(s.Config.Coordinator.QueryTimeout)
 	s.queryExecutor.TaskManager.LogQueriesAfter = time.Duration(s.Config.Coordinator.LogQueriesAfter)
@@ -231,6 +282,7 @@ func (s *Server) initTSDBStore() error {
 	s.snapshotterService = snapshotter.NewService()
 	s.snapshotterService.TSDBStore = s.tsdbStore
 	s.snapshotterService.MetaClient = s.metaClient
+	s.snapshotterService.Node = s.Node
 
 	// Open TSDB store.
 	if err := s.tsdbStore.Open(); err != nil {
@@ -279,6 +331,13 @@ func (s *Server) initHTTPServer() error {
 	h.QueryExecutor = s.queryExecutor
 	h.Monitor = s.monitor
 	h.PointsWriter = s.pointsWriter
+	h.Tracer = s.tracer
+	h.TSDBStore = s.tsdbStore
+	h.HintedHandoff = s.hintedHandoff
+	h.DiskPaths = []string{s.Config.Data.Dir, s.Config.Data.WALDir}
+	if s.Config.HintedHandoff.Enabled {
+		h.DiskPaths = append(h.DiskPaths, s.Config.HintedHandoff.Dir)
+	}
 	h.logger = logger.BgLogger()
 	h.Open()
 
@@ -368,11 +427,17 @@ func (s *Server) startHTTPServer() {
 	}
 }
 
-const RequestClusterJoin = 0x01
+const (
+	RequestClusterJoin    = 0x01
+	RequestClusterReplace = 0x02
+)
 
 type Request struct {
 	Type  uint8
 	Peers []string
+
+	// NodeID is the ID of the node replaced by a RequestClusterReplace.
+	NodeID uint64 `json:",omitempty"`
 }
 
 func (s *Server) startNodeServer() {
@@ -407,6 +472,20 @@ func (s *Server) startNodeServer() {
 
 				s.joinCluster(conn, r.Peers)
 
+			case RequestClusterReplace:
+				if !s.NewNode {
+					conn.Close()
+					continue
+				}
+
+				if len(r.Peers) == 0 || r.NodeID == 0 {
+					s.logger.Error("Invalid replace request: empty Peers or node ID")
+					conn.Close()
+					continue
+				}
+
+				s.replaceNode(conn, r.Peers, r.NodeID)
+
 			default:
 				s.logger.Error(fmt.Sprintf("request type unknown: %v", r.Type))
 			}
@@ -485,6 +564,12 @@ func (s *Server) Statistics(tags map[string]string) []models.Statistic {
 	statistics = append(statistics, s.queryExecutor.Statistics(tags)...)
 	statistics = append(statistics, s.tsdbStore.Statistics(tags)...)
 	statistics = append(statistics, s.pointsWriter.Statistics(tags)...)
+	if s.hintedHandoff != nil {
+		statistics = append(statistics, s.hintedHandoff.Statistics(tags)...)
+	}
+	if h, ok := s.httpHandler.(monitor.Reporter); ok {
+		statistics = append(statistics, h.Statistics(tags)...)
+	}
 	for _, srv := range s.services {
 		if m, ok := srv.(monitor.Reporter); ok {
 			statistics = append(statistics, m.Statistics(tags)...)
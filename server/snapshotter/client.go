@@ -13,6 +13,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/cnosdatabase/cnosdb/meta"
 	"github.com/cnosdatabase/cnosdb/pkg/network"
@@ -102,6 +103,13 @@ func decodeUintPair(bits []byte) (uint64, uint64, error) {
 }
 
 func (c *Client) UploadShard(shardID, newShardID uint64, destinationDatabase, restoreTimeToLive string, tr *tar.Reader) error {
+	return c.UploadShardFiles(shardID, newShardID, destinationDatabase, restoreTimeToLive, tr, nil)
+}
+
+// UploadShardFiles uploads the files of a shard archive for which keep
+// returns true, given their paths within the shard, or all of them if keep
+// is nil.
+func (c *Client) UploadShardFiles(shardID, newShardID uint64, destinationDatabase, restoreTimeToLive string, tr *tar.Reader, keep func(name string) bool) error {
 	conn, err := network.Dial("tcp", c.host, MuxHeader)
 	if err != nil {
 		return err
@@ -140,6 +148,10 @@ func (c *Client) UploadShard(shardID, newShardID uint64, destinationDatabase, re
 			restoreTimeToLive = names[1]
 		}
 
+		if keep != nil && !keep(filepath.ToSlash(filepath.Join(names[3:]...))) {
+			continue
+		}
+
 		filepathArgs := []string{destinationDatabase, restoreTimeToLive, strconv.FormatUint(newShardID, 10)}
 		filepathArgs = append(filepathArgs, names[3:]...)
 		hdr.Name = filepath.ToSlash(filepath.Join(filepathArgs...))
@@ -188,6 +200,31 @@ func (c *Client) MetastoreBackup() (*meta.Data, error) {
 	return &data, nil
 }
 
+// ShardBackup requests a backup of a shard as a tar archive. If since is not
+// zero, only the files changed since then are included. The caller must close
+// the returned reader.
+func (c *Client) ShardBackup(shardID uint64, since time.Time) (io.ReadCloser, error) {
+	conn, err := network.Dial("tcp", c.host, MuxHeader)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{
+		Type:    RequestShardBackup,
+		ShardID: shardID,
+		Since:   since,
+	}
+	if _, err := conn.Write([]byte{byte(req.Type)}); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("encode snapshot request: %s", err)
+	}
+	return conn, nil
+}
+
 // doRequest sends a request to the snapshotter service and returns the result.
 func (c *Client) doRequest(req *Request) ([]byte, error) {
 	// Connect to snapshotter service.
@@ -38,6 +38,10 @@ type Service struct {
 	MetaClient interface {
 		encoding.BinaryMarshaler
 		Database(name string) *meta.DatabaseInfo
+		Databases() []meta.DatabaseInfo
+		ShardOwner(shardID uint64) (database, ttl string, sgi *meta.RegionInfo)
+		Data() meta.Data
+		SetData(data *meta.Data) error
 	}
 
 	TSDBStore interface {
@@ -165,6 +169,30 @@ func (s *Service) updateShardsLive(conn net.Conn) error {
 	}
 	sid := binary.BigEndian.Uint64(sidBytes[:])
 
+	// Only the owners of a shard take its data.
+	database, ttl, sgi := s.MetaClient.ShardOwner(sid)
+	var shard *meta.ShardInfo
+	if sgi != nil {
+		for i := range sgi.Shards {
+			if sgi.Shards[i].ID == sid {
+				shard = &sgi.Shards[i]
+			}
+		}
+	}
+	if shard == nil {
+		return fmt.Errorf("shard %d not found", sid)
+	}
+	if !shard.OwnedBy(s.Node.ID) {
+		return fmt.Errorf("shard %d is not owned by node %d", sid, s.Node.ID)
+	}
+
+	// A restored shard is created by its owners when its data is uploaded.
+	if s.TSDBStore.Shard(sid) == nil {
+		if err := s.TSDBStore.CreateShard(database, ttl, sid, true); err != nil {
+			return err
+		}
+	}
+
 	if err := s.TSDBStore.SetShardEnabled(sid, false); err != nil {
 		return err
 	}
@@ -183,7 +211,7 @@ func (s *Service) updateMetaStore(conn net.Conn, bits []byte, backupDBName, rest
 		return fmt.Errorf("failed to decode meta: %s", err)
 	}
 
-	data := s.MetaClient.(*meta.Client).Data()
+	data := s.MetaClient.Data()
 
 	IDMap, newDBs, err := data.ImportData(md, backupDBName, restoreDBName, backupTTLName, restoreTTLName)
 	if err != nil {
@@ -193,7 +221,7 @@ func (s *Service) updateMetaStore(conn net.Conn, bits []byte, backupDBName, rest
 		return err
 	}
 
-	err = s.MetaClient.(*meta.Client).SetData(&data)
+	err = s.MetaClient.SetData(&data)
 	if err != nil {
 		return err
 	}
@@ -210,6 +238,7 @@ func (s *Service) updateMetaStore(conn net.Conn, bits []byte, backupDBName, rest
 // iterate over a list of newDB's that should have just been added to the metadata
 // If the db was not created in the metadata return an error.
 // None of the shards should exist on a new DB, and CreateShard protects against double-creation.
+// Only the shards owned by this node are created.
 func (s *Service) createNewDBShards(data meta.Data, newDBs []string) error {
 	for _, restoreDBName := range newDBs {
 		dbi := data.Database(restoreDBName)
@@ -219,6 +248,9 @@ func (s *Service) createNewDBShards(data meta.Data, newDBs []string) error {
 		for _, ttli := range dbi.TimeToLives {
 			for _, sgi := range ttli.Regions {
 				for _, shard := range sgi.Shards {
+					if !shard.OwnedBy(s.Node.ID) {
+						continue
+					}
 					err := s.TSDBStore.CreateShard(restoreDBName, ttli.Name, shard.ID, true)
 					if err != nil {
 						return err
@@ -301,7 +333,7 @@ func (s *Service) writeDatabaseInfo(conn net.Conn, database string) error {
 		dbs = append(dbs, *db)
 	} else {
 		// we'll allow collecting info on all databases
-		dbs = s.MetaClient.(*meta.Client).Databases()
+		dbs = s.MetaClient.Databases()
 	}
 
 	for _, db := range dbs {